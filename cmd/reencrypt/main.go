@@ -0,0 +1,120 @@
+// Command reencrypt encrypts stored router and customer credentials with the
+// current crypto key. Plain-text values written before encryption and values
+// sealed with a previous key are rewritten; run it once after upgrading and
+// again after every key rotation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mikrobill/config"
+	database "mikrobill/internal/infrastructure/db/postgres"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// secretColumn is a column holding credentials, keyed by the table's id column
+type secretColumn struct {
+	table  string
+	column string
+}
+
+var secretColumns = []secretColumn{
+	{table: "mikrotik", column: "api_encrypted_password"},
+	{table: "customers", column: "pppoe_password"},
+	{table: "customers", column: "hotspot_password"},
+}
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to the config file")
+	dryRun := flag.Bool("dry-run", false, "count the values that would be rewritten without changing them")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	if err := pkg_logger.InitLogger(cfg.Logger.Environment); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer pkg_logger.Sync()
+
+	cipher, err := cfg.Crypto.Cipher()
+	if err != nil {
+		pkg_logger.Fatal("Failed to create credential cipher", zap.Error(err))
+	}
+
+	db, err := database.InitDatabase(cfg.Database)
+	if err != nil {
+		pkg_logger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	for _, col := range secretColumns {
+		count, err := reencrypt(db, cipher, col, *dryRun)
+		if err != nil {
+			pkg_logger.Fatal("Failed to re-encrypt credentials",
+				zap.String("table", col.table),
+				zap.String("column", col.column),
+				zap.Error(err),
+			)
+		}
+		pkg_logger.Info("Credentials re-encrypted",
+			zap.String("table", col.table),
+			zap.String("column", col.column),
+			zap.String("key_id", cipher.KeyID()),
+			zap.Int("rewritten", count),
+			zap.Bool("dry_run", *dryRun),
+		)
+	}
+}
+
+// reencrypt rewrites every value of a column that is not sealed with the current
+// key, in one transaction so a failure leaves the column untouched.
+func reencrypt(db *gorm.DB, cipher *utils.Cipher, col secretColumn, dryRun bool) (int, error) {
+	count := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID    string
+			Value string
+		}
+		err := tx.Table(col.table).
+			Select(fmt.Sprintf("id, %s AS value", col.column)).
+			Where(fmt.Sprintf("%s IS NOT NULL AND %s <> ''", col.column, col.column)).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if !cipher.NeedsRotation(row.Value) {
+				continue
+			}
+			count++
+			if dryRun {
+				continue
+			}
+
+			plain, err := cipher.Decrypt(row.Value)
+			if err != nil {
+				return fmt.Errorf("row %s: %w", row.ID, err)
+			}
+			sealed, err := cipher.Encrypt(plain)
+			if err != nil {
+				return fmt.Errorf("row %s: %w", row.ID, err)
+			}
+			if err := tx.Table(col.table).Where("id = ?", row.ID).Update(col.column, sealed).Error; err != nil {
+				return fmt.Errorf("row %s: %w", row.ID, err)
+			}
+		}
+		return nil
+	})
+	return count, err
+}
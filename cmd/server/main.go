@@ -80,5 +80,8 @@ func main() {
 		pkg_logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Stop background workers
+	r.Shutdown()
+
 	pkg_logger.Info("Server stopped gracefully")
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"mikrobill/pkg/utils"
 	"os"
 	"strconv"
 	"time"
@@ -16,6 +17,8 @@ type Config struct {
 	Redis    RedisConfig    `yaml:"redis"`
 	Crypto   CryptoConfig   `yaml:"crypto"`
 	Logger   LoggerConfig   `yaml:"logger"`
+
+	PaymentGateway PaymentGatewayConfig `yaml:"payment_gateway"`
 }
 
 type ServerConfig struct {
@@ -53,12 +56,37 @@ type RedisConfig struct {
 
 type CryptoConfig struct {
 	EncryptionKey string `yaml:"encryption_key"`
+	KeyID         string `yaml:"key_id"` // prefixed to ciphertexts, defaults to k1
+	// Retired keys by id, kept so values written before a rotation can still be read
+	PreviousKeys map[string]string `yaml:"previous_keys"`
+}
+
+// Cipher builds the cipher used for credentials stored in the database
+func (c *CryptoConfig) Cipher() (*utils.Cipher, error) {
+	keyID := c.KeyID
+	if keyID == "" {
+		keyID = "k1"
+	}
+	return utils.NewCipher(keyID, c.EncryptionKey, c.PreviousKeys)
 }
 
 type LoggerConfig struct {
 	Environment string `yaml:"environment"`
 }
 
+type PaymentGatewayConfig struct {
+	ChargeExpiry time.Duration  `yaml:"charge_expiry"` // how long a payment link stays valid
+	Midtrans     MidtransConfig `yaml:"midtrans"`
+}
+
+type MidtransConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	ServerKey string        `yaml:"server_key"`
+	APIURL    string        `yaml:"api_url"`  // Core API (status), defaults to sandbox
+	SnapURL   string        `yaml:"snap_url"` // Snap API (charges), defaults to sandbox
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -86,6 +114,12 @@ func LoadConfig(path string) (*Config, error) {
 	if encKey := os.Getenv("ENCRYPTION_KEY"); encKey != "" {
 		config.Crypto.EncryptionKey = encKey
 	}
+	if keyID := os.Getenv("ENCRYPTION_KEY_ID"); keyID != "" {
+		config.Crypto.KeyID = keyID
+	}
+	if serverKey := os.Getenv("MIDTRANS_SERVER_KEY"); serverKey != "" {
+		config.PaymentGateway.Midtrans.ServerKey = serverKey
+	}
 	if redisHost := os.Getenv("REDIS_HOST"); redisHost != "" {
 		config.Redis.Host = redisHost
 	}
@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AgentHandler handles reseller agents: admin management and the agents' own endpoints
+type AgentHandler struct {
+	service usecase.AgentUsecase
+}
+
+// NewAgentHandler creates a new agent handler
+func NewAgentHandler(service usecase.AgentUsecase) *AgentHandler {
+	return &AgentHandler{
+		service: service,
+	}
+}
+
+// CreateAgent handles registering a new agent
+// POST /api/agents
+func (h *AgentHandler) CreateAgent(c *gin.Context) {
+	var req model.CreateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	agent, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[AgentHandler] CreateAgent - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": agent})
+}
+
+// ListAgents handles listing agents with filters
+// GET /api/agents?status=&search=&page=&limit=
+func (h *AgentHandler) ListAgents(c *gin.Context) {
+	var req model.AgentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[AgentHandler] ListAgents - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetAgent handles getting a single agent with its balance
+// GET /api/agents/:id
+func (h *AgentHandler) GetAgent(c *gin.Context) {
+	agent, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": agent})
+}
+
+// UpdateAgent handles updating an agent
+// PUT /api/agents/:id
+func (h *AgentHandler) UpdateAgent(c *gin.Context) {
+	var req model.UpdateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	agent, err := h.service.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[AgentHandler] UpdateAgent - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": agent})
+}
+
+// ListAgentTransactions handles listing an agent's ledger
+// GET /api/agents/:id/transactions?type=&from=&to=&page=&limit=
+func (h *AgentHandler) ListAgentTransactions(c *gin.Context) {
+	h.listTransactions(c, c.Param("id"))
+}
+
+// ListBalanceRequests handles listing top-up requests of all agents
+// GET /api/agents/balance-requests?status=&agent_id=&page=&limit=
+func (h *AgentHandler) ListBalanceRequests(c *gin.Context) {
+	var req model.BalanceRequestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListBalanceRequests(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// ApproveBalanceRequest handles approving a top-up, crediting the agent's balance
+// POST /api/agents/balance-requests/:id/approve
+func (h *AgentHandler) ApproveBalanceRequest(c *gin.Context) {
+	h.processBalanceRequest(c, true)
+}
+
+// RejectBalanceRequest handles rejecting a top-up
+// POST /api/agents/balance-requests/:id/reject
+func (h *AgentHandler) RejectBalanceRequest(c *gin.Context) {
+	h.processBalanceRequest(c, false)
+}
+
+func (h *AgentHandler) processBalanceRequest(c *gin.Context, approve bool) {
+	var req model.ProcessBalanceRequestRequest
+	// Notes are optional, so an empty body is fine
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var processedBy *int64
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(int64); ok {
+			processedBy = &id
+		}
+	}
+
+	request, err := h.service.ProcessTopUp(c.Request.Context(), c.Param("id"), approve, req, processedBy)
+	if err != nil {
+		log.Printf("[AgentHandler] ProcessBalanceRequest - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": request})
+}
+
+// Login handles agent authentication
+// POST /api/agent/login
+func (h *AgentHandler) Login(c *gin.Context) {
+	var req model.AgentLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	resp, err := h.service.Login(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// GetProfile handles getting the logged-in agent with its balance
+// GET /api/agent/profile
+func (h *AgentHandler) GetProfile(c *gin.Context) {
+	agent, err := h.service.GetByID(c.Request.Context(), c.GetString("agent_id"))
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": agent})
+}
+
+// ListMyTransactions handles listing the logged-in agent's ledger
+// GET /api/agent/transactions?type=&from=&to=&page=&limit=
+func (h *AgentHandler) ListMyTransactions(c *gin.Context) {
+	h.listTransactions(c, c.GetString("agent_id"))
+}
+
+func (h *AgentHandler) listTransactions(c *gin.Context, agentID string) {
+	var req model.AgentTransactionListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListTransactions(c.Request.Context(), agentID, req)
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// RequestTopUp handles the logged-in agent asking for a balance top-up
+// POST /api/agent/balance-requests
+func (h *AgentHandler) RequestTopUp(c *gin.Context) {
+	var req model.CreateBalanceRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	request, err := h.service.RequestTopUp(c.Request.Context(), c.GetString("agent_id"), req)
+	if err != nil {
+		log.Printf("[AgentHandler] RequestTopUp - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": request})
+}
+
+// ListMyBalanceRequests handles listing the logged-in agent's top-up requests
+// GET /api/agent/balance-requests?status=&page=&limit=
+func (h *AgentHandler) ListMyBalanceRequests(c *gin.Context) {
+	var req model.BalanceRequestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	req.AgentID = c.GetString("agent_id")
+
+	result, err := h.service.ListBalanceRequests(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// CollectPayment handles the logged-in agent recording a customer's invoice payment
+// POST /api/agent/invoices/:id/payments
+func (h *AgentHandler) CollectPayment(c *gin.Context) {
+	var req model.AgentCollectPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	payment, invoice, err := h.service.CollectPayment(c.Request.Context(), c.GetString("agent_id"), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[AgentHandler] CollectPayment - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Payment recorded successfully",
+		"data": gin.H{
+			"payment": payment,
+			"invoice": invoice,
+		},
+	})
+}
+
+// RecordVoucherSale handles the logged-in agent recording a sold voucher
+// POST /api/agent/voucher-sales
+func (h *AgentHandler) RecordVoucherSale(c *gin.Context) {
+	var req model.AgentVoucherSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sale, err := h.service.RecordVoucherSale(c.Request.Context(), c.GetString("agent_id"), req)
+	if err != nil {
+		log.Printf("[AgentHandler] RecordVoucherSale - Service error: %v", err)
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": sale})
+}
+
+// ListMyVoucherSales handles listing the logged-in agent's voucher sales
+// GET /api/agent/voucher-sales?page=&limit=
+func (h *AgentHandler) ListMyVoucherSales(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result, err := h.service.ListVoucherSales(c.Request.Context(), c.GetString("agent_id"), page, limit)
+	if err != nil {
+		c.JSON(agentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// paginated writes a paginated list in the shape used by the list endpoints
+func paginated(c *gin.Context, result *model.PaginationResponse) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result.Data,
+		"meta": gin.H{
+			"page":        result.Page,
+			"limit":       result.PageSize,
+			"total_items": result.TotalItems,
+			"total_pages": result.TotalPages,
+		},
+	})
+}
+
+// agentErrorStatus maps agent errors to HTTP status codes
+func agentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, utils.ErrAgentInactive):
+		return http.StatusForbidden
+	case errors.Is(err, utils.ErrAgentNotFound), errors.Is(err, utils.ErrBalanceRequestNotFound),
+		errors.Is(err, utils.ErrMikrotikNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrAgentAlreadyExists), errors.Is(err, utils.ErrBalanceRequestProcessed),
+		errors.Is(err, utils.ErrVoucherNotAvailable):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrInsufficientBalance):
+		return http.StatusUnprocessableEntity
+	default:
+		return paymentErrorStatus(err)
+	}
+}
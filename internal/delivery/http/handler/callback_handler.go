@@ -4,21 +4,25 @@ import (
 	"fmt"
 	"log"
 	"mikrobill/internal/entity"
+	"mikrobill/internal/port/repository"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CallbackHandler handles MikroTik callbacks
 type CallbackHandler struct {
-	repo      entity.CustomerRepository
-	publisher entity.RedisPublisher
+	repo        entity.CustomerRepository
+	sessionRepo repository.CustomerSessionRepository
+	publisher   entity.RedisPublisher
 }
 
 // NewCallbackHandler creates a new callback handler
-func NewCallbackHandler(repo entity.CustomerRepository, publisher entity.RedisPublisher) *CallbackHandler {
+func NewCallbackHandler(repo entity.CustomerRepository, sessionRepo repository.CustomerSessionRepository, publisher entity.RedisPublisher) *CallbackHandler {
 	return &CallbackHandler{
-		repo:      repo,
-		publisher: publisher,
+		repo:        repo,
+		sessionRepo: sessionRepo,
+		publisher:   publisher,
 	}
 }
 
@@ -51,7 +55,13 @@ func (h *CallbackHandler) HandlePPPoEUp(c *gin.Context) {
 
 	log.Printf("[INFO] Found customer: ID=%s, Name=%s", targetCustomer.ID, targetCustomer.Name)
 
-	err = h.repo.UpdateCustomerStatus(targetCustomer.ID, "active", &req.IPAddress, &req.MacAddress, &req.Interface)
+	// A suspended customer stays suspended until billing reactivates them
+	status := "active"
+	if targetCustomer.Status == "suspended" {
+		status = "suspended"
+	}
+
+	err = h.repo.UpdateCustomerStatus(targetCustomer.ID, status, &req.IPAddress, &req.MacAddress, &req.Interface)
 	if err != nil {
 		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -59,6 +69,26 @@ func (h *CallbackHandler) HandlePPPoEUp(c *gin.Context) {
 
 	log.Printf("Callback: Customer %s (%s) is now ONLINE", targetCustomer.Name, req.User)
 
+	session := &entity.CustomerSession{
+		CustomerID:  targetCustomer.ID,
+		MikrotikID:  targetCustomer.MikrotikID,
+		ServiceType: "pppoe",
+		Username:    req.User,
+		StartedAt:   time.Now(),
+	}
+	if req.IPAddress != "" {
+		session.IPAddress = &req.IPAddress
+	}
+	if req.MacAddress != "" {
+		session.CallerID = &req.MacAddress
+	}
+	if req.Interface != "" {
+		session.Interface = &req.Interface
+	}
+	if err := h.sessionRepo.Open(c.Request.Context(), session); err != nil {
+		log.Printf("[WARN] Failed to record session start: %v", err)
+	}
+
 	// Publish event to Redis
 	eventData := fmt.Sprintf(`{"type":"pppoe_event","status":"connected","customer_id":"%s","name":"%s","ip":"%s","interface":"%s"}`,
 		targetCustomer.ID, targetCustomer.Name, req.IPAddress, req.Interface)
@@ -72,7 +102,10 @@ func (h *CallbackHandler) HandlePPPoEUp(c *gin.Context) {
 
 // PPPoEDownRequest represents the payload for on-down callback
 type PPPoEDownRequest struct {
-	User string `json:"user" binding:"required"`
+	User     string `json:"user" binding:"required"`
+	Reason   string `json:"reason"` // e.g. $"last-disconnect-reason" of the secret
+	BytesIn  *int64 `json:"bytes_in"`
+	BytesOut *int64 `json:"bytes_out"`
 }
 
 // HandlePPPoEDown handles PPPoE on-down callback
@@ -96,7 +129,13 @@ func (h *CallbackHandler) HandlePPPoEDown(c *gin.Context) {
 
 	log.Printf("[INFO] Found customer: ID=%s, Name=%s", targetCustomer.ID, targetCustomer.Name)
 
-	err = h.repo.UpdateCustomerStatus(targetCustomer.ID, "inactive", nil, nil, nil)
+	// Suspension kicks the session, which must not overwrite the suspended status
+	status := "inactive"
+	if targetCustomer.Status == "suspended" {
+		status = "suspended"
+	}
+
+	err = h.repo.UpdateCustomerStatus(targetCustomer.ID, status, nil, nil, nil)
 	if err != nil {
 		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
 		return
@@ -104,6 +143,16 @@ func (h *CallbackHandler) HandlePPPoEDown(c *gin.Context) {
 
 	log.Printf("Callback: Customer %s (%s) is now OFFLINE", targetCustomer.Name, req.User)
 
+	end := entity.CustomerSessionEnd{
+		EndedAt:  time.Now(),
+		Reason:   req.Reason,
+		BytesIn:  req.BytesIn,
+		BytesOut: req.BytesOut,
+	}
+	if err := h.sessionRepo.Close(c.Request.Context(), targetCustomer.ID, "", end); err != nil {
+		log.Printf("[WARN] Failed to record session end: %v", err)
+	}
+
 	// Publish event to Redis
 	eventData := fmt.Sprintf(`{"type":"pppoe_event","status":"disconnected","customer_id":"%s","name":"%s"}`,
 		targetCustomer.ID, targetCustomer.Name)
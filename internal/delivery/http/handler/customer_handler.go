@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
 	"mikrobill/internal/usecase"
-	"strconv"
+	"mikrobill/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,14 +29,24 @@ type CreateCustomerRequest struct {
 	MikrotikID  string `json:"mikrotik_id" binding:"required"` // Add MikrotikID
 	Name        string `json:"name" binding:"required"`
 	Username    string `json:"username" binding:"required"`     // App username
-	ServiceType string `json:"service_type" binding:"required"` // pppoe, hotspot
+	ServiceType string `json:"service_type" binding:"required"` // pppoe, hotspot, static_ip
 
 	PPPoEUsername  *string `json:"pppoe_username"`
 	PPPoEPassword  *string `json:"pppoe_password"`
 	PPPoEProfileID *string `json:"pppoe_profile_id"`
 
-	Phone *string `json:"phone"`
-	Email *string `json:"email"`
+	// Static IP: the address is bound to the MAC address with a static ARP entry
+	StaticIP          *string `json:"static_ip" binding:"omitempty,ipv4"`
+	StaticIPProfileID *string `json:"static_ip_profile_id"`
+	MacAddress        *string `json:"mac_address" binding:"omitempty,mac"`
+
+	Phone   *string `json:"phone"`
+	Email   *string `json:"email"`
+	Address *string `json:"address"`
+
+	BillingDay     int     `json:"billing_day" binding:"omitempty,min=1,max=31"`
+	AutoSuspension *bool   `json:"auto_suspension"`
+	SuspensionMode *string `json:"suspension_mode" binding:"omitempty,oneof=disable isolate"` // empty inherits the router's mode
 }
 
 // CreateCustomer handles customer creation
@@ -49,21 +61,32 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 	newID := uuid.New().String()
 
 	customer := &entity.Customer{
-		ID:             newID,
-		MikrotikID:     req.MikrotikID, // Assign MikrotikID
-		Name:           req.Name,
-		Username:       req.Username,
-		ServiceType:    req.ServiceType,
-		PPPoEUsername:  req.PPPoEUsername,
-		PPPoEPassword:  req.PPPoEPassword,
-		PPPoEProfileID: req.PPPoEProfileID,
-		Phone:          req.Phone,
-		Email:          req.Email,
-		Status:         "inactive", // Default status
+		ID:                newID,
+		MikrotikID:        req.MikrotikID, // Assign MikrotikID
+		Name:              req.Name,
+		Username:          req.Username,
+		ServiceType:       req.ServiceType,
+		PPPoEUsername:     req.PPPoEUsername,
+		PPPoEPassword:     req.PPPoEPassword,
+		PPPoEProfileID:    req.PPPoEProfileID,
+		StaticIP:          req.StaticIP,
+		StaticIPProfileID: req.StaticIPProfileID,
+		MacAddress:        req.MacAddress,
+		Phone:             req.Phone,
+		Email:             req.Email,
+		Address:           req.Address,
+		BillingDay:        req.BillingDay,
+		AutoSuspension:    req.AutoSuspension,
+		SuspensionMode:    req.SuspensionMode,
+		Status:            "inactive", // Default status
 	}
 
 	if err := h.service.CreateCustomer(customer); err != nil {
 		log.Printf("Failed to create customer: %v", err)
+		if errors.Is(err, utils.ErrSealedPassword) {
+			c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
@@ -88,18 +111,29 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 	// Map request to domain.Customer
 	// Note: In real app, we should fetch first to merge, but Service handles full update currently
 	customer := &entity.Customer{
-		ID:             id,
-		Name:           req.Name,
-		Username:       req.Username,
-		ServiceType:    req.ServiceType,
-		PPPoEUsername:  req.PPPoEUsername,
-		PPPoEPassword:  req.PPPoEPassword,
-		PPPoEProfileID: req.PPPoEProfileID,
-		Phone:          req.Phone,
-		Email:          req.Email,
+		ID:                id,
+		Name:              req.Name,
+		Username:          req.Username,
+		ServiceType:       req.ServiceType,
+		PPPoEUsername:     req.PPPoEUsername,
+		PPPoEPassword:     req.PPPoEPassword,
+		PPPoEProfileID:    req.PPPoEProfileID,
+		StaticIP:          req.StaticIP,
+		StaticIPProfileID: req.StaticIPProfileID,
+		MacAddress:        req.MacAddress,
+		Phone:             req.Phone,
+		Email:             req.Email,
+		Address:           req.Address,
+		BillingDay:        req.BillingDay,
+		AutoSuspension:    req.AutoSuspension,
+		SuspensionMode:    req.SuspensionMode,
 	}
 
 	if err := h.service.UpdateCustomer(customer); err != nil {
+		if errors.Is(err, utils.ErrSealedPassword) {
+			c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
@@ -120,6 +154,39 @@ func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "success"})
 }
 
+// ListSyncJobs handles listing the customer's latest router sync jobs
+// GET /api/customers/:id/sync
+func (h *CustomerHandler) ListSyncJobs(c *gin.Context) {
+	jobs, err := h.service.ListSyncJobs(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": jobs})
+}
+
+// ResyncCustomer handles queueing a new router sync of the customer
+// POST /api/customers/:id/sync
+func (h *CustomerHandler) ResyncCustomer(c *gin.Context) {
+	job, err := h.service.ResyncCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, utils.ErrCustomerNotFound):
+			status = 404
+		case errors.Is(err, utils.ErrNothingToSync):
+			status = 400
+		default:
+			log.Printf("[CustomerHandler] ResyncCustomer - Service error: %v", err)
+		}
+		c.JSON(status, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(202, gin.H{"status": "success", "data": job})
+}
+
 // GetCustomer handles getting single customer
 // GET /api/customers/:id
 func (h *CustomerHandler) GetCustomer(c *gin.Context) {
@@ -134,25 +201,37 @@ func (h *CustomerHandler) GetCustomer(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "success", "data": customer})
 }
 
-// ListCustomers handles listing customers with pagination
+// ListCustomers handles searching, filtering and paging through customers
 // GET /api/customers
 func (h *CustomerHandler) ListCustomers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	var req model.CustomerListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 
-	customers, total, err := h.service.ListCustomers(page, limit)
+	page, err := h.service.ListCustomers(req)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
+	meta := gin.H{
+		"limit":       page.Limit,
+		"count":       len(page.Customers),
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	}
+	if page.Page > 0 {
+		meta["page"] = page.Page
+	}
 	c.JSON(200, gin.H{
 		"status": "success",
-		"data":   customers,
-		"meta": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
+		"data":   page.Customers,
+		"meta":   meta,
 	})
 }
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerImportHandler handles importing customers
+type CustomerImportHandler struct {
+	service usecase.CustomerImportUsecase
+}
+
+// NewCustomerImportHandler creates a new customer import handler
+func NewCustomerImportHandler(service usecase.CustomerImportUsecase) *CustomerImportHandler {
+	return &CustomerImportHandler{
+		service: service,
+	}
+}
+
+// ImportFromRouter handles importing a router's PPP secrets and hotspot users as customers
+// POST /api/mikrotiks/:id/customers/import
+func (h *CustomerImportHandler) ImportFromRouter(c *gin.Context) {
+	var req model.RouterImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportFromRouter(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[CustomerImportHandler] ImportFromRouter - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+}
+
+// maxImportFileSize caps uploaded spreadsheets
+const maxImportFileSize = 10 << 20
+
+// ImportFile handles importing customers from a CSV or XLSX file. Large files are
+// queued; the response then carries the job to poll.
+// POST /api/customers/import (multipart: file, mikrotik_id, mapping, dry_run, provision, async)
+func (h *CustomerImportHandler) ImportFile(c *gin.Context) {
+	var req model.FileImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "file is required"})
+		return
+	}
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"status": "error", "message": "file is larger than 10 MB"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	resp, err := h.service.ImportFile(c.Request.Context(), req, header.Filename, data)
+	if err != nil {
+		log.Printf("[CustomerImportHandler] ImportFile - Service error: %v", err)
+		c.JSON(customerImportErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Queued {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, gin.H{"status": "success", "data": resp})
+}
+
+// GetImportJob handles getting the state and result of a queued import
+// GET /api/customers/import/:id
+func (h *CustomerImportHandler) GetImportJob(c *gin.Context) {
+	job, err := h.service.GetImportJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(customerImportErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": job})
+}
+
+// Export handles streaming the filtered customer list as CSV or XLSX
+// GET /api/customers/export?format=csv|xlsx&mikrotik_id=&service_type=&status=&search=
+func (h *CustomerImportHandler) Export(c *gin.Context) {
+	var req model.CustomerExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	ext := "csv"
+	if req.Format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		ext = "xlsx"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="customers-%s.%s"`, time.Now().Format("20060102"), ext))
+
+	if err := h.service.Export(c.Request.Context(), req, c.Writer); err != nil {
+		log.Printf("[CustomerImportHandler] Export - Service error: %v", err)
+		// Once rows are streamed the status is sent and the download is cut short instead
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.Header("Content-Type", "")
+			c.JSON(customerImportErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		}
+	}
+}
+
+func customerImportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedFileFormat), errors.Is(err, utils.ErrInvalidImportFile):
+		return http.StatusBadRequest
+	case errors.Is(err, utils.ErrImportJobNotFound):
+		return http.StatusNotFound
+	default:
+		return hotspotErrorStatus(err)
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerSessionHandler handles a customer's PPPoE and hotspot session history
+type CustomerSessionHandler struct {
+	service usecase.CustomerSessionUsecase
+}
+
+// NewCustomerSessionHandler creates a new customer session handler
+func NewCustomerSessionHandler(service usecase.CustomerSessionUsecase) *CustomerSessionHandler {
+	return &CustomerSessionHandler{
+		service: service,
+	}
+}
+
+// ListSessions handles listing a customer's sessions, newest first
+// GET /api/customers/:id/sessions?page=&limit=
+func (h *CustomerSessionHandler) ListSessions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := h.service.List(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		log.Printf("[CustomerSessionHandler] ListSessions - Service error: %v", err)
+		c.JSON(customerSessionErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// SessionStats handles uptime and traffic totals of a customer's sessions
+// GET /api/customers/:id/sessions/stats?days=30
+func (h *CustomerSessionHandler) SessionStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	stats, err := h.service.Stats(c.Request.Context(), c.Param("id"), days)
+	if err != nil {
+		log.Printf("[CustomerSessionHandler] SessionStats - Service error: %v", err)
+		c.JSON(customerSessionErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": stats})
+}
+
+// customerSessionErrorStatus maps session history errors to HTTP status codes
+func customerSessionErrorStatus(err error) int {
+	if errors.Is(err, utils.ErrCustomerNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FUPHandler handles fair usage quota status
+type FUPHandler struct {
+	service usecase.FUPUsecase
+}
+
+// NewFUPHandler creates a new fair usage handler
+func NewFUPHandler(service usecase.FUPUsecase) *FUPHandler {
+	return &FUPHandler{
+		service: service,
+	}
+}
+
+// GetStatus handles a customer's usage against their profile's quota in the current billing cycle
+// GET /api/customers/:id/fup
+func (h *FUPHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("[FUPHandler] GetStatus - Service error: %v", err)
+		c.JSON(fupErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": status})
+}
+
+// fupErrorStatus maps fair usage errors to HTTP status codes
+func fupErrorStatus(err error) int {
+	if errors.Is(err, utils.ErrCustomerNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HotspotHandler handles a router's hotspot
+type HotspotHandler struct {
+	service usecase.HotspotUsecase
+}
+
+// NewHotspotHandler creates a new hotspot handler
+func NewHotspotHandler(service usecase.HotspotUsecase) *HotspotHandler {
+	return &HotspotHandler{
+		service: service,
+	}
+}
+
+// ListUsers handles listing hotspot users
+// GET /api/mikrotiks/:id/hotspot/users?search=&profile=&server=&disabled=&page=&limit=
+func (h *HotspotHandler) ListUsers(c *gin.Context) {
+	var req model.HotspotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListUsers(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] ListUsers - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetUser handles getting a hotspot user by router id or name
+// GET /api/mikrotiks/:id/hotspot/users/:user
+func (h *HotspotHandler) GetUser(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), c.Param("id"), c.Param("user"))
+	if err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": user})
+}
+
+// CreateUser handles adding a hotspot user
+// POST /api/mikrotiks/:id/hotspot/users
+func (h *HotspotHandler) CreateUser(c *gin.Context) {
+	var req model.HotspotUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] CreateUser - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": user})
+}
+
+// UpdateUser handles updating a hotspot user
+// PUT /api/mikrotiks/:id/hotspot/users/:user
+func (h *HotspotHandler) UpdateUser(c *gin.Context) {
+	var req model.UpdateHotspotUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	user, err := h.service.UpdateUser(c.Request.Context(), c.Param("id"), c.Param("user"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] UpdateUser - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": user})
+}
+
+// DeleteUser handles removing a hotspot user
+// DELETE /api/mikrotiks/:id/hotspot/users/:user
+func (h *HotspotHandler) DeleteUser(c *gin.Context) {
+	if err := h.service.DeleteUser(c.Request.Context(), c.Param("id"), c.Param("user")); err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Hotspot user removed"})
+}
+
+// ListProfiles handles listing hotspot user profiles
+// GET /api/mikrotiks/:id/hotspot/profiles?search=&page=&limit=
+func (h *HotspotHandler) ListProfiles(c *gin.Context) {
+	var req model.HotspotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListProfiles(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] ListProfiles - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetProfile handles getting a hotspot user profile by router id or name
+// GET /api/mikrotiks/:id/hotspot/profiles/:profile
+func (h *HotspotHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Request.Context(), c.Param("id"), c.Param("profile"))
+	if err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": profile})
+}
+
+// CreateProfile handles adding a hotspot user profile
+// POST /api/mikrotiks/:id/hotspot/profiles
+func (h *HotspotHandler) CreateProfile(c *gin.Context) {
+	var req model.HotspotProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	profile, err := h.service.CreateProfile(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] CreateProfile - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": profile})
+}
+
+// UpdateProfile handles updating a hotspot user profile
+// PUT /api/mikrotiks/:id/hotspot/profiles/:profile
+func (h *HotspotHandler) UpdateProfile(c *gin.Context) {
+	var req model.UpdateHotspotProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(c.Request.Context(), c.Param("id"), c.Param("profile"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] UpdateProfile - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": profile})
+}
+
+// DeleteProfile handles removing a hotspot user profile
+// DELETE /api/mikrotiks/:id/hotspot/profiles/:profile
+func (h *HotspotHandler) DeleteProfile(c *gin.Context) {
+	if err := h.service.DeleteProfile(c.Request.Context(), c.Param("id"), c.Param("profile")); err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Hotspot user profile removed"})
+}
+
+// ListActive handles listing active hotspot sessions
+// GET /api/mikrotiks/:id/hotspot/active?search=&server=&page=&limit=
+func (h *HotspotHandler) ListActive(c *gin.Context) {
+	var req model.HotspotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListActive(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] ListActive - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// Disconnect handles kicking an active hotspot session
+// DELETE /api/mikrotiks/:id/hotspot/active/:active
+func (h *HotspotHandler) Disconnect(c *gin.Context) {
+	if err := h.service.Disconnect(c.Request.Context(), c.Param("id"), c.Param("active")); err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Hotspot session disconnected"})
+}
+
+// ListHosts handles listing hotspot hosts
+// GET /api/mikrotiks/:id/hotspot/hosts?search=&server=&page=&limit=
+func (h *HotspotHandler) ListHosts(c *gin.Context) {
+	var req model.HotspotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListHosts(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] ListHosts - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// RemoveHost handles removing a hotspot host
+// DELETE /api/mikrotiks/:id/hotspot/hosts/:host
+func (h *HotspotHandler) RemoveHost(c *gin.Context) {
+	if err := h.service.RemoveHost(c.Request.Context(), c.Param("id"), c.Param("host")); err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Hotspot host removed"})
+}
+
+// ListVouchers handles listing the router's stored vouchers
+// GET /api/mikrotiks/:id/hotspot/vouchers?batch_id=&state=&agent_id=&search=&page=&limit=
+func (h *HotspotHandler) ListVouchers(c *gin.Context) {
+	var req model.VoucherListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListVouchers(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GenerateVouchers handles generating a voucher batch on the router
+// POST /api/mikrotiks/:id/hotspot/vouchers
+func (h *HotspotHandler) GenerateVouchers(c *gin.Context) {
+	var req model.GenerateVoucherBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var createdBy *int64
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(int64); ok {
+			createdBy = &id
+		}
+	}
+
+	result, err := h.service.GenerateVouchers(c.Request.Context(), c.Param("id"), req, createdBy)
+	if err != nil {
+		log.Printf("[HotspotHandler] GenerateVouchers - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": result})
+}
+
+// Report handles the sales recorded by profile on-login scripts for a day or month
+// GET /api/mikrotiks/:id/hotspot/reports?date=YYYY-MM-DD|month=YYYY-MM
+func (h *HotspotHandler) Report(c *gin.Context) {
+	var req model.HotspotReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[HotspotHandler] Report - Service error: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report})
+}
+
+// hotspotErrorStatus maps hotspot errors to HTTP status codes
+func hotspotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrHotspotUserNotFound), errors.Is(err, utils.ErrHotspotProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrInvalidDate):
+		return http.StatusBadRequest
+	case errors.Is(err, utils.ErrRouterRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, utils.ErrConnectionFailed):
+		return http.StatusBadGateway
+	default:
+		return voucherErrorStatus(err)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler handles HTTP requests for invoices
+type InvoiceHandler struct {
+	service usecase.InvoiceUsecase
+}
+
+// NewInvoiceHandler creates a new invoice handler
+func NewInvoiceHandler(service usecase.InvoiceUsecase) *InvoiceHandler {
+	return &InvoiceHandler{
+		service: service,
+	}
+}
+
+// ListInvoices handles listing invoices with filters
+// GET /api/invoices?status=&customer_id=&mikrotik_id=&type=&due_from=&due_to=&search=&page=&limit=
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	var req model.InvoiceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[InvoiceHandler] ListInvoices - Service error: %v", err)
+		c.JSON(invoiceErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result.Data,
+		"meta": gin.H{
+			"page":        result.Page,
+			"limit":       result.PageSize,
+			"total_items": result.TotalItems,
+			"total_pages": result.TotalPages,
+		},
+	})
+}
+
+// CreateInvoice handles creating a manual invoice
+// POST /api/invoices
+func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
+	var req model.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	invoice, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[InvoiceHandler] CreateInvoice - Service error: %v", err)
+		c.JSON(invoiceErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Invoice created successfully",
+		"data":    invoice,
+	})
+}
+
+// GetInvoice handles getting a single invoice
+// GET /api/invoices/:id
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": invoice})
+}
+
+// UpdateInvoice handles updating an invoice
+// PUT /api/invoices/:id
+func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
+	var req model.UpdateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	invoice, err := h.service.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[InvoiceHandler] UpdateInvoice - Service error: %v", err)
+		c.JSON(invoiceErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": invoice})
+}
+
+// DeleteInvoice handles deleting an invoice
+// DELETE /api/invoices/:id
+func (h *InvoiceHandler) DeleteInvoice(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("[InvoiceHandler] DeleteInvoice - Service error: %v", err)
+		c.JSON(invoiceErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// GenerateInvoices handles running monthly invoice generation for a date
+// POST /api/invoices/generate
+func (h *InvoiceHandler) GenerateInvoices(c *gin.Context) {
+	var req model.GenerateInvoicesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		date = parsed
+	}
+
+	result, err := h.service.GenerateMonthlyInvoices(c.Request.Context(), date)
+	if err != nil {
+		log.Printf("[InvoiceHandler] GenerateInvoices - Service error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+}
+
+// invoiceErrorStatus maps billing errors to HTTP status codes
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrInvoiceNotFound), errors.Is(err, utils.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrInvoiceAlreadyExists), errors.Is(err, utils.ErrInvoiceNotDeletable),
+		errors.Is(err, utils.ErrInvoiceStatusChange):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrInvalidDate):
+		return http.StatusBadRequest
+	case errors.Is(err, utils.ErrInvoicePriceNotSet):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
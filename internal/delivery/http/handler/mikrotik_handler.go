@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"mikrobill/internal/model"
 	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
 	"net/http"
 	"strconv"
 
@@ -79,3 +81,131 @@ func (h *MikrotikHandler) CreateMikrotik(c *gin.Context) {
 		"data":    mikrotik,
 	})
 }
+
+// GetMikrotik handles getting a mikrotik by id
+// GET /api/mikrotiks/:id
+func (h *MikrotikHandler) GetMikrotik(c *gin.Context) {
+	mikrotik, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": mikrotik})
+}
+
+// UpdateMikrotik handles updating a mikrotik; omitted fields keep their value
+// PUT /api/mikrotiks/:id
+func (h *MikrotikHandler) UpdateMikrotik(c *gin.Context) {
+	var req model.UpdateMikrotikRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.service.Update(c.Request.Context(), id, req); err != nil {
+		log.Printf("[MikrotikHandler] UpdateMikrotik - Service error: %v", err)
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	mikrotik, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Mikrotik updated successfully",
+		"data":    mikrotik,
+	})
+}
+
+// DeleteMikrotik handles deleting a mikrotik that has no customers
+// DELETE /api/mikrotiks/:id
+func (h *MikrotikHandler) DeleteMikrotik(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("[MikrotikHandler] DeleteMikrotik - Service error: %v", err)
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Mikrotik deleted successfully"})
+}
+
+// TestConnection handles dialing a mikrotik and reading its system resource
+// POST /api/mikrotiks/:id/test
+func (h *MikrotikHandler) TestConnection(c *gin.Context) {
+	if err := h.service.TestConnectionByID(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Connection successful"})
+}
+
+// ActivateMikrotik handles making a mikrotik the default one
+// POST /api/mikrotiks/:id/activate
+func (h *MikrotikHandler) ActivateMikrotik(c *gin.Context) {
+	if err := h.service.SetActiveMikrotik(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("[MikrotikHandler] ActivateMikrotik - Service error: %v", err)
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	active, err := h.service.GetActiveMikrotik(c.Request.Context())
+	if err != nil {
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Mikrotik activated successfully",
+		"data":    active,
+	})
+}
+
+// GetActiveMikrotik handles getting the default mikrotik
+// GET /api/mikrotiks/active
+func (h *MikrotikHandler) GetActiveMikrotik(c *gin.Context) {
+	active, err := h.service.GetActiveMikrotik(c.Request.Context())
+	if err != nil {
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": active})
+}
+
+// ListStatusHistory handles listing a router's status transitions
+// GET /api/mikrotiks/:id/status-history?page=&limit=
+func (h *MikrotikHandler) ListStatusHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := h.service.ListStatusHistory(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		log.Printf("[MikrotikHandler] ListStatusHistory - Service error: %v", err)
+		c.JSON(mikrotikErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// mikrotikErrorStatus maps mikrotik errors to HTTP status codes
+func mikrotikErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrMikrotikNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrMikrotikInUse):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrConnectionFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
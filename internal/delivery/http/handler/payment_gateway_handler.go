@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxNotificationSize bounds the callback body read from a gateway
+const maxNotificationSize = 1 << 20
+
+// PaymentGatewayHandler handles online payment charges and gateway callbacks
+type PaymentGatewayHandler struct {
+	service usecase.PaymentGatewayUsecase
+}
+
+// NewPaymentGatewayHandler creates a new payment gateway handler
+func NewPaymentGatewayHandler(service usecase.PaymentGatewayUsecase) *PaymentGatewayHandler {
+	return &PaymentGatewayHandler{
+		service: service,
+	}
+}
+
+// CreateCharge handles creating an online payment link for an invoice
+// POST /api/invoices/:id/gateway-charges
+func (h *PaymentGatewayHandler) CreateCharge(c *gin.Context) {
+	var req model.CreateGatewayChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	txn, err := h.service.CreateCharge(c.Request.Context(), c.Param("id"), req.Gateway)
+	if err != nil {
+		log.Printf("[PaymentGatewayHandler] CreateCharge - Service error: %v", err)
+		c.JSON(gatewayErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": txn})
+}
+
+// ListInvoiceCharges handles listing the gateway transactions of one invoice
+// GET /api/invoices/:id/gateway-charges
+func (h *PaymentGatewayHandler) ListInvoiceCharges(c *gin.Context) {
+	txns, err := h.service.ListByInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(gatewayErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": txns})
+}
+
+// SyncTransaction handles pulling a transaction's status from its gateway
+// POST /api/gateway-transactions/:id/sync
+func (h *PaymentGatewayHandler) SyncTransaction(c *gin.Context) {
+	txn, err := h.service.SyncStatus(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("[PaymentGatewayHandler] SyncTransaction - Service error: %v", err)
+		c.JSON(gatewayErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": txn})
+}
+
+// HandleNotification handles payment notifications pushed by a gateway.
+// Anything but a 2xx makes the gateway retry, so only unprocessable requests are rejected.
+// POST /api/callbacks/payments/:gateway
+func (h *PaymentGatewayHandler) HandleNotification(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxNotificationSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	gateway := c.Param("gateway")
+	if err := h.service.HandleNotification(c.Request.Context(), gateway, c.Request.Header, body); err != nil {
+		log.Printf("[PaymentGatewayHandler] HandleNotification - %s: %v", gateway, err)
+		c.JSON(gatewayErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// gatewayErrorStatus maps payment gateway errors to HTTP status codes
+func gatewayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, utils.ErrGatewayNotFound), errors.Is(err, utils.ErrGatewayTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrNothingToPay):
+		return http.StatusUnprocessableEntity
+	default:
+		return paymentErrorStatus(err)
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentHandler handles HTTP requests for invoice payments
+type PaymentHandler struct {
+	service usecase.PaymentUsecase
+}
+
+// NewPaymentHandler creates a new payment handler
+func NewPaymentHandler(service usecase.PaymentUsecase) *PaymentHandler {
+	return &PaymentHandler{
+		service: service,
+	}
+}
+
+// RecordPayment handles recording a cash/transfer payment against an invoice
+// POST /api/invoices/:id/payments
+func (h *PaymentHandler) RecordPayment(c *gin.Context) {
+	var req model.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	// Set by AuthMiddleware when the route is protected
+	var receivedBy *int64
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(int64); ok {
+			receivedBy = &id
+		}
+	}
+
+	payment, invoice, err := h.service.RecordPayment(c.Request.Context(), c.Param("id"), req, receivedBy)
+	if err != nil {
+		log.Printf("[PaymentHandler] RecordPayment - Service error: %v", err)
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Payment recorded successfully",
+		"data": gin.H{
+			"payment": payment,
+			"invoice": invoice,
+		},
+	})
+}
+
+// ListInvoicePayments handles listing the payments of one invoice
+// GET /api/invoices/:id/payments
+func (h *PaymentHandler) ListInvoicePayments(c *gin.Context) {
+	result, err := h.service.List(c.Request.Context(), model.PaymentListRequest{
+		InvoiceID: c.Param("id"),
+		Page:      1,
+		PageSize:  100,
+	})
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result.Data})
+}
+
+// ListPayments handles listing payments with filters
+// GET /api/payments?invoice_id=&customer_id=&method=&from=&to=&page=&limit=
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	var req model.PaymentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[PaymentHandler] ListPayments - Service error: %v", err)
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result.Data,
+		"meta": gin.H{
+			"page":        result.Page,
+			"limit":       result.PageSize,
+			"total_items": result.TotalItems,
+			"total_pages": result.TotalPages,
+		},
+	})
+}
+
+// GetPayment handles getting a single payment
+// GET /api/payments/:id
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	payment, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": payment})
+}
+
+// GetReceipt handles getting the receipt of a payment
+// GET /api/payments/:id/receipt
+func (h *PaymentHandler) GetReceipt(c *gin.Context) {
+	receipt, err := h.service.GetReceipt(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": receipt})
+}
+
+// VoidPayment handles removing a payment recorded by mistake
+// DELETE /api/payments/:id
+func (h *PaymentHandler) VoidPayment(c *gin.Context) {
+	invoice, err := h.service.VoidPayment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("[PaymentHandler] VoidPayment - Service error: %v", err)
+		c.JSON(paymentErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": invoice})
+}
+
+// paymentErrorStatus maps payment errors to HTTP status codes
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrPaymentExceedsBalance), errors.Is(err, utils.ErrInvoiceNotPayable):
+		return http.StatusUnprocessableEntity
+	default:
+		return invoiceErrorStatus(err)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"net/http"
 
 	"mikrobill/internal/entity"
-	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/usecase"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -15,15 +15,15 @@ import (
 
 // PingHandler handles ping requests to customer IPs
 type PingHandler struct {
-	client *mikrotik.Client
-	repo   entity.CustomerRepository
+	routers usecase.RouterManager
+	repo    entity.CustomerRepository
 }
 
 // NewPingHandler creates a new ping handler
-func NewPingHandler(client *mikrotik.Client, repo entity.CustomerRepository) *PingHandler {
+func NewPingHandler(routers usecase.RouterManager, repo entity.CustomerRepository) *PingHandler {
 	return &PingHandler{
-		client: client,
-		repo:   repo,
+		routers: routers,
+		repo:    repo,
 	}
 }
 
@@ -69,7 +69,7 @@ func (h *PingHandler) PingCustomerByID(c *gin.Context) {
 	}
 
 	// Perform ping
-	pingResult, err := h.pingIPAddress(ipAddress)
+	pingResult, err := h.pingIPAddress(c.Request.Context(), customer.MikrotikID, ipAddress)
 	if err != nil {
 		c.JSON(500, gin.H{
 			"status":        "error",
@@ -154,7 +154,15 @@ func (h *PingHandler) PingCustomerStream(c *gin.Context) {
 		}
 	}()
 
-	ptStream, err := h.client.StreamPing(ctx, ipAddress, "56", "1") // 1s interval by default
+	// Streams get their own connection to the customer's router
+	client, err := h.routers.Stream(ctx, customer.MikrotikID)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": "Failed to start ping: " + err.Error()})
+		return
+	}
+	defer client.Close()
+
+	ptStream, err := client.StreamPing(ctx, ipAddress, "56", "1") // 1s interval by default
 	if err != nil {
 		ws.WriteJSON(map[string]string{"type": "error", "error": "Failed to start ping: " + err.Error()})
 		return
@@ -246,11 +254,16 @@ type PingResult struct {
 	Received    string
 }
 
-func (h *PingHandler) pingIPAddress(ip string) (*PingResult, error) {
+func (h *PingHandler) pingIPAddress(ctx context.Context, mikrotikID, ip string) (*PingResult, error) {
+	client, err := h.routers.Client(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Execute simple ping command (non-streaming)
 	// count=3
 	cmd := []string{"/ping", "=address=" + ip, "=count=3"}
-	res, err := h.client.RunArgs(cmd)
+	res, err := client.RunArgs(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PPPHandler handles a router's PPP secrets, profiles and sessions
+type PPPHandler struct {
+	service usecase.PPPUsecase
+}
+
+// NewPPPHandler creates a new PPP handler
+func NewPPPHandler(service usecase.PPPUsecase) *PPPHandler {
+	return &PPPHandler{
+		service: service,
+	}
+}
+
+// ListActive handles listing active PPP sessions
+// GET /api/mikrotiks/:id/ppp/active?search=&service=&page=&limit=
+func (h *PPPHandler) ListActive(c *gin.Context) {
+	var req model.PPPListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListActive(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[PPPHandler] ListActive - Service error: %v", err)
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// Disconnect handles kicking a PPP session by router id (*1A), or every session of a username
+// DELETE /api/mikrotiks/:id/ppp/active/:active
+func (h *PPPHandler) Disconnect(c *gin.Context) {
+	active := c.Param("active")
+	if !strings.HasPrefix(active, "*") {
+		result, err := h.service.DisconnectUser(c.Request.Context(), c.Param("id"), active)
+		if err != nil {
+			c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+		return
+	}
+
+	if err := h.service.Disconnect(c.Request.Context(), c.Param("id"), active); err != nil {
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "PPP session disconnected"})
+}
+
+// ListSecrets handles listing PPP secrets, optionally only those no customer uses
+// GET /api/mikrotiks/:id/ppp/secrets?unlinked=true&search=&service=&profile=&disabled=&page=&limit=
+func (h *PPPHandler) ListSecrets(c *gin.Context) {
+	var req model.PPPListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListSecrets(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[PPPHandler] ListSecrets - Service error: %v", err)
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetSecret handles getting a PPP secret by router id or name
+// GET /api/mikrotiks/:id/ppp/secrets/:secret
+func (h *PPPHandler) GetSecret(c *gin.Context) {
+	secret, err := h.service.GetSecret(c.Request.Context(), c.Param("id"), c.Param("secret"))
+	if err != nil {
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": secret})
+}
+
+// EnableSecret handles enabling a PPP secret no customer uses
+// POST /api/mikrotiks/:id/ppp/secrets/:secret/enable
+func (h *PPPHandler) EnableSecret(c *gin.Context) {
+	secret, err := h.service.EnableSecret(c.Request.Context(), c.Param("id"), c.Param("secret"))
+	if err != nil {
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": secret})
+}
+
+// DisableSecret handles disabling a PPP secret no customer uses
+// POST /api/mikrotiks/:id/ppp/secrets/:secret/disable
+func (h *PPPHandler) DisableSecret(c *gin.Context) {
+	secret, err := h.service.DisableSecret(c.Request.Context(), c.Param("id"), c.Param("secret"))
+	if err != nil {
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": secret})
+}
+
+// ListProfiles handles listing the router's PPP profiles
+// GET /api/mikrotiks/:id/ppp/profiles?search=&page=&limit=
+func (h *PPPHandler) ListProfiles(c *gin.Context) {
+	var req model.PPPListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListProfiles(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[PPPHandler] ListProfiles - Service error: %v", err)
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetProfile handles getting a PPP profile by router id or name
+// GET /api/mikrotiks/:id/ppp/profiles/:profile
+func (h *PPPHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Request.Context(), c.Param("id"), c.Param("profile"))
+	if err != nil {
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": profile})
+}
+
+// Stats handles PPP session and secret counts per service
+// GET /api/mikrotiks/:id/ppp/stats
+func (h *PPPHandler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("[PPPHandler] Stats - Service error: %v", err)
+		c.JSON(pppErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": stats})
+}
+
+// pppErrorStatus maps PPP errors to HTTP status codes
+func pppErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrSecretNotFound), errors.Is(err, utils.ErrPPPProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrSecretLinked):
+		return http.StatusConflict
+	default:
+		return hotspotErrorStatus(err)
+	}
+}
@@ -39,8 +39,19 @@ type CreateProfileRequest struct {
 	Price                *float64 `json:"price"`
 	SyncWithMikrotik     bool     `json:"sync_with_mikrotik"`
 
+	// Monthly data quota in bytes and the rate applied past it; 0 turns the quota off
+	FUPQuotaBytes    *int64  `json:"fup_quota_bytes" binding:"omitempty,min=0"`
+	FUPRateLimitUp   *string `json:"fup_rate_limit_up"`
+	FUPRateLimitDown *string `json:"fup_rate_limit_down"`
+
 	// PPPoE specific fields
 	PPPoE *PPPoEDetailsRequest `json:"pppoe_details,omitempty"`
+
+	// Static IP specific fields
+	StaticIP *StaticIPDetailsRequest `json:"static_ip_details,omitempty"`
+
+	// Queue shaping on top of the rate limit; omitted on update removes it
+	QueueSettings *QueueSettingsRequest `json:"queue_settings,omitempty"`
 }
 
 // PPPoEDetailsRequest represents PPPoE-specific fields
@@ -58,6 +69,38 @@ type PPPoEDetailsRequest struct {
 	UseEncryption  bool    `json:"use_encryption"`
 }
 
+// StaticIPDetailsRequest represents static-IP specific fields
+type StaticIPDetailsRequest struct {
+	IPPool              *string  `json:"ip_pool"`
+	Gateway             string   `json:"gateway" binding:"required,ipv4"`
+	Netmask             string   `json:"netmask"`
+	Interface           string   `json:"interface" binding:"required"` // parent interface when vlan_id is set
+	AllowedMACAddresses []string `json:"allowed_mac_addresses" binding:"omitempty,dive,mac"`
+	FirewallChain       *string  `json:"firewall_chain"`
+	VLANID              *int     `json:"vlan_id" binding:"omitempty,min=1,max=4094"`
+	VLANPriority        *int     `json:"vlan_priority" binding:"omitempty,min=0,max=7"`
+	RouteDistance       *int     `json:"route_distance"`
+	RoutingMark         *string  `json:"routing_mark"`
+}
+
+// QueueSettingsRequest represents a profile's queue settings; limits take a
+// RouterOS rate such as 512k or 10M
+type QueueSettingsRequest struct {
+	QueueType          string   `json:"queue_type"`
+	ParentQueue        *string  `json:"parent_queue"`
+	Priority           string   `json:"priority" binding:"omitempty,numeric"`
+	BurstLimitUp       *string  `json:"burst_limit_up"`
+	BurstLimitDown     *string  `json:"burst_limit_down"`
+	BurstThresholdUp   *string  `json:"burst_threshold_up"`
+	BurstThresholdDown *string  `json:"burst_threshold_down"`
+	BurstTime          string   `json:"burst_time"`
+	LimitAtUp          *string  `json:"limit_at_up"`
+	LimitAtDown        *string  `json:"limit_at_down"`
+	MaxLimitUp         *string  `json:"max_limit_up"`
+	MaxLimitDown       *string  `json:"max_limit_down"`
+	PacketMarks        []string `json:"packet_marks"`
+}
+
 // CreateProfile handles profile creation
 // POST /api/profiles
 func (h *ProfileHandler) CreateProfile(c *gin.Context) {
@@ -79,6 +122,13 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 		})
 		return
 	}
+	if req.ProfileType == "static_ip" && req.StaticIP == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "static_ip_details required for static_ip profile",
+		})
+		return
+	}
 
 	// Create profile domain model
 	profile := &entity.MikrotikProfile{
@@ -97,6 +147,9 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 		Price:                req.Price,
 		SyncWithMikrotik:     req.SyncWithMikrotik,
 		IsActive:             true,
+		FUPQuotaBytes:        req.FUPQuotaBytes,
+		FUPRateLimitUp:       req.FUPRateLimitUp,
+		FUPRateLimitDown:     req.FUPRateLimitDown,
 	}
 
 	// Create PPPoE details if provided
@@ -128,7 +181,7 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 	}
 
 	// Create profile with sync
-	if err := h.service.CreateProfileWithSync(profile, pppoeDetails); err != nil {
+	if err := h.service.CreateProfileWithSync(profile, pppoeDetails, staticIPDetails(profile.ID, req.StaticIP), queueSettings(profile.ID, req.QueueSettings)); err != nil {
 		log.Printf("[ProfileHandler] CreateProfile - Service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -166,6 +219,13 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		})
 		return
 	}
+	if req.ProfileType == "static_ip" && req.StaticIP == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "static_ip_details required for static_ip profile",
+		})
+		return
+	}
 
 	// Create profile domain model
 	profile := &entity.MikrotikProfile{
@@ -184,6 +244,9 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		Price:                req.Price,
 		SyncWithMikrotik:     req.SyncWithMikrotik,
 		IsActive:             true,
+		FUPQuotaBytes:        req.FUPQuotaBytes,
+		FUPRateLimitUp:       req.FUPRateLimitUp,
+		FUPRateLimitDown:     req.FUPRateLimitDown,
 	}
 
 	// Create PPPoE details if provided
@@ -215,7 +278,7 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// Update profile with sync
-	if err := h.service.UpdateProfileWithSync(profile, pppoeDetails); err != nil {
+	if err := h.service.UpdateProfileWithSync(profile, pppoeDetails, staticIPDetails(id, req.StaticIP), queueSettings(id, req.QueueSettings)); err != nil {
 		log.Printf("[ProfileHandler] UpdateProfile - Service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -342,3 +405,63 @@ func (h *ProfileHandler) SyncAllProfiles(c *gin.Context) {
 		"message": "All profiles synced from MikroTik successfully",
 	})
 }
+
+// staticIPDetails converts the static-IP details of a profile request
+func staticIPDetails(profileID string, req *StaticIPDetailsRequest) *entity.MikrotikProfileStaticIP {
+	if req == nil {
+		return nil
+	}
+
+	netmask := req.Netmask
+	if netmask == "" {
+		netmask = "255.255.255.0"
+	}
+	return &entity.MikrotikProfileStaticIP{
+		ProfileID:           profileID,
+		IPPool:              req.IPPool,
+		Gateway:             req.Gateway,
+		Netmask:             netmask,
+		Interface:           &req.Interface,
+		AllowedMACAddresses: req.AllowedMACAddresses,
+		FirewallChain:       req.FirewallChain,
+		VLANID:              req.VLANID,
+		VLANPriority:        req.VLANPriority,
+		RouteDistance:       req.RouteDistance,
+		RoutingMark:         req.RoutingMark,
+	}
+}
+
+// queueSettings converts the queue settings of a profile request, filling the
+// defaults of the mikrotik_queue_settings table
+func queueSettings(profileID string, req *QueueSettingsRequest) *entity.MikrotikQueueSettings {
+	if req == nil {
+		return nil
+	}
+
+	settings := &entity.MikrotikQueueSettings{
+		ProfileID:          profileID,
+		QueueType:          req.QueueType,
+		ParentQueue:        req.ParentQueue,
+		Priority:           req.Priority,
+		BurstLimitUp:       req.BurstLimitUp,
+		BurstLimitDown:     req.BurstLimitDown,
+		BurstThresholdUp:   req.BurstThresholdUp,
+		BurstThresholdDown: req.BurstThresholdDown,
+		BurstTime:          req.BurstTime,
+		LimitAtUp:          req.LimitAtUp,
+		LimitAtDown:        req.LimitAtDown,
+		MaxLimitUp:         req.MaxLimitUp,
+		MaxLimitDown:       req.MaxLimitDown,
+		PacketMarks:        req.PacketMarks,
+	}
+	if settings.QueueType == "" {
+		settings.QueueType = "default"
+	}
+	if settings.Priority == "" {
+		settings.Priority = "8"
+	}
+	if settings.BurstTime == "" {
+		settings.BurstTime = "0s"
+	}
+	return settings
+}
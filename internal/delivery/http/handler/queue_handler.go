@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueHandler handles a router's simple queues and queue trees
+type QueueHandler struct {
+	service usecase.QueueUsecase
+}
+
+// NewQueueHandler creates a new queue handler
+func NewQueueHandler(service usecase.QueueUsecase) *QueueHandler {
+	return &QueueHandler{
+		service: service,
+	}
+}
+
+// ListSimple handles listing simple queues
+// GET /api/mikrotiks/:id/queues/simple?search=&parent=&disabled=&page=&limit=
+func (h *QueueHandler) ListSimple(c *gin.Context) {
+	var req model.QueueListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListSimple(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] ListSimple - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetSimple handles getting a simple queue by router id or name
+// GET /api/mikrotiks/:id/queues/simple/:queue
+func (h *QueueHandler) GetSimple(c *gin.Context) {
+	queue, err := h.service.GetSimple(c.Request.Context(), c.Param("id"), c.Param("queue"))
+	if err != nil {
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": queue})
+}
+
+// CreateSimple handles adding a simple queue
+// POST /api/mikrotiks/:id/queues/simple
+func (h *QueueHandler) CreateSimple(c *gin.Context) {
+	var req model.SimpleQueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	queue, err := h.service.CreateSimple(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] CreateSimple - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": queue})
+}
+
+// UpdateSimple handles updating a simple queue
+// PUT /api/mikrotiks/:id/queues/simple/:queue
+func (h *QueueHandler) UpdateSimple(c *gin.Context) {
+	var req model.UpdateSimpleQueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	queue, err := h.service.UpdateSimple(c.Request.Context(), c.Param("id"), c.Param("queue"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] UpdateSimple - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": queue})
+}
+
+// DeleteSimple handles removing a simple queue
+// DELETE /api/mikrotiks/:id/queues/simple/:queue
+func (h *QueueHandler) DeleteSimple(c *gin.Context) {
+	if err := h.service.DeleteSimple(c.Request.Context(), c.Param("id"), c.Param("queue")); err != nil {
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Simple queue removed"})
+}
+
+// ListTree handles listing queue tree entrys
+// GET /api/mikrotiks/:id/queues/tree?search=&parent=&disabled=&page=&limit=
+func (h *QueueHandler) ListTree(c *gin.Context) {
+	var req model.QueueListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListTree(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] ListTree - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetTree handles getting a queue tree entry by router id or name
+// GET /api/mikrotiks/:id/queues/tree/:queue
+func (h *QueueHandler) GetTree(c *gin.Context) {
+	queue, err := h.service.GetTree(c.Request.Context(), c.Param("id"), c.Param("queue"))
+	if err != nil {
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": queue})
+}
+
+// CreateTree handles adding a queue tree entry
+// POST /api/mikrotiks/:id/queues/tree
+func (h *QueueHandler) CreateTree(c *gin.Context) {
+	var req model.QueueTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	queue, err := h.service.CreateTree(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] CreateTree - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": queue})
+}
+
+// UpdateTree handles updating a queue tree entry
+// PUT /api/mikrotiks/:id/queues/tree/:queue
+func (h *QueueHandler) UpdateTree(c *gin.Context) {
+	var req model.UpdateQueueTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	queue, err := h.service.UpdateTree(c.Request.Context(), c.Param("id"), c.Param("queue"), req)
+	if err != nil {
+		log.Printf("[QueueHandler] UpdateTree - Service error: %v", err)
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": queue})
+}
+
+// DeleteTree handles removing a queue tree entry
+// DELETE /api/mikrotiks/:id/queues/tree/:queue
+func (h *QueueHandler) DeleteTree(c *gin.Context) {
+	if err := h.service.DeleteTree(c.Request.Context(), c.Param("id"), c.Param("queue")); err != nil {
+		c.JSON(queueErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Queue tree entry removed"})
+}
+
+func queueErrorStatus(err error) int {
+	if errors.Is(err, utils.ErrQueueNotFound) {
+		return http.StatusNotFound
+	}
+	return hotspotErrorStatus(err)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconcileHandler handles drift between a router and the database
+type ReconcileHandler struct {
+	service usecase.ReconcileUsecase
+}
+
+// NewReconcileHandler creates a new reconcile handler
+func NewReconcileHandler(service usecase.ReconcileUsecase) *ReconcileHandler {
+	return &ReconcileHandler{
+		service: service,
+	}
+}
+
+// Drift handles reporting the drift without changing anything
+// GET /api/mikrotiks/:id/drift?direction=push|adopt
+func (h *ReconcileHandler) Drift(c *gin.Context) {
+	var req model.ReconcileRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	req.DryRun = true
+
+	h.reconcile(c, "Drift", req)
+}
+
+// Reconcile handles resolving the drift, pushing the database state to the router
+// or adopting the router state; dry_run only reports what would change
+// POST /api/mikrotiks/:id/reconcile
+func (h *ReconcileHandler) Reconcile(c *gin.Context) {
+	var req model.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	h.reconcile(c, "Reconcile", req)
+}
+
+func (h *ReconcileHandler) reconcile(c *gin.Context, method string, req model.ReconcileRequest) {
+	report, err := h.service.Reconcile(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[ReconcileHandler] %s - Service error: %v", method, err)
+		c.JSON(hotspotErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuspensionHandler handles HTTP requests for customer suspension
+type SuspensionHandler struct {
+	service usecase.SuspensionUsecase
+}
+
+// NewSuspensionHandler creates a new suspension handler
+func NewSuspensionHandler(service usecase.SuspensionUsecase) *SuspensionHandler {
+	return &SuspensionHandler{
+		service: service,
+	}
+}
+
+// SuspendCustomer handles suspending a customer manually
+// POST /api/customers/:id/suspend
+func (h *SuspensionHandler) SuspendCustomer(c *gin.Context) {
+	var req model.SuspendCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if err := h.service.Suspend(c.Request.Context(), c.Param("id"), req.Reason); err != nil {
+		log.Printf("[SuspensionHandler] SuspendCustomer - Service error: %v", err)
+		c.JSON(suspensionErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Customer suspended"})
+}
+
+// ReactivateCustomer handles lifting a customer's suspension
+// POST /api/customers/:id/reactivate
+func (h *SuspensionHandler) ReactivateCustomer(c *gin.Context) {
+	if err := h.service.Reactivate(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("[SuspensionHandler] ReactivateCustomer - Service error: %v", err)
+		c.JSON(suspensionErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Customer reactivated"})
+}
+
+// ProcessOverdue handles running overdue marking and suspension immediately
+// POST /api/invoices/process-overdue
+func (h *SuspensionHandler) ProcessOverdue(c *gin.Context) {
+	result, err := h.service.ProcessOverdue(c.Request.Context(), time.Now())
+	if err != nil {
+		log.Printf("[SuspensionHandler] ProcessOverdue - Service error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+}
+
+// suspensionErrorStatus maps suspension errors to HTTP status codes
+func suspensionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrCustomerNotFound), errors.Is(err, utils.ErrMikrotikNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrSuspensionNotSupported):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, utils.ErrSecretNotFound), errors.Is(err, utils.ErrStaticIPNotProvisioned):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,7 +3,6 @@ package handler
 import (
 	"log"
 	"mikrobill/internal/entity"
-	"mikrobill/internal/infrastructure/mikrotik"
 	"mikrobill/internal/usecase"
 	"net/http"
 
@@ -16,20 +15,18 @@ type TrafficMonitorHandler struct {
 	service     *usecase.OnDemandTrafficService
 	repo        entity.CustomerRepository
 	pingHandler *PingHandler
-	mtClient    *mikrotik.Client
 }
 
 // NewTrafficMonitorHandler creates a new handler
 func NewTrafficMonitorHandler(
 	service *usecase.OnDemandTrafficService,
 	repo entity.CustomerRepository,
-	mtClient *mikrotik.Client,
+	routers usecase.RouterManager,
 ) *TrafficMonitorHandler {
 	return &TrafficMonitorHandler{
 		service:     service,
 		repo:        repo,
-		pingHandler: NewPingHandler(mtClient, repo),
-		mtClient:    mtClient,
+		pingHandler: NewPingHandler(routers, repo),
 	}
 }
 
@@ -37,7 +34,7 @@ func NewTrafficMonitorHandler(
 // GET /api/monitor/status
 func (h *TrafficMonitorHandler) GetStatus(c *gin.Context) {
 	// Get customer count
-	customers, _, err := h.repo.ListCustomers(1, 1000)
+	page, err := h.repo.ListCustomers(entity.CustomerListQuery{Limit: 1000})
 	if err != nil {
 		log.Printf("[Handler] Failed to get customers: %v", err)
 		c.JSON(200, gin.H{
@@ -50,7 +47,7 @@ func (h *TrafficMonitorHandler) GetStatus(c *gin.Context) {
 
 	// Count active monitors
 	activeCount := 0
-	for _, cust := range customers {
+	for _, cust := range page.Customers {
 		if cust.Status == "active" {
 			activeCount++
 		}
@@ -58,7 +55,7 @@ func (h *TrafficMonitorHandler) GetStatus(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"status":         "ok",
-		"customer_count": len(customers),
+		"customer_count": len(page.Customers),
 		"monitor_count":  activeCount,
 	})
 }
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler handles a customer's accounted traffic
+type UsageHandler struct {
+	service usecase.UsageUsecase
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(service usecase.UsageUsecase) *UsageHandler {
+	return &UsageHandler{
+		service: service,
+	}
+}
+
+// GetUsage handles a customer's traffic per hour, day or month
+// GET /api/customers/:id/usage?from=YYYY-MM-DD&to=YYYY-MM-DD&granularity=day
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	var req model.UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	usage, err := h.service.GetUsage(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[UsageHandler] GetUsage - Service error: %v", err)
+		c.JSON(usageErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": usage})
+}
+
+// usageErrorStatus maps usage errors to HTTP status codes
+func usageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrInvalidDate):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
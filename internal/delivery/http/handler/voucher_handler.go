@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VoucherHandler handles stored hotspot voucher batches
+type VoucherHandler struct {
+	service usecase.VoucherUsecase
+}
+
+// NewVoucherHandler creates a new voucher handler
+func NewVoucherHandler(service usecase.VoucherUsecase) *VoucherHandler {
+	return &VoucherHandler{
+		service: service,
+	}
+}
+
+// GenerateBatch handles generating a voucher batch on a router
+// POST /api/mikrotiks/:id/voucher-batches
+func (h *VoucherHandler) GenerateBatch(c *gin.Context) {
+	var req model.GenerateVoucherBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	var createdBy *int64
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(int64); ok {
+			createdBy = &id
+		}
+	}
+
+	result, err := h.service.GenerateBatch(c.Request.Context(), c.Param("id"), req, createdBy)
+	if err != nil {
+		log.Printf("[VoucherHandler] GenerateBatch - Service error: %v", err)
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": result})
+}
+
+// ListBatches handles listing the voucher batches of a router
+// GET /api/mikrotiks/:id/voucher-batches?page=&limit=
+func (h *VoucherHandler) ListBatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result, err := h.service.ListBatches(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetBatch handles getting a batch with all its vouchers
+// GET /api/voucher-batches/:id
+func (h *VoucherHandler) GetBatch(c *gin.Context) {
+	batch, err := h.service.GetBatch(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": batch})
+}
+
+// PrintBatch handles reprinting a batch, optionally only its vouchers in one state
+// GET /api/voucher-batches/:id/print?state=unsold
+func (h *VoucherHandler) PrintBatch(c *gin.Context) {
+	batch, err := h.service.PrintBatch(c.Request.Context(), c.Param("id"), c.Query("state"))
+	if err != nil {
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": batch})
+}
+
+// ListVouchers handles listing vouchers with filters
+// GET /api/vouchers?mikrotik_id=&batch_id=&state=unsold|sold|used|expired&agent_id=&search=&page=&limit=
+func (h *VoucherHandler) ListVouchers(c *gin.Context) {
+	var req model.VoucherListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("[VoucherHandler] ListVouchers - Service error: %v", err)
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	paginated(c, result)
+}
+
+// GetVoucher handles getting a single voucher
+// GET /api/vouchers/:id
+func (h *VoucherHandler) GetVoucher(c *gin.Context) {
+	voucher, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": voucher})
+}
+
+// MarkSold handles recording a voucher sold over the counter
+// POST /api/vouchers/:id/sell
+func (h *VoucherHandler) MarkSold(c *gin.Context) {
+	voucher, err := h.service.MarkSold(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": voucher})
+}
+
+// Reconcile handles comparing a router's stored vouchers with its hotspot users
+// POST /api/mikrotiks/:id/vouchers/reconcile
+func (h *VoucherHandler) Reconcile(c *gin.Context) {
+	result, err := h.service.Reconcile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("[VoucherHandler] Reconcile - Service error: %v", err)
+		c.JSON(voucherErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+}
+
+// voucherErrorStatus maps voucher errors to HTTP status codes
+func voucherErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrVoucherBatchNotFound), errors.Is(err, utils.ErrVoucherNotFound),
+		errors.Is(err, utils.ErrMikrotikNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrVoucherNotAvailable):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrVoucherGeneration):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mikrobill/internal/model"
+	"mikrobill/internal/usecase"
+	"mikrobill/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VoucherSheetHandler handles printable voucher sheets and their templates
+type VoucherSheetHandler struct {
+	service usecase.VoucherSheetUsecase
+}
+
+// NewVoucherSheetHandler creates a new voucher sheet handler
+func NewVoucherSheetHandler(service usecase.VoucherSheetUsecase) *VoucherSheetHandler {
+	return &VoucherSheetHandler{
+		service: service,
+	}
+}
+
+// RenderSheet handles rendering a batch as a printable sheet
+// GET /api/voucher-batches/:id/sheet?format=html|pdf&template_id=&state=&columns=&rows=&qr=&dns_name=
+func (h *VoucherSheetHandler) RenderSheet(c *gin.Context) {
+	var req model.VoucherSheetRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	sheet, err := h.service.RenderSheet(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		log.Printf("[VoucherSheetHandler] RenderSheet - Service error: %v", err)
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+sheet.FileName+`"`)
+	c.Data(http.StatusOK, sheet.ContentType, sheet.Body)
+}
+
+// ListTemplates handles listing voucher templates
+// GET /api/voucher-templates?type=html|pdf
+func (h *VoucherSheetHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context(), c.Query("type"))
+	if err != nil {
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": templates})
+}
+
+// CreateTemplate handles creating a voucher template
+// POST /api/voucher-templates
+func (h *VoucherSheetHandler) CreateTemplate(c *gin.Context) {
+	var req model.CreateVoucherTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	tpl, err := h.service.CreateTemplate(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": tpl})
+}
+
+// GetTemplate handles getting a voucher template
+// GET /api/voucher-templates/:id
+func (h *VoucherSheetHandler) GetTemplate(c *gin.Context) {
+	tpl, err := h.service.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": tpl})
+}
+
+// UpdateTemplate handles updating a voucher template
+// PUT /api/voucher-templates/:id
+func (h *VoucherSheetHandler) UpdateTemplate(c *gin.Context) {
+	var req model.UpdateVoucherTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	tpl, err := h.service.UpdateTemplate(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": tpl})
+}
+
+// DeleteTemplate handles deleting a voucher template
+// DELETE /api/voucher-templates/:id
+func (h *VoucherSheetHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.service.DeleteTemplate(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(voucherSheetErrorStatus(err), gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Voucher template deleted"})
+}
+
+// voucherSheetErrorStatus maps voucher sheet errors to HTTP status codes
+func voucherSheetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrVoucherTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrVoucherTemplateExists):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrInvalidVoucherTemplate):
+		return http.StatusUnprocessableEntity
+	default:
+		return voucherErrorStatus(err)
+	}
+}
@@ -48,6 +48,10 @@ func AuthMiddleware(jwtService *service.JWTService) gin.HandlerFunc {
 		c.Set("user_role", claims.Role)
 		// Set role as array for Casbin compatibility
 		c.Set("user_roles", []string{claims.Role})
+		// Agent tokens identify a reseller rather than a user
+		if claims.AgentID != "" {
+			c.Set("agent_id", claims.AgentID)
+		}
 
 		pkg_logger.Debug("User authenticated",
 			zap.Int64("user_id", claims.UserID),
@@ -4,9 +4,15 @@ import (
 	"context"
 	"log"
 	"mikrobill/internal/delivery/http/handler"
+	"mikrobill/internal/delivery/http/middleware"
+	"mikrobill/internal/delivery/worker"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/payment"
 	"mikrobill/internal/port/repository"
+	"mikrobill/internal/port/service"
 	"mikrobill/internal/usecase"
 	"mikrobill/pkg/pub_sub"
+	"mikrobill/pkg/queue"
 )
 
 // setupAppRoutes configures application routes (Customers, Profiles, Monitoring)
@@ -18,20 +24,73 @@ func (r *Router) setupAppRoutes() {
 	customerRepo := repository.NewDatabaseCustomerRepository(r.db)
 	mikrotikRepo := repository.NewMikrotikRepository(r.db)
 	profileRepo := repository.NewDatabaseProfileRepository(r.db)
+	invoiceRepo := repository.NewInvoiceRepository(r.db)
+	settingRepo := repository.NewSettingRepository(r.db)
+	paymentRepo := repository.NewPaymentRepository(r.db)
+	gatewayRepo := repository.NewPaymentGatewayRepository(r.db)
+	agentRepo := repository.NewAgentRepository(r.db)
+	voucherRepo := repository.NewVoucherRepository(r.db)
+	voucherTemplateRepo := repository.NewVoucherTemplateRepository(r.db)
+	customerSessionRepo := repository.NewCustomerSessionRepository(r.db)
+	usageRepo := repository.NewUsageRepository(r.db)
+	fupRepo := repository.NewFUPRepository(r.db)
+	customerImportRepo := repository.NewCustomerImportRepository(r.db)
+	customerSyncRepo := repository.NewCustomerSyncRepository(r.db)
 
 	// 3. Initialize Services (Usecases)
-	// Mikrotik UseCase (to get client)
-	mikrotikUseCase := usecase.NewMikrotikUseCase(mikrotikRepo, r.config.Crypto.EncryptionKey)
+	// Connections to every active router, dialed when a customer on it is first served
+	routerManager := usecase.NewRouterManager(mikrotikRepo, r.cipher)
+	routerManager.Start()
+	r.onShutdown(routerManager.Stop)
 
-	// Get Active Mikrotik Client
-	mtClient, err := mikrotikUseCase.GetMikrotikClient()
-	if err != nil {
-		log.Printf("[Router] WARNING: Failed to get active Mikrotik client: %v. Feature dealing with Mikrotik will be disabled until restart/reload.", err)
-	}
+	// Mikrotik UseCase (to get client); drops open connections when a router changes
+	mikrotikUseCase := usecase.NewMikrotikUseCase(mikrotikRepo, r.cipher, routerManager)
+
+	// Tasks handed to the background workers from requests
+	jobQueue := queue.NewClient(r.queueConfig())
+	r.onShutdown(func() { jobQueue.Close() })
 
-	customerService := usecase.NewCustomerService(customerRepo, profileRepo, mtClient)
+	queueUsecase := usecase.NewQueueUsecase(mikrotikUseCase)
+	staticIPUsecase := usecase.NewStaticIPUsecase(customerRepo, profileRepo, mikrotikUseCase, queueUsecase)
+	// Router changes of customers are recorded as sync jobs and run by the customer sync worker
+	customerService := usecase.NewCustomerService(customerRepo, profileRepo, customerSyncRepo, routerManager, staticIPUsecase, jobQueue, r.cipher)
 	profileService := usecase.NewProfileService(profileRepo, mikrotikUseCase)
-	trafficService := usecase.NewOnDemandTrafficService(mtClient, customerRepo, redisPublisher)
+	trafficService := usecase.NewOnDemandTrafficService(routerManager, customerRepo, redisPublisher)
+	invoiceUsecase := usecase.NewInvoiceUsecase(invoiceRepo, settingRepo, customerRepo, profileRepo)
+	suspensionUsecase := usecase.NewSuspensionUsecase(customerRepo, profileRepo, invoiceRepo, settingRepo, mikrotikRepo, fupRepo, mikrotikUseCase, staticIPUsecase, redisPublisher)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, gatewayRepo, settingRepo, redisPublisher, suspensionUsecase)
+	paymentGatewayUsecase := usecase.NewPaymentGatewayUsecase(gatewayRepo, invoiceRepo, paymentUsecase,
+		r.paymentGateways(), r.config.PaymentGateway.ChargeExpiry)
+
+	voucherUsecase := usecase.NewVoucherUsecase(voucherRepo, mikrotikRepo, mikrotikUseCase)
+	voucherSheetUsecase := usecase.NewVoucherSheetUsecase(voucherTemplateRepo, settingRepo, voucherUsecase, mikrotikUseCase)
+	hotspotUsecase := usecase.NewHotspotUsecase(mikrotikUseCase, voucherUsecase)
+	pppUsecase := usecase.NewPPPUsecase(customerRepo, mikrotikUseCase)
+	customerSessionUsecase := usecase.NewCustomerSessionUsecase(customerSessionRepo, customerRepo)
+	usageUsecase := usecase.NewUsageUsecase(usageRepo, customerRepo, mikrotikRepo, routerManager)
+	fupUsecase := usecase.NewFUPUsecase(fupRepo, usageRepo, customerRepo, profileRepo, mikrotikUseCase, redisPublisher)
+	reconcileUsecase := usecase.NewReconcileUsecase(customerRepo, profileRepo, mikrotikRepo, fupRepo, voucherRepo, mikrotikUseCase, profileService, r.cipher)
+	customerImportUsecase := usecase.NewCustomerImportUsecase(customerRepo, profileRepo, mikrotikRepo, voucherRepo, customerImportRepo,
+		mikrotikUseCase, profileService, customerService, jobQueue, r.cipher)
+
+	// Agents authenticate with the same JWT secret as users, under the agent role
+	jwtService := service.NewJWTService(r.config.JWT.SecretKey, r.config.JWT.TokenDuration)
+	agentUsecase := usecase.NewAgentUsecase(agentRepo, mikrotikRepo, paymentUsecase, &service.PasswordService{}, jwtService)
+
+	// Online state from the routers' own session lists, alongside the pppoe callbacks
+	sessionTracker := usecase.NewSessionTracker(mikrotikRepo, customerRepo, customerSessionRepo, routerManager, redisPublisher)
+	sessionTracker.Start()
+	r.onShutdown(sessionTracker.Stop)
+
+	// Background jobs (billing schedule)
+	r.startJobs(
+		worker.NewBillingWorker(invoiceUsecase, suspensionUsecase),
+		worker.NewHotspotWorker(voucherUsecase),
+		worker.NewMikrotikWorker(mikrotikUseCase),
+		worker.NewUsageWorker(usageUsecase, fupUsecase),
+		worker.NewCustomerImportWorker(customerImportUsecase),
+		worker.NewCustomerSyncWorker(customerService),
+	)
 
 	// 4. Initialize Handlers
 	wsHandler := handler.NewWebSocketHandler()
@@ -56,11 +115,26 @@ func (r *Router) setupAppRoutes() {
 		}
 	}()
 
-	callbackHandler := handler.NewCallbackHandler(customerRepo, redisPublisher)
+	callbackHandler := handler.NewCallbackHandler(customerRepo, customerSessionRepo, redisPublisher)
 	customerHandler := handler.NewCustomerHandler(customerService)
 	profileHandler := handler.NewProfileHandler(profileService)
-	trafficHandler := handler.NewTrafficMonitorHandler(trafficService, customerRepo, mtClient)
+	trafficHandler := handler.NewTrafficMonitorHandler(trafficService, customerRepo, routerManager)
 	mikrotikHandler := handler.NewMikrotikHandler(mikrotikUseCase)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceUsecase)
+	paymentHandler := handler.NewPaymentHandler(paymentUsecase)
+	suspensionHandler := handler.NewSuspensionHandler(suspensionUsecase)
+	paymentGatewayHandler := handler.NewPaymentGatewayHandler(paymentGatewayUsecase)
+	agentHandler := handler.NewAgentHandler(agentUsecase)
+	voucherHandler := handler.NewVoucherHandler(voucherUsecase)
+	voucherSheetHandler := handler.NewVoucherSheetHandler(voucherSheetUsecase)
+	hotspotHandler := handler.NewHotspotHandler(hotspotUsecase)
+	pppHandler := handler.NewPPPHandler(pppUsecase)
+	queueHandler := handler.NewQueueHandler(queueUsecase)
+	customerSessionHandler := handler.NewCustomerSessionHandler(customerSessionUsecase)
+	usageHandler := handler.NewUsageHandler(usageUsecase)
+	fupHandler := handler.NewFUPHandler(fupUsecase)
+	reconcileHandler := handler.NewReconcileHandler(reconcileUsecase)
+	customerImportHandler := handler.NewCustomerImportHandler(customerImportUsecase)
 
 	// 5. Register Routes based on user request
 
@@ -73,12 +147,87 @@ func (r *Router) setupAppRoutes() {
 		// Common Routes
 		api.GET("/mikrotiks", mikrotikHandler.ListMikrotiks)
 		api.POST("/mikrotiks", mikrotikHandler.CreateMikrotik)
+		api.GET("/mikrotiks/active", mikrotikHandler.GetActiveMikrotik)
+		api.GET("/mikrotiks/:id", mikrotikHandler.GetMikrotik)
+		api.PUT("/mikrotiks/:id", mikrotikHandler.UpdateMikrotik)
+		api.DELETE("/mikrotiks/:id", mikrotikHandler.DeleteMikrotik)
+		api.POST("/mikrotiks/:id/test", mikrotikHandler.TestConnection)
+		api.POST("/mikrotiks/:id/activate", mikrotikHandler.ActivateMikrotik)
+		api.GET("/mikrotiks/:id/status-history", mikrotikHandler.ListStatusHistory)
+
+		// Drift between the router's secrets, profiles and hotspot users and the database
+		api.GET("/mikrotiks/:id/drift", reconcileHandler.Drift)
+		api.POST("/mikrotiks/:id/reconcile", reconcileHandler.Reconcile)
+
+		// Subscribers created on the router before mikrobill
+		api.POST("/mikrotiks/:id/customers/import", customerImportHandler.ImportFromRouter)
+
+		// Hotspot voucher batches stored per router
+		api.GET("/mikrotiks/:id/voucher-batches", voucherHandler.ListBatches)
+		api.POST("/mikrotiks/:id/voucher-batches", voucherHandler.GenerateBatch)
+		api.POST("/mikrotiks/:id/vouchers/reconcile", voucherHandler.Reconcile)
+
+		// Hotspot management on a router
+		hs := api.Group("/mikrotiks/:id/hotspot")
+		{
+			hs.GET("/users", hotspotHandler.ListUsers)
+			hs.POST("/users", hotspotHandler.CreateUser)
+			hs.GET("/users/:user", hotspotHandler.GetUser)
+			hs.PUT("/users/:user", hotspotHandler.UpdateUser)
+			hs.DELETE("/users/:user", hotspotHandler.DeleteUser)
+
+			hs.GET("/profiles", hotspotHandler.ListProfiles)
+			hs.POST("/profiles", hotspotHandler.CreateProfile)
+			hs.GET("/profiles/:profile", hotspotHandler.GetProfile)
+			hs.PUT("/profiles/:profile", hotspotHandler.UpdateProfile)
+			hs.DELETE("/profiles/:profile", hotspotHandler.DeleteProfile)
+
+			hs.GET("/active", hotspotHandler.ListActive)
+			hs.DELETE("/active/:active", hotspotHandler.Disconnect)
+			hs.GET("/hosts", hotspotHandler.ListHosts)
+			hs.DELETE("/hosts/:host", hotspotHandler.RemoveHost)
+
+			hs.GET("/vouchers", hotspotHandler.ListVouchers)
+			hs.POST("/vouchers", hotspotHandler.GenerateVouchers)
+			hs.GET("/reports", hotspotHandler.Report)
+		}
+
+		// PPP on a router; customer secrets are managed through /customers
+		pppRoutes := api.Group("/mikrotiks/:id/ppp")
+		{
+			pppRoutes.GET("/active", pppHandler.ListActive)
+			pppRoutes.DELETE("/active/:active", pppHandler.Disconnect)
+			pppRoutes.GET("/secrets", pppHandler.ListSecrets)
+			pppRoutes.GET("/secrets/:secret", pppHandler.GetSecret)
+			pppRoutes.POST("/secrets/:secret/enable", pppHandler.EnableSecret)
+			pppRoutes.POST("/secrets/:secret/disable", pppHandler.DisableSecret)
+			pppRoutes.GET("/profiles", pppHandler.ListProfiles)
+			pppRoutes.GET("/profiles/:profile", pppHandler.GetProfile)
+			pppRoutes.GET("/stats", pppHandler.Stats)
+		}
+
+		// Queues on a router; customer queues follow their profile's queue settings
+		queues := api.Group("/mikrotiks/:id/queues")
+		{
+			queues.GET("/simple", queueHandler.ListSimple)
+			queues.POST("/simple", queueHandler.CreateSimple)
+			queues.GET("/simple/:queue", queueHandler.GetSimple)
+			queues.PUT("/simple/:queue", queueHandler.UpdateSimple)
+			queues.DELETE("/simple/:queue", queueHandler.DeleteSimple)
+
+			queues.GET("/tree", queueHandler.ListTree)
+			queues.POST("/tree", queueHandler.CreateTree)
+			queues.GET("/tree/:queue", queueHandler.GetTree)
+			queues.PUT("/tree/:queue", queueHandler.UpdateTree)
+			queues.DELETE("/tree/:queue", queueHandler.DeleteTree)
+		}
 
 		// Callback routes (MikroTik WebHooks)
 		callbacks := api.Group("/callbacks")
 		{
 			callbacks.POST("/pppoe-up", callbackHandler.HandlePPPoEUp)
 			callbacks.POST("/pppoe-down", callbackHandler.HandlePPPoEDown)
+			callbacks.POST("/payments/:gateway", paymentGatewayHandler.HandleNotification)
 		}
 
 		// Customer routes (CRUD)
@@ -87,10 +236,32 @@ func (r *Router) setupAppRoutes() {
 			// CRUD operations (handled by CustomerHandler)
 			customers.GET("", customerHandler.ListCustomers)
 			customers.POST("", customerHandler.CreateCustomer)
+
+			// Spreadsheet import and export
+			customers.POST("/import", customerImportHandler.ImportFile)
+			customers.GET("/import/:id", customerImportHandler.GetImportJob)
+			customers.GET("/export", customerImportHandler.Export)
+
 			customers.GET("/:id", customerHandler.GetCustomer)
 			customers.PUT("/:id", customerHandler.UpdateCustomer)
 			customers.DELETE("/:id", customerHandler.DeleteCustomer)
 
+			// Router sync state (outbox jobs)
+			customers.GET("/:id/sync", customerHandler.ListSyncJobs)
+			customers.POST("/:id/sync", customerHandler.ResyncCustomer)
+
+			// Billing suspension (handled by SuspensionHandler)
+			customers.POST("/:id/suspend", suspensionHandler.SuspendCustomer)
+			customers.POST("/:id/reactivate", suspensionHandler.ReactivateCustomer)
+
+			// Session history (handled by CustomerSessionHandler)
+			customers.GET("/:id/sessions", customerSessionHandler.ListSessions)
+			customers.GET("/:id/sessions/stats", customerSessionHandler.SessionStats)
+
+			// Traffic accounting (handled by UsageHandler)
+			customers.GET("/:id/usage", usageHandler.GetUsage)
+			customers.GET("/:id/fup", fupHandler.GetStatus)
+
 			// Monitoring Specifics (handled by TrafficMonitorHandler)
 			// These extend the customer resource
 			customers.GET("/:id/ping", trafficHandler.GetPingHandler().PingCustomerByID)
@@ -118,6 +289,87 @@ func (r *Router) setupAppRoutes() {
 			profiles.POST("/:id/sync", profileHandler.SyncProfile)
 			profiles.POST("/sync-all/:mikrotik_id", profileHandler.SyncAllProfiles)
 		}
+
+		// Invoice routes (CRUD and monthly generation)
+		invoices := api.Group("/invoices")
+		{
+			invoices.GET("", invoiceHandler.ListInvoices)
+			invoices.POST("", invoiceHandler.CreateInvoice)
+			invoices.POST("/generate", invoiceHandler.GenerateInvoices)
+			invoices.POST("/process-overdue", suspensionHandler.ProcessOverdue)
+			invoices.GET("/:id", invoiceHandler.GetInvoice)
+			invoices.PUT("/:id", invoiceHandler.UpdateInvoice)
+			invoices.DELETE("/:id", invoiceHandler.DeleteInvoice)
+
+			// Payments against an invoice
+			invoices.GET("/:id/payments", paymentHandler.ListInvoicePayments)
+			invoices.POST("/:id/payments", paymentHandler.RecordPayment)
+
+			// Online payment through a gateway
+			invoices.GET("/:id/gateway-charges", paymentGatewayHandler.ListInvoiceCharges)
+			invoices.POST("/:id/gateway-charges", paymentGatewayHandler.CreateCharge)
+		}
+
+		api.POST("/gateway-transactions/:id/sync", paymentGatewayHandler.SyncTransaction)
+
+		// Payment routes
+		payments := api.Group("/payments")
+		{
+			payments.GET("", paymentHandler.ListPayments)
+			payments.GET("/:id", paymentHandler.GetPayment)
+			payments.GET("/:id/receipt", paymentHandler.GetReceipt)
+			payments.DELETE("/:id", paymentHandler.VoidPayment)
+		}
+
+		// Voucher routes
+		api.GET("/voucher-batches/:id", voucherHandler.GetBatch)
+		api.GET("/voucher-batches/:id/print", voucherHandler.PrintBatch)
+		api.GET("/voucher-batches/:id/sheet", voucherSheetHandler.RenderSheet)
+		vouchers := api.Group("/vouchers")
+		{
+			vouchers.GET("", voucherHandler.ListVouchers)
+			vouchers.GET("/:id", voucherHandler.GetVoucher)
+			vouchers.POST("/:id/sell", voucherHandler.MarkSold)
+		}
+
+		// Printable voucher sheet templates
+		voucherTemplates := api.Group("/voucher-templates")
+		{
+			voucherTemplates.GET("", voucherSheetHandler.ListTemplates)
+			voucherTemplates.POST("", voucherSheetHandler.CreateTemplate)
+			voucherTemplates.GET("/:id", voucherSheetHandler.GetTemplate)
+			voucherTemplates.PUT("/:id", voucherSheetHandler.UpdateTemplate)
+			voucherTemplates.DELETE("/:id", voucherSheetHandler.DeleteTemplate)
+		}
+
+		// Agent management (admin staff only)
+		agents := api.Group("/agents")
+		agents.Use(middleware.AuthMiddleware(jwtService), middleware.RequireRole(
+			string(entity.UserRoleSuperAdmin), string(entity.UserRoleAdmin), string(entity.UserRoleFinance)))
+		{
+			agents.GET("", agentHandler.ListAgents)
+			agents.POST("", agentHandler.CreateAgent)
+			agents.GET("/balance-requests", agentHandler.ListBalanceRequests)
+			agents.POST("/balance-requests/:id/approve", agentHandler.ApproveBalanceRequest)
+			agents.POST("/balance-requests/:id/reject", agentHandler.RejectBalanceRequest)
+			agents.GET("/:id", agentHandler.GetAgent)
+			agents.PUT("/:id", agentHandler.UpdateAgent)
+			agents.GET("/:id/transactions", agentHandler.ListAgentTransactions)
+		}
+
+		// Agent self-service (agent tokens only)
+		api.POST("/agent/login", agentHandler.Login)
+		agentSelf := api.Group("/agent")
+		agentSelf.Use(middleware.AuthMiddleware(jwtService), middleware.RequireRole(entity.AgentRole))
+		{
+			agentSelf.GET("/profile", agentHandler.GetProfile)
+			agentSelf.GET("/transactions", agentHandler.ListMyTransactions)
+			agentSelf.GET("/balance-requests", agentHandler.ListMyBalanceRequests)
+			agentSelf.POST("/balance-requests", agentHandler.RequestTopUp)
+			agentSelf.POST("/invoices/:id/payments", agentHandler.CollectPayment)
+			agentSelf.GET("/voucher-sales", agentHandler.ListMyVoucherSales)
+			agentSelf.POST("/voucher-sales", agentHandler.RecordVoucherSale)
+		}
 	}
 
 	// Protected routes example (if needed, reuse middleware)
@@ -133,3 +385,20 @@ func (r *Router) setupAppRoutes() {
 
 	log.Println("[Router] App routes registered")
 }
+
+// paymentGateways builds the registry of online payment gateways enabled in config
+func (r *Router) paymentGateways() *payment.Registry {
+	registry := payment.NewRegistry()
+
+	if cfg := r.config.PaymentGateway.Midtrans; cfg.Enabled {
+		registry.Register(payment.NewMidtrans(payment.MidtransConfig{
+			ServerKey: cfg.ServerKey,
+			APIURL:    cfg.APIURL,
+			SnapURL:   cfg.SnapURL,
+			Timeout:   cfg.Timeout,
+		}))
+	}
+
+	log.Printf("[Router] Payment gateways enabled: %v", registry.Names())
+	return registry
+}
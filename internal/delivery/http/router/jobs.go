@@ -0,0 +1,57 @@
+package router
+
+import (
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// jobWorker is implemented by the background workers in internal/delivery/worker
+type jobWorker interface {
+	Register(registry *queue.HandlerRegistry)
+	PeriodicTasks() []queue.PeriodicTask
+}
+
+// queueConfig builds the asynq connection settings from the Redis config
+func (r *Router) queueConfig() queue.Config {
+	return queue.Config{
+		RedisAddr:     r.config.Redis.Address(),
+		RedisPassword: r.config.Redis.Password,
+		RedisDB:       r.config.Redis.DB,
+	}
+}
+
+// startJobs starts the queue server and the periodic scheduler for the given workers
+func (r *Router) startJobs(workers ...jobWorker) {
+	cfg := r.queueConfig()
+
+	registry := queue.NewHandlerRegistry()
+	var periodic []queue.PeriodicTask
+	for _, w := range workers {
+		w.Register(registry)
+		periodic = append(periodic, w.PeriodicTasks()...)
+	}
+
+	server := queue.NewServer(queue.DefaultServerConfig(cfg), registry)
+	if err := server.Start(); err != nil {
+		pkg_logger.Error("Failed to start queue server", zap.Error(err))
+		return
+	}
+	r.onShutdown(server.Stop)
+
+	// Billing dates follow the server's local time, not UTC
+	schedCfg := queue.DefaultSchedulerConfig(cfg)
+	schedCfg.Location = time.Local
+
+	scheduler := queue.NewScheduler(schedCfg)
+	if err := scheduler.RegisterMultiple(periodic); err != nil {
+		pkg_logger.Error("Failed to register periodic tasks", zap.Error(err))
+	}
+	if err := scheduler.Start(); err != nil {
+		pkg_logger.Error("Failed to start scheduler", zap.Error(err))
+		return
+	}
+	r.onShutdown(scheduler.Stop)
+}
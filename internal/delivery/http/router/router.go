@@ -7,6 +7,7 @@ import (
 	"mikrobill/internal/delivery/http/middleware"
 	"mikrobill/pkg/filelog"
 	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
 
 	"github.com/casbin/casbin/v2"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
@@ -21,6 +22,11 @@ type Router struct {
 	db       *gorm.DB
 	config   *config.Config
 	enforcer *casbin.Enforcer
+	// cipher encrypts router and customer credentials stored in the database
+	cipher *utils.Cipher
+
+	// shutdownHooks stop background workers, run in reverse order by Shutdown
+	shutdownHooks []func()
 }
 
 // NewRouter creates a new router instance with all dependencies
@@ -42,6 +48,11 @@ func NewRouter(db *gorm.DB, cfg *config.Config) (*Router, error) {
 		pkg_logger.Warn("Failed to load Casbin policies, starting with empty policy")
 	}
 
+	cipher, err := cfg.Crypto.Cipher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential cipher: %w", err)
+	}
+
 	// Initialize file logger for API access logs
 	if err := filelog.Init(); err != nil {
 		pkg_logger.Warn("Failed to initialize file logger")
@@ -52,6 +63,7 @@ func NewRouter(db *gorm.DB, cfg *config.Config) (*Router, error) {
 		db:       db,
 		config:   cfg,
 		enforcer: enforcer,
+		cipher:   cipher,
 	}
 
 	// Setup middlewares
@@ -99,6 +111,18 @@ func (r *Router) setupRoutes() {
 	// etc.
 }
 
+// onShutdown registers a function to run when the router shuts down
+func (r *Router) onShutdown(fn func()) {
+	r.shutdownHooks = append(r.shutdownHooks, fn)
+}
+
+// Shutdown stops background workers started by the router
+func (r *Router) Shutdown() {
+	for i := len(r.shutdownHooks) - 1; i >= 0; i-- {
+		r.shutdownHooks[i]()
+	}
+}
+
 // Accessor methods
 func (r *Router) DB() *gorm.DB                  { return r.db }
 func (r *Router) Config() *config.Config        { return r.config }
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	TaskGenerateInvoices   = "billing:generate_invoices"
+	TaskProcessSuspensions = "billing:process_suspensions"
+)
+
+// GenerateInvoicesPayload is the payload of TaskGenerateInvoices. An empty date means today.
+type GenerateInvoicesPayload struct {
+	Date  string `json:"date,omitempty"`
+	Force bool   `json:"force,omitempty"` // ignore billing.auto_generate_invoice
+}
+
+// ProcessSuspensionsPayload is the payload of TaskProcessSuspensions
+type ProcessSuspensionsPayload struct{}
+
+// BillingWorker runs background billing tasks
+type BillingWorker struct {
+	invoiceUsecase    usecase.InvoiceUsecase
+	suspensionUsecase usecase.SuspensionUsecase
+}
+
+// NewBillingWorker creates a new billing worker
+func NewBillingWorker(invoiceUsecase usecase.InvoiceUsecase, suspensionUsecase usecase.SuspensionUsecase) *BillingWorker {
+	return &BillingWorker{
+		invoiceUsecase:    invoiceUsecase,
+		suspensionUsecase: suspensionUsecase,
+	}
+}
+
+// Register registers the billing task handlers
+func (w *BillingWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, TaskGenerateInvoices, w.handleGenerateInvoices)
+	queue.RegisterTyped(registry, TaskProcessSuspensions, w.handleProcessSuspensions)
+}
+
+// PeriodicTasks returns the billing tasks that run on a schedule
+func (w *BillingWorker) PeriodicTasks() []queue.PeriodicTask {
+	return []queue.PeriodicTask{
+		// Shortly after midnight so the day's billing date is settled
+		queue.NewPeriodicTask("billing-generate-invoices", "5 0 * * *", TaskGenerateInvoices,
+			GenerateInvoicesPayload{}, queue.IdempotentTask.ToAsynqOptions()...),
+		// Hourly so suspensions follow the grace period closely and missed reactivations are retried
+		queue.NewPeriodicTask("billing-process-suspensions", "15 * * * *", TaskProcessSuspensions,
+			ProcessSuspensionsPayload{}, queue.IdempotentTask.ToAsynqOptions()...),
+	}
+}
+
+func (w *BillingWorker) handleGenerateInvoices(ctx context.Context, payload GenerateInvoicesPayload) error {
+	if !payload.Force && !w.invoiceUsecase.AutoGenerateEnabled(ctx) {
+		pkg_logger.Info("Automatic invoice generation disabled, skipping")
+		return nil
+	}
+
+	date := time.Now()
+	if payload.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", payload.Date, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid date %q: %w", payload.Date, err)
+		}
+		date = parsed
+	}
+
+	result, err := w.invoiceUsecase.GenerateMonthlyInvoices(ctx, date)
+	if err != nil {
+		return err
+	}
+
+	if result.Failed > 0 {
+		pkg_logger.Warn("Some invoices could not be generated",
+			zap.String("date", result.Date),
+			zap.Int("failed", result.Failed),
+			zap.Strings("errors", result.Errors),
+		)
+	}
+	return nil
+}
+
+func (w *BillingWorker) handleProcessSuspensions(ctx context.Context, _ ProcessSuspensionsPayload) error {
+	result, err := w.suspensionUsecase.ProcessOverdue(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if result.Failed > 0 {
+		pkg_logger.Warn("Some customers could not be suspended or reactivated",
+			zap.String("date", result.Date),
+			zap.Int("failed", result.Failed),
+			zap.Strings("errors", result.Errors),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"mikrobill/pkg/utils"
+
+	"go.uber.org/zap"
+)
+
+// CustomerImportWorker processes spreadsheet imports queued by the import endpoint
+type CustomerImportWorker struct {
+	importUsecase usecase.CustomerImportUsecase
+}
+
+// NewCustomerImportWorker creates a new customer import worker
+func NewCustomerImportWorker(importUsecase usecase.CustomerImportUsecase) *CustomerImportWorker {
+	return &CustomerImportWorker{
+		importUsecase: importUsecase,
+	}
+}
+
+// Register registers the customer import task handlers
+func (w *CustomerImportWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, usecase.TaskImportCustomers, w.handleImportCustomers)
+}
+
+// PeriodicTasks returns nil: imports only run when queued
+func (w *CustomerImportWorker) PeriodicTasks() []queue.PeriodicTask {
+	return nil
+}
+
+func (w *CustomerImportWorker) handleImportCustomers(ctx context.Context, payload usecase.ImportCustomersPayload) error {
+	err := w.importUsecase.RunImportJob(ctx, payload.JobID)
+	if errors.Is(err, utils.ErrImportJobNotFound) {
+		// Nothing to retry
+		pkg_logger.Warn("Queued import job does not exist", zap.String("job_id", payload.JobID))
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+const TaskRelayCustomerSyncs = "customers:relay_syncs"
+
+// RelayCustomerSyncsPayload is the payload of TaskRelayCustomerSyncs
+type RelayCustomerSyncsPayload struct{}
+
+// CustomerSyncWorker carries customer sync jobs to the routers
+type CustomerSyncWorker struct {
+	customerService *usecase.CustomerService
+}
+
+// NewCustomerSyncWorker creates a new customer sync worker
+func NewCustomerSyncWorker(customerService *usecase.CustomerService) *CustomerSyncWorker {
+	return &CustomerSyncWorker{
+		customerService: customerService,
+	}
+}
+
+// Register registers the customer sync task handlers
+func (w *CustomerSyncWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, usecase.TaskSyncCustomer, w.handleSyncCustomer)
+	queue.RegisterTyped(registry, TaskRelayCustomerSyncs, w.handleRelayCustomerSyncs)
+	// From 10 seconds to a 30 minute cap, so a router that is down for a while
+	// still gets its changes within the retries
+	registry.SetRetryDelay(usecase.TaskSyncCustomer, queue.ExponentialBackoff(10*time.Second, 30*time.Minute))
+}
+
+// PeriodicTasks returns the customer sync tasks that run on a schedule
+func (w *CustomerSyncWorker) PeriodicTasks() []queue.PeriodicTask {
+	return []queue.PeriodicTask{
+		// Picks up jobs whose task was lost, such as when Redis was down at commit
+		queue.NewPeriodicTask("customers-relay-syncs", queue.EveryMinute, TaskRelayCustomerSyncs,
+			RelayCustomerSyncsPayload{}, queue.QuickTask.ToAsynqOptions()...),
+	}
+}
+
+func (w *CustomerSyncWorker) handleSyncCustomer(ctx context.Context, payload usecase.SyncCustomerPayload) error {
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	err := w.customerService.RunSyncJob(ctx, payload.JobID, retried >= maxRetry)
+	if errors.Is(err, utils.ErrSyncJobNotFound) {
+		// Nothing to retry
+		pkg_logger.Warn("Queued customer sync job does not exist", zap.String("job_id", payload.JobID))
+		return nil
+	}
+	return err
+}
+
+func (w *CustomerSyncWorker) handleRelayCustomerSyncs(ctx context.Context, _ RelayCustomerSyncsPayload) error {
+	queued, err := w.customerService.RelaySyncJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		pkg_logger.Info("Stale customer sync jobs queued", zap.Int("queued", queued))
+	}
+	return nil
+}
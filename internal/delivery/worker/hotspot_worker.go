@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+const TaskReconcileVouchers = "hotspot:reconcile_vouchers"
+
+// ReconcileVouchersPayload is the payload of TaskReconcileVouchers
+type ReconcileVouchersPayload struct{}
+
+// HotspotWorker runs background hotspot tasks
+type HotspotWorker struct {
+	voucherUsecase usecase.VoucherUsecase
+}
+
+// NewHotspotWorker creates a new hotspot worker
+func NewHotspotWorker(voucherUsecase usecase.VoucherUsecase) *HotspotWorker {
+	return &HotspotWorker{
+		voucherUsecase: voucherUsecase,
+	}
+}
+
+// Register registers the hotspot task handlers
+func (w *HotspotWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, TaskReconcileVouchers, w.handleReconcileVouchers)
+}
+
+// PeriodicTasks returns the hotspot tasks that run on a schedule
+func (w *HotspotWorker) PeriodicTasks() []queue.PeriodicTask {
+	return []queue.PeriodicTask{
+		// Often enough that voucher states in reports lag the routers by minutes, not hours
+		queue.NewPeriodicTask("hotspot-reconcile-vouchers", "*/30 * * * *", TaskReconcileVouchers,
+			ReconcileVouchersPayload{}, queue.IdempotentTask.ToAsynqOptions()...),
+	}
+}
+
+func (w *HotspotWorker) handleReconcileVouchers(ctx context.Context, _ ReconcileVouchersPayload) error {
+	results, err := w.voucherUsecase.ReconcileAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Failed > 0 {
+			pkg_logger.Warn("Vouchers could not be reconciled",
+				zap.String("mikrotik_id", r.MikrotikID),
+				zap.Int("failed", r.Failed),
+				zap.Strings("errors", r.Errors),
+			)
+		}
+	}
+	return nil
+}
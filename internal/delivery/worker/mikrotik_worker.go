@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+const TaskPollRouterHealth = "mikrotik:poll_health"
+
+// PollRouterHealthPayload is the payload of TaskPollRouterHealth
+type PollRouterHealthPayload struct{}
+
+// MikrotikWorker runs background router tasks
+type MikrotikWorker struct {
+	mikrotikUseCase usecase.MikrotikUseCase
+}
+
+// NewMikrotikWorker creates a new mikrotik worker
+func NewMikrotikWorker(mikrotikUseCase usecase.MikrotikUseCase) *MikrotikWorker {
+	return &MikrotikWorker{
+		mikrotikUseCase: mikrotikUseCase,
+	}
+}
+
+// Register registers the mikrotik task handlers
+func (w *MikrotikWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, TaskPollRouterHealth, w.handlePollHealth)
+}
+
+// PeriodicTasks returns the mikrotik tasks that run on a schedule
+func (w *MikrotikWorker) PeriodicTasks() []queue.PeriodicTask {
+	return []queue.PeriodicTask{
+		// A missed round is not worth retrying, the next one starts a minute later
+		queue.NewPeriodicTask("mikrotik-poll-health", queue.EveryMinute, TaskPollRouterHealth,
+			PollRouterHealthPayload{}, queue.QuickTask.ToAsynqOptions()...),
+	}
+}
+
+func (w *MikrotikWorker) handlePollHealth(ctx context.Context, _ PollRouterHealthPayload) error {
+	result, err := w.mikrotikUseCase.PollHealth(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Offline > 0 {
+		pkg_logger.Warn("Some routers did not answer the health poll",
+			zap.Int("checked", result.Checked),
+			zap.Int("offline", result.Offline),
+			zap.Strings("errors", result.Errors),
+		)
+	}
+	return nil
+}
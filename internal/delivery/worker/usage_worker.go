@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"mikrobill/internal/usecase"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const TaskCollectUsage = "usage:collect"
+
+// CollectUsagePayload is the payload of TaskCollectUsage
+type CollectUsagePayload struct{}
+
+// UsageWorker samples customer traffic counters and enforces fair usage quotas
+type UsageWorker struct {
+	usageUsecase usecase.UsageUsecase
+	fupUsecase   usecase.FUPUsecase
+}
+
+// NewUsageWorker creates a new usage worker
+func NewUsageWorker(usageUsecase usecase.UsageUsecase, fupUsecase usecase.FUPUsecase) *UsageWorker {
+	return &UsageWorker{
+		usageUsecase: usageUsecase,
+		fupUsecase:   fupUsecase,
+	}
+}
+
+// Register registers the usage task handlers
+func (w *UsageWorker) Register(registry *queue.HandlerRegistry) {
+	queue.RegisterTyped(registry, TaskCollectUsage, w.handleCollect)
+}
+
+// PeriodicTasks returns the usage tasks that run on a schedule
+func (w *UsageWorker) PeriodicTasks() []queue.PeriodicTask {
+	return []queue.PeriodicTask{
+		// Counters keep growing on the router, so a skipped round is caught up by the next
+		queue.NewPeriodicTask("usage-collect", queue.Every5Minutes, TaskCollectUsage,
+			CollectUsagePayload{}, queue.QuickTask.ToAsynqOptions()...),
+	}
+}
+
+func (w *UsageWorker) handleCollect(ctx context.Context, _ CollectUsagePayload) error {
+	result, err := w.usageUsecase.Collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		pkg_logger.Warn("Usage collection skipped some routers",
+			zap.Int("routers", result.Routers),
+			zap.Strings("errors", result.Errors),
+		)
+	}
+
+	// Quotas are checked against the usage just recorded
+	fup, err := w.fupUsecase.Enforce(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if fup.Failed > 0 {
+		pkg_logger.Warn("Fair usage could not be enforced for some customers",
+			zap.Int("failed", fup.Failed),
+			zap.Strings("errors", fup.Errors),
+		)
+	}
+	return nil
+}
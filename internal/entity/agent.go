@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"math"
+	"time"
+)
+
+// AgentRole is the JWT role carried by agent tokens
+const AgentRole = "agent"
+
+type AgentStatus string
+
+const (
+	AgentStatusActive    AgentStatus = "active"
+	AgentStatusInactive  AgentStatus = "inactive"
+	AgentStatusSuspended AgentStatus = "suspended"
+)
+
+type AgentTransactionType string
+
+const (
+	AgentTransactionDeposit        AgentTransactionType = "deposit"
+	AgentTransactionWithdrawal     AgentTransactionType = "withdrawal"
+	AgentTransactionVoucherSale    AgentTransactionType = "voucher_sale"
+	AgentTransactionMonthlyPayment AgentTransactionType = "monthly_payment"
+	AgentTransactionCommission     AgentTransactionType = "commission"
+)
+
+type AgentRequestStatus string
+
+const (
+	AgentRequestPending  AgentRequestStatus = "pending"
+	AgentRequestApproved AgentRequestStatus = "approved"
+	AgentRequestRejected AgentRequestStatus = "rejected"
+)
+
+// Agent is a reseller who sells vouchers and collects customer payments from a prepaid balance
+type Agent struct {
+	ID             string      `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Username       string      `json:"username" gorm:"column:username;type:varchar(100);not null"`
+	Name           string      `json:"name" gorm:"column:name;type:varchar(255);not null"`
+	Phone          string      `json:"phone" gorm:"column:phone;type:varchar(20);not null"`
+	Email          *string     `json:"email,omitempty" gorm:"column:email;type:varchar(255)"`
+	PasswordHash   string      `json:"-" gorm:"column:password_hash;type:text;not null"`
+	Address        *string     `json:"address,omitempty" gorm:"column:address;type:text"`
+	Status         AgentStatus `json:"status" gorm:"column:status;type:agent_status;not null;default:'active'"`
+	CommissionRate float64     `json:"commission_rate" gorm:"column:commission_rate;type:decimal(5,2);not null;default:5"`
+	LastLogin      *time.Time  `json:"last_login,omitempty" gorm:"column:last_login;type:timestamptz"`
+	CreatedAt      time.Time   `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt      time.Time   `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+
+	// Relations
+	Balance *AgentBalance `json:"balance,omitempty" gorm:"foreignKey:AgentID"`
+}
+
+func (Agent) TableName() string { return "agents" }
+
+// Commission returns the agent's commission for a sale or collection of the given amount
+func (a *Agent) Commission(amount float64) float64 {
+	return math.Round(amount*a.CommissionRate) / 100
+}
+
+// AgentBalance is the running total of an agent's ledger
+type AgentBalance struct {
+	AgentID   string    `json:"agent_id" gorm:"primaryKey;type:uuid;column:agent_id"`
+	Balance   float64   `json:"balance" gorm:"column:balance;type:decimal(15,2);not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (AgentBalance) TableName() string { return "agent_balances" }
+
+// AgentTransaction is one ledger entry. Amount is signed: credits positive, debits negative.
+type AgentTransaction struct {
+	ID              string               `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	AgentID         string               `json:"agent_id" gorm:"column:agent_id;type:uuid;not null"`
+	TransactionType AgentTransactionType `json:"transaction_type" gorm:"column:transaction_type;type:agent_transaction_type;not null"`
+	Amount          float64              `json:"amount" gorm:"column:amount;type:decimal(15,2);not null"`
+	BalanceBefore   float64              `json:"balance_before" gorm:"column:balance_before;type:decimal(15,2);not null"`
+	BalanceAfter    float64              `json:"balance_after" gorm:"column:balance_after;type:decimal(15,2);not null"`
+	Description     *string              `json:"description,omitempty" gorm:"column:description;type:text"`
+	ReferenceType   *string              `json:"reference_type,omitempty" gorm:"column:reference_type;type:varchar(50)"`
+	ReferenceID     *string              `json:"reference_id,omitempty" gorm:"column:reference_id;type:varchar(100)"`
+	Status          string               `json:"status" gorm:"column:status;type:transaction_status;not null;default:'completed'"`
+	CreatedBy       *int64               `json:"created_by,omitempty" gorm:"column:created_by"`
+	CreatedAt       time.Time            `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+}
+
+func (AgentTransaction) TableName() string { return "agent_transactions" }
+
+// AgentBalanceRequest is a top-up asked for by an agent and approved or rejected by an admin
+type AgentBalanceRequest struct {
+	ID            string             `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	AgentID       string             `json:"agent_id" gorm:"column:agent_id;type:uuid;not null"`
+	Amount        float64            `json:"amount" gorm:"column:amount;type:decimal(15,2);not null"`
+	Status        AgentRequestStatus `json:"status" gorm:"column:status;type:request_status;not null;default:'pending'"`
+	Notes         *string            `json:"notes,omitempty" gorm:"column:notes;type:text"`
+	AdminNotes    *string            `json:"admin_notes,omitempty" gorm:"column:admin_notes;type:text"`
+	TransactionID *string            `json:"transaction_id,omitempty" gorm:"column:transaction_id;type:uuid"`
+	RequestedAt   time.Time          `json:"requested_at" gorm:"column:requested_at;type:timestamptz;default:now()"`
+	ProcessedAt   *time.Time         `json:"processed_at,omitempty" gorm:"column:processed_at;type:timestamptz"`
+	ProcessedBy   *int64             `json:"processed_by,omitempty" gorm:"column:processed_by"`
+
+	// Relations
+	Agent *Agent `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+}
+
+func (AgentBalanceRequest) TableName() string { return "agent_balance_requests" }
+
+// AgentVoucherSale is a hotspot voucher sold by an agent
+type AgentVoucherSale struct {
+	ID               string    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	AgentID          string    `json:"agent_id" gorm:"column:agent_id;type:uuid;not null"`
+	MikrotikID       string    `json:"mikrotik_id" gorm:"column:mikrotik_id;type:uuid;not null"`
+	VoucherCode      string    `json:"voucher_code" gorm:"column:voucher_code;type:varchar(100);not null"`
+	ProfileName      string    `json:"profile_name" gorm:"column:profile_name;type:varchar(100);not null"`
+	Price            float64   `json:"price" gorm:"column:price;type:decimal(15,2);not null"`
+	CommissionAmount float64   `json:"commission_amount" gorm:"column:commission_amount;type:decimal(15,2);not null;default:0"`
+	CustomerName     *string   `json:"customer_name,omitempty" gorm:"column:customer_name;type:varchar(255)"`
+	CustomerPhone    *string   `json:"customer_phone,omitempty" gorm:"column:customer_phone;type:varchar(20)"`
+	SoldAt           time.Time `json:"sold_at" gorm:"column:sold_at;type:timestamptz;default:now()"`
+}
+
+func (AgentVoucherSale) TableName() string { return "agent_voucher_sales" }
@@ -5,6 +5,25 @@ import (
 	"time"
 )
 
+// Suspension reasons stored in customers.suspension_reason
+const (
+	SuspensionReasonOverdue = "overdue"
+	SuspensionReasonManual  = "manual"
+)
+
+// Suspension modes: disable the PPP secret or move it to the router's isolation profile
+const (
+	SuspensionModeDisable = "disable"
+	SuspensionModeIsolate = "isolate"
+)
+
+// Address lists a static-IP customer's address is kept on. The router's firewall
+// is expected to drop the blocked list; isolation uses the router's isolation list.
+const (
+	StaticIPAllowedList = "mikrobill-allowed"
+	StaticIPBlockedList = "mikrobill-blocked"
+)
+
 // Customer represents a customer in the system
 type Customer struct {
 	ID          string  `json:"id" gorm:"primaryKey"`
@@ -13,22 +32,24 @@ type Customer struct {
 	Name        string  `json:"name" gorm:"column:name"`
 	Phone       *string `json:"phone" gorm:"column:phone"`
 	Email       *string `json:"email" gorm:"column:email"`
+	Address     *string `json:"address" gorm:"column:address"`
 	ServiceType string  `json:"service_type" gorm:"column:service_type"` // pppoe, hotspot, static_ip
 
 	// PPPoE specific
 	PPPoEUsername  *string `json:"pppoe_username" gorm:"column:pppoe_username"`
-	PPPoEPassword  *string `json:"pppoe_password" gorm:"column:pppoe_password"`
+	PPPoEPassword  *string `json:"-" gorm:"column:pppoe_password"` // sealed, never sent to clients
 	PPPoEProfileID *string `json:"pppoe_profile_id" gorm:"column:pppoe_profile_id"`
 
 	// Hotspot specific
 	HotspotUsername  *string `json:"hotspot_username" gorm:"column:hotspot_username"`
-	HotspotPassword  *string `json:"hotspot_password" gorm:"column:hotspot_password"`
+	HotspotPassword  *string `json:"-" gorm:"column:hotspot_password"` // sealed, never sent to clients
 	HotspotProfileID *string `json:"hotspot_profile_id" gorm:"column:hotspot_profile_id"`
 	HotspotMacAddr   *string `json:"hotspot_mac_address" gorm:"column:hotspot_mac_address"`
 	HotspotIPAddress *string `json:"hotspot_ip_address" gorm:"column:hotspot_ip_address"`
 
 	// Static IP
-	StaticIP *string `json:"static_ip" gorm:"column:static_ip"`
+	StaticIP          *string `json:"static_ip" gorm:"column:static_ip"`
+	StaticIPProfileID *string `json:"static_ip_profile_id" gorm:"column:static_ip_profile_id"`
 
 	// Network info
 	AssignedIP *string    `json:"assigned_ip" gorm:"column:assigned_ip"`
@@ -36,7 +57,26 @@ type Customer struct {
 	LastOnline *time.Time `json:"last_online" gorm:"column:last_online"`
 	Interface  *string    `json:"interface" gorm:"column:interface"`
 
-	Status    string    `json:"status"` // active, suspended, inactive, pending
+	Status string `json:"status"` // active, suspended, inactive, pending
+
+	// Billing
+	BillingDay     int        `json:"billing_day" gorm:"column:billing_day;default:15"`
+	AutoSuspension *bool      `json:"auto_suspension" gorm:"column:auto_suspension;default:true"`
+	JoinDate       *time.Time `json:"join_date" gorm:"column:join_date;default:now()"`
+
+	// Suspension
+	SuspendedAt      *time.Time `json:"suspended_at" gorm:"column:suspended_at"`
+	SuspensionReason *string    `json:"suspension_reason" gorm:"column:suspension_reason"`
+	SuspensionMode   *string    `json:"suspension_mode" gorm:"column:suspension_mode"` // nil inherits the router's mode
+	Isolated         bool       `json:"isolated" gorm:"column:isolated;default:false"`
+	// Profile the secret is restored to when an isolated customer is reactivated
+	OriginalProfileID *string `json:"original_profile_id" gorm:"column:original_profile_id"`
+
+	// Router sync: pending until the customer's sync jobs reached the router
+	SyncStatus string     `json:"sync_status" gorm:"column:sync_status;default:'synced'"`
+	SyncError  *string    `json:"sync_error" gorm:"column:sync_error"`
+	SyncedAt   *time.Time `json:"synced_at" gorm:"column:synced_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -68,7 +108,79 @@ type CustomerRepository interface {
 	CreateCustomer(customer *Customer) error
 	UpdateCustomer(customer *Customer) error
 	DeleteCustomer(id string) error
-	ListCustomers(page, limit int) ([]*Customer, int, error)
+	// ListCustomers returns a page of the filtered, sorted customer list
+	ListCustomers(query CustomerListQuery) (*CustomerPage, error)
+
+	// Billing
+	GetBillableCustomers(billingDays []int) ([]*Customer, error)
+	UpdateSuspension(id string, status string, suspendedAt *time.Time, reason *string) error
+	GetSuspendedCustomers() ([]*Customer, error)
+	UpdateIsolation(id string, isolated bool, originalProfileID *string) error
+
+	// Router
+	GetPPPoEUsernames(mikrotikID string) (map[string]string, error)
+	// GetRouterSessionCustomers lists a router's PPPoE and hotspot customers
+	GetRouterSessionCustomers(mikrotikID string) ([]*Customer, error)
+	// GetCustomerBySession finds the router's customer logging in as username with
+	// the given service type; nil when no customer uses it
+	GetCustomerBySession(mikrotikID, serviceType, username string) (*Customer, error)
+
+	// Import and export
+	// GetTakenValues returns which of the values a router's customers already use in
+	// column: username, phone, pppoe_username, hotspot_username or static_ip
+	GetTakenValues(mikrotikID, column string, values []string) (map[string]bool, error)
+	ExportCustomers(filter CustomerFilter, size int, fn func([]*Customer) error) error
+}
+
+// CustomerFilter narrows a customer list; empty fields match everything
+type CustomerFilter struct {
+	MikrotikID  string
+	ServiceType string
+	Status      string
+	// ProfileID matches the customer's PPPoE, hotspot or static IP plan
+	ProfileID  string
+	SyncStatus string
+	// Online matches customers with, or without, an open session
+	Online *bool
+	// Overdue matches customers with, or without, an unsettled invoice past its due date
+	Overdue *bool
+	// Search is a full-text search over name, username, phone, address and the PPPoE
+	// and hotspot usernames; every word must match the start of a word, and a number
+	// may also match anywhere in the phone number
+	Search string
+}
+
+// Columns a customer list can be sorted by
+const (
+	CustomerSortName       = "name"
+	CustomerSortUsername   = "username"
+	CustomerSortCreatedAt  = "created_at"
+	CustomerSortBillingDay = "billing_day"
+	CustomerSortStatus     = "status"
+)
+
+// CustomerListQuery asks for one page of a customer list. Pages are chained by
+// cursor: the NextCursor of a page, passed with the same sort, returns the next.
+// Without a cursor the page is read by offset instead.
+type CustomerListQuery struct {
+	Filter CustomerFilter
+	Sort   string // one of the CustomerSort columns; created_at when empty
+	Desc   bool
+	Limit  int
+	Cursor string
+	Page   int // offset page when Cursor is empty; 1 when zero
+}
+
+// CustomerPage is a page of a customer list
+type CustomerPage struct {
+	Customers []*Customer
+	// Total counts the customers matching the filter on all pages
+	Total int64
+	// NextCursor is empty on the last page
+	NextCursor string
+	// Page is the offset page read, zero for a page read by cursor
+	Page  int
+	Limit int
 }
 
 // RedisPublisher defines interface for publishing to Redis
@@ -77,6 +189,11 @@ type RedisPublisher interface {
 	PublishStream(streamKey string, data string) error
 }
 
+// AutoSuspensionEnabled reports whether billing may suspend this customer (defaults to true)
+func (c *Customer) AutoSuspensionEnabled() bool {
+	return c.AutoSuspension == nil || *c.AutoSuspension
+}
+
 // GetInterfaceNameForCustomer returns the interface name for monitoring
 func (c *Customer) GetInterfaceNameForCustomer() (string, error) {
 	switch c.ServiceType {
@@ -88,7 +205,11 @@ func (c *Customer) GetInterfaceNameForCustomer() (string, error) {
 	case "hotspot":
 		return "", fmt.Errorf("hotspot interface monitoring not implemented yet")
 	case "static_ip":
-		return "", fmt.Errorf("static IP interface monitoring not implemented yet")
+		// Set when the customer is provisioned: the profile's interface or VLAN
+		if c.Interface != nil && *c.Interface != "" {
+			return *c.Interface, nil
+		}
+		return "", fmt.Errorf("static ip customer %s is not provisioned on an interface", c.ID)
 	default:
 		return "", fmt.Errorf("unsupported service type: %s", c.ServiceType)
 	}
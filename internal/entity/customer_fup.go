@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Usage thresholds, in percent of the quota, that are notified once per billing cycle
+const (
+	FUPWarningPercent  = 80
+	FUPExceededPercent = 100
+)
+
+// CustomerFUPState is a customer's fair usage state in the current billing cycle
+type CustomerFUPState struct {
+	CustomerID      string     `gorm:"primaryKey;column:customer_id;type:uuid"`
+	CycleStart      time.Time  `gorm:"column:cycle_start;type:date;not null"`
+	NotifiedPercent int        `gorm:"column:notified_percent;not null"`
+	Throttled       bool       `gorm:"column:throttled;not null"`
+	ThrottledAt     *time.Time `gorm:"column:throttled_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (CustomerFUPState) TableName() string { return "customer_fup_states" }
+
+// FUPCandidate is a PPPoE customer on a profile with a quota, or one still throttled
+// by a quota that has since been removed
+type FUPCandidate struct {
+	CustomerID string
+	ProfileID  string
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Customer import job states
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// CustomerImportJob is a spreadsheet import processed by the background workers.
+// Rows holds the mapped rows until the job finishes, Result the per-row report.
+type CustomerImportJob struct {
+	ID         string          `gorm:"primaryKey;column:id;type:uuid;default:uuid_generate_v4()"`
+	MikrotikID string          `gorm:"column:mikrotik_id;type:uuid;not null"`
+	FileName   string          `gorm:"column:file_name;type:varchar(255);not null"`
+	Status     string          `gorm:"column:status;type:varchar(20);not null;default:'pending'"`
+	Provision  bool            `gorm:"column:provision;not null;default:false"`
+	Rows       json.RawMessage `gorm:"column:rows;type:jsonb"`
+	TotalRows  int             `gorm:"column:total_rows;not null;default:0"`
+	Result     json.RawMessage `gorm:"column:result;type:jsonb"`
+	Error      *string         `gorm:"column:error"`
+	StartedAt  *time.Time      `gorm:"column:started_at"`
+	FinishedAt *time.Time      `gorm:"column:finished_at"`
+	CreatedAt  time.Time       `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time       `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (CustomerImportJob) TableName() string { return "customer_import_jobs" }
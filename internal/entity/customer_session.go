@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// CustomerSession is one PPPoE or hotspot login of a customer
+type CustomerSession struct {
+	ID               string     `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	CustomerID       string     `gorm:"column:customer_id;type:uuid;not null"`
+	MikrotikID       string     `gorm:"column:mikrotik_id;type:uuid;not null"`
+	ServiceType      string     `gorm:"column:service_type;type:varchar(20);not null"`
+	RouterSessionID  *string    `gorm:"column:router_session_id;type:varchar(32)"`
+	Username         string     `gorm:"column:username;type:varchar(100);not null"`
+	IPAddress        *string    `gorm:"column:ip_address;type:inet"`
+	CallerID         *string    `gorm:"column:caller_id;type:varchar(64)"`
+	Interface        *string    `gorm:"column:interface;type:varchar(64)"`
+	StartedAt        time.Time  `gorm:"column:started_at;type:timestamptz;not null"`
+	EndedAt          *time.Time `gorm:"column:ended_at;type:timestamptz"`
+	DisconnectReason *string    `gorm:"column:disconnect_reason;type:text"`
+	BytesIn          *int64     `gorm:"column:bytes_in"`
+	BytesOut         *int64     `gorm:"column:bytes_out"`
+
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (CustomerSession) TableName() string { return "customer_sessions" }
+
+// CustomerSessionEnd is what is known about a session when it ends
+type CustomerSessionEnd struct {
+	EndedAt  time.Time
+	Reason   string
+	BytesIn  *int64
+	BytesOut *int64
+}
+
+// CustomerSessionStats aggregates a customer's sessions over a period
+type CustomerSessionStats struct {
+	Sessions       int64
+	UptimeSeconds  float64 // time online within the period
+	LongestSeconds float64
+	BytesIn        int64
+	BytesOut       int64
+	LastStartedAt  *time.Time
+	Online         bool
+}
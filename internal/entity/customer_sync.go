@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Customer sync states stored in customers.sync_status
+const (
+	SyncStatusPending = "pending"
+	SyncStatusSynced  = "synced"
+	SyncStatusFailed  = "failed"
+)
+
+// Sync job operations: upsert makes the router match the customer's current row,
+// delete removes the entries of the customer in the snapshot
+const (
+	SyncOperationUpsert = "upsert"
+	SyncOperationDelete = "delete"
+)
+
+// Sync job states
+const (
+	SyncJobPending = "pending"
+	SyncJobRunning = "running"
+	SyncJobDone    = "done"
+	SyncJobFailed  = "failed"
+)
+
+// CustomerSyncJob is an outbox entry: a router change recorded in the transaction
+// of the customer change it follows, and carried out by the background workers
+type CustomerSyncJob struct {
+	ID             string          `json:"id" gorm:"primaryKey;column:id;type:uuid;default:uuid_generate_v4()"`
+	CustomerID     string          `json:"customer_id" gorm:"column:customer_id;type:uuid;not null"`
+	MikrotikID     string          `json:"mikrotik_id" gorm:"column:mikrotik_id;type:uuid;not null"`
+	Operation      string          `json:"operation" gorm:"column:operation;type:varchar(20);not null"`
+	IdempotencyKey string          `json:"idempotency_key" gorm:"column:idempotency_key;type:varchar(150);not null"`
+	Snapshot       json.RawMessage `json:"-" gorm:"column:snapshot;type:jsonb"`
+	Status         string          `json:"status" gorm:"column:status;type:varchar(20);not null;default:'pending'"`
+	Attempts       int             `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	LastError      *string         `json:"last_error" gorm:"column:last_error"`
+	ProcessedAt    *time.Time      `json:"processed_at" gorm:"column:processed_at"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (CustomerSyncJob) TableName() string { return "customer_sync_jobs" }
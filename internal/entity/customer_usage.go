@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Usage rollup granularities
+const (
+	UsageGranularityHour  = "hour"
+	UsageGranularityDay   = "day"
+	UsageGranularityMonth = "month"
+)
+
+// UsageCounter is the last byte counter read for a customer's session
+type UsageCounter struct {
+	CustomerID string    `gorm:"primaryKey;column:customer_id;type:uuid"`
+	CounterKey string    `gorm:"primaryKey;column:counter_key;type:varchar(100)"`
+	MikrotikID string    `gorm:"column:mikrotik_id;type:uuid;not null"`
+	BytesUp    int64     `gorm:"column:bytes_up;not null"`
+	BytesDown  int64     `gorm:"column:bytes_down;not null"`
+	SampledAt  time.Time `gorm:"column:sampled_at;type:timestamptz;not null"`
+}
+
+func (UsageCounter) TableName() string { return "customer_usage_counters" }
+
+// UsageDelta is traffic counted for a customer since the previous sample
+type UsageDelta struct {
+	CustomerID string
+	BytesUp    int64
+	BytesDown  int64
+}
+
+// UsagePeriod is one rollup row
+type UsagePeriod struct {
+	PeriodStart time.Time
+	BytesUp     int64
+	BytesDown   int64
+}
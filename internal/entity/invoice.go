@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"math"
+	"time"
+)
+
+type InvoiceStatus string
+
+const (
+	InvoiceStatusUnpaid        InvoiceStatus = "unpaid"
+	InvoiceStatusPartiallyPaid InvoiceStatus = "partially_paid"
+	InvoiceStatusPaid          InvoiceStatus = "paid"
+	InvoiceStatusOverdue       InvoiceStatus = "overdue"
+	InvoiceStatusCancelled     InvoiceStatus = "cancelled"
+)
+
+type InvoiceType string
+
+const (
+	InvoiceTypeMonthly      InvoiceType = "monthly"
+	InvoiceTypeVoucher      InvoiceType = "voucher"
+	InvoiceTypeInstallation InvoiceType = "installation"
+	InvoiceTypeOther        InvoiceType = "other"
+)
+
+// Invoice represents a bill issued to a customer
+type Invoice struct {
+	ID            string        `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	MikrotikID    string        `json:"mikrotik_id" gorm:"column:mikrotik_id;type:uuid;not null"`
+	CustomerID    string        `json:"customer_id" gorm:"column:customer_id;type:uuid;not null"`
+	ProfileID     *string       `json:"profile_id,omitempty" gorm:"column:profile_id;type:uuid"`
+	InvoiceNumber string        `json:"invoice_number" gorm:"column:invoice_number;type:varchar(50);not null"`
+	InvoiceType   InvoiceType   `json:"invoice_type" gorm:"column:invoice_type;type:invoice_type;default:'monthly'"`
+	ProfileName   *string       `json:"profile_name,omitempty" gorm:"column:profile_name;type:varchar(100)"`
+	Description   *string       `json:"description,omitempty" gorm:"column:description;type:text"`
+	Amount        float64       `json:"amount" gorm:"column:amount;type:decimal(15,2);not null"`
+	LateFee       float64       `json:"late_fee" gorm:"column:late_fee;type:decimal(15,2);not null;default:0"`
+	PaidAmount    float64       `json:"paid_amount" gorm:"column:paid_amount;type:decimal(15,2);not null;default:0"`
+	PeriodStart   *time.Time    `json:"period_start,omitempty" gorm:"column:period_start;type:date"`
+	PeriodEnd     *time.Time    `json:"period_end,omitempty" gorm:"column:period_end;type:date"`
+	IssueDate     time.Time     `json:"issue_date" gorm:"column:issue_date;type:date;not null"`
+	DueDate       time.Time     `json:"due_date" gorm:"column:due_date;type:date;not null"`
+	Status        InvoiceStatus `json:"status" gorm:"column:status;type:invoice_status;default:'unpaid'"`
+	PaidAt        *time.Time    `json:"paid_at,omitempty" gorm:"column:paid_at;type:timestamptz"`
+	Notes         *string       `json:"notes,omitempty" gorm:"column:notes;type:text"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt     time.Time     `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+
+	// Relations
+	Customer *Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+}
+
+func (Invoice) TableName() string { return "invoices" }
+
+// Total returns the amount billed including any late fee
+func (inv *Invoice) Total() float64 {
+	return inv.Amount + inv.LateFee
+}
+
+// Outstanding returns the amount still to be paid, rounded to cents
+func (inv *Invoice) Outstanding() float64 {
+	remaining := math.Round((inv.Total()-inv.PaidAmount)*100) / 100
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// ApplyPaidAmount sets the paid total and derives the payment status from it.
+// Cancelled invoices keep their status.
+func (inv *Invoice) ApplyPaidAmount(paid float64, at time.Time) {
+	inv.PaidAmount = math.Round(paid*100) / 100
+	if inv.Status == InvoiceStatusCancelled {
+		return
+	}
+
+	switch {
+	case inv.Outstanding() == 0:
+		inv.Status = InvoiceStatusPaid
+		if inv.PaidAt == nil {
+			inv.PaidAt = &at
+		}
+	case inv.PaidAmount > 0:
+		inv.Status = InvoiceStatusPartiallyPaid
+		inv.PaidAt = nil
+	default:
+		inv.Status = InvoiceStatusUnpaid
+		inv.PaidAt = nil
+	}
+}
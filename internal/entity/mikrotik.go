@@ -13,7 +13,7 @@ const (
 type Mikrotik struct {
 	ID                   string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
 	Name                 string         `gorm:"column:name;type:text;not null"`
-	Host                 string         `gorm:"column:host;type:inet;not null"`
+	Host                 string         `gorm:"column:host;type:varchar(255);not null"`
 	Port                 int            `gorm:"column:port;not null;default:8728"`
 	APIUsername          string         `gorm:"column:api_username;type:text;not null"`
 	APIEncryptedPassword string         `gorm:"column:api_encrypted_password;type:text"`
@@ -22,12 +22,53 @@ type Mikrotik struct {
 	Location             string         `gorm:"column:location;type:varchar(100)"`
 	Description          string         `gorm:"column:description;type:text"`
 	IsActive             bool           `gorm:"column:is_active;not null;default:true"`
+	IsDefault            bool           `gorm:"column:is_default;not null;default:false"` // served by the single-router endpoints
 	Status               MikrotikStatus `gorm:"column:status;type:mikrotik_status;not null;default:'offline'"`
 	LastSync             *time.Time     `gorm:"column:last_sync;type:timestamptz"`
-	CreatedAt            time.Time      `gorm:"column:created_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt            time.Time      `gorm:"column:updated_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+
+	// Billing suspension strategy for customers on this router
+	SuspensionMode       string `gorm:"column:suspension_mode;type:varchar(20);not null;default:'disable'"`
+	IsolationProfile     string `gorm:"column:isolation_profile;type:varchar(100);not null;default:'isolir'"`
+	IsolationRateLimit   string `gorm:"column:isolation_rate_limit;type:varchar(50);not null;default:'256k/256k'"`
+	IsolationAddressList string `gorm:"column:isolation_address_list;type:varchar(100);not null;default:'isolir'"`
+
+	// Last reading of the health poller
+	CPULoad         *int       `gorm:"column:cpu_load"`
+	FreeMemory      *int64     `gorm:"column:free_memory"`
+	TotalMemory     *int64     `gorm:"column:total_memory"`
+	Uptime          string     `gorm:"column:uptime;type:varchar(50)"`
+	Version         string     `gorm:"column:routeros_version;type:varchar(50)"`
+	BoardName       string     `gorm:"column:board_name;type:varchar(100)"`
+	SerialNumber    string     `gorm:"column:serial_number;type:varchar(50)"`
+	HealthCheckedAt *time.Time `gorm:"column:health_checked_at;type:timestamptz"`
+
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
 
 	// Relations (these are defined in respective files)
 }
 
 func (Mikrotik) TableName() string { return "mikrotik" }
+
+// MikrotikHealth is what the health poller reads from a router
+type MikrotikHealth struct {
+	CPULoad      int
+	FreeMemory   int64
+	TotalMemory  int64
+	Uptime       string
+	Version      string
+	BoardName    string
+	SerialNumber string
+}
+
+// MikrotikStatusChange records a router moving from one status to another
+type MikrotikStatusChange struct {
+	ID         string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	MikrotikID string          `gorm:"column:mikrotik_id;type:uuid;not null"`
+	FromStatus *MikrotikStatus `gorm:"column:from_status;type:mikrotik_status"`
+	ToStatus   MikrotikStatus  `gorm:"column:to_status;type:mikrotik_status;not null"`
+	Reason     string          `gorm:"column:reason;type:text"`
+	CreatedAt  time.Time       `gorm:"column:created_at;type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (MikrotikStatusChange) TableName() string { return "mikrotik_status_history" }
@@ -0,0 +1,68 @@
+package entity
+
+import "time"
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash     PaymentMethod = "cash"
+	PaymentMethodTransfer PaymentMethod = "transfer"
+	PaymentMethodOther    PaymentMethod = "other"
+	PaymentMethodGateway  PaymentMethod = "gateway"
+)
+
+// Payment represents money received against an invoice. An invoice may have several.
+type Payment struct {
+	ID              string        `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	InvoiceID       string        `json:"invoice_id" gorm:"column:invoice_id;type:uuid;not null"`
+	CustomerID      string        `json:"customer_id" gorm:"column:customer_id;type:uuid;not null"`
+	ReceiptNumber   string        `json:"receipt_number" gorm:"column:receipt_number;type:varchar(50);not null"`
+	Amount          float64       `json:"amount" gorm:"column:amount;type:decimal(15,2);not null"`
+	PaymentMethod   PaymentMethod `json:"payment_method" gorm:"column:payment_method;type:payment_method;not null;default:'cash'"`
+	PaymentDate     time.Time     `json:"payment_date" gorm:"column:payment_date;type:timestamptz;not null"`
+	ReferenceNumber *string       `json:"reference_number,omitempty" gorm:"column:reference_number;type:varchar(100)"`
+	ReceivedBy      *int64        `json:"received_by,omitempty" gorm:"column:received_by"`
+	AgentID         *string       `json:"agent_id,omitempty" gorm:"column:agent_id;type:uuid"`
+	Notes           *string       `json:"notes,omitempty" gorm:"column:notes;type:text"`
+	CreatedAt       time.Time     `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+
+	// Relations
+	Invoice  *Invoice  `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	Customer *Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+}
+
+func (Payment) TableName() string { return "payments" }
+
+type GatewayTransactionStatus string
+
+const (
+	GatewayTransactionPending GatewayTransactionStatus = "pending"
+	GatewayTransactionSuccess GatewayTransactionStatus = "success"
+	GatewayTransactionFailed  GatewayTransactionStatus = "failed"
+	GatewayTransactionExpired GatewayTransactionStatus = "expired"
+)
+
+// PaymentGatewayTransaction is an online charge created at a payment gateway for an invoice
+type PaymentGatewayTransaction struct {
+	ID               string                   `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	InvoiceID        string                   `json:"invoice_id" gorm:"column:invoice_id;type:uuid;not null"`
+	Gateway          string                   `json:"gateway" gorm:"column:gateway;type:varchar(50);not null"`
+	OrderID          string                   `json:"order_id" gorm:"column:order_id;type:varchar(100);not null"`
+	TransactionID    *string                  `json:"transaction_id,omitempty" gorm:"column:transaction_id;type:varchar(100)"`
+	PaymentURL       *string                  `json:"payment_url,omitempty" gorm:"column:payment_url;type:text"`
+	Token            *string                  `json:"token,omitempty" gorm:"column:token;type:varchar(255)"`
+	Amount           float64                  `json:"amount" gorm:"column:amount;type:decimal(15,2);not null"`
+	Status           GatewayTransactionStatus `json:"status" gorm:"column:status;type:payment_status;not null;default:'pending'"`
+	PaymentType      *string                  `json:"payment_type,omitempty" gorm:"column:payment_type;type:varchar(50)"`
+	FraudStatus      *string                  `json:"fraud_status,omitempty" gorm:"column:fraud_status;type:varchar(50)"`
+	PaymentID        *string                  `json:"payment_id,omitempty" gorm:"column:payment_id;type:uuid"`
+	SettledAt        *time.Time               `json:"settled_at,omitempty" gorm:"column:settled_at;type:timestamptz"`
+	SettleError      *string                  `json:"settle_error,omitempty" gorm:"column:settle_error;type:text"`
+	LastNotification *string                  `json:"-" gorm:"column:last_notification;type:text"`
+	ExpiresAt        *time.Time               `json:"expires_at,omitempty" gorm:"column:expires_at;type:timestamptz"`
+	PaidAt           *time.Time               `json:"paid_at,omitempty" gorm:"column:paid_at;type:timestamptz"`
+	CreatedAt        time.Time                `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt        time.Time                `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (PaymentGatewayTransaction) TableName() string { return "payment_gateway_transactions" }
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -25,11 +27,36 @@ type MikrotikProfile struct {
 	CreatedAt            time.Time  `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt            time.Time  `json:"updated_at" gorm:"not null;default:now()"`
 
+	// Fair usage policy: past the quota in a billing cycle the rate drops to the FUP rate
+	FUPQuotaBytes    *int64  `json:"fup_quota_bytes,omitempty" gorm:"column:fup_quota_bytes"`
+	FUPRateLimitUp   *string `json:"fup_rate_limit_up,omitempty" gorm:"column:fup_rate_limit_up;type:varchar(50)"`
+	FUPRateLimitDown *string `json:"fup_rate_limit_down,omitempty" gorm:"column:fup_rate_limit_down;type:varchar(50)"`
+
 	// Relations
 	Mikrotik     *Mikrotik             `json:"mikrotik,omitempty" gorm:"foreignKey:MikrotikID"`
 	PPPoEDetails *MikrotikProfilePPPoE `json:"pppoe_details,omitempty" gorm:"foreignKey:ProfileID"`
 }
 
+// FUPEnabled reports whether the profile limits customers past a data quota
+func (p *MikrotikProfile) FUPEnabled() bool {
+	return p.FUPQuotaBytes != nil && *p.FUPQuotaBytes > 0
+}
+
+// fupProfileSuffix marks the router profile throttled customers are moved to
+const fupProfileSuffix = "-fup"
+
+// FUPProfileName is the name of the router profile throttled customers are moved to
+func (p *MikrotikProfile) FUPProfileName() string {
+	return p.Name + fupProfileSuffix
+}
+
+// PlanNameFromFUP returns the plan profile name of a FUP profile name, or false when
+// name is not one
+func PlanNameFromFUP(name string) (string, bool) {
+	plan, ok := strings.CutSuffix(name, fupProfileSuffix)
+	return plan, ok && plan != ""
+}
+
 // MikrotikProfilePPPoE represents PPPoE-specific profile settings
 type MikrotikProfilePPPoE struct {
 	ProfileID      string  `json:"profile_id" gorm:"primaryKey;type:uuid;column:profile_id"`
@@ -49,10 +76,58 @@ type MikrotikProfilePPPoE struct {
 	Profile *MikrotikProfile `json:"-" gorm:"foreignKey:ProfileID"`
 }
 
+// MikrotikProfileStaticIP represents static-IP specific settings. Customers are
+// bound to Interface, or to a VLAN on it when VLANID is set.
+type MikrotikProfileStaticIP struct {
+	ProfileID           string      `json:"profile_id" gorm:"primaryKey;type:uuid;column:profile_id"`
+	IPPool              *string     `json:"ip_pool,omitempty" gorm:"column:ip_pool;type:varchar(50)"`
+	Gateway             string      `json:"gateway" gorm:"type:varchar(50);not null"`
+	Netmask             string      `json:"netmask" gorm:"type:varchar(50);not null;default:'255.255.255.0'"`
+	Interface           *string     `json:"interface,omitempty" gorm:"column:interface;type:varchar(50)"`
+	AllowedMACAddresses StringArray `json:"allowed_mac_addresses,omitempty" gorm:"column:allowed_mac_addresses;type:text[]"`
+	FirewallChain       *string     `json:"firewall_chain,omitempty" gorm:"column:firewall_chain;type:varchar(50)"`
+	VLANID              *int        `json:"vlan_id,omitempty" gorm:"column:vlan_id"`
+	VLANPriority        *int        `json:"vlan_priority,omitempty" gorm:"column:vlan_priority"`
+	RouteDistance       *int        `json:"route_distance,omitempty" gorm:"column:route_distance;default:1"`
+	RoutingMark         *string     `json:"routing_mark,omitempty" gorm:"column:routing_mark;type:varchar(50)"`
+
+	// Relations
+	Profile *MikrotikProfile `json:"-" gorm:"foreignKey:ProfileID"`
+}
+
+// VLANInterfaceName is the name of the profile's VLAN interface on the router
+func (d *MikrotikProfileStaticIP) VLANInterfaceName() string {
+	if d.VLANID == nil {
+		return ""
+	}
+	return fmt.Sprintf("vlan%d", *d.VLANID)
+}
+
+// MikrotikQueueSettings represents a profile's queue shaping on top of its rate
+// limit: bursts, guaranteed rate, parent queue and priority
+type MikrotikQueueSettings struct {
+	ProfileID          string      `json:"profile_id" gorm:"primaryKey;type:uuid;column:profile_id"`
+	QueueType          string      `json:"queue_type" gorm:"column:queue_type;type:varchar(50);default:'default'"`
+	ParentQueue        *string     `json:"parent_queue,omitempty" gorm:"column:parent_queue;type:varchar(50)"`
+	Priority           string      `json:"priority" gorm:"type:varchar(20);default:'8'"`
+	BurstLimitUp       *string     `json:"burst_limit_up,omitempty" gorm:"column:burst_limit_up;type:varchar(50)"`
+	BurstLimitDown     *string     `json:"burst_limit_down,omitempty" gorm:"column:burst_limit_down;type:varchar(50)"`
+	BurstThresholdUp   *string     `json:"burst_threshold_up,omitempty" gorm:"column:burst_threshold_up;type:varchar(50)"`
+	BurstThresholdDown *string     `json:"burst_threshold_down,omitempty" gorm:"column:burst_threshold_down;type:varchar(50)"`
+	BurstTime          string      `json:"burst_time" gorm:"column:burst_time;type:varchar(20);default:'0s'"`
+	LimitAtUp          *string     `json:"limit_at_up,omitempty" gorm:"column:limit_at_up;type:varchar(50)"`
+	LimitAtDown        *string     `json:"limit_at_down,omitempty" gorm:"column:limit_at_down;type:varchar(50)"`
+	MaxLimitUp         *string     `json:"max_limit_up,omitempty" gorm:"column:max_limit_up;type:varchar(50)"`
+	MaxLimitDown       *string     `json:"max_limit_down,omitempty" gorm:"column:max_limit_down;type:varchar(50)"`
+	PacketMarks        StringArray `json:"packet_marks,omitempty" gorm:"column:packet_marks;type:text[]"`
+}
+
 // ProfileWithPPPoE combines profile with PPPoE details for API responses
 type ProfileWithPPPoE struct {
 	MikrotikProfile
-	PPPoEDetails *MikrotikProfilePPPoE `json:"pppoe_details,omitempty"`
+	PPPoEDetails    *MikrotikProfilePPPoE    `json:"pppoe_details,omitempty"`
+	StaticIPDetails *MikrotikProfileStaticIP `json:"static_ip_details,omitempty"`
+	QueueSettings   *MikrotikQueueSettings   `json:"queue_settings,omitempty"`
 }
 
 // ProfileRepository defines database operations for profiles
@@ -70,6 +145,11 @@ type ProfileRepository interface {
 
 	// Sync operations
 	UpdateSyncStatus(id string, lastSync time.Time) error
+
+	// SaveQueueSettings replaces the profile's queue settings; nil removes them
+	SaveQueueSettings(profileID string, settings *MikrotikQueueSettings) error
+	// SaveStaticIPDetails replaces the profile's static-IP settings; nil removes them
+	SaveStaticIPDetails(profileID string, details *MikrotikProfileStaticIP) error
 }
 
 func (MikrotikProfile) TableName() string {
@@ -79,3 +159,11 @@ func (MikrotikProfile) TableName() string {
 func (MikrotikProfilePPPoE) TableName() string {
 	return "mikrotik_profile_pppoe"
 }
+
+func (MikrotikProfileStaticIP) TableName() string {
+	return "mikrotik_profile_static_ip"
+}
+
+func (MikrotikQueueSettings) TableName() string {
+	return "mikrotik_queue_settings"
+}
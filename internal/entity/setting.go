@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+// AppSetting represents a key/value application setting grouped by category
+type AppSetting struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Category     string    `json:"category" gorm:"column:category;type:varchar(50);not null"`
+	SettingKey   string    `json:"setting_key" gorm:"column:setting_key;type:varchar(100);not null"`
+	SettingValue *string   `json:"setting_value" gorm:"column:setting_value;type:text"`
+	SettingType  string    `json:"setting_type" gorm:"column:setting_type;type:varchar(20);default:'string'"`
+	Description  *string   `json:"description,omitempty" gorm:"column:description;type:text"`
+	IsEncrypted  bool      `json:"is_encrypted" gorm:"column:is_encrypted;default:false"`
+	IsSystem     bool      `json:"is_system" gorm:"column:is_system;default:false"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (AppSetting) TableName() string { return "app_settings" }
+
+// CompanyProfile holds company branding and invoice settings
+type CompanyProfile struct {
+	ID                 string    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	CompanyName        string    `json:"company_name" gorm:"column:company_name;type:varchar(255);not null"`
+	CompanyAddress     *string   `json:"company_address,omitempty" gorm:"column:company_address;type:text"`
+	CompanyPhone       *string   `json:"company_phone,omitempty" gorm:"column:company_phone;type:varchar(20)"`
+	CompanyEmail       *string   `json:"company_email,omitempty" gorm:"column:company_email;type:varchar(255)"`
+	CompanyWebsite     *string   `json:"company_website,omitempty" gorm:"column:company_website;type:varchar(255)"`
+	LogoURL            *string   `json:"logo_url,omitempty" gorm:"column:logo_url;type:varchar(255)"`
+	FaviconURL         *string   `json:"favicon_url,omitempty" gorm:"column:favicon_url;type:varchar(255)"`
+	PrimaryColor       string    `json:"primary_color" gorm:"column:primary_color;type:varchar(7);default:'#3B82F6'"`
+	SecondaryColor     string    `json:"secondary_color" gorm:"column:secondary_color;type:varchar(7);default:'#1E40AF'"`
+	InvoicePrefix      string    `json:"invoice_prefix" gorm:"column:invoice_prefix;type:varchar(10);default:'INV'"`
+	InvoiceStartNumber int       `json:"invoice_start_number" gorm:"column:invoice_start_number;default:1000"`
+	InvoiceNextNumber  *int      `json:"invoice_next_number,omitempty" gorm:"column:invoice_next_number"`
+	InvoiceTerms       *string   `json:"invoice_terms,omitempty" gorm:"column:invoice_terms;type:text"`
+	InvoiceFooter      *string   `json:"invoice_footer,omitempty" gorm:"column:invoice_footer;type:text"`
+	DefaultTaxRate     float64   `json:"default_tax_rate" gorm:"column:default_tax_rate;type:decimal(5,2);default:11.00"`
+	TaxID              *string   `json:"tax_id,omitempty" gorm:"column:tax_id;type:varchar(50)"`
+	CreatedAt          time.Time `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt          time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (CompanyProfile) TableName() string { return "company_profile" }
+
+// Setting categories and keys used by the billing module
+const (
+	SettingCategoryBilling = "billing"
+
+	SettingAutoGenerateInvoice = "auto_generate_invoice"
+	SettingInvoiceDueDays      = "invoice_due_days"
+	SettingLateFeePercentage   = "late_fee_percentage"
+	SettingGracePeriodDays     = "grace_period_days"
+)
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// StringArray maps a Postgres TEXT[] column
+type StringArray []string
+
+// Value encodes the array as a Postgres array literal
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	quoted := make([]string, len(a))
+	for i, s := range a {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}", nil
+}
+
+// Scan decodes a one-dimensional Postgres array literal
+func (a *StringArray) Scan(src interface{}) error {
+	var literal string
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		literal = v
+	case []byte:
+		literal = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringArray", src)
+	}
+
+	if len(literal) < 2 || literal[0] != '{' || literal[len(literal)-1] != '}' {
+		return fmt.Errorf("invalid array literal %q", literal)
+	}
+	body := literal[1 : len(literal)-1]
+
+	result := StringArray{}
+	if body == "" {
+		*a = result
+		return nil
+	}
+
+	var (
+		elem    strings.Builder
+		quoted  bool
+		inQuote bool
+	)
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case inQuote && c == '\\' && i+1 < len(body):
+			i++
+			elem.WriteByte(body[i])
+		case c == '"':
+			inQuote = !inQuote
+			quoted = true
+		case c == ',' && !inQuote:
+			result = append(result, arrayElement(elem.String(), quoted))
+			elem.Reset()
+			quoted = false
+		default:
+			elem.WriteByte(c)
+		}
+	}
+	*a = append(result, arrayElement(elem.String(), quoted))
+	return nil
+}
+
+// arrayElement keeps an unquoted NULL out of the result as an empty string
+func arrayElement(s string, quoted bool) string {
+	if !quoted && strings.EqualFold(s, "NULL") {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package entity
+
+import "time"
+
+type VoucherStatus string
+
+const (
+	VoucherStatusActive    VoucherStatus = "active"
+	VoucherStatusUsed      VoucherStatus = "used"
+	VoucherStatusExpired   VoucherStatus = "expired"
+	VoucherStatusCancelled VoucherStatus = "cancelled"
+)
+
+// Voucher states used for listing. Unsold and sold are both VoucherStatusActive,
+// told apart by SoldAt.
+const (
+	VoucherStateUnsold  = "unsold"
+	VoucherStateSold    = "sold"
+	VoucherStateUsed    = "used"
+	VoucherStateExpired = "expired"
+)
+
+// VoucherBatch is one bulk generation of hotspot vouchers on a router
+type VoucherBatch struct {
+	ID              string    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	MikrotikID      string    `json:"mikrotik_id" gorm:"column:mikrotik_id;type:uuid;not null"`
+	BatchName       string    `json:"batch_name" gorm:"column:batch_name;type:varchar(100);not null"`
+	ProfileName     string    `json:"profile_name" gorm:"column:profile_name;type:varchar(100);not null"`
+	Server          *string   `json:"server,omitempty" gorm:"column:server;type:varchar(50)"`
+	UserType        string    `json:"user_type" gorm:"column:user_type;type:varchar(2);not null"`
+	CharType        string    `json:"char_type" gorm:"column:char_type;type:varchar(10);not null"`
+	CodePrefix      *string   `json:"code_prefix,omitempty" gorm:"column:code_prefix;type:varchar(20)"`
+	CodeLength      int       `json:"code_length" gorm:"column:code_length;not null"`
+	TotalVouchers   int       `json:"total_vouchers" gorm:"column:total_vouchers;not null"`
+	PricePerVoucher float64   `json:"price_per_voucher" gorm:"column:price_per_voucher;type:decimal(15,2);not null;default:0"`
+	Validity        *string   `json:"validity,omitempty" gorm:"column:validity;type:varchar(20)"`
+	TimeLimit       *string   `json:"time_limit,omitempty" gorm:"column:time_limit;type:varchar(20)"`
+	DataLimit       *string   `json:"data_limit,omitempty" gorm:"column:data_limit;type:varchar(20)"`
+	RouterComment   string    `json:"router_comment" gorm:"column:router_comment;type:varchar(255);not null"`
+	CreatedBy       *int64    `json:"created_by,omitempty" gorm:"column:created_by"`
+	CreatedAt       time.Time `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+
+	// Relations
+	Vouchers []HotspotVoucher `json:"vouchers,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+func (VoucherBatch) TableName() string { return "voucher_batches" }
+
+// HotspotVoucher is a single hotspot user generated as a voucher
+type HotspotVoucher struct {
+	ID             string        `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	MikrotikID     string        `json:"mikrotik_id" gorm:"column:mikrotik_id;type:uuid;not null"`
+	BatchID        string        `json:"batch_id" gorm:"column:batch_id;type:uuid;not null"`
+	Username       string        `json:"username" gorm:"column:username;type:varchar(100);not null"`
+	Password       string        `json:"password" gorm:"column:password;type:varchar(100);not null"`
+	ProfileName    string        `json:"profile_name" gorm:"column:profile_name;type:varchar(100);not null"`
+	Price          float64       `json:"price" gorm:"column:price;type:decimal(15,2);not null;default:0"`
+	Validity       *string       `json:"validity,omitempty" gorm:"column:validity;type:varchar(20)"`
+	TimeLimit      *string       `json:"time_limit,omitempty" gorm:"column:time_limit;type:varchar(20)"`
+	DataLimit      *string       `json:"data_limit,omitempty" gorm:"column:data_limit;type:varchar(20)"`
+	Status         VoucherStatus `json:"status" gorm:"column:status;type:voucher_status;not null;default:'active'"`
+	SoldAt         *time.Time    `json:"sold_at,omitempty" gorm:"column:sold_at;type:timestamptz"`
+	AgentID        *string       `json:"agent_id,omitempty" gorm:"column:agent_id;type:uuid"`
+	ActivatedAt    *time.Time    `json:"activated_at,omitempty" gorm:"column:activated_at;type:timestamptz"`
+	ExpiredAt      *time.Time    `json:"expired_at,omitempty" gorm:"column:expired_at;type:timestamptz"`
+	MikrotikUserID *string       `json:"mikrotik_user_id,omitempty" gorm:"column:mikrotik_user_id;type:varchar(100)"`
+	LastSync       *time.Time    `json:"last_sync,omitempty" gorm:"column:last_sync;type:timestamptz"`
+	CreatedBy      *int64        `json:"created_by,omitempty" gorm:"column:created_by"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt      time.Time     `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (HotspotVoucher) TableName() string { return "hotspot_vouchers" }
+
+// State returns the listing state of the voucher
+func (v *HotspotVoucher) State() string {
+	switch v.Status {
+	case VoucherStatusUsed:
+		return VoucherStateUsed
+	case VoucherStatusExpired, VoucherStatusCancelled:
+		return VoucherStateExpired
+	}
+	if v.SoldAt != nil {
+		return VoucherStateSold
+	}
+	return VoucherStateUnsold
+}
+
+// Voucher template types
+const (
+	VoucherTemplateHTML = "html"
+	VoucherTemplatePDF  = "pdf"
+)
+
+// VoucherTemplate is a user-editable layout for printing voucher sheets. HTML
+// templates render the whole sheet; PDF templates render the text of one card.
+type VoucherTemplate struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	TemplateName string    `json:"template_name" gorm:"column:template_name;type:varchar(100);not null"`
+	TemplateType string    `json:"template_type" gorm:"column:template_type;type:varchar(10);not null"`
+	Description  *string   `json:"description,omitempty" gorm:"column:description;type:text"`
+	Content      string    `json:"content" gorm:"column:content;type:text;not null"`
+	Columns      int       `json:"columns" gorm:"column:columns;not null;default:4"`
+	RowsPerPage  int       `json:"rows_per_page" gorm:"column:rows_per_page;not null;default:8"`
+	ShowQR       bool      `json:"show_qr" gorm:"column:show_qr;not null;default:false"`
+	IsDefault    bool      `json:"is_default" gorm:"column:is_default;not null;default:false"`
+	IsActive     bool      `json:"is_active" gorm:"column:is_active;not null;default:true"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;type:timestamptz;default:now()"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamptz;default:now()"`
+}
+
+func (VoucherTemplate) TableName() string { return "voucher_templates" }
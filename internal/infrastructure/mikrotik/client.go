@@ -2,6 +2,7 @@ package mikrotik
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,49 +19,55 @@ type Config struct {
 	Password string
 	Timeout  time.Duration
 	UseTLS   bool
-	Queue    int // optional: default 100
+	Queue    int  // optional: default 100
+	Async    bool // tag commands so one connection can serve concurrent callers
 }
 
-// Client wraps *routeros.Client to make it reusable and configurable.
+// ErrClientClosed is returned once a client is closed, instead of redialing a
+// connection its owner has given up on
+var ErrClientClosed = errors.New("mikrotik client is closed")
+
+// Client wraps *routeros.Client to make it reusable and configurable. It is safe
+// for concurrent use: Reconnect swaps the connection while callers run on it.
 type Client struct {
-	*routeros.Client        // embedded → all default methods available!
-	Config           Config // Expose config for creating new instances
-	mu               sync.Mutex
+	Config Config // Expose config for creating new instances
+
+	mu     sync.RWMutex // guards conn and closed
+	conn   *routeros.Client
+	closed bool
 }
 
 // NewClient creates and returns a new MikroTik client.
 func NewClient(cfg Config) (*Client, error) {
+	return NewClientContext(context.Background(), cfg)
+}
+
+// NewClientContext is NewClient with a dial that also stops when ctx is done
+func NewClientContext(ctx context.Context, cfg Config) (*Client, error) {
 	client := &Client{Config: cfg}
-	if err := client.connect(); err != nil {
+	if err := client.connect(ctx); err != nil {
 		return nil, err
 	}
 	return client, nil
 }
 
-func (c *Client) connect() error {
+func (c *Client) connect(ctx context.Context) error {
 	address := fmt.Sprintf("%s:%d", c.Config.Host, c.Config.Port)
 
+	if c.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Config.Timeout)
+		defer cancel()
+	}
+
 	var (
 		conn *routeros.Client
 		err  error
 	)
-
-	if c.Config.Timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), c.Config.Timeout)
-		defer cancel()
-
-		if c.Config.UseTLS {
-			conn, err = routeros.DialTLSContext(ctx, address, c.Config.Username, c.Config.Password, nil)
-		} else {
-			conn, err = routeros.DialContext(ctx, address, c.Config.Username, c.Config.Password)
-		}
+	if c.Config.UseTLS {
+		conn, err = routeros.DialTLSContext(ctx, address, c.Config.Username, c.Config.Password, nil)
 	} else {
-		// WITHOUT CONTEXT (SAFE)
-		if c.Config.UseTLS {
-			conn, err = routeros.DialTLS(address, c.Config.Username, c.Config.Password, nil)
-		} else {
-			conn, err = routeros.Dial(address, c.Config.Username, c.Config.Password)
-		}
+		conn, err = routeros.DialContext(ctx, address, c.Config.Username, c.Config.Password)
 	}
 
 	if err != nil {
@@ -70,40 +77,159 @@ func (c *Client) connect() error {
 	if c.Config.Queue > 0 {
 		conn.Queue = c.Config.Queue
 	}
+	if c.Config.Async {
+		conn.Async()
+	}
 
-	c.Client = conn
+	c.conn = conn
 	return nil
 }
 
+// current returns the live connection
+func (c *Client) current() (*routeros.Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	return c.conn, nil
+}
+
 // Reconnect attempts to re-establish the connection
 func (c *Client) Reconnect() error {
+	conn, err := c.current()
+	if err != nil {
+		return err
+	}
+	_, err = c.reconnect(conn)
+	return err
+}
+
+// reconnect replaces a broken connection. When another caller already replaced it,
+// its connection is returned instead of dialing again, so concurrent failures on
+// one connection share a single redial.
+func (c *Client) reconnect(broken *routeros.Client) (*routeros.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	if c.conn != broken {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	if err := c.connect(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.conn, nil
+}
+
+// Close closes the connection for good; later calls fail with ErrClientClosed
+func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.Client != nil {
-		c.Client.Close()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// retry runs fn on the connection, and once more on a fresh one when the
+// connection turned out to be broken
+func retry[T any](c *Client, fn func(conn *routeros.Client) (T, error)) (T, error) {
+	var zero T
+	conn, err := c.current()
+	if err != nil {
+		return zero, err
+	}
+
+	result, err := fn(conn)
+	if err != nil && isConnectionError(err) {
+		if conn, recErr := c.reconnect(conn); recErr == nil {
+			return fn(conn)
+		}
 	}
-	return c.connect()
+	return result, err
 }
 
 // Run overrides routeros.Client.Run with auto-reconnection support
 func (c *Client) Run(sentence ...string) (*routeros.Reply, error) {
-	reply, err := c.Client.Run(sentence...)
+	return c.RunArgs(sentence)
+}
+
+// RunArgs is Run with the sentence as a slice
+func (c *Client) RunArgs(sentence []string) (*routeros.Reply, error) {
+	return retry(c, func(conn *routeros.Client) (*routeros.Reply, error) {
+		return conn.RunArgs(sentence)
+	})
+}
+
+// RunContext is Run bounded by ctx
+func (c *Client) RunContext(ctx context.Context, sentence ...string) (*routeros.Reply, error) {
+	return retry(c, func(conn *routeros.Client) (*routeros.Reply, error) {
+		return conn.RunArgsContext(ctx, sentence)
+	})
+}
+
+// AsyncContext switches the connection to tagged commands until ctx ends, so
+// several listens can share it
+func (c *Client) AsyncContext(ctx context.Context) <-chan error {
+	conn, err := c.current()
+	if err != nil {
+		errC := make(chan error, 1)
+		errC <- err
+		close(errC)
+		return errC
+	}
+	return conn.AsyncContext(ctx)
+}
+
+// ListenArgsContext starts a listen command. Listens are not retried: the caller
+// decides whether a dropped stream is worth redialing.
+func (c *Client) ListenArgsContext(ctx context.Context, sentence []string) (*routeros.ListenReply, error) {
+	conn, err := c.current()
 	if err != nil {
-		if isConnectionError(err) {
-			// Try to reconnect
-			if recErr := c.Reconnect(); recErr == nil {
-				// Retry command
-				return c.Client.Run(sentence...)
-			}
-		}
 		return nil, err
 	}
-	return reply, nil
+	return conn.ListenArgsContext(ctx, sentence)
+}
+
+// ListenArgsQueueContext is ListenArgsContext with the reply queue size set
+func (c *Client) ListenArgsQueueContext(ctx context.Context, sentence []string, queueSize int) (*routeros.ListenReply, error) {
+	conn, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	return conn.ListenArgsQueueContext(ctx, sentence, queueSize)
+}
+
+// DeviceMessage returns the message of an error the router itself reported (a !trap),
+// as opposed to a failure to talk to it.
+func DeviceMessage(err error) (string, bool) {
+	var devErr *routeros.DeviceError
+	if errors.As(err, &devErr) {
+		if m := devErr.Sentence.Map["message"]; m != "" {
+			return m, true
+		}
+		return devErr.Error(), true
+	}
+	return "", false
 }
 
 func isConnectionError(err error) bool {
 	msg := err.Error()
+	if errors.Is(err, ErrClientClosed) {
+		return false
+	}
 	return strings.Contains(msg, "loop has ended") ||
 		strings.Contains(msg, "closed network connection") ||
 		strings.Contains(msg, "broken pipe") ||
@@ -0,0 +1,203 @@
+package mikrotik
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ==================== ARP Methods ====================
+
+// FindARPEntryID returns the ID of the static ARP entry for an address
+func (c *Client) FindARPEntryID(address string) (string, error) {
+	cmd := []string{
+		"/ip/arp/print",
+		"?address=" + address,
+		"?dynamic=false",
+		"=.proplist=.id",
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.Re) == 0 {
+		return "", nil // Not found
+	}
+
+	return r.Re[0].Map[".id"], nil
+}
+
+// CreateARPEntry binds an address to a MAC address on an interface
+func (c *Client) CreateARPEntry(address, macAddress, iface, comment string, disabled bool) (string, error) {
+	cmd := []string{
+		"/ip/arp/add",
+		"=address=" + address,
+		"=mac-address=" + macAddress,
+		"=interface=" + iface,
+		"=disabled=" + yesNo(disabled),
+	}
+	if comment != "" {
+		cmd = append(cmd, "=comment="+comment)
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create arp entry: %w", err)
+	}
+	return r.Done.Map["ret"], nil
+}
+
+// UpdateARPEntry updates an existing ARP entry
+func (c *Client) UpdateARPEntry(id, macAddress, iface, comment string, disabled bool) error {
+	cmd := []string{
+		"/ip/arp/set",
+		"=.id=" + id,
+		"=disabled=" + yesNo(disabled),
+	}
+	if macAddress != "" {
+		cmd = append(cmd, "=mac-address="+macAddress)
+	}
+	if iface != "" {
+		cmd = append(cmd, "=interface="+iface)
+	}
+	if comment != "" {
+		cmd = append(cmd, "=comment="+comment)
+	}
+
+	if _, err := c.RunArgs(cmd); err != nil {
+		return fmt.Errorf("failed to update arp entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteARPEntry deletes an ARP entry by ID
+func (c *Client) DeleteARPEntry(id string) error {
+	if _, err := c.Run("/ip/arp/remove", "=.id="+id); err != nil {
+		return fmt.Errorf("failed to delete arp entry: %w", err)
+	}
+	return nil
+}
+
+// ==================== Interface Methods ====================
+
+// FindInterfaceID returns the ID of an interface by name
+func (c *Client) FindInterfaceID(name string) (string, error) {
+	r, err := c.Run("/interface/print", "?name="+name, "=.proplist=.id")
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.Re) == 0 {
+		return "", nil // Not found
+	}
+
+	return r.Re[0].Map[".id"], nil
+}
+
+// CreateVLANInterface creates a VLAN interface on a parent interface
+func (c *Client) CreateVLANInterface(name, parent string, vlanID int, comment string) error {
+	cmd := []string{
+		"/interface/vlan/add",
+		"=name=" + name,
+		"=interface=" + parent,
+		"=vlan-id=" + strconv.Itoa(vlanID),
+	}
+	if comment != "" {
+		cmd = append(cmd, "=comment="+comment)
+	}
+
+	if _, err := c.RunArgs(cmd); err != nil {
+		return fmt.Errorf("failed to create vlan interface: %w", err)
+	}
+	return nil
+}
+
+// FindIPAddressID returns the ID of an interface address, given as address/prefix
+func (c *Client) FindIPAddressID(address, iface string) (string, error) {
+	r, err := c.Run("/ip/address/print", "?address="+address, "?interface="+iface, "=.proplist=.id")
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.Re) == 0 {
+		return "", nil // Not found
+	}
+
+	return r.Re[0].Map[".id"], nil
+}
+
+// CreateIPAddress adds an address, given as address/prefix, to an interface
+func (c *Client) CreateIPAddress(address, iface, comment string) error {
+	cmd := []string{
+		"/ip/address/add",
+		"=address=" + address,
+		"=interface=" + iface,
+	}
+	if comment != "" {
+		cmd = append(cmd, "=comment="+comment)
+	}
+
+	if _, err := c.RunArgs(cmd); err != nil {
+		return fmt.Errorf("failed to create ip address: %w", err)
+	}
+	return nil
+}
+
+// ==================== Address List Methods ====================
+
+// FindAddressListEntries returns the firewall address-list entries of an address
+// carrying comment, on any list
+func (c *Client) FindAddressListEntries(address, comment string) ([]map[string]string, error) {
+	r, err := c.Run("/ip/firewall/address-list/print", "?address="+address, "?comment="+comment)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]map[string]string, len(r.Re))
+	for i, re := range r.Re {
+		entries[i] = re.Map
+	}
+	return entries, nil
+}
+
+// CreateAddressListEntry adds an address to a firewall address list
+func (c *Client) CreateAddressListEntry(list, address, comment string) (string, error) {
+	cmd := []string{
+		"/ip/firewall/address-list/add",
+		"=list=" + list,
+		"=address=" + address,
+	}
+	if comment != "" {
+		cmd = append(cmd, "=comment="+comment)
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create address list entry: %w", err)
+	}
+	return r.Done.Map["ret"], nil
+}
+
+// UpdateAddressListEntry moves an address-list entry to another list
+func (c *Client) UpdateAddressListEntry(id, list string) error {
+	if _, err := c.Run("/ip/firewall/address-list/set", "=.id="+id, "=list="+list); err != nil {
+		return fmt.Errorf("failed to update address list entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteAddressListEntry deletes an address-list entry by ID
+func (c *Client) DeleteAddressListEntry(id string) error {
+	if _, err := c.Run("/ip/firewall/address-list/remove", "=.id="+id); err != nil {
+		return fmt.Errorf("failed to delete address list entry: %w", err)
+	}
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -19,6 +19,10 @@ type PPPoEProfileParams struct {
 	OnlyOne          bool
 	DNSServer        string
 	AddressPool      string
+	AddressList      string // firewall address list the session's remote address is added to
+	RateLimit        string // full rate-limit with burst and priority, overrides RateLimitUp/Down
+	ParentQueue      string
+	QueueType        string
 }
 
 // ==================== PPPoE Secret Methods ====================
@@ -131,7 +135,9 @@ func (c *Client) CreatePPPoEProfile(params PPPoEProfileParams) error {
 	if params.RemoteAddress != "" {
 		cmd = append(cmd, "=remote-address="+params.RemoteAddress)
 	}
-	if params.RateLimitUp != "" || params.RateLimitDown != "" {
+	if params.RateLimit != "" {
+		cmd = append(cmd, "=rate-limit="+params.RateLimit)
+	} else if params.RateLimitUp != "" || params.RateLimitDown != "" {
 		rateLimit := params.RateLimitUp + "/" + params.RateLimitDown
 		cmd = append(cmd, "=rate-limit="+rateLimit)
 	}
@@ -150,6 +156,15 @@ func (c *Client) CreatePPPoEProfile(params PPPoEProfileParams) error {
 	if params.DNSServer != "" {
 		cmd = append(cmd, "=dns-server="+params.DNSServer)
 	}
+	if params.AddressList != "" {
+		cmd = append(cmd, "=address-list="+params.AddressList)
+	}
+	if params.ParentQueue != "" {
+		cmd = append(cmd, "=parent-queue="+params.ParentQueue)
+	}
+	if params.QueueType != "" {
+		cmd = append(cmd, "=queue-type="+params.QueueType)
+	}
 
 	_, err := c.RunArgs(cmd)
 	if err != nil {
@@ -186,7 +201,9 @@ func (c *Client) UpdatePPPoEProfile(params PPPoEProfileParams) error {
 	if params.RemoteAddress != "" {
 		cmd = append(cmd, "=remote-address="+params.RemoteAddress)
 	}
-	if params.RateLimitUp != "" || params.RateLimitDown != "" {
+	if params.RateLimit != "" {
+		cmd = append(cmd, "=rate-limit="+params.RateLimit)
+	} else if params.RateLimitUp != "" || params.RateLimitDown != "" {
 		rateLimit := params.RateLimitUp + "/" + params.RateLimitDown
 		cmd = append(cmd, "=rate-limit="+rateLimit)
 	}
@@ -207,6 +224,15 @@ func (c *Client) UpdatePPPoEProfile(params PPPoEProfileParams) error {
 	if params.DNSServer != "" {
 		cmd = append(cmd, "=dns-server="+params.DNSServer)
 	}
+	if params.AddressList != "" {
+		cmd = append(cmd, "=address-list="+params.AddressList)
+	}
+	if params.ParentQueue != "" {
+		cmd = append(cmd, "=parent-queue="+params.ParentQueue)
+	}
+	if params.QueueType != "" {
+		cmd = append(cmd, "=queue-type="+params.QueueType)
+	}
 
 	_, err = c.RunArgs(cmd)
 	if err != nil {
@@ -356,11 +382,11 @@ func (c *Client) StreamPing(
 		args = append(args, "=interval="+interval)
 	}
 
-	reply, err := c.Client.ListenArgsContext(ctx, args)
+	reply, err := c.ListenArgsContext(ctx, args)
 	if err != nil {
 		if IsConnectionError(err) {
 			if recErr := c.Reconnect(); recErr == nil {
-				reply, err = c.Client.ListenArgsContext(ctx, args)
+				reply, err = c.ListenArgsContext(ctx, args)
 			}
 		}
 	}
@@ -17,6 +17,21 @@ func (s *Service) GetReportByDate(date string, useCache bool) ([]map[string]stri
 	return reports, nil
 }
 
+// GetReports mendapatkan semua report penjualan yang dicatat script on-login
+func (s *Service) GetReports() ([]map[string]string, error) {
+	reply, err := s.client.Run("/system/script/print", "?comment=mikhmon")
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]map[string]string, len(reply.Re))
+	for i, re := range reply.Re {
+		reports[i] = re.Map
+	}
+
+	return reports, nil
+}
+
 // GetReportCount mendapatkan jumlah report
 func (s *Service) GetReportCount(date string) (int, error) {
 	reply, err := s.client.Run("/system/script/print", "?source="+date)
@@ -32,6 +32,21 @@ func (s *Service) GetHotspotServers() ([]map[string]string, error) {
 	return servers, nil
 }
 
+// GetServerProfiles mendapatkan hotspot server profiles (dns-name, login-by, dll)
+func (s *Service) GetServerProfiles() ([]map[string]string, error) {
+	reply, err := s.client.Run("/ip/hotspot/profile/print")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]map[string]string, len(reply.Re))
+	for i, re := range reply.Re {
+		profiles[i] = re.Map
+	}
+
+	return profiles, nil
+}
+
 // GetHotspotHosts mendapatkan hotspot hosts
 func (s *Service) GetHotspotHosts() ([]map[string]string, error) {
 	reply, err := s.client.Run("/ip/hotspot/host/print")
@@ -156,7 +156,7 @@ func (s *Service) UpdateUser(userID string, updates model.UserUpdateRequest) (*m
 		args = append(args, "=disabled="+boolToYesNo(*updates.Disabled))
 	}
 
-	sentences := append([]string{"/ip/hotspot/user/SET"}, args...)
+	sentences := append([]string{"/ip/hotspot/user/set"}, args...)
 	_, err := s.client.RunArgs(sentences)
 	if err != nil {
 		return &model.UserResponse{
@@ -96,11 +96,6 @@ func (s *Service) GenerateVouchers(config model.VoucherRequest) (*model.VoucherR
 			}
 		}
 
-		generatedUsers = append(generatedUsers, model.GeneratedVoucher{
-			Username: username,
-			Password: password,
-		})
-
 		// Add user to MikroTik
 		args := []string{
 			"=name=" + username,
@@ -120,15 +115,24 @@ func (s *Service) GenerateVouchers(config model.VoucherRequest) (*model.VoucherR
 		}
 
 		sentence := append([]string{"/ip/hotspot/user/add"}, args...)
-		_, err := s.client.RunArgs(sentence)
+		reply, err := s.client.RunArgs(sentence)
 		if err != nil {
 			return &model.VoucherResponse{
 				Message: "error",
 				Data: model.VoucherResponseData{
-					Error: err.Error(),
+					Comment:  fullComment,
+					Profile:  config.Profile,
+					Error:    err.Error(),
+					Vouchers: generatedUsers,
 				},
 			}, err
 		}
+
+		generatedUsers = append(generatedUsers, model.GeneratedVoucher{
+			ID:       reply.Done.Map["ret"],
+			Username: username,
+			Password: password,
+		})
 	}
 
 	// Get created users by comment
@@ -148,10 +152,11 @@ func (s *Service) GenerateVouchers(config model.VoucherRequest) (*model.VoucherR
 		return &model.VoucherResponse{
 			Message: "success",
 			Data: model.VoucherResponseData{
-				Count:   len(users),
-				Comment: fullComment,
-				Profile: config.Profile,
-				Users:   users,
+				Count:    len(users),
+				Comment:  fullComment,
+				Profile:  config.Profile,
+				Users:    users,
+				Vouchers: generatedUsers,
 			},
 		}, nil
 	}
@@ -159,9 +164,10 @@ func (s *Service) GenerateVouchers(config model.VoucherRequest) (*model.VoucherR
 	return &model.VoucherResponse{
 		Message: "success",
 		Data: model.VoucherResponseData{
-			Count:   config.Qty,
-			Comment: fullComment,
-			Profile: config.Profile,
+			Count:    config.Qty,
+			Comment:  fullComment,
+			Profile:  config.Profile,
+			Vouchers: generatedUsers,
 		},
 	}, nil
 }
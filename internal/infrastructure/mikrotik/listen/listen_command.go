@@ -2,6 +2,8 @@ package listen
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -49,12 +51,13 @@ type ListenerConfig struct {
    LISTENER
 ========================= */
 
-// listenCommand menerima command sebagai string atau []string
+// listenCommand menerima command sebagai string atau []string dan berjalan sampai
+// listen berhenti; error dikembalikan bila listen gagal atau koneksi terputus
 func listenCommand(
 	ctx context.Context,
 	client *mikrotik.Client,
 	cfg ListenerConfig,
-) {
+) error {
 	// Konversi command ke []string
 	var cmdArgs []string
 	var cmdKey string
@@ -66,7 +69,7 @@ func listenCommand(
 	case []string:
 		if len(v) == 0 {
 			pkg_logger.Error("empty command array")
-			return
+			return errors.New("empty command array")
 		}
 		cmdArgs = v
 		cmdKey = v[0] // gunakan command pertama sebagai key
@@ -74,7 +77,7 @@ func listenCommand(
 		pkg_logger.Error("invalid command type",
 			zap.Any("type", v),
 		)
-		return
+		return fmt.Errorf("invalid command type %T", v)
 	}
 
 	// Pastikan state untuk command ini ada
@@ -95,7 +98,7 @@ func listenCommand(
 			zap.String("cmd", cmdKey),
 			zap.Error(err),
 		)
-		return
+		return err
 	}
 
 	pkg_logger.Info("listening started",
@@ -168,6 +171,7 @@ func listenCommand(
 	pkg_logger.Info("listening stopped",
 		zap.String("cmd", cmdKey),
 	)
+	return reply.Err()
 }
 
 /* =========================
@@ -206,6 +210,19 @@ func StartListenerWithConfig(
 	go listenCommand(ctx, client, cfg)
 }
 
+// Listen - seperti StartListenerWithConfig tetapi memblokir sampai listen berhenti,
+// untuk pemanggil yang perlu berlangganan ulang saat koneksi putus
+func Listen(
+	ctx context.Context,
+	client *mikrotik.Client,
+	cfg ListenerConfig,
+) error {
+	if cfg.QueueLen == 0 {
+		cfg.QueueLen = 100
+	}
+	return listenCommand(ctx, client, cfg)
+}
+
 /* =========================
    CONTOH PENGGUNAAN
 ========================= */
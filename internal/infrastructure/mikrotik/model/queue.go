@@ -0,0 +1,79 @@
+package model
+
+// ========== SIMPLE QUEUE ==========
+
+// SimpleQueueRequest adalah request untuk membuat simple queue. Nilai berpasangan
+// ditulis upload/download, mis. MaxLimit 5M/10M.
+type SimpleQueueRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Target         string `json:"target" binding:"required"` // IP, subnet atau interface
+	Parent         string `json:"parent,omitempty"`
+	MaxLimit       string `json:"maxLimit,omitempty"`
+	LimitAt        string `json:"limitAt,omitempty"`
+	BurstLimit     string `json:"burstLimit,omitempty"`
+	BurstThreshold string `json:"burstThreshold,omitempty"`
+	BurstTime      string `json:"burstTime,omitempty"` // mis. 8s/8s
+	Priority       string `json:"priority,omitempty"`  // mis. 8/8
+	Queue          string `json:"queue,omitempty"`     // queue type, mis. default-small/default-small
+	PacketMarks    string `json:"packetMarks,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+	Disabled       bool   `json:"disabled,omitempty"`
+}
+
+// SimpleQueueUpdateRequest untuk update simple queue
+type SimpleQueueUpdateRequest struct {
+	Name           string `json:"name,omitempty"`
+	Target         string `json:"target,omitempty"`
+	Parent         string `json:"parent,omitempty"`
+	MaxLimit       string `json:"maxLimit,omitempty"`
+	LimitAt        string `json:"limitAt,omitempty"`
+	BurstLimit     string `json:"burstLimit,omitempty"`
+	BurstThreshold string `json:"burstThreshold,omitempty"`
+	BurstTime      string `json:"burstTime,omitempty"`
+	Priority       string `json:"priority,omitempty"`
+	Queue          string `json:"queue,omitempty"`
+	PacketMarks    string `json:"packetMarks,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+	Disabled       *bool  `json:"disabled,omitempty"`
+}
+
+// ========== QUEUE TREE ==========
+
+// QueueTreeRequest adalah request untuk membuat queue tree. Berbeda dengan simple
+// queue, setiap limit hanya satu arah.
+type QueueTreeRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Parent         string `json:"parent" binding:"required"` // global, interface atau queue lain
+	PacketMark     string `json:"packetMark,omitempty"`
+	MaxLimit       string `json:"maxLimit,omitempty"`
+	LimitAt        string `json:"limitAt,omitempty"`
+	BurstLimit     string `json:"burstLimit,omitempty"`
+	BurstThreshold string `json:"burstThreshold,omitempty"`
+	BurstTime      string `json:"burstTime,omitempty"`
+	Priority       string `json:"priority,omitempty"`
+	Queue          string `json:"queue,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+	Disabled       bool   `json:"disabled,omitempty"`
+}
+
+// QueueTreeUpdateRequest untuk update queue tree
+type QueueTreeUpdateRequest struct {
+	Name           string `json:"name,omitempty"`
+	Parent         string `json:"parent,omitempty"`
+	PacketMark     string `json:"packetMark,omitempty"`
+	MaxLimit       string `json:"maxLimit,omitempty"`
+	LimitAt        string `json:"limitAt,omitempty"`
+	BurstLimit     string `json:"burstLimit,omitempty"`
+	BurstThreshold string `json:"burstThreshold,omitempty"`
+	BurstTime      string `json:"burstTime,omitempty"`
+	Priority       string `json:"priority,omitempty"`
+	Queue          string `json:"queue,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+	Disabled       *bool  `json:"disabled,omitempty"`
+}
+
+// QueueResponse adalah response dari operasi queue
+type QueueResponse struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
@@ -52,10 +52,13 @@ type VoucherResponseData struct {
 	Profile string       `json:"profile,omitempty"`
 	Users   []UserData   `json:"users,omitempty"`
 	Error   string       `json:"error,omitempty"`
+	// Vouchers added to the router, in order; on error only those added before it
+	Vouchers []GeneratedVoucher `json:"vouchers,omitempty"`
 }
 
 // GeneratedVoucher adalah data voucher yang di-generate
 type GeneratedVoucher struct {
+	ID       string `json:".id,omitempty"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
\ No newline at end of file
@@ -0,0 +1,16 @@
+package queue
+
+// appendArg menambahkan =key=value bila value tidak kosong
+func appendArg(args []string, key, value string) []string {
+	if value == "" {
+		return args
+	}
+	return append(args, "="+key+"="+value)
+}
+
+func boolToYesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
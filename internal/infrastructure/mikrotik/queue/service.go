@@ -0,0 +1,15 @@
+package queue
+
+import "mikrobill/internal/infrastructure/mikrotik"
+
+// Service adalah service untuk operasi MikroTik Queue (simple queue dan queue tree)
+type Service struct {
+	client *mikrotik.Client
+}
+
+// NewService membuat instance baru dari Queue Service
+func NewService(client *mikrotik.Client) *Service {
+	return &Service{
+		client: client,
+	}
+}
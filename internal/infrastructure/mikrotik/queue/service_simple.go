@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"fmt"
+	"mikrobill/internal/infrastructure/mikrotik/model"
+)
+
+// ========== SIMPLE QUEUE MANAGEMENT ==========
+
+// AddSimpleQueue membuat simple queue baru
+func (s *Service) AddSimpleQueue(config model.SimpleQueueRequest) (*model.QueueResponse, error) {
+	args := []string{
+		"=name=" + config.Name,
+		"=target=" + config.Target,
+		"=disabled=" + boolToYesNo(config.Disabled),
+	}
+	args = appendArg(args, "parent", config.Parent)
+	args = appendArg(args, "max-limit", config.MaxLimit)
+	args = appendArg(args, "limit-at", config.LimitAt)
+	args = appendArg(args, "burst-limit", config.BurstLimit)
+	args = appendArg(args, "burst-threshold", config.BurstThreshold)
+	args = appendArg(args, "burst-time", config.BurstTime)
+	args = appendArg(args, "priority", config.Priority)
+	args = appendArg(args, "queue", config.Queue)
+	args = appendArg(args, "packet-marks", config.PacketMarks)
+	args = appendArg(args, "comment", config.Comment)
+
+	sentence := append([]string{"/queue/simple/add"}, args...)
+	reply, err := s.client.RunArgs(sentence)
+	if err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data: model.SimpleSuccessData{
+			Created: true,
+			ID:      reply.Done.Map["ret"],
+			Name:    config.Name,
+		},
+	}, nil
+}
+
+// GetSimpleQueue mendapatkan detail simple queue berdasarkan nama
+func (s *Service) GetSimpleQueue(name string) (*model.QueueResponse, error) {
+	reply, err := s.client.Run("/queue/simple/print", "?name="+name)
+	if err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	if len(reply.Re) == 0 {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: "Simple queue not found"},
+		}, fmt.Errorf("simple queue not found")
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    reply.Re[0].Map,
+	}, nil
+}
+
+// GetAllSimpleQueues mendapatkan semua simple queue
+func (s *Service) GetAllSimpleQueues() (*model.QueueResponse, error) {
+	reply, err := s.client.Run("/queue/simple/print")
+	if err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	queues := make([]map[string]string, len(reply.Re))
+	for i, re := range reply.Re {
+		queues[i] = re.Map
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    queues,
+	}, nil
+}
+
+// UpdateSimpleQueue mengupdate simple queue
+func (s *Service) UpdateSimpleQueue(queueID string, updates model.SimpleQueueUpdateRequest) (*model.QueueResponse, error) {
+	args := []string{"=.id=" + queueID}
+	args = appendArg(args, "name", updates.Name)
+	args = appendArg(args, "target", updates.Target)
+	args = appendArg(args, "parent", updates.Parent)
+	args = appendArg(args, "max-limit", updates.MaxLimit)
+	args = appendArg(args, "limit-at", updates.LimitAt)
+	args = appendArg(args, "burst-limit", updates.BurstLimit)
+	args = appendArg(args, "burst-threshold", updates.BurstThreshold)
+	args = appendArg(args, "burst-time", updates.BurstTime)
+	args = appendArg(args, "priority", updates.Priority)
+	args = appendArg(args, "queue", updates.Queue)
+	args = appendArg(args, "packet-marks", updates.PacketMarks)
+	args = appendArg(args, "comment", updates.Comment)
+	if updates.Disabled != nil {
+		args = append(args, "=disabled="+boolToYesNo(*updates.Disabled))
+	}
+
+	sentence := append([]string{"/queue/simple/set"}, args...)
+	if _, err := s.client.RunArgs(sentence); err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    model.SimpleSuccessData{Updated: true},
+	}, nil
+}
+
+// DeleteSimpleQueue menghapus simple queue
+func (s *Service) DeleteSimpleQueue(queueID string) (*model.QueueResponse, error) {
+	if _, err := s.client.Run("/queue/simple/remove", "=.id="+queueID); err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    model.SimpleSuccessData{Deleted: true},
+	}, nil
+}
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"mikrobill/internal/infrastructure/mikrotik/model"
+)
+
+// ========== QUEUE TREE MANAGEMENT ==========
+
+// AddQueueTree membuat queue tree baru
+func (s *Service) AddQueueTree(config model.QueueTreeRequest) (*model.QueueResponse, error) {
+	args := []string{
+		"=name=" + config.Name,
+		"=parent=" + config.Parent,
+		"=disabled=" + boolToYesNo(config.Disabled),
+	}
+	args = appendArg(args, "packet-mark", config.PacketMark)
+	args = appendArg(args, "max-limit", config.MaxLimit)
+	args = appendArg(args, "limit-at", config.LimitAt)
+	args = appendArg(args, "burst-limit", config.BurstLimit)
+	args = appendArg(args, "burst-threshold", config.BurstThreshold)
+	args = appendArg(args, "burst-time", config.BurstTime)
+	args = appendArg(args, "priority", config.Priority)
+	args = appendArg(args, "queue", config.Queue)
+	args = appendArg(args, "comment", config.Comment)
+
+	sentence := append([]string{"/queue/tree/add"}, args...)
+	reply, err := s.client.RunArgs(sentence)
+	if err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data: model.SimpleSuccessData{
+			Created: true,
+			ID:      reply.Done.Map["ret"],
+			Name:    config.Name,
+		},
+	}, nil
+}
+
+// GetAllQueueTrees mendapatkan semua queue tree
+func (s *Service) GetAllQueueTrees() (*model.QueueResponse, error) {
+	reply, err := s.client.Run("/queue/tree/print")
+	if err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	trees := make([]map[string]string, len(reply.Re))
+	for i, re := range reply.Re {
+		trees[i] = re.Map
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    trees,
+	}, nil
+}
+
+// UpdateQueueTree mengupdate queue tree
+func (s *Service) UpdateQueueTree(queueID string, updates model.QueueTreeUpdateRequest) (*model.QueueResponse, error) {
+	args := []string{"=.id=" + queueID}
+	args = appendArg(args, "name", updates.Name)
+	args = appendArg(args, "parent", updates.Parent)
+	args = appendArg(args, "packet-mark", updates.PacketMark)
+	args = appendArg(args, "max-limit", updates.MaxLimit)
+	args = appendArg(args, "limit-at", updates.LimitAt)
+	args = appendArg(args, "burst-limit", updates.BurstLimit)
+	args = appendArg(args, "burst-threshold", updates.BurstThreshold)
+	args = appendArg(args, "burst-time", updates.BurstTime)
+	args = appendArg(args, "priority", updates.Priority)
+	args = appendArg(args, "queue", updates.Queue)
+	args = appendArg(args, "comment", updates.Comment)
+	if updates.Disabled != nil {
+		args = append(args, "=disabled="+boolToYesNo(*updates.Disabled))
+	}
+
+	sentence := append([]string{"/queue/tree/set"}, args...)
+	if _, err := s.client.RunArgs(sentence); err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    model.SimpleSuccessData{Updated: true},
+	}, nil
+}
+
+// DeleteQueueTree menghapus queue tree
+func (s *Service) DeleteQueueTree(queueID string) (*model.QueueResponse, error) {
+	if _, err := s.client.Run("/queue/tree/remove", "=.id="+queueID); err != nil {
+		return &model.QueueResponse{
+			Message: "error",
+			Data:    model.ErrorData{Error: err.Error()},
+		}, err
+	}
+
+	return &model.QueueResponse{
+		Message: "success",
+		Data:    model.SimpleSuccessData{Deleted: true},
+	}, nil
+}
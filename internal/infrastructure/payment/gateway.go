@@ -0,0 +1,100 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Status is the normalized state of a gateway transaction (mirrors the payment_status enum)
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+	StatusExpired Status = "expired"
+)
+
+// ChargeRequest describes an online payment to be created at the gateway
+type ChargeRequest struct {
+	OrderID       string
+	Amount        float64
+	Description   string
+	CustomerName  string
+	CustomerEmail string
+	CustomerPhone string
+	Expiry        time.Duration
+}
+
+// ChargeResponse holds what the customer needs to complete the payment
+type ChargeResponse struct {
+	Token      string
+	PaymentURL string
+	ExpiresAt  *time.Time
+}
+
+// Notification is a gateway's report on the state of a transaction, either pushed
+// to the callback endpoint or pulled with GetStatus
+type Notification struct {
+	OrderID       string
+	TransactionID string
+	Status        Status
+	Amount        float64
+	PaymentType   string
+	FraudStatus   string
+	PaidAt        *time.Time
+	Raw           []byte
+}
+
+// PaymentGateway is implemented by every online payment provider
+type PaymentGateway interface {
+	// Name is the identifier used in routes and stored on transactions
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*ChargeResponse, error)
+	// ParseNotification verifies the callback signature and decodes the payload.
+	// It returns utils.ErrInvalidSignature when the signature does not match.
+	ParseNotification(header http.Header, body []byte) (*Notification, error)
+	GetStatus(ctx context.Context, orderID string) (*Notification, error)
+}
+
+// Registry looks up the configured gateways by name
+type Registry struct {
+	gateways map[string]PaymentGateway
+}
+
+// NewRegistry creates a registry holding the given gateways
+func NewRegistry(gateways ...PaymentGateway) *Registry {
+	r := &Registry{gateways: make(map[string]PaymentGateway)}
+	for _, g := range gateways {
+		r.Register(g)
+	}
+	return r
+}
+
+// Register adds a gateway, replacing any with the same name
+func (r *Registry) Register(g PaymentGateway) {
+	r.gateways[g.Name()] = g
+}
+
+// Get returns the gateway with the given name
+func (r *Registry) Get(name string) (PaymentGateway, error) {
+	g, ok := r.gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrGatewayNotFound, name)
+	}
+	return g, nil
+}
+
+// Names returns the names of all registered gateways
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
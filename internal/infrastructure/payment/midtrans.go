@@ -0,0 +1,288 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMidtransAPIURL  = "https://api.sandbox.midtrans.com"
+	defaultMidtransSnapURL = "https://app.sandbox.midtrans.com"
+)
+
+// MidtransConfig configures the Midtrans gateway. APIURL and SnapURL may point to
+// the same stand-in server in tests.
+type MidtransConfig struct {
+	ServerKey string
+	APIURL    string
+	SnapURL   string
+	Timeout   time.Duration
+}
+
+// Midtrans implements PaymentGateway on top of the Midtrans Snap and Core APIs
+type Midtrans struct {
+	serverKey  string
+	apiURL     string
+	snapURL    string
+	httpClient *http.Client
+}
+
+// NewMidtrans creates a Midtrans gateway
+func NewMidtrans(cfg MidtransConfig) *Midtrans {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultMidtransAPIURL
+	}
+	if cfg.SnapURL == "" {
+		cfg.SnapURL = defaultMidtransSnapURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+
+	return &Midtrans{
+		serverKey: cfg.ServerKey,
+		apiURL:    strings.TrimRight(cfg.APIURL, "/"),
+		snapURL:   strings.TrimRight(cfg.SnapURL, "/"),
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+func (m *Midtrans) Name() string { return "midtrans" }
+
+type midtransSnapRequest struct {
+	TransactionDetails struct {
+		OrderID     string `json:"order_id"`
+		GrossAmount int64  `json:"gross_amount"`
+	} `json:"transaction_details"`
+	CustomerDetails *midtransCustomer `json:"customer_details,omitempty"`
+	ItemDetails     []midtransItem    `json:"item_details,omitempty"`
+	Expiry          *midtransExpiry   `json:"expiry,omitempty"`
+}
+
+type midtransCustomer struct {
+	FirstName string `json:"first_name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+type midtransItem struct {
+	ID       string `json:"id"`
+	Price    int64  `json:"price"`
+	Quantity int    `json:"quantity"`
+	Name     string `json:"name"`
+}
+
+type midtransExpiry struct {
+	Unit     string `json:"unit"`
+	Duration int64  `json:"duration"`
+}
+
+type midtransSnapResponse struct {
+	Token         string   `json:"token"`
+	RedirectURL   string   `json:"redirect_url"`
+	ErrorMessages []string `json:"error_messages"`
+}
+
+// midtransNotification is both the HTTP notification body and the status API response
+type midtransNotification struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
+	SettlementTime    string `json:"settlement_time"`
+	TransactionTime   string `json:"transaction_time"`
+	StatusMessage     string `json:"status_message"`
+}
+
+// CreateCharge creates a Snap transaction and returns its token and redirect URL
+func (m *Midtrans) CreateCharge(ctx context.Context, req ChargeRequest) (*ChargeResponse, error) {
+	// IDR has no minor unit; Snap rejects fractional amounts
+	amount := int64(math.Round(req.Amount))
+
+	var body midtransSnapRequest
+	body.TransactionDetails.OrderID = req.OrderID
+	body.TransactionDetails.GrossAmount = amount
+	if req.CustomerName != "" || req.CustomerEmail != "" || req.CustomerPhone != "" {
+		body.CustomerDetails = &midtransCustomer{
+			FirstName: req.CustomerName,
+			Email:     req.CustomerEmail,
+			Phone:     req.CustomerPhone,
+		}
+	}
+	if req.Description != "" {
+		body.ItemDetails = []midtransItem{{ID: req.OrderID, Price: amount, Quantity: 1, Name: truncate(req.Description, 50)}}
+	}
+	if req.Expiry > 0 {
+		body.Expiry = &midtransExpiry{Unit: "minutes", Duration: int64(req.Expiry / time.Minute)}
+	}
+
+	var resp midtransSnapResponse
+	if err := m.doRequest(ctx, http.MethodPost, m.snapURL+"/snap/v1/transactions", body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Token == "" {
+		return nil, fmt.Errorf("midtrans: empty token in response: %s", strings.Join(resp.ErrorMessages, "; "))
+	}
+
+	result := &ChargeResponse{
+		Token:      resp.Token,
+		PaymentURL: resp.RedirectURL,
+	}
+	if req.Expiry > 0 {
+		expiresAt := time.Now().Add(req.Expiry)
+		result.ExpiresAt = &expiresAt
+	}
+	return result, nil
+}
+
+// ParseNotification verifies signature_key = SHA512(order_id + status_code + gross_amount + server_key)
+func (m *Midtrans) ParseNotification(_ http.Header, body []byte) (*Notification, error) {
+	var n midtransNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("midtrans: invalid notification body: %w", err)
+	}
+	if n.OrderID == "" {
+		return nil, fmt.Errorf("midtrans: notification without order_id")
+	}
+
+	expected := m.signature(n.OrderID, n.StatusCode, n.GrossAmount)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(n.SignatureKey))) != 1 {
+		return nil, utils.ErrInvalidSignature
+	}
+
+	return n.toNotification(body)
+}
+
+// GetStatus queries the current state of a transaction from the Core API
+func (m *Midtrans) GetStatus(ctx context.Context, orderID string) (*Notification, error) {
+	var n midtransNotification
+	endpoint := m.apiURL + "/v2/" + url.PathEscape(orderID) + "/status"
+	if err := m.doRequest(ctx, http.MethodGet, endpoint, nil, &n); err != nil {
+		return nil, err
+	}
+	// The status API answers 200 with its own status_code for unknown orders
+	if n.StatusCode == "404" {
+		return nil, fmt.Errorf("midtrans: %s", n.StatusMessage)
+	}
+
+	raw, _ := json.Marshal(n)
+	return n.toNotification(raw)
+}
+
+func (m *Midtrans) signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + m.serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *Midtrans) doRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("midtrans: marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("midtrans: create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(m.serverKey, "")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("midtrans: execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("midtrans: read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("midtrans: API error: status=%d, body=%s", resp.StatusCode, string(data))
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("midtrans: decode response: %w", err)
+	}
+	return nil
+}
+
+func (n midtransNotification) toNotification(raw []byte) (*Notification, error) {
+	amount, err := strconv.ParseFloat(n.GrossAmount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("midtrans: invalid gross_amount %q", n.GrossAmount)
+	}
+
+	result := &Notification{
+		OrderID:       n.OrderID,
+		TransactionID: n.TransactionID,
+		Status:        midtransStatus(n.TransactionStatus, n.FraudStatus),
+		Amount:        amount,
+		PaymentType:   n.PaymentType,
+		FraudStatus:   n.FraudStatus,
+		Raw:           raw,
+	}
+	if result.Status == StatusSuccess {
+		paidAt := time.Now()
+		for _, ts := range []string{n.SettlementTime, n.TransactionTime} {
+			// Midtrans timestamps are in WIB without a zone designator
+			if t, err := time.ParseInLocation("2006-01-02 15:04:05", ts, time.Local); err == nil {
+				paidAt = t
+				break
+			}
+		}
+		result.PaidAt = &paidAt
+	}
+	return result, nil
+}
+
+// midtransStatus maps Midtrans transaction_status/fraud_status to a normalized Status
+func midtransStatus(transactionStatus, fraudStatus string) Status {
+	switch transactionStatus {
+	case "settlement":
+		return StatusSuccess
+	case "capture":
+		// Card payments flagged by fraud detection stay pending until reviewed
+		if fraudStatus == "" || fraudStatus == "accept" {
+			return StatusSuccess
+		}
+		return StatusPending
+	case "deny", "cancel", "failure":
+		return StatusFailed
+	case "expire":
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -0,0 +1,166 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testServerKey = "SB-Mid-server-test"
+
+// newTestMidtrans points both Midtrans APIs at a stand-in server
+func newTestMidtrans(t *testing.T, handler http.HandlerFunc) *Midtrans {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewMidtrans(MidtransConfig{
+		ServerKey: testServerKey,
+		APIURL:    srv.URL,
+		SnapURL:   srv.URL + "/",
+		Timeout:   5 * time.Second,
+	})
+}
+
+func TestMidtransCreateCharge(t *testing.T) {
+	var got midtransSnapRequest
+	m := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/snap/v1/transactions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if user, _, ok := r.BasicAuth(); !ok || user != testServerKey {
+			t.Errorf("request not authenticated with the server key")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"tok-1","redirect_url":"https://pay.example/tok-1"}`))
+	})
+
+	resp, err := m.CreateCharge(context.Background(), ChargeRequest{
+		OrderID:      "INV-1-abc",
+		Amount:       150000.4,
+		Description:  "Internet 20 Mbps",
+		CustomerName: "Budi",
+		Expiry:       24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CreateCharge: %v", err)
+	}
+	if resp.Token != "tok-1" || resp.PaymentURL != "https://pay.example/tok-1" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if resp.ExpiresAt == nil {
+		t.Errorf("expiry not set")
+	}
+	if got.TransactionDetails.OrderID != "INV-1-abc" || got.TransactionDetails.GrossAmount != 150000 {
+		t.Errorf("unexpected transaction details %+v", got.TransactionDetails)
+	}
+	if got.Expiry == nil || got.Expiry.Unit != "minutes" || got.Expiry.Duration != 1440 {
+		t.Errorf("unexpected expiry %+v", got.Expiry)
+	}
+	if len(got.ItemDetails) != 1 || got.ItemDetails[0].Price != 150000 {
+		t.Errorf("item price must match gross amount: %+v", got.ItemDetails)
+	}
+}
+
+func TestMidtransCreateChargeError(t *testing.T) {
+	m := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error_messages":["transaction_details.order_id has already been taken"]}`))
+	})
+
+	if _, err := m.CreateCharge(context.Background(), ChargeRequest{OrderID: "INV-1", Amount: 1000}); err == nil {
+		t.Fatal("expected an error for a rejected charge")
+	}
+}
+
+func TestMidtransGetStatus(t *testing.T) {
+	m := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/INV-1/status":
+			w.Write([]byte(`{"order_id":"INV-1","transaction_id":"trx-9","status_code":"200",
+				"gross_amount":"150000.00","transaction_status":"settlement","payment_type":"qris",
+				"settlement_time":"2026-10-01 10:00:00"}`))
+		case "/v2/INV-2/status":
+			w.Write([]byte(`{"order_id":"INV-2","status_code":"200","gross_amount":"150000.00",
+				"transaction_status":"capture","fraud_status":"challenge"}`))
+		default:
+			w.Write([]byte(`{"status_code":"404","status_message":"Transaction doesn't exist."}`))
+		}
+	})
+
+	n, err := m.GetStatus(context.Background(), "INV-1")
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if n.Status != StatusSuccess || n.Amount != 150000 || n.TransactionID != "trx-9" || n.PaymentType != "qris" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	want := time.Date(2026, 10, 1, 10, 0, 0, 0, time.Local)
+	if n.PaidAt == nil || !n.PaidAt.Equal(want) {
+		t.Errorf("paid at %v, want %v", n.PaidAt, want)
+	}
+
+	n, err = m.GetStatus(context.Background(), "INV-2")
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if n.Status != StatusPending {
+		t.Errorf("challenged capture must stay pending, got %s", n.Status)
+	}
+
+	if _, err := m.GetStatus(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func TestMidtransParseNotification(t *testing.T) {
+	m := NewMidtrans(MidtransConfig{ServerKey: testServerKey})
+
+	notification := func(grossAmount, signature string) []byte {
+		body, _ := json.Marshal(map[string]string{
+			"order_id":           "INV-1",
+			"status_code":        "200",
+			"gross_amount":       grossAmount,
+			"signature_key":      signature,
+			"transaction_status": "settlement",
+			"transaction_id":     "trx-9",
+		})
+		return body
+	}
+	valid := m.signature("INV-1", "200", "150000.00")
+
+	n, err := m.ParseNotification(nil, notification("150000.00", valid))
+	if err != nil {
+		t.Fatalf("valid notification rejected: %v", err)
+	}
+	if n.OrderID != "INV-1" || n.Status != StatusSuccess || n.Amount != 150000 {
+		t.Errorf("unexpected notification %+v", n)
+	}
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"tampered amount", notification("1.00", valid)},
+		{"wrong key", notification("150000.00", NewMidtrans(MidtransConfig{ServerKey: "other"}).signature("INV-1", "200", "150000.00"))},
+		{"missing signature", notification("150000.00", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.ParseNotification(nil, tt.body); !errors.Is(err, utils.ErrInvalidSignature) {
+				t.Errorf("got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	if _, err := m.ParseNotification(nil, []byte(`{"status_code":"200"}`)); err == nil {
+		t.Error("expected an error for a notification without order_id")
+	}
+}
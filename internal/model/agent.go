@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+type CreateAgentRequest struct {
+	Username       string   `json:"username" binding:"required,min=3,max=100"`
+	Name           string   `json:"name" binding:"required"`
+	Phone          string   `json:"phone" binding:"required,max=20"`
+	Email          string   `json:"email" binding:"omitempty,email"`
+	Password       string   `json:"password" binding:"required,min=8"`
+	Address        string   `json:"address"`
+	CommissionRate *float64 `json:"commission_rate" binding:"omitempty,gte=0,lte=100"`
+}
+
+type UpdateAgentRequest struct {
+	Name           *string  `json:"name"`
+	Phone          *string  `json:"phone" binding:"omitempty,max=20"`
+	Email          *string  `json:"email" binding:"omitempty,email"`
+	Password       *string  `json:"password" binding:"omitempty,min=8"`
+	Address        *string  `json:"address"`
+	Status         *string  `json:"status" binding:"omitempty,oneof=active inactive suspended"`
+	CommissionRate *float64 `json:"commission_rate" binding:"omitempty,gte=0,lte=100"`
+}
+
+type AgentListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	Status   string `form:"status"`
+	Search   string `form:"search"`
+}
+
+type AgentLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AgentLoginResponse returned after an agent authenticates
+type AgentLoginResponse struct {
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Agent     interface{} `json:"agent"`
+}
+
+type AgentTransactionListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	Type     string `form:"type"`
+	From     string `form:"from"` // YYYY-MM-DD
+	To       string `form:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type CreateBalanceRequestRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Notes  string  `json:"notes"`
+}
+
+type ProcessBalanceRequestRequest struct {
+	AdminNotes string `json:"admin_notes"`
+}
+
+type BalanceRequestListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	AgentID  string `form:"agent_id"`
+	Status   string `form:"status"`
+}
+
+// AgentCollectPaymentRequest is a customer payment taken in the field by an agent
+type AgentCollectPaymentRequest struct {
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+	ReferenceNumber string  `json:"reference_number"`
+	Notes           string  `json:"notes"`
+}
+
+type AgentVoucherSaleRequest struct {
+	MikrotikID    string  `json:"mikrotik_id" binding:"required"`
+	VoucherCode   string  `json:"voucher_code" binding:"required"`
+	ProfileName   string  `json:"profile_name" binding:"required"`
+	Price         float64 `json:"price" binding:"required,gt=0"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerPhone string  `json:"customer_phone"`
+}
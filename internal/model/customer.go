@@ -0,0 +1,25 @@
+package model
+
+// CustomerFilterParams selects customers for the list and the export
+type CustomerFilterParams struct {
+	Search      string `form:"search"` // words matched against name, username, phone and address
+	Status      string `form:"status" binding:"omitempty,oneof=active suspended inactive pending"`
+	ServiceType string `form:"service_type" binding:"omitempty,oneof=pppoe hotspot static_ip"`
+	MikrotikID  string `form:"mikrotik_id"`
+	ProfileID   string `form:"profile_id"`
+	SyncStatus  string `form:"sync_status" binding:"omitempty,oneof=pending synced failed"`
+	Online      *bool  `form:"online"`  // has an open session
+	Overdue     *bool  `form:"overdue"` // has an unpaid invoice past its due date
+}
+
+// CustomerListRequest reads one page of the customer list, newest first unless
+// sorted otherwise. Cursor is the next_cursor of the previous page, read with the
+// same sort and order; without it Page is read by offset.
+type CustomerListRequest struct {
+	CustomerFilterParams
+	Sort   string `form:"sort" binding:"omitempty,oneof=name username created_at billing_day status"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor string `form:"cursor"`
+}
@@ -0,0 +1,84 @@
+package model
+
+// Import row results
+const (
+	ImportCreated = "created"
+	ImportMerged  = "merged"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// RouterImportRequest imports a router's PPP secrets and hotspot users as customers
+type RouterImportRequest struct {
+	// Services limits the import to pppoe or hotspot; empty imports both
+	Services []string `json:"services" binding:"omitempty,dive,oneof=pppoe hotspot"`
+	// OnDuplicate decides what happens to a login that is already a customer: skip
+	// leaves the customer alone, merge takes the router's password and profile
+	OnDuplicate string `json:"on_duplicate" binding:"omitempty,oneof=skip merge"`
+	// ImportProfiles pulls PPP profiles the database does not have from the router
+	ImportProfiles bool `json:"import_profiles"`
+	DryRun         bool `json:"dry_run"`
+}
+
+type ImportResult struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Merged  int         `json:"merged"`
+	Skipped int         `json:"skipped"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+type ImportRow struct {
+	// Line is the row's line in an imported file, counting the header as line 1
+	Line        int    `json:"line,omitempty"`
+	ServiceType string `json:"service_type"`
+	Username    string `json:"username"`
+	Profile     string `json:"profile,omitempty"`
+	Result      string `json:"result"`
+	CustomerID  string `json:"customer_id,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// FileImportRequest is the form of a spreadsheet import; the CSV or XLSX file is the
+// multipart field "file"
+type FileImportRequest struct {
+	MikrotikID string `form:"mikrotik_id" binding:"required"`
+	// Mapping is a JSON object from customer field to the file's column header;
+	// unmapped fields are read from the column named like the field
+	Mapping string `form:"mapping"`
+	// DryRun validates the rows and reports what would be created
+	DryRun bool `form:"dry_run"`
+	// Provision queues the customers' PPP secrets and static IP entries for the router
+	Provision bool `form:"provision"`
+	// Async queues the import even when the file is small
+	Async bool `form:"async"`
+}
+
+// FileImportResponse carries the result of an import run in the request, or the
+// job it was queued as
+type FileImportResponse struct {
+	Queued bool               `json:"queued"`
+	Result *ImportResult      `json:"result,omitempty"`
+	Job    *ImportJobResponse `json:"job,omitempty"`
+}
+
+type ImportJobResponse struct {
+	ID         string        `json:"id"`
+	MikrotikID string        `json:"mikrotik_id"`
+	FileName   string        `json:"file_name"`
+	Status     string        `json:"status"`
+	Provision  bool          `json:"provision"`
+	TotalRows  int           `json:"total_rows"`
+	Result     *ImportResult `json:"result,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  string        `json:"started_at,omitempty"`
+	FinishedAt string        `json:"finished_at,omitempty"`
+	CreatedAt  string        `json:"created_at"`
+}
+
+type CustomerExportRequest struct {
+	CustomerFilterParams
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
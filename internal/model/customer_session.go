@@ -0,0 +1,32 @@
+package model
+
+type CustomerSessionResponse struct {
+	ID               string `json:"id"`
+	MikrotikID       string `json:"mikrotik_id"`
+	ServiceType      string `json:"service_type"`
+	Username         string `json:"username"`
+	IPAddress        string `json:"ip_address,omitempty"`
+	CallerID         string `json:"caller_id,omitempty"`
+	Interface        string `json:"interface,omitempty"`
+	StartedAt        string `json:"started_at"`
+	EndedAt          string `json:"ended_at,omitempty"` // empty while the session is open
+	DurationSeconds  int64  `json:"duration_seconds"`
+	DisconnectReason string `json:"disconnect_reason,omitempty"`
+	BytesIn          *int64 `json:"bytes_in"`
+	BytesOut         *int64 `json:"bytes_out"`
+}
+
+// CustomerSessionStats summarizes a customer's sessions over the last Days days
+type CustomerSessionStats struct {
+	CustomerID            string  `json:"customer_id"`
+	Days                  int     `json:"days"`
+	Online                bool    `json:"online"`
+	Sessions              int64   `json:"sessions"`
+	UptimeSeconds         int64   `json:"uptime_seconds"`
+	UptimePercent         float64 `json:"uptime_percent"`
+	AverageSessionSeconds int64   `json:"average_session_seconds"`
+	LongestSessionSeconds int64   `json:"longest_session_seconds"`
+	BytesIn               int64   `json:"bytes_in"`
+	BytesOut              int64   `json:"bytes_out"`
+	LastStartedAt         string  `json:"last_started_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// FUPResult summarizes one fair usage enforcement run
+type FUPResult struct {
+	Checked   int      `json:"checked"`
+	Throttled int      `json:"throttled"`
+	Restored  int      `json:"restored"`
+	Notified  int      `json:"notified"`
+	Skipped   int      `json:"skipped"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// FUPStatus is a customer's usage against the quota of their profile in the
+// current billing cycle
+type FUPStatus struct {
+	CustomerID  string  `json:"customer_id"`
+	Enabled     bool    `json:"enabled"`
+	QuotaBytes  int64   `json:"quota_bytes"`
+	UsedBytes   int64   `json:"used_bytes"`
+	Percent     float64 `json:"percent"`
+	Throttled   bool    `json:"throttled"`
+	ThrottledAt string  `json:"throttled_at,omitempty"`
+	CycleStart  string  `json:"cycle_start"`
+	CycleEnd    string  `json:"cycle_end"`
+}
+
+// FUPEvent is published on the mikrotik:events channel when a customer reaches a
+// usage threshold or is restored at the start of a billing cycle
+type FUPEvent struct {
+	Type       string `json:"type"` // fup_warning, fup_exceeded, fup_restored
+	CustomerID string `json:"customer_id"`
+	Name       string `json:"name"`
+	Percent    int    `json:"percent"`
+	UsedBytes  int64  `json:"used_bytes"`
+	QuotaBytes int64  `json:"quota_bytes"`
+	Throttled  bool   `json:"throttled"`
+	Timestamp  string `json:"timestamp"`
+}
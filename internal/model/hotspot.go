@@ -0,0 +1,92 @@
+package model
+
+// HotspotListRequest filters a router list; filters that do not apply to a list are ignored
+type HotspotListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	Search   string `form:"search"` // name, user, address, mac-address or comment containing it
+	Profile  string `form:"profile"`
+	Server   string `form:"server"`
+	Disabled *bool  `form:"disabled"`
+}
+
+type HotspotUserRequest struct {
+	Name       string `json:"name" binding:"required,max=100"`
+	Password   string `json:"password"`
+	Profile    string `json:"profile" binding:"required"`
+	Server     string `json:"server"`
+	MacAddress string `json:"mac_address" binding:"omitempty,mac"`
+	TimeLimit  string `json:"time_limit"` // e.g. 3h
+	DataLimit  string `json:"data_limit"` // e.g. 500M, 1G
+	Comment    string `json:"comment"`
+	Disabled   bool   `json:"disabled"`
+}
+
+type UpdateHotspotUserRequest struct {
+	Name       string `json:"name" binding:"max=100"`
+	Password   string `json:"password"`
+	Profile    string `json:"profile"`
+	MacAddress string `json:"mac_address" binding:"omitempty,mac"`
+	TimeLimit  string `json:"time_limit"`
+	DataLimit  string `json:"data_limit"`
+	Comment    string `json:"comment"`
+	Disabled   *bool  `json:"disabled"`
+}
+
+type HotspotProfileRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	SharedUsers  *int   `json:"shared_users" binding:"omitempty,min=1,max=100"`
+	RateLimit    string `json:"rate_limit"` // e.g. 1M/2M
+	ExpireMode   string `json:"expire_mode" binding:"omitempty,oneof=0 ntf ntfc rem remc"`
+	Validity     string `json:"validity"`
+	Price        string `json:"price" binding:"omitempty,numeric"`
+	SellingPrice string `json:"selling_price" binding:"omitempty,numeric"`
+	AddressPool  string `json:"address_pool"`
+	LockUser     string `json:"lock_user" binding:"omitempty,oneof=Enable Disable"`
+	LockServer   string `json:"lock_server" binding:"omitempty,oneof=Enable Disable"`
+	ParentQueue  string `json:"parent_queue"`
+}
+
+type UpdateHotspotProfileRequest struct {
+	SharedUsers *int   `json:"shared_users" binding:"omitempty,min=1,max=100"`
+	RateLimit   string `json:"rate_limit"`
+	ExpireMode  string `json:"expire_mode" binding:"omitempty,oneof=0 ntf ntfc rem remc"`
+	Validity    string `json:"validity"`
+	Price       string `json:"price" binding:"omitempty,numeric"`
+	AddressPool string `json:"address_pool"`
+	LockUser    string `json:"lock_user" binding:"omitempty,oneof=Enable Disable"`
+	LockServer  string `json:"lock_server" binding:"omitempty,oneof=Enable Disable"`
+	ParentQueue string `json:"parent_queue"`
+}
+
+// HotspotReportRequest selects a day or a month of sales recorded by profile on-login scripts
+type HotspotReportRequest struct {
+	Date  string `form:"date"`  // YYYY-MM-DD
+	Month string `form:"month"` // YYYY-MM
+}
+
+type HotspotSale struct {
+	Date       string  `json:"date"`
+	Time       string  `json:"time"`
+	Username   string  `json:"username"`
+	Price      float64 `json:"price"`
+	Address    string  `json:"address"`
+	MacAddress string  `json:"mac_address"`
+	Validity   string  `json:"validity"`
+	Profile    string  `json:"profile"`
+	Comment    string  `json:"comment"`
+}
+
+type HotspotProfileSales struct {
+	Profile string  `json:"profile"`
+	Count   int     `json:"count"`
+	Total   float64 `json:"total"`
+}
+
+type HotspotReport struct {
+	Period    string                `json:"period"`
+	Count     int                   `json:"count"`
+	Total     float64               `json:"total"`
+	ByProfile []HotspotProfileSales `json:"by_profile"`
+	Sales     []HotspotSale         `json:"sales"`
+}
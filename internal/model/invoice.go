@@ -0,0 +1,44 @@
+package model
+
+type CreateInvoiceRequest struct {
+	CustomerID  string   `json:"customer_id" binding:"required"`
+	InvoiceType string   `json:"invoice_type" binding:"omitempty,oneof=monthly voucher installation other"`
+	Amount      *float64 `json:"amount"`
+	Description string   `json:"description"`
+	PeriodStart string   `json:"period_start"` // YYYY-MM-DD
+	DueDate     string   `json:"due_date"`     // YYYY-MM-DD, defaults to issue date + invoice_due_days
+	Notes       string   `json:"notes"`
+}
+
+type UpdateInvoiceRequest struct {
+	Amount      *float64 `json:"amount"`
+	Description *string  `json:"description"`
+	DueDate     string   `json:"due_date"`
+	Status      string   `json:"status" binding:"omitempty,oneof=overdue cancelled"` // paid and partially_paid follow from payments
+	Notes       *string  `json:"notes"`
+}
+
+type InvoiceListRequest struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"limit"`
+	Search     string `form:"search"`
+	MikrotikID string `form:"mikrotik_id"`
+	CustomerID string `form:"customer_id"`
+	Status     string `form:"status"`
+	Type       string `form:"type"`
+	DueFrom    string `form:"due_from"`
+	DueTo      string `form:"due_to"`
+}
+
+type GenerateInvoicesRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD, defaults to today
+}
+
+type InvoiceGenerationResult struct {
+	Date      string   `json:"date"`
+	Processed int      `json:"processed"`
+	Created   int      `json:"created"`
+	Skipped   int      `json:"skipped"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
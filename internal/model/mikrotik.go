@@ -2,8 +2,8 @@ package model
 
 type CreateMikrotikRequest struct {
 	Name        string `json:"name" binding:"required"`
-	Host        string `json:"host" binding:"required"`
-	Port        int    `json:"port" binding:"required"`
+	Host        string `json:"host" binding:"required,hostname_rfc1123|ip"`
+	Port        int    `json:"port" binding:"required,min=1,max=65535"`
 	APIUsername string `json:"api_username" binding:"required"`
 	APIPassword string `json:"api_password" binding:"required"`
 	Keepalive   bool   `json:"keepalive"`
@@ -11,12 +11,18 @@ type CreateMikrotikRequest struct {
 	Location    string `json:"location"`
 	Description string `json:"description"`
 	IsActive    bool   `json:"is_active"`
+
+	// Billing suspension strategy (defaults: disable, isolir, 256k/256k, isolir)
+	SuspensionMode       string `json:"suspension_mode" binding:"omitempty,oneof=disable isolate"`
+	IsolationProfile     string `json:"isolation_profile"`
+	IsolationRateLimit   string `json:"isolation_rate_limit"`
+	IsolationAddressList string `json:"isolation_address_list"`
 }
 
 type UpdateMikrotikRequest struct {
 	Name        string `json:"name"`
-	Host        string `json:"host"`
-	Port        int    `json:"port"`
+	Host        string `json:"host" binding:"omitempty,hostname_rfc1123|ip"`
+	Port        int    `json:"port" binding:"omitempty,min=1,max=65535"`
 	APIUsername string `json:"api_username"`
 	APIPassword string `json:"api_password"`
 	Keepalive   *bool  `json:"keepalive"`
@@ -24,6 +30,11 @@ type UpdateMikrotikRequest struct {
 	Location    string `json:"location"`
 	Description string `json:"description"`
 	IsActive    *bool  `json:"is_active"`
+
+	SuspensionMode       string `json:"suspension_mode" binding:"omitempty,oneof=disable isolate"`
+	IsolationProfile     string `json:"isolation_profile"`
+	IsolationRateLimit   string `json:"isolation_rate_limit"`
+	IsolationAddressList string `json:"isolation_address_list"`
 }
 
 // Mikrotik Active Management
@@ -42,20 +53,51 @@ type MikrotikResponse struct {
 	Location    string `json:"location"`
 	Description string `json:"description"`
 	IsActive    bool   `json:"is_active"`
+	IsDefault   bool   `json:"is_default"`
 	Status      string `json:"status"`
 	Version     string `json:"version"`
 	Uptime      string `json:"uptime"`
 	LastSync    string `json:"last_sync"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
+
+	// Last reading of the health poller, empty until the router answered once
+	CPULoad         *int   `json:"cpu_load"`
+	FreeMemory      *int64 `json:"free_memory"`
+	TotalMemory     *int64 `json:"total_memory"`
+	BoardName       string `json:"board_name"`
+	SerialNumber    string `json:"serial_number"`
+	HealthCheckedAt string `json:"health_checked_at"`
+
+	SuspensionMode       string `json:"suspension_mode"`
+	IsolationProfile     string `json:"isolation_profile"`
+	IsolationRateLimit   string `json:"isolation_rate_limit"`
+	IsolationAddressList string `json:"isolation_address_list"`
 }
 
 type MikrotikStatusResponse struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	IsActive bool   `json:"is_active"`
-	Status   string `json:"status"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	IsActive  bool   `json:"is_active"`
+	IsDefault bool   `json:"is_default"`
+	Status    string `json:"status"`
+}
+
+type MikrotikStatusChangeResponse struct {
+	ID         string `json:"id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// MikrotikHealthPollResult summarizes one round of the router health poller
+type MikrotikHealthPollResult struct {
+	Checked int      `json:"checked"`
+	Online  int      `json:"online"`
+	Offline int      `json:"offline"`
+	Errors  []string `json:"errors,omitempty"`
 }
 
 type PaginationRequest struct {
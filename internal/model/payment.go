@@ -0,0 +1,66 @@
+package model
+
+type CreatePaymentRequest struct {
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+	PaymentMethod   string  `json:"payment_method" binding:"omitempty,oneof=cash transfer other"`
+	PaymentDate     string  `json:"payment_date"` // RFC3339 or YYYY-MM-DD, defaults to now
+	ReferenceNumber string  `json:"reference_number"`
+	Notes           string  `json:"notes"`
+}
+
+type PaymentListRequest struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"limit"`
+	InvoiceID  string `form:"invoice_id"`
+	CustomerID string `form:"customer_id"`
+	Method     string `form:"method"`
+	From       string `form:"from"` // YYYY-MM-DD
+	To         string `form:"to"`   // YYYY-MM-DD, inclusive
+}
+
+// PaymentReceipt is the printable proof of a single payment
+type PaymentReceipt struct {
+	ReceiptNumber   string  `json:"receipt_number"`
+	PaymentDate     string  `json:"payment_date"`
+	Amount          float64 `json:"amount"`
+	PaymentMethod   string  `json:"payment_method"`
+	ReferenceNumber string  `json:"reference_number,omitempty"`
+	Notes           string  `json:"notes,omitempty"`
+
+	InvoiceNumber string  `json:"invoice_number"`
+	InvoiceAmount float64 `json:"invoice_amount"`
+	PaidAmount    float64 `json:"paid_amount"`
+	Outstanding   float64 `json:"outstanding"`
+	InvoiceStatus string  `json:"invoice_status"`
+	Period        string  `json:"period,omitempty"`
+
+	CustomerName    string `json:"customer_name"`
+	CustomerPhone   string `json:"customer_phone,omitempty"`
+	CustomerAddress string `json:"customer_address,omitempty"`
+
+	CompanyName    string `json:"company_name"`
+	CompanyAddress string `json:"company_address,omitempty"`
+	CompanyPhone   string `json:"company_phone,omitempty"`
+	CompanyEmail   string `json:"company_email,omitempty"`
+	LogoURL        string `json:"logo_url,omitempty"`
+}
+
+// PaymentEvent is published on the mikrotik:events channel when a payment is recorded
+type PaymentEvent struct {
+	Type          string  `json:"type"`
+	PaymentID     string  `json:"payment_id"`
+	ReceiptNumber string  `json:"receipt_number"`
+	InvoiceID     string  `json:"invoice_id"`
+	InvoiceNumber string  `json:"invoice_number"`
+	CustomerID    string  `json:"customer_id"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+	PaidAmount    float64 `json:"paid_amount"`
+	Outstanding   float64 `json:"outstanding"`
+	InvoiceStatus string  `json:"invoice_status"`
+	Timestamp     string  `json:"timestamp"`
+}
+
+type CreateGatewayChargeRequest struct {
+	Gateway string `json:"gateway" binding:"required"`
+}
@@ -0,0 +1,45 @@
+package model
+
+// PPPListRequest filters PPP secrets and active sessions read from a router
+type PPPListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	Search   string `form:"search"` // name, address, caller-id or comment containing it
+	Service  string `form:"service" binding:"omitempty,oneof=any pppoe pptp l2tp ovpn sstp"`
+	Profile  string `form:"profile"`
+	Disabled *bool  `form:"disabled"`
+	Unlinked bool   `form:"unlinked"` // secrets only: those no customer uses
+}
+
+// PPPServiceStats counts one PPP service on a router
+type PPPServiceStats struct {
+	Service         string `json:"service"`
+	Active          int    `json:"active"`
+	Secrets         int    `json:"secrets"`
+	DisabledSecrets int    `json:"disabled_secrets"`
+}
+
+type PPPStats struct {
+	MikrotikID      string            `json:"mikrotik_id"`
+	Active          int               `json:"active"`
+	Secrets         int               `json:"secrets"`
+	UnlinkedSecrets int               `json:"unlinked_secrets"`
+	ByService       []PPPServiceStats `json:"by_service"`
+}
+
+type PPPDisconnectResult struct {
+	Username     string `json:"username"`
+	Disconnected int    `json:"disconnected"`
+}
+
+// SessionEvent is published on the mikrotik:events channel as type pppoe_event when a
+// customer's PPP or hotspot session starts or ends
+type SessionEvent struct {
+	Type       string `json:"type"`
+	Status     string `json:"status"` // connected, disconnected
+	CustomerID string `json:"customer_id"`
+	Name       string `json:"name"`
+	IP         string `json:"ip,omitempty"`
+	Interface  string `json:"interface,omitempty"`
+	Service    string `json:"service"` // pppoe, hotspot
+}
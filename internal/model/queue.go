@@ -0,0 +1,74 @@
+package model
+
+// QueueListRequest filters the simple queues or queue trees read from a router
+type QueueListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"limit"`
+	Search   string `form:"search"` // name, target, packet mark or comment containing it
+	Parent   string `form:"parent"`
+	Disabled *bool  `form:"disabled"`
+}
+
+// SimpleQueueRequest creates a simple queue; paired values are upload/download, e.g. 5M/10M
+type SimpleQueueRequest struct {
+	Name           string   `json:"name" binding:"required,max=100"`
+	Target         string   `json:"target" binding:"required"` // address, subnet or interface
+	Parent         string   `json:"parent"`
+	MaxLimit       string   `json:"max_limit"`
+	LimitAt        string   `json:"limit_at"`
+	BurstLimit     string   `json:"burst_limit"`
+	BurstThreshold string   `json:"burst_threshold"`
+	BurstTime      string   `json:"burst_time"` // e.g. 8s/8s
+	Priority       string   `json:"priority"`   // e.g. 8/8
+	Queue          string   `json:"queue"`      // queue types, e.g. default-small/default-small
+	PacketMarks    []string `json:"packet_marks"`
+	Comment        string   `json:"comment"`
+	Disabled       bool     `json:"disabled"`
+}
+
+type UpdateSimpleQueueRequest struct {
+	Name           string   `json:"name" binding:"max=100"`
+	Target         string   `json:"target"`
+	Parent         string   `json:"parent"`
+	MaxLimit       string   `json:"max_limit"`
+	LimitAt        string   `json:"limit_at"`
+	BurstLimit     string   `json:"burst_limit"`
+	BurstThreshold string   `json:"burst_threshold"`
+	BurstTime      string   `json:"burst_time"`
+	Priority       string   `json:"priority"`
+	Queue          string   `json:"queue"`
+	PacketMarks    []string `json:"packet_marks"`
+	Comment        string   `json:"comment"`
+	Disabled       *bool    `json:"disabled"`
+}
+
+// QueueTreeRequest creates a queue tree entry; limits are one direction, e.g. 10M
+type QueueTreeRequest struct {
+	Name           string `json:"name" binding:"required,max=100"`
+	Parent         string `json:"parent" binding:"required"` // global, an interface or another queue
+	PacketMark     string `json:"packet_mark"`
+	MaxLimit       string `json:"max_limit"`
+	LimitAt        string `json:"limit_at"`
+	BurstLimit     string `json:"burst_limit"`
+	BurstThreshold string `json:"burst_threshold"`
+	BurstTime      string `json:"burst_time"`
+	Priority       string `json:"priority" binding:"omitempty,numeric"`
+	Queue          string `json:"queue"`
+	Comment        string `json:"comment"`
+	Disabled       bool   `json:"disabled"`
+}
+
+type UpdateQueueTreeRequest struct {
+	Name           string `json:"name" binding:"max=100"`
+	Parent         string `json:"parent"`
+	PacketMark     string `json:"packet_mark"`
+	MaxLimit       string `json:"max_limit"`
+	LimitAt        string `json:"limit_at"`
+	BurstLimit     string `json:"burst_limit"`
+	BurstThreshold string `json:"burst_threshold"`
+	BurstTime      string `json:"burst_time"`
+	Priority       string `json:"priority" binding:"omitempty,numeric"`
+	Queue          string `json:"queue"`
+	Comment        string `json:"comment"`
+	Disabled       *bool  `json:"disabled"`
+}
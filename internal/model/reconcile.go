@@ -0,0 +1,63 @@
+package model
+
+// Reconcile directions: push writes the database state to the router, adopt takes
+// the router state into the database
+const (
+	ReconcilePush  = "push"
+	ReconcileAdopt = "adopt"
+)
+
+// Drift item kinds and types
+const (
+	DriftKindPPPSecret   = "ppp_secret"
+	DriftKindPPPProfile  = "ppp_profile"
+	DriftKindHotspotUser = "hotspot_user"
+
+	DriftMissingOnRouter  = "missing_on_router"
+	DriftOrphanedOnRouter = "orphaned_on_router"
+	DriftMismatch         = "mismatch"
+)
+
+type ReconcileRequest struct {
+	Direction string `json:"direction" form:"direction" binding:"omitempty,oneof=push adopt"`
+	// DryRun reports the actions without changing anything
+	DryRun bool `json:"dry_run" form:"dry_run"`
+	// RemoveOrphans lets push delete router entries the database does not know
+	RemoveOrphans bool `json:"remove_orphans" form:"remove_orphans"`
+}
+
+type DriftReport struct {
+	MikrotikID string       `json:"mikrotik_id"`
+	CheckedAt  string       `json:"checked_at"`
+	Direction  string       `json:"direction"`
+	DryRun     bool         `json:"dry_run"`
+	Summary    DriftSummary `json:"summary"`
+	Items      []DriftItem  `json:"items"`
+}
+
+type DriftSummary struct {
+	Missing    int `json:"missing"`
+	Orphaned   int `json:"orphaned"`
+	Mismatched int `json:"mismatched"`
+	Applied    int `json:"applied"`
+	Failed     int `json:"failed"`
+}
+
+type DriftItem struct {
+	Kind       string      `json:"kind"`
+	Type       string      `json:"type"`
+	Name       string      `json:"name"`
+	CustomerID string      `json:"customer_id,omitempty"`
+	ProfileID  string      `json:"profile_id,omitempty"`
+	Fields     []FieldDiff `json:"fields,omitempty"`
+	// Action is what reconciling does with the item, "none" when it is only reported
+	Action  string `json:"action"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Database string `json:"database"`
+	Router   string `json:"router"`
+}
@@ -0,0 +1,26 @@
+package model
+
+type SuspendCustomerRequest struct {
+	Reason string `json:"reason"`
+}
+
+type SuspensionResult struct {
+	Date          string   `json:"date"`
+	MarkedOverdue int64    `json:"marked_overdue"`
+	Suspended     int      `json:"suspended"`
+	Reactivated   int      `json:"reactivated"`
+	Skipped       int      `json:"skipped"`
+	Failed        int      `json:"failed"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+// CustomerStatusEvent is published on the mikrotik:events channel when billing
+// suspends or reactivates a customer
+type CustomerStatusEvent struct {
+	Type       string `json:"type"`
+	CustomerID string `json:"customer_id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	Timestamp  string `json:"timestamp"`
+}
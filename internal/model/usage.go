@@ -0,0 +1,36 @@
+package model
+
+// UsageRequest selects a customer's usage; dates are YYYY-MM-DD and both ends are
+// included. Defaults to the current month by day.
+type UsageRequest struct {
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Granularity string `form:"granularity" binding:"omitempty,oneof=hour day month"`
+}
+
+type UsagePoint struct {
+	PeriodStart string `json:"period_start"`
+	BytesUp     int64  `json:"bytes_up"`
+	BytesDown   int64  `json:"bytes_down"`
+	BytesTotal  int64  `json:"bytes_total"`
+}
+
+type UsageResponse struct {
+	CustomerID  string       `json:"customer_id"`
+	Granularity string       `json:"granularity"`
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	BytesUp     int64        `json:"bytes_up"`
+	BytesDown   int64        `json:"bytes_down"`
+	BytesTotal  int64        `json:"bytes_total"`
+	Points      []UsagePoint `json:"points"`
+}
+
+// UsageCollectResult summarizes one round of the usage collector
+type UsageCollectResult struct {
+	Routers   int      `json:"routers"`
+	Customers int      `json:"customers"`
+	BytesUp   int64    `json:"bytes_up"`
+	BytesDown int64    `json:"bytes_down"`
+	Errors    []string `json:"errors,omitempty"`
+}
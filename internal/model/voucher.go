@@ -0,0 +1,141 @@
+package model
+
+import (
+	"html/template"
+	"time"
+)
+
+type GenerateVoucherBatchRequest struct {
+	BatchName  string  `json:"batch_name" binding:"required,max=100"`
+	Qty        int     `json:"qty" binding:"required,min=1,max=500"`
+	Server     string  `json:"server"`
+	UserType   string  `json:"user_type" binding:"required,oneof=up vc"`
+	UserLength int     `json:"user_length" binding:"required,min=3,max=20"`
+	Prefix     string  `json:"prefix" binding:"max=20"`
+	CharType   string  `json:"char_type" binding:"required,oneof=lower upper upplow mix mix1 mix2 num lower1 upper1 upplow1"`
+	Profile    string  `json:"profile" binding:"required"`
+	Price      float64 `json:"price" binding:"gte=0"`
+	Validity   string  `json:"validity"`   // e.g. 1d, printed on the voucher
+	TimeLimit  string  `json:"time_limit"` // router limit-uptime, e.g. 3h
+	DataLimit  string  `json:"data_limit"` // e.g. 500M, 1G
+}
+
+// GenerateVoucherBatchResult reports a generation; Created is less than Requested
+// when the router failed part way, in which case Error says why.
+type GenerateVoucherBatchResult struct {
+	Requested int         `json:"requested"`
+	Created   int         `json:"created"`
+	Error     string      `json:"error,omitempty"`
+	Batch     interface{} `json:"batch"`
+}
+
+type VoucherListRequest struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"limit"`
+	MikrotikID string `form:"mikrotik_id"`
+	BatchID    string `form:"batch_id"`
+	State      string `form:"state" binding:"omitempty,oneof=unsold sold used expired"`
+	AgentID    string `form:"agent_id"`
+	Search     string `form:"search"`
+}
+
+// VoucherReconcileResult summarizes one comparison of stored vouchers with router users
+type VoucherReconcileResult struct {
+	MikrotikID string   `json:"mikrotik_id"`
+	Checked    int      `json:"checked"`
+	Used       int      `json:"used"`
+	Expired    int      `json:"expired"`
+	Missing    int      `json:"missing"` // removed from the router before being used
+	Untracked  []string `json:"untracked,omitempty"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+type CreateVoucherTemplateRequest struct {
+	TemplateName string `json:"template_name" binding:"required,max=100"`
+	TemplateType string `json:"template_type" binding:"required,oneof=html pdf"`
+	Description  string `json:"description"`
+	Content      string `json:"content" binding:"required"`
+	Columns      int    `json:"columns" binding:"omitempty,min=1,max=8"`
+	RowsPerPage  int    `json:"rows_per_page" binding:"omitempty,min=1,max=20"`
+	ShowQR       bool   `json:"show_qr"`
+	IsDefault    bool   `json:"is_default"`
+}
+
+type UpdateVoucherTemplateRequest struct {
+	TemplateName *string `json:"template_name" binding:"omitempty,max=100"`
+	Description  *string `json:"description"`
+	Content      *string `json:"content"`
+	Columns      *int    `json:"columns" binding:"omitempty,min=1,max=8"`
+	RowsPerPage  *int    `json:"rows_per_page" binding:"omitempty,min=1,max=20"`
+	ShowQR       *bool   `json:"show_qr"`
+	IsDefault    *bool   `json:"is_default"`
+	IsActive     *bool   `json:"is_active"`
+}
+
+// VoucherSheetRequest selects how a batch is printed; zero values fall back to the template
+type VoucherSheetRequest struct {
+	Format      string `form:"format" binding:"omitempty,oneof=html pdf"`
+	TemplateID  string `form:"template_id"`
+	State       string `form:"state" binding:"omitempty,oneof=unsold sold used expired"`
+	Columns     int    `form:"columns" binding:"omitempty,min=1,max=8"`
+	RowsPerPage int    `form:"rows" binding:"omitempty,min=1,max=20"`
+	QR          *bool  `form:"qr"`
+	DNSName     string `form:"dns_name"` // overrides the router's hotspot DNS name
+}
+
+// VoucherSheet is a rendered sheet ready to be sent to the client
+type VoucherSheet struct {
+	ContentType string
+	FileName    string
+	Body        []byte
+}
+
+// VoucherSheetData is the data HTML voucher templates are executed with
+type VoucherSheetData struct {
+	Company     VoucherSheetCompany
+	Batch       VoucherSheetBatch
+	DNSName     string
+	Columns     int
+	RowsPerPage int
+	PrintedAt   time.Time
+	Vouchers    []VoucherSheetItem
+}
+
+// VoucherCardData is the data PDF voucher templates are executed with, once per card
+type VoucherCardData struct {
+	Company VoucherSheetCompany
+	Batch   VoucherSheetBatch
+	DNSName string
+	Voucher VoucherSheetItem
+}
+
+type VoucherSheetCompany struct {
+	Name           string
+	Phone          string
+	Website        string
+	LogoURL        string
+	PrimaryColor   string
+	SecondaryColor string
+}
+
+type VoucherSheetBatch struct {
+	ID          string
+	BatchName   string
+	ProfileName string
+	Server      string
+}
+
+type VoucherSheetItem struct {
+	Number    int
+	Username  string
+	Password  string
+	IsCode    bool // username and password are the same, printed once
+	Profile   string
+	Price     string
+	Validity  string
+	TimeLimit string
+	DataLimit string
+	LoginURL  string
+	QRCode    template.URL // PNG data URI of LoginURL, empty when QR codes are off
+}
@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mikrobill/internal/entity"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AgentFilter holds the optional filters for listing agents
+type AgentFilter struct {
+	Status   string
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// AgentTransactionFilter holds the optional filters for listing ledger entries
+type AgentTransactionFilter struct {
+	AgentID  string
+	Type     string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// AgentBalanceRequestFilter holds the optional filters for listing top-up requests
+type AgentBalanceRequestFilter struct {
+	AgentID  string
+	Status   string
+	Page     int
+	PageSize int
+}
+
+type AgentRepository interface {
+	// Create inserts the agent together with its zero balance row
+	Create(ctx context.Context, agent *entity.Agent) error
+	GetByID(ctx context.Context, id string) (*entity.Agent, error)
+	GetByUsername(ctx context.Context, username string) (*entity.Agent, error)
+	List(ctx context.Context, filter AgentFilter) ([]entity.Agent, int64, error)
+	Update(ctx context.Context, agent *entity.Agent) error
+	UpdateLastLogin(ctx context.Context, id string, at time.Time) error
+	ExistsByUsernameOrPhone(ctx context.Context, username, phone, excludeID string) (bool, error)
+
+	// PostEntries appends ledger entries and moves the balance in one transaction.
+	// Entries are applied in order; none is written if any would take the balance below zero.
+	PostEntries(ctx context.Context, agentID string, entries ...*entity.AgentTransaction) error
+	ListTransactions(ctx context.Context, filter AgentTransactionFilter) ([]entity.AgentTransaction, int64, error)
+
+	CreateBalanceRequest(ctx context.Context, req *entity.AgentBalanceRequest) error
+	GetBalanceRequest(ctx context.Context, id string) (*entity.AgentBalanceRequest, error)
+	ListBalanceRequests(ctx context.Context, filter AgentBalanceRequestFilter) ([]entity.AgentBalanceRequest, int64, error)
+	// ProcessBalanceRequest approves or rejects a pending request. On approval the deposit
+	// entry is posted in the same transaction, so a request is credited at most once.
+	ProcessBalanceRequest(ctx context.Context, id string, approve bool, adminNotes *string, processedBy *int64) (*entity.AgentBalanceRequest, error)
+
+	// CollectPayment records a customer payment taken by an agent and posts the agent's
+	// ledger entries atomically. entries are built from the payment once it has an ID.
+	CollectPayment(ctx context.Context, agentID string, payment *entity.Payment, validate func(inv *entity.Invoice) error, entries func(payment *entity.Payment) []*entity.AgentTransaction) (*entity.Invoice, error)
+	// RecordVoucherSale stores a voucher sale and posts its ledger entries atomically.
+	// A voucher generated by mikrobill is marked sold and can only be sold once.
+	RecordVoucherSale(ctx context.Context, sale *entity.AgentVoucherSale, entries func(sale *entity.AgentVoucherSale) []*entity.AgentTransaction) error
+	ListVoucherSales(ctx context.Context, agentID string, page, pageSize int) ([]entity.AgentVoucherSale, int64, error)
+}
+
+type agentRepository struct {
+	db *gorm.DB
+}
+
+func NewAgentRepository(db *gorm.DB) AgentRepository {
+	return &agentRepository{db: db}
+}
+
+func (r *agentRepository) Create(ctx context.Context, agent *entity.Agent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(agent).Error; err != nil {
+			return err
+		}
+		agent.Balance = &entity.AgentBalance{AgentID: agent.ID}
+		return tx.Create(agent.Balance).Error
+	})
+}
+
+func (r *agentRepository) GetByID(ctx context.Context, id string) (*entity.Agent, error) {
+	var agent entity.Agent
+	err := r.db.WithContext(ctx).Preload("Balance").First(&agent, "id = ?", id).Error
+	return &agent, err
+}
+
+func (r *agentRepository) GetByUsername(ctx context.Context, username string) (*entity.Agent, error) {
+	var agent entity.Agent
+	err := r.db.WithContext(ctx).Preload("Balance").First(&agent, "username = ?", username).Error
+	return &agent, err
+}
+
+func (r *agentRepository) List(ctx context.Context, filter AgentFilter) ([]entity.Agent, int64, error) {
+	var agents []entity.Agent
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.Agent{})
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Search != "" {
+		like := "%" + filter.Search + "%"
+		query = query.Where("username ILIKE ? OR name ILIKE ? OR phone ILIKE ?", like, like, like)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Preload("Balance").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("name ASC").
+		Find(&agents).Error
+
+	return agents, total, err
+}
+
+func (r *agentRepository) Update(ctx context.Context, agent *entity.Agent) error {
+	return r.db.WithContext(ctx).Model(&entity.Agent{}).
+		Where("id = ?", agent.ID).
+		Select("name", "phone", "email", "password_hash", "address", "status", "commission_rate").
+		Updates(agent).Error
+}
+
+func (r *agentRepository) UpdateLastLogin(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Agent{}).
+		Where("id = ?", id).
+		UpdateColumn("last_login", at).Error
+}
+
+func (r *agentRepository) ExistsByUsernameOrPhone(ctx context.Context, username, phone, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&entity.Agent{}).
+		Where("username = ? OR phone = ?", username, phone)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *agentRepository) PostEntries(ctx context.Context, agentID string, entries ...*entity.AgentTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return postAgentEntries(tx, agentID, entries)
+	})
+}
+
+// postAgentEntries locks the agent's balance row, writes each entry with its running
+// balance and stores the final total. It is shared by every operation that touches the ledger.
+func postAgentEntries(tx *gorm.DB, agentID string, entries []*entity.AgentTransaction) error {
+	var bal entity.AgentBalance
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bal, "agent_id = ?", agentID).Error; err != nil {
+		return err
+	}
+
+	balance := bal.Balance
+	for _, e := range entries {
+		e.AgentID = agentID
+		e.BalanceBefore = balance
+		balance = math.Round((balance+e.Amount)*100) / 100
+		if balance < 0 {
+			return fmt.Errorf("%w: balance %.2f, required %.2f", utils.ErrInsufficientBalance, bal.Balance, -e.Amount)
+		}
+		e.BalanceAfter = balance
+		if e.Status == "" {
+			e.Status = "completed"
+		}
+		if err := tx.Create(e).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&entity.AgentBalance{}).
+		Where("agent_id = ?", agentID).
+		Updates(map[string]interface{}{"balance": balance, "updated_at": time.Now()}).Error
+}
+
+// reverseAgentEntries posts the opposite of every ledger entry attached to a reference.
+// Debits are undone first, so the refund funds any commission being clawed back.
+func reverseAgentEntries(tx *gorm.DB, agentID, referenceType, referenceID, description string) error {
+	var originals []entity.AgentTransaction
+	if err := tx.Where("agent_id = ? AND reference_type = ? AND reference_id = ?", agentID, referenceType, referenceID).
+		Order("amount ASC").
+		Find(&originals).Error; err != nil {
+		return err
+	}
+
+	entries := make([]*entity.AgentTransaction, 0, len(originals))
+	for _, o := range originals {
+		entries = append(entries, &entity.AgentTransaction{
+			TransactionType: o.TransactionType,
+			Amount:          -o.Amount,
+			Description:     &description,
+			ReferenceType:   o.ReferenceType,
+			ReferenceID:     o.ReferenceID,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return postAgentEntries(tx, agentID, entries)
+}
+
+func (r *agentRepository) ListTransactions(ctx context.Context, filter AgentTransactionFilter) ([]entity.AgentTransaction, int64, error) {
+	var txns []entity.AgentTransaction
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.AgentTransaction{})
+
+	if filter.AgentID != "" {
+		query = query.Where("agent_id = ?", filter.AgentID)
+	}
+	if filter.Type != "" {
+		query = query.Where("transaction_type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("created_at DESC").
+		Find(&txns).Error
+
+	return txns, total, err
+}
+
+func (r *agentRepository) CreateBalanceRequest(ctx context.Context, req *entity.AgentBalanceRequest) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(req).Error
+}
+
+func (r *agentRepository) GetBalanceRequest(ctx context.Context, id string) (*entity.AgentBalanceRequest, error) {
+	var req entity.AgentBalanceRequest
+	err := r.db.WithContext(ctx).Preload("Agent").First(&req, "id = ?", id).Error
+	return &req, err
+}
+
+func (r *agentRepository) ListBalanceRequests(ctx context.Context, filter AgentBalanceRequestFilter) ([]entity.AgentBalanceRequest, int64, error) {
+	var reqs []entity.AgentBalanceRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.AgentBalanceRequest{})
+
+	if filter.AgentID != "" {
+		query = query.Where("agent_id = ?", filter.AgentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Preload("Agent").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("requested_at DESC").
+		Find(&reqs).Error
+
+	return reqs, total, err
+}
+
+func (r *agentRepository) ProcessBalanceRequest(ctx context.Context, id string, approve bool, adminNotes *string, processedBy *int64) (*entity.AgentBalanceRequest, error) {
+	var req entity.AgentBalanceRequest
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if req.Status != entity.AgentRequestPending {
+			return fmt.Errorf("%w: %s", utils.ErrBalanceRequestProcessed, req.Status)
+		}
+
+		now := time.Now()
+		req.Status = entity.AgentRequestRejected
+		req.AdminNotes = adminNotes
+		req.ProcessedAt = &now
+		req.ProcessedBy = processedBy
+
+		if approve {
+			refType := "balance_request"
+			description := "Top-up approved"
+			deposit := &entity.AgentTransaction{
+				TransactionType: entity.AgentTransactionDeposit,
+				Amount:          req.Amount,
+				Description:     &description,
+				ReferenceType:   &refType,
+				ReferenceID:     &req.ID,
+				CreatedBy:       processedBy,
+			}
+			if err := postAgentEntries(tx, req.AgentID, []*entity.AgentTransaction{deposit}); err != nil {
+				return err
+			}
+			req.Status = entity.AgentRequestApproved
+			req.TransactionID = &deposit.ID
+		}
+
+		return tx.Model(&entity.AgentBalanceRequest{}).
+			Where("id = ?", req.ID).
+			Select("status", "admin_notes", "processed_at", "processed_by", "transaction_id").
+			Updates(&req).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *agentRepository) CollectPayment(ctx context.Context, agentID string, payment *entity.Payment, validate func(inv *entity.Invoice) error, entries func(payment *entity.Payment) []*entity.AgentTransaction) (*entity.Invoice, error) {
+	var inv entity.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		payment.AgentID = &agentID
+		if err := recordPayment(tx, payment, validate, &inv); err != nil {
+			return err
+		}
+		return postAgentEntries(tx, agentID, entries(payment))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *agentRepository) RecordVoucherSale(ctx context.Context, sale *entity.AgentVoucherSale, entries func(sale *entity.AgentVoucherSale) []*entity.AgentTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		found, sold, err := markVoucherSold(tx, sale.MikrotikID, sale.VoucherCode, &sale.AgentID, sale.SoldAt)
+		if err != nil {
+			return err
+		}
+		if found && !sold {
+			return utils.ErrVoucherNotAvailable
+		}
+		if err := tx.Create(sale).Error; err != nil {
+			return err
+		}
+		return postAgentEntries(tx, sale.AgentID, entries(sale))
+	})
+}
+
+func (r *agentRepository) ListVoucherSales(ctx context.Context, agentID string, page, pageSize int) ([]entity.AgentVoucherSale, int64, error) {
+	var sales []entity.AgentVoucherSale
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.AgentVoucherSale{}).Where("agent_id = ?", agentID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Order("sold_at DESC").
+		Find(&sales).Error
+
+	return sales, total, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CustomerImportRepository interface {
+	Create(ctx context.Context, job *entity.CustomerImportJob) error
+	GetByID(ctx context.Context, id string) (*entity.CustomerImportJob, error)
+	// Start marks a pending job running; false when another worker took it already
+	Start(ctx context.Context, id string) (bool, error)
+	// Finish stores the job's outcome and drops its rows
+	Finish(ctx context.Context, id, status string, result json.RawMessage, errMsg *string) error
+}
+
+type customerImportRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerImportRepository(db *gorm.DB) CustomerImportRepository {
+	return &customerImportRepository{db: db}
+}
+
+func (r *customerImportRepository) Create(ctx context.Context, job *entity.CustomerImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *customerImportRepository) GetByID(ctx context.Context, id string) (*entity.CustomerImportJob, error) {
+	var job entity.CustomerImportJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *customerImportRepository) Start(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.CustomerImportJob{}).
+		Where("id = ? AND status = ?", id, entity.ImportJobPending).
+		Updates(map[string]interface{}{"status": entity.ImportJobRunning, "started_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *customerImportRepository) Finish(ctx context.Context, id, status string, result json.RawMessage, errMsg *string) error {
+	return r.db.WithContext(ctx).Model(&entity.CustomerImportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"result":      result,
+			"error":       errMsg,
+			"rows":        gorm.Expr("NULL"),
+			"finished_at": time.Now(),
+		}).Error
+}
@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
 	"mikrobill/internal/entity"
+	"mikrobill/pkg/utils"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"gorm.io/gorm"
 )
@@ -137,7 +144,7 @@ func (r *DatabaseCustomerRepository) CreateCustomer(c *entity.Customer) error {
 	c.UpdatedAt = time.Now()
 	
 	log.Printf("[CustomerRepo] CreateCustomer - Customer details - ServiceType: %s, Status: %s, PPPoE Username: %s\n", 
-		c.ServiceType, c.Status, c.PPPoEUsername)
+		c.ServiceType, c.Status, derefString(c.PPPoEUsername))
 	
 	err := r.db.Create(c).Error
 	if err != nil {
@@ -156,7 +163,7 @@ func (r *DatabaseCustomerRepository) UpdateCustomer(c *entity.Customer) error {
 	c.UpdatedAt = time.Now()
 	
 	log.Printf("[CustomerRepo] UpdateCustomer - Customer details - ServiceType: %s, Status: %s, PPPoE Username: %s\n", 
-		c.ServiceType, c.Status, c.PPPoEUsername)
+		c.ServiceType, c.Status, derefString(c.PPPoEUsername))
 	
 	result := r.db.Model(&entity.Customer{}).
 		Where("id = ?", c.ID).
@@ -191,27 +198,407 @@ func (r *DatabaseCustomerRepository) DeleteCustomer(id string) error {
 	return nil
 }
 
-// ListCustomers returns paginated customers
-func (r *DatabaseCustomerRepository) ListCustomers(page, limit int) ([]*entity.Customer, int, error) {
+// ListCustomers returns a page of the filtered customer list. Pages after a
+// cursor are read by keyset on the sort column and id, so a page costs the same
+// however deep it is and rows added meanwhile do not shift the pages that follow.
+// Pages without one are read by offset, as clients paging by number expect.
+func (r *DatabaseCustomerRepository) ListCustomers(query entity.CustomerListQuery) (*entity.CustomerPage, error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = entity.CustomerSortCreatedAt
+	}
+	if !slices.Contains(customerSortColumns, sort) {
+		return nil, fmt.Errorf("cannot sort customers by %s", sort)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	page := &entity.CustomerPage{Limit: limit}
+	err := r.db.Model(&entity.Customer{}).Scopes(filterCustomers(query.Filter)).Count(&page.Total).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] ListCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to count customers: %w", err)
+	}
+
+	order := "ASC"
+	if query.Desc {
+		order = "DESC"
+	}
+	db := r.db.Scopes(filterCustomers(query.Filter)).
+		Order(sort + " " + order).
+		Order("id " + order)
+	if query.Cursor != "" {
+		after, err := decodeCustomerCursor(query.Cursor, sort, query.Desc)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if query.Desc {
+			op = "<"
+		}
+		db = db.Where("("+sort+", id) "+op+" (?, ?)", after.value, after.ID)
+	} else {
+		page.Page = query.Page
+		if page.Page < 1 {
+			page.Page = 1
+		}
+		db = db.Offset((page.Page - 1) * limit)
+	}
+
+	// One row past the page tells whether another page follows
+	if err := db.Limit(limit + 1).Find(&page.Customers).Error; err != nil {
+		log.Printf("[CustomerRepo] ListCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	if len(page.Customers) > limit {
+		page.Customers = page.Customers[:limit]
+		page.NextCursor = encodeCustomerCursor(page.Customers[limit-1], sort, query.Desc)
+	}
+	return page, nil
+}
+
+// customerSortColumns are the columns of entity's CustomerSort constants
+var customerSortColumns = []string{
+	entity.CustomerSortName,
+	entity.CustomerSortUsername,
+	entity.CustomerSortCreatedAt,
+	entity.CustomerSortBillingDay,
+	entity.CustomerSortStatus,
+}
+
+// customerSearchVector is the expression of the idx_customers_search index; the
+// query must repeat it exactly for the index to be used
+const customerSearchVector = `to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(username, '') || ' ' ||
+	coalesce(phone, '') || ' ' || coalesce(address, '') || ' ' ||
+	coalesce(pppoe_username, '') || ' ' || coalesce(hotspot_username, ''))`
+
+// filterCustomers applies a customer filter to a query on the customers table
+func filterCustomers(filter entity.CustomerFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.MikrotikID != "" {
+			db = db.Where("mikrotik_id = ?", filter.MikrotikID)
+		}
+		if filter.ServiceType != "" {
+			db = db.Where("service_type = ?", filter.ServiceType)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.SyncStatus != "" {
+			db = db.Where("sync_status = ?", filter.SyncStatus)
+		}
+		if filter.ProfileID != "" {
+			db = db.Where("? IN (pppoe_profile_id, hotspot_profile_id, static_ip_profile_id)", filter.ProfileID)
+		}
+		if filter.Online != nil {
+			online := "EXISTS (SELECT 1 FROM customer_sessions s WHERE s.customer_id = customers.id AND s.ended_at IS NULL)"
+			if !*filter.Online {
+				online = "NOT " + online
+			}
+			db = db.Where(online)
+		}
+		if filter.Overdue != nil {
+			overdue := "EXISTS (SELECT 1 FROM invoices i WHERE i.customer_id = customers.id AND i.status IN ? AND i.due_date < CURRENT_DATE)"
+			if !*filter.Overdue {
+				overdue = "NOT " + overdue
+			}
+			db = db.Where(overdue, openInvoiceStatuses)
+		}
+		tsquery, numbers := searchTerms(filter.Search)
+		if tsquery != "" {
+			db = db.Where(customerSearchVector+" @@ to_tsquery('simple', ?)", tsquery)
+		}
+		// Numbers also match anywhere in the phone number, which the index only
+		// matches from the start
+		for _, n := range numbers {
+			db = db.Where("("+customerSearchVector+" @@ to_tsquery('simple', ?) OR phone ILIKE ?)", n+":*", "%"+n+"%")
+		}
+		return db
+	}
+}
+
+// searchTerms splits free text into a tsquery matching rows that have a word
+// starting with each of the text's words, so "budi 0812" finds budi01 on 081234,
+// and the words made only of digits, which are searched apart
+func searchTerms(text string) (string, []string) {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var prefixes, numbers []string
+	for _, w := range words {
+		if strings.IndexFunc(w, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			numbers = append(numbers, w)
+			continue
+		}
+		prefixes = append(prefixes, strings.ToLower(w)+":*")
+	}
+	return strings.Join(prefixes, " & "), numbers
+}
+
+// customerCursor is the position after the last row of a page, tied to the sort
+// the page was read with
+type customerCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+
+	value interface{}
+}
+
+func encodeCustomerCursor(c *entity.Customer, sort string, desc bool) string {
+	cursor := customerCursor{Sort: sort, Desc: desc, ID: c.ID}
+	switch sort {
+	case entity.CustomerSortUsername:
+		cursor.Value = c.Username
+	case entity.CustomerSortCreatedAt:
+		cursor.Value = c.CreatedAt.Format(time.RFC3339Nano)
+	case entity.CustomerSortBillingDay:
+		cursor.Value = strconv.Itoa(c.BillingDay)
+	case entity.CustomerSortStatus:
+		cursor.Value = c.Status
+	default:
+		cursor.Value = c.Name
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCustomerCursor(s, sort string, desc bool) (*customerCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, utils.ErrInvalidCursor
+	}
+	var cursor customerCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, utils.ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Desc != desc {
+		return nil, utils.ErrInvalidCursor
+	}
+
+	switch sort {
+	case entity.CustomerSortCreatedAt:
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, utils.ErrInvalidCursor
+		}
+		cursor.value = t
+	case entity.CustomerSortBillingDay:
+		day, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return nil, utils.ErrInvalidCursor
+		}
+		cursor.value = day
+	default:
+		cursor.value = cursor.Value
+	}
+	return &cursor, nil
+}
+
+// GetBillableCustomers returns customers whose billing day is one of the given days.
+// Suspended customers are included so unpaid months keep accruing; pending ones are not.
+func (r *DatabaseCustomerRepository) GetBillableCustomers(billingDays []int) ([]*entity.Customer, error) {
+	log.Printf("[CustomerRepo] GetBillableCustomers - Querying customers for billing days: %v\n", billingDays)
+
 	var customers []*entity.Customer
-	var total int64
-	
-	// Count total
-	err := r.db.Model(&entity.Customer{}).Count(&total).Error
+
+	err := r.db.Where("billing_day IN ? AND status IN ?", billingDays, []string{"active", "inactive", "suspended"}).
+		Order("name").
+		Find(&customers).Error
+
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count customers: %w", err)
+		log.Printf("[CustomerRepo] GetBillableCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query billable customers: %w", err)
 	}
-	
-	offset := (page - 1) * limit
-	
-	err = r.db.Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
+
+	log.Printf("[CustomerRepo] GetBillableCustomers - SUCCESS: Found %d customers\n", len(customers))
+	return customers, nil
+}
+
+// UpdateSuspension sets the status together with the suspension bookkeeping columns.
+// Nil suspendedAt/reason clear them, which is what reactivation wants.
+func (r *DatabaseCustomerRepository) UpdateSuspension(id string, status string, suspendedAt *time.Time, reason *string) error {
+	log.Printf("[CustomerRepo] UpdateSuspension - Updating customer %s to status: %s\n", id, status)
+
+	result := r.db.Model(&entity.Customer{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"suspended_at":      suspendedAt,
+			"suspension_reason": reason,
+			"updated_at":        time.Now(),
+		})
+
+	if result.Error != nil {
+		log.Printf("[CustomerRepo] UpdateSuspension - ERROR: %v\n", result.Error)
+		return fmt.Errorf("failed to update customer suspension: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("customer not found: %s", id)
+	}
+
+	return nil
+}
+
+// GetSuspendedCustomers retrieves all suspended customers
+func (r *DatabaseCustomerRepository) GetSuspendedCustomers() ([]*entity.Customer, error) {
+	var customers []*entity.Customer
+
+	err := r.db.Where("status = ?", "suspended").
+		Order("suspended_at").
 		Find(&customers).Error
-	
+
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query customers: %w", err)
+		log.Printf("[CustomerRepo] GetSuspendedCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query suspended customers: %w", err)
 	}
-	
-	return customers, int(total), nil
-}
\ No newline at end of file
+
+	return customers, nil
+}
+
+// UpdateIsolation records whether the customer's secret sits in the isolation profile
+// and which profile to restore once the suspension is lifted
+func (r *DatabaseCustomerRepository) UpdateIsolation(id string, isolated bool, originalProfileID *string) error {
+	log.Printf("[CustomerRepo] UpdateIsolation - Updating customer %s isolated: %t\n", id, isolated)
+
+	result := r.db.Model(&entity.Customer{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"isolated":            isolated,
+			"original_profile_id": originalProfileID,
+			"updated_at":          time.Now(),
+		})
+
+	if result.Error != nil {
+		log.Printf("[CustomerRepo] UpdateIsolation - ERROR: %v\n", result.Error)
+		return fmt.Errorf("failed to update customer isolation: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("customer not found: %s", id)
+	}
+
+	return nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// GetPPPoEUsernames maps the PPPoE usernames of a router's customers to their customer IDs
+func (r *DatabaseCustomerRepository) GetPPPoEUsernames(mikrotikID string) (map[string]string, error) {
+	var rows []struct {
+		ID            string
+		PPPoEUsername string `gorm:"column:pppoe_username"`
+	}
+
+	err := r.db.Model(&entity.Customer{}).
+		Select("id, pppoe_username").
+		Where("mikrotik_id = ? AND pppoe_username IS NOT NULL AND pppoe_username <> ''", mikrotikID).
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] GetPPPoEUsernames - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query pppoe usernames: %w", err)
+	}
+
+	usernames := make(map[string]string, len(rows))
+	for _, row := range rows {
+		usernames[row.PPPoEUsername] = row.ID
+	}
+	return usernames, nil
+}
+
+// GetRouterSessionCustomers lists a router's PPPoE and hotspot customers
+func (r *DatabaseCustomerRepository) GetRouterSessionCustomers(mikrotikID string) ([]*entity.Customer, error) {
+	var customers []*entity.Customer
+
+	err := r.db.Where("mikrotik_id = ? AND service_type IN ?", mikrotikID, []string{"pppoe", "hotspot"}).
+		Find(&customers).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] GetRouterSessionCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	return customers, nil
+}
+
+// GetCustomerBySession finds the router's customer using a PPPoE or hotspot username
+func (r *DatabaseCustomerRepository) GetCustomerBySession(mikrotikID, serviceType, username string) (*entity.Customer, error) {
+	column := "pppoe_username"
+	if serviceType == "hotspot" {
+		column = "hotspot_username"
+	}
+
+	var customer entity.Customer
+	err := r.db.Where("mikrotik_id = ? AND service_type = ? AND "+column+" = ?", mikrotikID, serviceType, username).
+		First(&customer).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[CustomerRepo] GetCustomerBySession - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customer: %w", err)
+	}
+	return &customer, nil
+}
+
+// GetTakenValues returns which of the values a router's customers already use in
+// column, one of the columns unique per router
+func (r *DatabaseCustomerRepository) GetTakenValues(mikrotikID, column string, values []string) (map[string]bool, error) {
+	switch column {
+	case "username", "phone", "pppoe_username", "hotspot_username", "static_ip":
+	default:
+		return nil, fmt.Errorf("column %s is not unique per router", column)
+	}
+
+	taken := make(map[string]bool)
+	if len(values) == 0 {
+		return taken, nil
+	}
+
+	var found []string
+	err := r.db.Model(&entity.Customer{}).
+		Where("mikrotik_id = ? AND "+column+" IN ?", mikrotikID, values).
+		Pluck(column, &found).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] GetTakenValues - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	for _, v := range found {
+		taken[v] = true
+	}
+	return taken, nil
+}
+
+// ExportCustomers passes the customers matching filter to fn in batches of size,
+// ordered by name, so large lists are never held in memory at once
+func (r *DatabaseCustomerRepository) ExportCustomers(filter entity.CustomerFilter, size int, fn func([]*entity.Customer) error) error {
+	query := r.db.Model(&entity.Customer{}).Scopes(filterCustomers(filter))
+
+	var customers []*entity.Customer
+	offset := 0
+	for {
+		customers = customers[:0]
+		err := query.Session(&gorm.Session{}).Order("name, id").Limit(size).Offset(offset).Find(&customers).Error
+		if err != nil {
+			log.Printf("[CustomerRepo] ExportCustomers - ERROR: %v\n", err)
+			return fmt.Errorf("failed to query customers: %w", err)
+		}
+		if len(customers) == 0 {
+			return nil
+		}
+		if err := fn(customers); err != nil {
+			return err
+		}
+		if len(customers) < size {
+			return nil
+		}
+		offset += size
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"mikrobill/internal/entity"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomerSessionRepository interface {
+	// Open records a session start. The customer's open session with the same router
+	// session id, or one opened without an id, is updated instead, so the session
+	// listener and the callbacks can both report the same login.
+	Open(ctx context.Context, session *entity.CustomerSession) error
+	// Close ends the customer's open session with routerSessionID, or every open one
+	// when it is empty
+	Close(ctx context.Context, customerID, routerSessionID string, end entity.CustomerSessionEnd) error
+	// CloseStale ends the router's open sessions whose router session id is not in
+	// active under their service type. PPP and hotspot number their sessions
+	// independently, so the same id can be live in one and stale in the other.
+	CloseStale(ctx context.Context, mikrotikID string, active map[string][]string, end entity.CustomerSessionEnd) (int64, error)
+
+	List(ctx context.Context, customerID string, page, pageSize int) ([]entity.CustomerSession, int64, error)
+	Stats(ctx context.Context, customerID string, from, to time.Time) (*entity.CustomerSessionStats, error)
+}
+
+type customerSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerSessionRepository(db *gorm.DB) CustomerSessionRepository {
+	return &customerSessionRepository{db: db}
+}
+
+func (r *customerSessionRepository) Open(ctx context.Context, session *entity.CustomerSession) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("customer_id = ? AND ended_at IS NULL", session.CustomerID)
+		if session.RouterSessionID != nil {
+			query = query.Where("router_session_id = ? OR router_session_id IS NULL", *session.RouterSessionID)
+		}
+
+		var open entity.CustomerSession
+		err := query.Order("started_at DESC").First(&open).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(session).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if session.RouterSessionID != nil {
+			updates["router_session_id"] = *session.RouterSessionID
+		}
+		if session.IPAddress != nil {
+			updates["ip_address"] = *session.IPAddress
+		}
+		if session.CallerID != nil {
+			updates["caller_id"] = *session.CallerID
+		}
+		if session.Interface != nil {
+			updates["interface"] = *session.Interface
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&open).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		*session = open
+		return nil
+	})
+}
+
+func (r *customerSessionRepository) Close(ctx context.Context, customerID, routerSessionID string, end entity.CustomerSessionEnd) error {
+	query := r.db.WithContext(ctx).Model(&entity.CustomerSession{}).
+		Where("customer_id = ? AND ended_at IS NULL", customerID)
+	if routerSessionID != "" {
+		query = query.Where("router_session_id = ? OR router_session_id IS NULL", routerSessionID)
+	}
+	return query.Updates(sessionEndUpdates(end)).Error
+}
+
+func (r *customerSessionRepository) CloseStale(ctx context.Context, mikrotikID string, active map[string][]string, end entity.CustomerSessionEnd) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.CustomerSession{}).
+		Where("mikrotik_id = ? AND ended_at IS NULL", mikrotikID)
+
+	var (
+		live []string
+		args []interface{}
+	)
+	for service, ids := range active {
+		if len(ids) > 0 {
+			live = append(live, "(service_type = ? AND router_session_id IN ?)")
+			args = append(args, service, ids)
+		}
+	}
+	if len(live) > 0 {
+		query = query.Where("router_session_id IS NULL OR NOT ("+strings.Join(live, " OR ")+")", args...)
+	}
+	result := query.Updates(sessionEndUpdates(end))
+	return result.RowsAffected, result.Error
+}
+
+func (r *customerSessionRepository) List(ctx context.Context, customerID string, page, pageSize int) ([]entity.CustomerSession, int64, error) {
+	var sessions []entity.CustomerSession
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.CustomerSession{}).Where("customer_id = ?", customerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&sessions).Error
+	return sessions, total, err
+}
+
+func (r *customerSessionRepository) Stats(ctx context.Context, customerID string, from, to time.Time) (*entity.CustomerSessionStats, error) {
+	var stats entity.CustomerSessionStats
+
+	// Sessions overlapping the period count only the part inside it
+	err := r.db.WithContext(ctx).Model(&entity.CustomerSession{}).
+		Select(`COUNT(*) AS sessions,
+			COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(ended_at, now()), @to) - GREATEST(started_at, @from))), 0) AS uptime_seconds,
+			COALESCE(MAX(EXTRACT(EPOCH FROM COALESCE(ended_at, now()) - started_at)), 0) AS longest_seconds,
+			COALESCE(SUM(bytes_in), 0) AS bytes_in,
+			COALESCE(SUM(bytes_out), 0) AS bytes_out,
+			MAX(started_at) AS last_started_at,
+			COALESCE(BOOL_OR(ended_at IS NULL), false) AS online`,
+			map[string]interface{}{"from": from, "to": to}).
+		Where("customer_id = ? AND started_at < ? AND COALESCE(ended_at, now()) > ?", customerID, to, from).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func sessionEndUpdates(end entity.CustomerSessionEnd) map[string]interface{} {
+	updates := map[string]interface{}{"ended_at": end.EndedAt}
+	if end.Reason != "" {
+		updates["disconnect_reason"] = end.Reason
+	}
+	if end.BytesIn != nil {
+		updates["bytes_in"] = *end.BytesIn
+	}
+	if end.BytesOut != nil {
+		updates["bytes_out"] = *end.BytesOut
+	}
+	return updates
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomerSyncRepository writes customer changes together with the sync jobs that
+// carry them to the router, and tracks the jobs
+type CustomerSyncRepository interface {
+	// CreateCustomer inserts the customer and its job in one transaction; job may be nil
+	CreateCustomer(ctx context.Context, customer *entity.Customer, job *entity.CustomerSyncJob) error
+	// UpdateCustomer writes the customer's non-zero fields and its job in one transaction
+	UpdateCustomer(ctx context.Context, customer *entity.Customer, job *entity.CustomerSyncJob) error
+	// DeleteCustomer deletes the customer and records its job in one transaction
+	DeleteCustomer(ctx context.Context, id string, job *entity.CustomerSyncJob) error
+	// QueueSync records a job for an unchanged customer and marks it pending
+	QueueSync(ctx context.Context, job *entity.CustomerSyncJob) error
+
+	// GetCustomer returns the customer, or nil when it has been deleted
+	GetCustomer(ctx context.Context, id string) (*entity.Customer, error)
+	GetByID(ctx context.Context, id string) (*entity.CustomerSyncJob, error)
+	ListByCustomer(ctx context.Context, customerID string, limit int) ([]*entity.CustomerSyncJob, error)
+	// ListStale returns pending jobs created before pendingBefore and running jobs
+	// last touched before runningBefore: jobs whose task was lost or whose worker died.
+	// Jobs waiting behind an older job of their customer are left out.
+	ListStale(ctx context.Context, pendingBefore, runningBefore time.Time, limit int) ([]*entity.CustomerSyncJob, error)
+	// NextPending returns the customer's oldest pending job, or nil when there is none
+	NextPending(ctx context.Context, customerID string) (*entity.CustomerSyncJob, error)
+
+	// Claim marks a pending job, or a running one last touched before runningBefore,
+	// running and counts the attempt. Jobs of a customer run in the order they were
+	// recorded, so a job with an older pending or running job is not claimed either.
+	// False when the job is done, failed, taken or waiting its turn.
+	Claim(ctx context.Context, id string, runningBefore time.Time) (bool, error)
+	// Complete marks the job done, and the customer synced unless it has other jobs
+	// still to run
+	Complete(ctx context.Context, job *entity.CustomerSyncJob) error
+	// Fail records the job's error on the job and the customer. A final failure
+	// marks both failed; otherwise the job goes back to pending for its next retry.
+	Fail(ctx context.Context, job *entity.CustomerSyncJob, errMsg string, final bool) error
+}
+
+// syncJobInTurn holds for a job with no older pending or running job of its customer
+const syncJobInTurn = `NOT EXISTS (
+	SELECT 1 FROM customer_sync_jobs older
+	WHERE older.customer_id = customer_sync_jobs.customer_id
+		AND older.status IN ('pending', 'running')
+		AND (older.created_at, older.id) < (customer_sync_jobs.created_at, customer_sync_jobs.id))`
+
+type customerSyncRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerSyncRepository(db *gorm.DB) CustomerSyncRepository {
+	return &customerSyncRepository{db: db}
+}
+
+func (r *customerSyncRepository) CreateCustomer(ctx context.Context, customer *entity.Customer, job *entity.CustomerSyncJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if customer.CreatedAt.IsZero() {
+			customer.CreatedAt = now
+		}
+		customer.UpdatedAt = now
+		if err := tx.Create(customer).Error; err != nil {
+			return fmt.Errorf("failed to create customer: %w", err)
+		}
+		return insertSyncJob(tx, job)
+	})
+}
+
+func (r *customerSyncRepository) UpdateCustomer(ctx context.Context, customer *entity.Customer, job *entity.CustomerSyncJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		customer.UpdatedAt = time.Now()
+		result := tx.Model(&entity.Customer{}).Where("id = ?", customer.ID).Updates(customer)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update customer: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("customer not found: %s", customer.ID)
+		}
+		return insertSyncJob(tx, job)
+	})
+}
+
+func (r *customerSyncRepository) DeleteCustomer(ctx context.Context, id string, job *entity.CustomerSyncJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&entity.Customer{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete customer: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("customer not found: %s", id)
+		}
+		return insertSyncJob(tx, job)
+	})
+}
+
+func (r *customerSyncRepository) QueueSync(ctx context.Context, job *entity.CustomerSyncJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Customer{}).Where("id = ?", job.CustomerID).
+			Update("sync_status", entity.SyncStatusPending).Error
+		if err != nil {
+			return err
+		}
+		return insertSyncJob(tx, job)
+	})
+}
+
+func insertSyncJob(tx *gorm.DB, job *entity.CustomerSyncJob) error {
+	if job == nil {
+		return nil
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return fmt.Errorf("failed to record sync job: %w", err)
+	}
+	return nil
+}
+
+func (r *customerSyncRepository) GetCustomer(ctx context.Context, id string) (*entity.Customer, error) {
+	var customer entity.Customer
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&customer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerSyncRepository) GetByID(ctx context.Context, id string) (*entity.CustomerSyncJob, error) {
+	var job entity.CustomerSyncJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *customerSyncRepository) ListByCustomer(ctx context.Context, customerID string, limit int) ([]*entity.CustomerSyncJob, error) {
+	var jobs []*entity.CustomerSyncJob
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *customerSyncRepository) ListStale(ctx context.Context, pendingBefore, runningBefore time.Time, limit int) ([]*entity.CustomerSyncJob, error) {
+	var jobs []*entity.CustomerSyncJob
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND created_at < ?) OR (status = ? AND updated_at < ?)",
+			entity.SyncJobPending, pendingBefore, entity.SyncJobRunning, runningBefore).
+		Where(syncJobInTurn).
+		Order("created_at").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *customerSyncRepository) NextPending(ctx context.Context, customerID string) (*entity.CustomerSyncJob, error) {
+	var job entity.CustomerSyncJob
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ? AND status = ?", customerID, entity.SyncJobPending).
+		Order("created_at, id").
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *customerSyncRepository) Claim(ctx context.Context, id string, runningBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.CustomerSyncJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			id, entity.SyncJobPending, entity.SyncJobRunning, runningBefore).
+		Where(syncJobInTurn).
+		Updates(map[string]interface{}{
+			"status":   entity.SyncJobRunning,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *customerSyncRepository) Complete(ctx context.Context, job *entity.CustomerSyncJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&entity.CustomerSyncJob{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{
+				"status":       entity.SyncJobDone,
+				"last_error":   nil,
+				"processed_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.Customer{}).
+			Where("id = ? AND NOT EXISTS (?)", job.CustomerID,
+				r.db.Model(&entity.CustomerSyncJob{}).Select("1").
+					Where("customer_id = ? AND id <> ? AND status IN ?",
+						job.CustomerID, job.ID, []string{entity.SyncJobPending, entity.SyncJobRunning})).
+			Updates(map[string]interface{}{
+				"sync_status": entity.SyncStatusSynced,
+				"sync_error":  nil,
+				"synced_at":   now,
+			}).Error
+	})
+}
+
+func (r *customerSyncRepository) Fail(ctx context.Context, job *entity.CustomerSyncJob, errMsg string, final bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		jobUpdates := map[string]interface{}{"status": entity.SyncJobPending, "last_error": errMsg}
+		customerUpdates := map[string]interface{}{"sync_error": errMsg}
+		if final {
+			jobUpdates["status"] = entity.SyncJobFailed
+			jobUpdates["processed_at"] = time.Now()
+			customerUpdates["sync_status"] = entity.SyncStatusFailed
+		}
+
+		if err := tx.Model(&entity.CustomerSyncJob{}).Where("id = ?", job.ID).Updates(jobUpdates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.Customer{}).Where("id = ?", job.CustomerID).Updates(customerUpdates).Error
+	})
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"mikrobill/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FUPRepository interface {
+	ListCandidates(ctx context.Context) ([]entity.FUPCandidate, error)
+	GetState(ctx context.Context, customerID string) (*entity.CustomerFUPState, error)
+	SaveState(ctx context.Context, state *entity.CustomerFUPState) error
+	// ClearThrottle forgets that the customer was throttled, after something else
+	// put the plan profile back on the router
+	ClearThrottle(ctx context.Context, customerID string) error
+}
+
+type fupRepository struct {
+	db *gorm.DB
+}
+
+func NewFUPRepository(db *gorm.DB) FUPRepository {
+	return &fupRepository{db: db}
+}
+
+func (r *fupRepository) ListCandidates(ctx context.Context) ([]entity.FUPCandidate, error) {
+	var candidates []entity.FUPCandidate
+	err := r.db.WithContext(ctx).Table("customers c").
+		Select("c.id AS customer_id, p.id AS profile_id").
+		Joins("JOIN mikrotik_profiles p ON p.id = c.pppoe_profile_id").
+		Joins("LEFT JOIN customer_fup_states s ON s.customer_id = c.id").
+		Where("c.service_type = ? AND (p.fup_quota_bytes > 0 OR s.throttled)", "pppoe").
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+func (r *fupRepository) GetState(ctx context.Context, customerID string) (*entity.CustomerFUPState, error) {
+	var state entity.CustomerFUPState
+	if err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).First(&state).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *fupRepository) SaveState(ctx context.Context, state *entity.CustomerFUPState) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "customer_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cycle_start", "notified_percent", "throttled", "throttled_at", "updated_at"}),
+	}).Create(state).Error
+}
+
+func (r *fupRepository) ClearThrottle(ctx context.Context, customerID string) error {
+	return r.db.WithContext(ctx).Model(&entity.CustomerFUPState{}).
+		Where("customer_id = ? AND throttled", customerID).
+		Updates(map[string]interface{}{"throttled": false, "throttled_at": nil}).Error
+}
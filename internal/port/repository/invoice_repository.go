@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvoiceFilter holds the optional filters for listing invoices
+type InvoiceFilter struct {
+	MikrotikID string
+	CustomerID string
+	Status     string
+	Type       string
+	DueFrom    *time.Time
+	DueTo      *time.Time
+	Search     string
+	Page       int
+	PageSize   int
+}
+
+// openInvoiceStatuses are the statuses of invoices that still expect payment
+var openInvoiceStatuses = []entity.InvoiceStatus{
+	entity.InvoiceStatusUnpaid,
+	entity.InvoiceStatusPartiallyPaid,
+	entity.InvoiceStatusOverdue,
+}
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, inv *entity.Invoice) error
+	GetByID(ctx context.Context, id string) (*entity.Invoice, error)
+	List(ctx context.Context, filter InvoiceFilter) ([]entity.Invoice, int64, error)
+	Update(ctx context.Context, inv *entity.Invoice) error
+	Delete(ctx context.Context, id string) error
+
+	// Billing helpers
+	ExistsForPeriod(ctx context.Context, customerID string, periodStart time.Time) (bool, error)
+	MarkOverdue(ctx context.Context, today time.Time, lateFeePercentage float64) (int64, error)
+	ListOverdueCustomerIDs(ctx context.Context, dueBefore time.Time) ([]string, error)
+	CountOutstanding(ctx context.Context, customerID string, dueBefore time.Time) (int64, error)
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+// Create inserts an invoice and assigns the next invoice number from company_profile
+// within the same transaction so concurrent generators never hand out duplicates.
+func (r *invoiceRepository) Create(ctx context.Context, inv *entity.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		number, err := nextInvoiceNumber(tx)
+		if err != nil {
+			return err
+		}
+		inv.InvoiceNumber = number
+
+		return tx.Omit(clause.Associations).Create(inv).Error
+	})
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*entity.Invoice, error) {
+	var inv entity.Invoice
+	err := r.db.WithContext(ctx).Preload("Customer").First(&inv, "id = ?", id).Error
+	return &inv, err
+}
+
+func (r *invoiceRepository) List(ctx context.Context, filter InvoiceFilter) ([]entity.Invoice, int64, error) {
+	var invoices []entity.Invoice
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Joins("LEFT JOIN customers ON customers.id = invoices.customer_id")
+
+	if filter.MikrotikID != "" {
+		query = query.Where("invoices.mikrotik_id = ?", filter.MikrotikID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("invoices.customer_id = ?", filter.CustomerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("invoices.status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("invoices.invoice_type = ?", filter.Type)
+	}
+	if filter.DueFrom != nil {
+		query = query.Where("invoices.due_date >= ?", *filter.DueFrom)
+	}
+	if filter.DueTo != nil {
+		query = query.Where("invoices.due_date <= ?", *filter.DueTo)
+	}
+	if filter.Search != "" {
+		like := "%" + filter.Search + "%"
+		query = query.Where("invoices.invoice_number ILIKE ? OR customers.name ILIKE ?", like, like)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Preload("Customer").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("invoices.issue_date DESC, invoices.invoice_number DESC").
+		Find(&invoices).Error
+
+	return invoices, total, err
+}
+
+func (r *invoiceRepository) Update(ctx context.Context, inv *entity.Invoice) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(inv).Error
+}
+
+func (r *invoiceRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&entity.Invoice{}, "id = ?", id).Error
+}
+
+// ExistsForPeriod reports whether a monthly invoice was already issued for the period
+func (r *invoiceRepository) ExistsForPeriod(ctx context.Context, customerID string, periodStart time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Where("customer_id = ? AND period_start = ? AND invoice_type = ?",
+			customerID, periodStart.Format("2006-01-02"), entity.InvoiceTypeMonthly).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkOverdue flags open invoices whose due date has passed as overdue and charges the
+// late fee on the ones that do not carry one yet
+func (r *invoiceRepository) MarkOverdue(ctx context.Context, today time.Time, lateFeePercentage float64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Where("status IN ? AND due_date < ?",
+			[]entity.InvoiceStatus{entity.InvoiceStatusUnpaid, entity.InvoiceStatusPartiallyPaid},
+			today.Format("2006-01-02")).
+		Updates(map[string]interface{}{
+			"status":   entity.InvoiceStatusOverdue,
+			"late_fee": gorm.Expr("CASE WHEN late_fee = 0 THEN ROUND(amount * ? / 100, 2) ELSE late_fee END", lateFeePercentage),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ListOverdueCustomerIDs returns customers with an unsettled invoice due before the given date
+func (r *invoiceRepository) ListOverdueCustomerIDs(ctx context.Context, dueBefore time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Distinct("customer_id").
+		Where("status IN ? AND due_date < ?", openInvoiceStatuses, dueBefore.Format("2006-01-02")).
+		Pluck("customer_id", &ids).Error
+	return ids, err
+}
+
+// CountOutstanding counts a customer's unsettled invoices due before the given date
+func (r *invoiceRepository) CountOutstanding(ctx context.Context, customerID string, dueBefore time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Where("customer_id = ? AND status IN ? AND due_date < ?",
+			customerID, openInvoiceStatuses, dueBefore.Format("2006-01-02")).
+		Count(&count).Error
+	return count, err
+}
+
+// nextInvoiceNumber locks the company profile row and advances its counter
+func nextInvoiceNumber(tx *gorm.DB) (string, error) {
+	var cp entity.CompanyProfile
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Order("created_at ASC").
+		First(&cp).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to lock company profile: %w", err)
+	}
+
+	next := cp.InvoiceStartNumber
+	if cp.InvoiceNextNumber != nil && *cp.InvoiceNextNumber > next {
+		next = *cp.InvoiceNextNumber
+	}
+
+	err = tx.Model(&entity.CompanyProfile{}).
+		Where("id = ?", cp.ID).
+		Update("invoice_next_number", next+1).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to advance invoice number: %w", err)
+	}
+
+	prefix := cp.InvoicePrefix
+	if prefix == "" {
+		prefix = "INV"
+	}
+
+	return fmt.Sprintf("%s-%d", prefix, next), nil
+}
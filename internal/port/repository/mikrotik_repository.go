@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MikrotikRepository interface {
@@ -14,13 +15,26 @@ type MikrotikRepository interface {
 	List(ctx context.Context, page, pageSize int, search string) ([]entity.Mikrotik, int64, error)
 	Update(ctx context.Context, mk *entity.Mikrotik) error
 	Delete(ctx context.Context, id string) error
+	CountCustomers(ctx context.Context, id string) (int64, error)
 	UpdateStatus(ctx context.Context, id string, status string) error
 	UpdateLastSync(ctx context.Context, id string) error
 
+	// Health
+	ListAll(ctx context.Context) ([]entity.Mikrotik, error)
+	// ChangeStatus sets the status and records the transition, if it is one
+	ChangeStatus(ctx context.Context, id string, status entity.MikrotikStatus, reason string) error
+	// SaveHealth stores a poller reading; the router manager owns the status
+	SaveHealth(ctx context.Context, id string, health *entity.MikrotikHealth) error
+	ListStatusHistory(ctx context.Context, id string, page, pageSize int) ([]entity.MikrotikStatusChange, int64, error)
+
 	// Active Mikrotik Management
+	// GetActiveMikrotik returns the default mikrotik
 	GetActiveMikrotik(ctx context.Context) (*entity.Mikrotik, error)
 	SetActive(ctx context.Context, id string, active bool) error
 	DeactivateAll(ctx context.Context) error
+	// Activate makes id the default mikrotik and enables it; the other routers keep
+	// their is_active flag
+	Activate(ctx context.Context, id string) error
 }
 
 type mikrotikRepository struct {
@@ -69,10 +83,17 @@ func (r *mikrotikRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&entity.Mikrotik{}, "id = ?", id).Error
 }
 
+// CountCustomers counts the customers provisioned on a mikrotik
+func (r *mikrotikRepository) CountCustomers(ctx context.Context, id string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Customer{}).
+		Where("mikrotik_id = ?", id).
+		Count(&count).Error
+	return count, err
+}
+
 func (r *mikrotikRepository) UpdateStatus(ctx context.Context, id string, status string) error {
-	return r.db.WithContext(ctx).Model(&entity.Mikrotik{}).
-		Where("id = ?", id).
-		Update("status", status).Error
+	return r.ChangeStatus(ctx, id, entity.MikrotikStatus(status), "")
 }
 
 func (r *mikrotikRepository) UpdateLastSync(ctx context.Context, id string) error {
@@ -82,10 +103,10 @@ func (r *mikrotikRepository) UpdateLastSync(ctx context.Context, id string) erro
 		Update("last_sync", &now).Error
 }
 
-// GetActiveMikrotik retrieves the currently active mikrotik
+// GetActiveMikrotik retrieves the default mikrotik
 func (r *mikrotikRepository) GetActiveMikrotik(ctx context.Context) (*entity.Mikrotik, error) {
 	var mk entity.Mikrotik
-	err := r.db.WithContext(ctx).Where("is_active = ?", true).First(&mk).Error
+	err := r.db.WithContext(ctx).Where("is_default = ?", true).First(&mk).Error
 	return &mk, err
 }
 
@@ -102,3 +123,87 @@ func (r *mikrotikRepository) DeactivateAll(ctx context.Context) error {
 		Where("is_active = ?", true).
 		Update("is_active", false).Error
 }
+
+func (r *mikrotikRepository) Activate(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Mikrotik{}).
+			Where("is_default = ? AND id <> ?", true, id).
+			Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.Mikrotik{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"is_default": true, "is_active": true}).Error
+	})
+}
+
+func (r *mikrotikRepository) ListAll(ctx context.Context) ([]entity.Mikrotik, error) {
+	var mks []entity.Mikrotik
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&mks).Error
+	return mks, err
+}
+
+func (r *mikrotikRepository) ChangeStatus(ctx context.Context, id string, status entity.MikrotikStatus, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return changeStatus(tx, id, status, reason)
+	})
+}
+
+func (r *mikrotikRepository) SaveHealth(ctx context.Context, id string, health *entity.MikrotikHealth) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&entity.Mikrotik{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"cpu_load":          health.CPULoad,
+			"free_memory":       health.FreeMemory,
+			"total_memory":      health.TotalMemory,
+			"uptime":            health.Uptime,
+			"routeros_version":  health.Version,
+			"board_name":        health.BoardName,
+			"serial_number":     health.SerialNumber,
+			"health_checked_at": now,
+			"last_sync":         now,
+		}).Error
+}
+
+func (r *mikrotikRepository) ListStatusHistory(ctx context.Context, id string, page, pageSize int) ([]entity.MikrotikStatusChange, int64, error) {
+	var changes []entity.MikrotikStatusChange
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.MikrotikStatusChange{}).Where("mikrotik_id = ?", id)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&changes).Error
+	return changes, total, err
+}
+
+// changeStatus updates the status under a row lock so concurrent writers record
+// each transition once
+func changeStatus(tx *gorm.DB, id string, status entity.MikrotikStatus, reason string) error {
+	var current entity.Mikrotik
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		First(&current, "id = ?", id).Error
+	if err != nil {
+		return err
+	}
+	if current.Status == status {
+		return nil
+	}
+
+	if err := tx.Model(&entity.Mikrotik{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	from := current.Status
+	return tx.Create(&entity.MikrotikStatusChange{
+		MikrotikID: id,
+		FromStatus: &from,
+		ToStatus:   status,
+		Reason:     reason,
+	}).Error
+}
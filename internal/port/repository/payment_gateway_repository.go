@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentGatewayRepository interface {
+	Create(ctx context.Context, txn *entity.PaymentGatewayTransaction) error
+	GetByID(ctx context.Context, id string) (*entity.PaymentGatewayTransaction, error)
+	GetByOrderID(ctx context.Context, gateway, orderID string) (*entity.PaymentGatewayTransaction, error)
+	ListByInvoice(ctx context.Context, invoiceID string) ([]entity.PaymentGatewayTransaction, error)
+	// FindReusable returns a pending, unexpired charge for the same invoice, gateway and amount
+	FindReusable(ctx context.Context, invoiceID, gateway string, amount float64, now time.Time) (*entity.PaymentGatewayTransaction, error)
+	// UpdateStatus stores the latest gateway state and settle error. Settled transactions are
+	// never downgraded.
+	UpdateStatus(ctx context.Context, txn *entity.PaymentGatewayTransaction) error
+	// Settle records the payment for a transaction exactly once. The transaction row is locked
+	// and settled_at survives voiding the payment, so concurrent or repeated notifications see
+	// settled=false instead of paying twice.
+	Settle(ctx context.Context, txn *entity.PaymentGatewayTransaction, payment *entity.Payment, validate func(inv *entity.Invoice) error) (inv *entity.Invoice, settled bool, err error)
+}
+
+type paymentGatewayRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentGatewayRepository(db *gorm.DB) PaymentGatewayRepository {
+	return &paymentGatewayRepository{db: db}
+}
+
+func (r *paymentGatewayRepository) Create(ctx context.Context, txn *entity.PaymentGatewayTransaction) error {
+	return r.db.WithContext(ctx).Create(txn).Error
+}
+
+func (r *paymentGatewayRepository) GetByID(ctx context.Context, id string) (*entity.PaymentGatewayTransaction, error) {
+	var txn entity.PaymentGatewayTransaction
+	err := r.db.WithContext(ctx).First(&txn, "id = ?", id).Error
+	return &txn, err
+}
+
+func (r *paymentGatewayRepository) GetByOrderID(ctx context.Context, gateway, orderID string) (*entity.PaymentGatewayTransaction, error) {
+	var txn entity.PaymentGatewayTransaction
+	err := r.db.WithContext(ctx).
+		Where("gateway = ? AND order_id = ?", gateway, orderID).
+		First(&txn).Error
+	return &txn, err
+}
+
+func (r *paymentGatewayRepository) ListByInvoice(ctx context.Context, invoiceID string) ([]entity.PaymentGatewayTransaction, error) {
+	var txns []entity.PaymentGatewayTransaction
+	err := r.db.WithContext(ctx).
+		Where("invoice_id = ?", invoiceID).
+		Order("created_at DESC").
+		Find(&txns).Error
+	return txns, err
+}
+
+func (r *paymentGatewayRepository) FindReusable(ctx context.Context, invoiceID, gateway string, amount float64, now time.Time) (*entity.PaymentGatewayTransaction, error) {
+	var txn entity.PaymentGatewayTransaction
+	err := r.db.WithContext(ctx).
+		Where("invoice_id = ? AND gateway = ? AND status = ? AND amount = ?",
+			invoiceID, gateway, entity.GatewayTransactionPending, amount).
+		Where("payment_url IS NOT NULL").
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		First(&txn).Error
+	return &txn, err
+}
+
+func (r *paymentGatewayRepository) UpdateStatus(ctx context.Context, txn *entity.PaymentGatewayTransaction) error {
+	return r.db.WithContext(ctx).Model(&entity.PaymentGatewayTransaction{}).
+		Where("id = ? AND status <> ?", txn.ID, entity.GatewayTransactionSuccess).
+		Select("status", "transaction_id", "payment_type", "fraud_status", "last_notification", "settle_error").
+		Updates(txn).Error
+}
+
+func (r *paymentGatewayRepository) Settle(ctx context.Context, txn *entity.PaymentGatewayTransaction, payment *entity.Payment, validate func(inv *entity.Invoice) error) (*entity.Invoice, bool, error) {
+	var inv entity.Invoice
+	settled := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked entity.PaymentGatewayTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", txn.ID).Error; err != nil {
+			return err
+		}
+		if locked.SettledAt != nil {
+			return nil
+		}
+
+		payment.InvoiceID = locked.InvoiceID
+		if err := recordPayment(tx, payment, validate, &inv); err != nil {
+			return err
+		}
+
+		txn.Status = entity.GatewayTransactionSuccess
+		txn.PaymentID = &payment.ID
+		txn.PaidAt = &payment.PaymentDate
+		now := time.Now()
+		txn.SettledAt = &now
+		txn.SettleError = nil
+		if err := tx.Model(&entity.PaymentGatewayTransaction{}).
+			Where("id = ?", txn.ID).
+			Select("status", "payment_id", "paid_at", "settled_at", "settle_error", "transaction_id", "payment_type", "fraud_status", "last_notification").
+			Updates(txn).Error; err != nil {
+			return err
+		}
+
+		settled = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !settled {
+		return nil, false, nil
+	}
+	return &inv, true, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentFilter holds the optional filters for listing payments
+type PaymentFilter struct {
+	InvoiceID  string
+	CustomerID string
+	Method     string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+type PaymentRepository interface {
+	// Record inserts a payment and recalculates the invoice's paid total in one transaction.
+	// validate runs against the locked invoice before anything is written.
+	Record(ctx context.Context, payment *entity.Payment, validate func(inv *entity.Invoice) error) (*entity.Invoice, error)
+	GetByID(ctx context.Context, id string) (*entity.Payment, error)
+	List(ctx context.Context, filter PaymentFilter) ([]entity.Payment, int64, error)
+	// Void deletes a payment and recalculates the invoice's paid total.
+	// Ledger entries of an agent who collected the payment are reversed.
+	Void(ctx context.Context, id string) (*entity.Invoice, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Record(ctx context.Context, payment *entity.Payment, validate func(inv *entity.Invoice) error) (*entity.Invoice, error) {
+	var inv entity.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return recordPayment(tx, payment, validate, &inv)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// recordPayment is the body of Record, shared with gateway settlement so both run in the caller's transaction
+func recordPayment(tx *gorm.DB, payment *entity.Payment, validate func(inv *entity.Invoice) error, inv *entity.Invoice) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(inv, "id = ?", payment.InvoiceID).Error; err != nil {
+		return err
+	}
+	if validate != nil {
+		if err := validate(inv); err != nil {
+			return err
+		}
+	}
+
+	var seq int64
+	if err := tx.Raw("SELECT nextval('payment_receipt_seq')").Scan(&seq).Error; err != nil {
+		return fmt.Errorf("failed to allocate receipt number: %w", err)
+	}
+	payment.ReceiptNumber = fmt.Sprintf("RCP-%s-%06d", payment.PaymentDate.Format("200601"), seq)
+	payment.CustomerID = inv.CustomerID
+
+	if err := tx.Omit(clause.Associations).Create(payment).Error; err != nil {
+		return err
+	}
+
+	return recalculatePaidAmount(tx, inv, payment.PaymentDate)
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (*entity.Payment, error) {
+	var p entity.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Invoice").
+		Preload("Customer").
+		First(&p, "id = ?", id).Error
+	return &p, err
+}
+
+func (r *paymentRepository) List(ctx context.Context, filter PaymentFilter) ([]entity.Payment, int64, error) {
+	var payments []entity.Payment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.Payment{})
+
+	if filter.InvoiceID != "" {
+		query = query.Where("invoice_id = ?", filter.InvoiceID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Method != "" {
+		query = query.Where("payment_method = ?", filter.Method)
+	}
+	if filter.From != nil {
+		query = query.Where("payment_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("payment_date < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Preload("Customer").
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("payment_date DESC").
+		Find(&payments).Error
+
+	return payments, total, err
+}
+
+func (r *paymentRepository) Void(ctx context.Context, id string) (*entity.Invoice, error) {
+	var inv entity.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p entity.Payment
+		if err := tx.First(&p, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, "id = ?", p.InvoiceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entity.Payment{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if p.AgentID != nil {
+			if err := reverseAgentEntries(tx, *p.AgentID, "payment", p.ID, "Payment "+p.ReceiptNumber+" voided"); err != nil {
+				return err
+			}
+		}
+
+		return recalculatePaidAmount(tx, &inv, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// recalculatePaidAmount sums the invoice's payments and stores the derived paid total and status
+func recalculatePaidAmount(tx *gorm.DB, inv *entity.Invoice, at time.Time) error {
+	var paid float64
+	err := tx.Model(&entity.Payment{}).
+		Where("invoice_id = ?", inv.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&paid).Error
+	if err != nil {
+		return fmt.Errorf("failed to sum payments: %w", err)
+	}
+
+	inv.ApplyPaidAmount(paid, at)
+
+	return tx.Model(&entity.Invoice{}).
+		Where("id = ?", inv.ID).
+		Select("paid_amount", "status", "paid_at").
+		Updates(inv).Error
+}
@@ -152,6 +152,8 @@ func (r *DatabaseProfileRepository) GetProfileByID(id string) (*entity.ProfileWi
 			log.Printf("[ProfileRepo] GetProfileByID - WARNING: Failed to load PPPoE details: %v", err)
 		}
 	}
+	r.loadQueueSettings(result)
+	r.loadStaticIPDetails(result)
 
 	log.Printf("[ProfileRepo] GetProfileByID - SUCCESS: Found profile %s (%s)", profile.Name, profile.ID)
 	return result, nil
@@ -204,6 +206,8 @@ func (r *DatabaseProfileRepository) ListProfiles(mikrotikID *string, page, limit
 				result[i].PPPoEDetails = &pppoeDetails
 			}
 		}
+		r.loadQueueSettings(result[i])
+		r.loadStaticIPDetails(result[i])
 	}
 
 	log.Printf("[ProfileRepo] ListProfiles - SUCCESS: Found %d profiles (total: %d)", len(profiles), total)
@@ -237,6 +241,8 @@ func (r *DatabaseProfileRepository) GetProfilesByMikrotikID(mikrotikID string) (
 				result[i].PPPoEDetails = &pppoeDetails
 			}
 		}
+		r.loadQueueSettings(result[i])
+		r.loadStaticIPDetails(result[i])
 	}
 
 	log.Printf("[ProfileRepo] GetProfilesByMikrotikID - SUCCESS: Found %d profiles", len(profiles))
@@ -269,6 +275,8 @@ func (r *DatabaseProfileRepository) GetProfileByName(mikrotikID string, name str
 			result.PPPoEDetails = &pppoeDetails
 		}
 	}
+	r.loadQueueSettings(result)
+	r.loadStaticIPDetails(result)
 
 	log.Printf("[ProfileRepo] GetProfileByName - SUCCESS: Found profile %s", profile.ID)
 	return result, nil
@@ -295,3 +303,72 @@ func (r *DatabaseProfileRepository) UpdateSyncStatus(id string, lastSync time.Ti
 	log.Printf("[ProfileRepo] UpdateSyncStatus - SUCCESS: Updated sync status for %s", id)
 	return nil
 }
+
+// SaveQueueSettings replaces the queue settings of a profile, or removes them when settings is nil
+func (r *DatabaseProfileRepository) SaveQueueSettings(profileID string, settings *entity.MikrotikQueueSettings) error {
+	log.Printf("[ProfileRepo] SaveQueueSettings - Saving queue settings for profile: %s", profileID)
+
+	if settings == nil {
+		if err := r.db.Where("profile_id = ?", profileID).Delete(&entity.MikrotikQueueSettings{}).Error; err != nil {
+			log.Printf("[ProfileRepo] SaveQueueSettings - ERROR deleting: %v", err)
+			return fmt.Errorf("failed to delete queue settings: %w", err)
+		}
+		return nil
+	}
+
+	settings.ProfileID = profileID
+	if err := r.db.Save(settings).Error; err != nil {
+		log.Printf("[ProfileRepo] SaveQueueSettings - ERROR: %v", err)
+		return fmt.Errorf("failed to save queue settings: %w", err)
+	}
+
+	log.Printf("[ProfileRepo] SaveQueueSettings - SUCCESS: Saved queue settings for %s", profileID)
+	return nil
+}
+
+// SaveStaticIPDetails replaces the static-IP settings of a profile; nil removes them
+func (r *DatabaseProfileRepository) SaveStaticIPDetails(profileID string, details *entity.MikrotikProfileStaticIP) error {
+	log.Printf("[ProfileRepo] SaveStaticIPDetails - Saving static IP details for profile: %s", profileID)
+
+	if details == nil {
+		if err := r.db.Where("profile_id = ?", profileID).Delete(&entity.MikrotikProfileStaticIP{}).Error; err != nil {
+			log.Printf("[ProfileRepo] SaveStaticIPDetails - ERROR deleting: %v", err)
+			return fmt.Errorf("failed to delete static ip details: %w", err)
+		}
+		return nil
+	}
+
+	details.ProfileID = profileID
+	if err := r.db.Save(details).Error; err != nil {
+		log.Printf("[ProfileRepo] SaveStaticIPDetails - ERROR: %v", err)
+		return fmt.Errorf("failed to save static ip details: %w", err)
+	}
+
+	log.Printf("[ProfileRepo] SaveStaticIPDetails - SUCCESS: Saved static IP details for %s", profileID)
+	return nil
+}
+
+// loadQueueSettings attaches the profile's queue settings, if it has any
+func (r *DatabaseProfileRepository) loadQueueSettings(profile *entity.ProfileWithPPPoE) {
+	var settings entity.MikrotikQueueSettings
+	err := r.db.Where("profile_id = ?", profile.ID).First(&settings).Error
+	if err == nil {
+		profile.QueueSettings = &settings
+	} else if err != gorm.ErrRecordNotFound {
+		log.Printf("[ProfileRepo] loadQueueSettings - WARNING: Failed to load queue settings: %v", err)
+	}
+}
+
+// loadStaticIPDetails attaches the profile's static-IP settings, if it has any
+func (r *DatabaseProfileRepository) loadStaticIPDetails(profile *entity.ProfileWithPPPoE) {
+	if profile.ProfileType != "static_ip" {
+		return
+	}
+	var details entity.MikrotikProfileStaticIP
+	err := r.db.Where("profile_id = ?", profile.ID).First(&details).Error
+	if err == nil {
+		profile.StaticIPDetails = &details
+	} else if err != gorm.ErrRecordNotFound {
+		log.Printf("[ProfileRepo] loadStaticIPDetails - WARNING: Failed to load static ip details: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"mikrobill/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type SettingRepository interface {
+	GetCompanyProfile(ctx context.Context) (*entity.CompanyProfile, error)
+	GetSetting(ctx context.Context, category, key string) (*entity.AppSetting, error)
+	ListByCategory(ctx context.Context, category string) ([]entity.AppSetting, error)
+}
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) SettingRepository {
+	return &settingRepository{db: db}
+}
+
+// GetCompanyProfile retrieves the (single) company profile row
+func (r *settingRepository) GetCompanyProfile(ctx context.Context) (*entity.CompanyProfile, error) {
+	var cp entity.CompanyProfile
+	err := r.db.WithContext(ctx).Order("created_at ASC").First(&cp).Error
+	return &cp, err
+}
+
+func (r *settingRepository) GetSetting(ctx context.Context, category, key string) (*entity.AppSetting, error) {
+	var s entity.AppSetting
+	err := r.db.WithContext(ctx).
+		Where("category = ? AND setting_key = ?", category, key).
+		First(&s).Error
+	return &s, err
+}
+
+func (r *settingRepository) ListByCategory(ctx context.Context, category string) ([]entity.AppSetting, error) {
+	var settings []entity.AppSetting
+	err := r.db.WithContext(ctx).
+		Where("category = ?", category).
+		Order("setting_key").
+		Find(&settings).Error
+	return settings, err
+}
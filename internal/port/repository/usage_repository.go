@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsageRepository interface {
+	// Counters returns the router's last counters by counter key
+	Counters(ctx context.Context, mikrotikID string) (map[string]entity.UsageCounter, error)
+	// Record stores the new counters, sampled at at, in place of the router's previous
+	// ones and adds the deltas to the hourly, daily and monthly rollups containing at,
+	// in one transaction
+	Record(ctx context.Context, at time.Time, counters []entity.UsageCounter, deltas []entity.UsageDelta) error
+	// ListUsage returns the rollup rows of a granularity starting in [from, to)
+	ListUsage(ctx context.Context, customerID, granularity string, from, to time.Time) ([]entity.UsagePeriod, error)
+	// Total returns the customer's bytes up and down on the days in [from, to)
+	Total(ctx context.Context, customerID string, from, to time.Time) (int64, error)
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) Counters(ctx context.Context, mikrotikID string) (map[string]entity.UsageCounter, error) {
+	var rows []entity.UsageCounter
+	if err := r.db.WithContext(ctx).Where("mikrotik_id = ?", mikrotikID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]entity.UsageCounter, len(rows))
+	for _, row := range rows {
+		counters[row.CounterKey] = row
+	}
+	return counters, nil
+}
+
+func (r *usageRepository) Record(ctx context.Context, at time.Time, counters []entity.UsageCounter, deltas []entity.UsageDelta) error {
+	periods := []struct {
+		table string
+		start interface{}
+	}{
+		{"customer_usage_hourly", at.Truncate(time.Hour)},
+		// DATE columns get the local calendar day, whatever the database time zone is
+		{"customer_usage_daily", at.Format("2006-01-02")},
+		{"customer_usage_monthly", time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location()).Format("2006-01-02")},
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(counters) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "customer_id"}, {Name: "counter_key"}},
+				UpdateAll: true,
+			}).Create(&counters).Error
+			if err != nil {
+				return err
+			}
+			// Counters of sessions that have ended
+			err = tx.Where("mikrotik_id = ? AND sampled_at < ?", counters[0].MikrotikID, at).
+				Delete(&entity.UsageCounter{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, d := range deltas {
+			if d.BytesUp == 0 && d.BytesDown == 0 {
+				continue
+			}
+			for _, p := range periods {
+				err := tx.Exec(fmt.Sprintf(`INSERT INTO %[1]s (customer_id, period_start, bytes_up, bytes_down)
+					VALUES (?, ?, ?, ?)
+					ON CONFLICT (customer_id, period_start) DO UPDATE SET
+						bytes_up = %[1]s.bytes_up + EXCLUDED.bytes_up,
+						bytes_down = %[1]s.bytes_down + EXCLUDED.bytes_down`, p.table),
+					d.CustomerID, p.start, d.BytesUp, d.BytesDown).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *usageRepository) ListUsage(ctx context.Context, customerID, granularity string, from, to time.Time) ([]entity.UsagePeriod, error) {
+	table := "customer_usage_daily"
+	var start, end interface{} = from.Format("2006-01-02"), to.Format("2006-01-02")
+	switch granularity {
+	case entity.UsageGranularityHour:
+		table = "customer_usage_hourly"
+		start, end = from, to
+	case entity.UsageGranularityMonth:
+		table = "customer_usage_monthly"
+	}
+
+	var periods []entity.UsagePeriod
+	err := r.db.WithContext(ctx).Table(table).
+		Select("period_start, bytes_up, bytes_down").
+		Where("customer_id = ? AND period_start >= ? AND period_start < ?", customerID, start, end).
+		Order("period_start ASC").
+		Scan(&periods).Error
+	return periods, err
+}
+
+func (r *usageRepository) Total(ctx context.Context, customerID string, from, to time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Table("customer_usage_daily").
+		Select("COALESCE(SUM(bytes_up + bytes_down), 0)").
+		Where("customer_id = ? AND period_start >= ? AND period_start < ?",
+			customerID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"mikrobill/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VoucherFilter holds the optional filters for listing vouchers
+type VoucherFilter struct {
+	MikrotikID string
+	BatchID    string
+	State      string // one of the entity.VoucherState* values
+	AgentID    string
+	Search     string
+	Page       int
+	PageSize   int
+}
+
+type VoucherRepository interface {
+	// CreateBatch inserts a batch and its vouchers in one transaction
+	CreateBatch(ctx context.Context, batch *entity.VoucherBatch) error
+	GetBatch(ctx context.Context, id string) (*entity.VoucherBatch, error)
+	ListBatches(ctx context.Context, mikrotikID string, page, pageSize int) ([]entity.VoucherBatch, int64, error)
+	// BatchComments returns the router comments of every batch on a router
+	BatchComments(ctx context.Context, mikrotikID string) ([]string, error)
+
+	GetByID(ctx context.Context, id string) (*entity.HotspotVoucher, error)
+	List(ctx context.Context, filter VoucherFilter) ([]entity.HotspotVoucher, int64, error)
+	// ListOpen returns the router's vouchers that may still change state (active or used)
+	ListOpen(ctx context.Context, mikrotikID string) ([]entity.HotspotVoucher, error)
+	// ExistingUsernames returns which of the given usernames are stored for the router
+	ExistingUsernames(ctx context.Context, mikrotikID string, usernames []string) ([]string, error)
+	// UpdateSync stores the state observed on the router
+	UpdateSync(ctx context.Context, voucher *entity.HotspotVoucher) error
+	// MarkSold marks an unsold, unused voucher as sold. It reports false if the voucher was not available.
+	MarkSold(ctx context.Context, id string, agentID *string, at time.Time) (bool, error)
+}
+
+type voucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) VoucherRepository {
+	return &voucherRepository{db: db}
+}
+
+func (r *voucherRepository) CreateBatch(ctx context.Context, batch *entity.VoucherBatch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		vouchers := batch.Vouchers
+		if err := tx.Omit(clause.Associations).Create(batch).Error; err != nil {
+			return err
+		}
+		if len(vouchers) == 0 {
+			return nil
+		}
+		for i := range vouchers {
+			vouchers[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(&vouchers, 100).Error
+	})
+}
+
+func (r *voucherRepository) GetBatch(ctx context.Context, id string) (*entity.VoucherBatch, error) {
+	var batch entity.VoucherBatch
+	err := r.db.WithContext(ctx).
+		Preload("Vouchers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("username ASC")
+		}).
+		First(&batch, "id = ?", id).Error
+	return &batch, err
+}
+
+func (r *voucherRepository) ListBatches(ctx context.Context, mikrotikID string, page, pageSize int) ([]entity.VoucherBatch, int64, error) {
+	var batches []entity.VoucherBatch
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.VoucherBatch{}).Where("mikrotik_id = ?", mikrotikID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Order("created_at DESC").
+		Find(&batches).Error
+
+	return batches, total, err
+}
+
+func (r *voucherRepository) BatchComments(ctx context.Context, mikrotikID string) ([]string, error) {
+	var comments []string
+	err := r.db.WithContext(ctx).Model(&entity.VoucherBatch{}).
+		Where("mikrotik_id = ?", mikrotikID).
+		Pluck("router_comment", &comments).Error
+	return comments, err
+}
+
+func (r *voucherRepository) GetByID(ctx context.Context, id string) (*entity.HotspotVoucher, error) {
+	var v entity.HotspotVoucher
+	err := r.db.WithContext(ctx).First(&v, "id = ?", id).Error
+	return &v, err
+}
+
+func (r *voucherRepository) List(ctx context.Context, filter VoucherFilter) ([]entity.HotspotVoucher, int64, error) {
+	var vouchers []entity.HotspotVoucher
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.HotspotVoucher{})
+
+	if filter.MikrotikID != "" {
+		query = query.Where("mikrotik_id = ?", filter.MikrotikID)
+	}
+	if filter.BatchID != "" {
+		query = query.Where("batch_id = ?", filter.BatchID)
+	}
+	if filter.AgentID != "" {
+		query = query.Where("agent_id = ?", filter.AgentID)
+	}
+	if filter.Search != "" {
+		query = query.Where("username ILIKE ?", "%"+filter.Search+"%")
+	}
+	switch filter.State {
+	case entity.VoucherStateUnsold:
+		query = query.Where("status = ? AND sold_at IS NULL", entity.VoucherStatusActive)
+	case entity.VoucherStateSold:
+		query = query.Where("status = ? AND sold_at IS NOT NULL", entity.VoucherStatusActive)
+	case entity.VoucherStateUsed:
+		query = query.Where("status = ?", entity.VoucherStatusUsed)
+	case entity.VoucherStateExpired:
+		query = query.Where("status IN ?", []entity.VoucherStatus{entity.VoucherStatusExpired, entity.VoucherStatusCancelled})
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.
+		Offset(offset).
+		Limit(filter.PageSize).
+		Order("created_at DESC, username ASC").
+		Find(&vouchers).Error
+
+	return vouchers, total, err
+}
+
+func (r *voucherRepository) ListOpen(ctx context.Context, mikrotikID string) ([]entity.HotspotVoucher, error) {
+	var vouchers []entity.HotspotVoucher
+	err := r.db.WithContext(ctx).
+		Where("mikrotik_id = ? AND status IN ?", mikrotikID,
+			[]entity.VoucherStatus{entity.VoucherStatusActive, entity.VoucherStatusUsed}).
+		Find(&vouchers).Error
+	return vouchers, err
+}
+
+func (r *voucherRepository) ExistingUsernames(ctx context.Context, mikrotikID string, usernames []string) ([]string, error) {
+	var existing []string
+	if len(usernames) == 0 {
+		return existing, nil
+	}
+	err := r.db.WithContext(ctx).Model(&entity.HotspotVoucher{}).
+		Where("mikrotik_id = ? AND username IN ?", mikrotikID, usernames).
+		Pluck("username", &existing).Error
+	return existing, err
+}
+
+func (r *voucherRepository) UpdateSync(ctx context.Context, voucher *entity.HotspotVoucher) error {
+	return r.db.WithContext(ctx).Model(&entity.HotspotVoucher{}).
+		Where("id = ?", voucher.ID).
+		Select("status", "activated_at", "expired_at", "mikrotik_user_id", "last_sync").
+		Updates(voucher).Error
+}
+
+func (r *voucherRepository) MarkSold(ctx context.Context, id string, agentID *string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.HotspotVoucher{}).
+		Where("id = ? AND status = ? AND sold_at IS NULL", id, entity.VoucherStatusActive).
+		Updates(map[string]interface{}{"sold_at": at, "agent_id": agentID})
+	return result.RowsAffected > 0, result.Error
+}
+
+// markVoucherSold marks the stored voucher with the given username as sold inside the caller's
+// transaction. Vouchers created on the router outside mikrobill have no row and are accepted.
+func markVoucherSold(tx *gorm.DB, mikrotikID, username string, agentID *string, at time.Time) (found, sold bool, err error) {
+	var v entity.HotspotVoucher
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("mikrotik_id = ? AND username = ?", mikrotikID, username).
+		First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	if v.Status != entity.VoucherStatusActive || v.SoldAt != nil {
+		return true, false, nil
+	}
+
+	err = tx.Model(&entity.HotspotVoucher{}).
+		Where("id = ?", v.ID).
+		Updates(map[string]interface{}{"sold_at": at, "agent_id": agentID}).Error
+	return true, err == nil, err
+}
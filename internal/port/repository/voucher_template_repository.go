@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"mikrobill/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type VoucherTemplateRepository interface {
+	Create(ctx context.Context, tpl *entity.VoucherTemplate) error
+	GetByID(ctx context.Context, id string) (*entity.VoucherTemplate, error)
+	// GetDefault returns the active default template of a type
+	GetDefault(ctx context.Context, templateType string) (*entity.VoucherTemplate, error)
+	List(ctx context.Context, templateType string) ([]entity.VoucherTemplate, error)
+	Update(ctx context.Context, tpl *entity.VoucherTemplate) error
+	Delete(ctx context.Context, id string) error
+	ExistsByName(ctx context.Context, name, templateType, excludeID string) (bool, error)
+}
+
+type voucherTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherTemplateRepository(db *gorm.DB) VoucherTemplateRepository {
+	return &voucherTemplateRepository{db: db}
+}
+
+// Create stores a template; a new default takes over from the previous one of its type
+func (r *voucherTemplateRepository) Create(ctx context.Context, tpl *entity.VoucherTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tpl.IsDefault {
+			if err := clearDefaultTemplate(tx, tpl.TemplateType, ""); err != nil {
+				return err
+			}
+		}
+		return tx.Create(tpl).Error
+	})
+}
+
+func (r *voucherTemplateRepository) GetByID(ctx context.Context, id string) (*entity.VoucherTemplate, error) {
+	var tpl entity.VoucherTemplate
+	err := r.db.WithContext(ctx).First(&tpl, "id = ?", id).Error
+	return &tpl, err
+}
+
+func (r *voucherTemplateRepository) GetDefault(ctx context.Context, templateType string) (*entity.VoucherTemplate, error) {
+	var tpl entity.VoucherTemplate
+	err := r.db.WithContext(ctx).
+		Where("template_type = ? AND is_default AND is_active", templateType).
+		First(&tpl).Error
+	return &tpl, err
+}
+
+func (r *voucherTemplateRepository) List(ctx context.Context, templateType string) ([]entity.VoucherTemplate, error) {
+	var templates []entity.VoucherTemplate
+	query := r.db.WithContext(ctx)
+	if templateType != "" {
+		query = query.Where("template_type = ?", templateType)
+	}
+	err := query.Order("template_type, template_name").Find(&templates).Error
+	return templates, err
+}
+
+func (r *voucherTemplateRepository) Update(ctx context.Context, tpl *entity.VoucherTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tpl.IsDefault {
+			if err := clearDefaultTemplate(tx, tpl.TemplateType, tpl.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entity.VoucherTemplate{}).
+			Where("id = ?", tpl.ID).
+			Select("template_name", "description", "content", "columns", "rows_per_page", "show_qr", "is_default", "is_active").
+			Updates(tpl).Error
+	})
+}
+
+func (r *voucherTemplateRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&entity.VoucherTemplate{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *voucherTemplateRepository) ExistsByName(ctx context.Context, name, templateType, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&entity.VoucherTemplate{}).
+		Where("template_name = ? AND template_type = ?", name, templateType)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// clearDefaultTemplate unsets the default of a type, except on the template being saved
+func clearDefaultTemplate(tx *gorm.DB, templateType, exceptID string) error {
+	query := tx.Model(&entity.VoucherTemplate{}).
+		Where("template_type = ? AND is_default", templateType)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	return query.UpdateColumn("is_default", false).Error
+}
@@ -13,9 +13,10 @@ import (
 
 // JWTClaims contains the JWT payload
 type JWTClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID  int64  `json:"user_id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	AgentID string `json:"agent_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -58,6 +59,33 @@ func (s *JWTService) GenerateToken(userID int64, email string, role string) (str
 	return tokenString, expiresAt.Unix(), nil
 }
 
+// GenerateAgentToken creates a token for a reseller agent. Agents are not users,
+// so the token carries the agent ID and the agent role instead of a user ID.
+func (s *JWTService) GenerateAgentToken(agentID, username, role string) (string, int64, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.tokenDuration)
+
+	claims := JWTClaims{
+		Email:   username,
+		Role:    role,
+		AgentID: agentID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   agentID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(s.secretKey))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tokenString, expiresAt.Unix(), nil
+}
+
 // ValidateToken validates and parses a JWT token
 func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(
@@ -93,6 +121,9 @@ func (s *JWTService) RefreshToken(oldToken string) (string, int64, error) {
 		return "", 0, err
 	}
 
+	if claims.AgentID != "" {
+		return s.GenerateAgentToken(claims.AgentID, claims.Email, claims.Role)
+	}
 	return s.GenerateToken(claims.UserID, claims.Email, claims.Role)
 }
 
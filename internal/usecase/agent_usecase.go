@@ -0,0 +1,478 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	"mikrobill/internal/port/service"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultCommissionRate = 5.0
+
+type AgentUsecase interface {
+	Create(ctx context.Context, req model.CreateAgentRequest) (*entity.Agent, error)
+	GetByID(ctx context.Context, id string) (*entity.Agent, error)
+	List(ctx context.Context, req model.AgentListRequest) (*model.PaginationResponse, error)
+	Update(ctx context.Context, id string, req model.UpdateAgentRequest) (*entity.Agent, error)
+	Login(ctx context.Context, req model.AgentLoginRequest) (*model.AgentLoginResponse, error)
+
+	ListTransactions(ctx context.Context, agentID string, req model.AgentTransactionListRequest) (*model.PaginationResponse, error)
+
+	// RequestTopUp files a top-up for admin approval; the balance moves only once approved
+	RequestTopUp(ctx context.Context, agentID string, req model.CreateBalanceRequestRequest) (*entity.AgentBalanceRequest, error)
+	ListBalanceRequests(ctx context.Context, req model.BalanceRequestListRequest) (*model.PaginationResponse, error)
+	ProcessTopUp(ctx context.Context, id string, approve bool, req model.ProcessBalanceRequestRequest, processedBy *int64) (*entity.AgentBalanceRequest, error)
+
+	// CollectPayment records a customer's payment taken by the agent. The amount is debited
+	// from the agent's balance and the commission credited back in the same transaction.
+	CollectPayment(ctx context.Context, agentID, invoiceID string, req model.AgentCollectPaymentRequest) (*entity.Payment, *entity.Invoice, error)
+	// RecordVoucherSale debits the voucher price from the agent and credits the commission
+	RecordVoucherSale(ctx context.Context, agentID string, req model.AgentVoucherSaleRequest) (*entity.AgentVoucherSale, error)
+	ListVoucherSales(ctx context.Context, agentID string, page, pageSize int) (*model.PaginationResponse, error)
+}
+
+type agentUsecase struct {
+	agentRepo       repository.AgentRepository
+	mikrotikRepo    repository.MikrotikRepository
+	paymentUsecase  PaymentUsecase
+	passwordService *service.PasswordService
+	jwtService      *service.JWTService
+}
+
+func NewAgentUsecase(
+	agentRepo repository.AgentRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	paymentUsecase PaymentUsecase,
+	passwordService *service.PasswordService,
+	jwtService *service.JWTService,
+) AgentUsecase {
+	return &agentUsecase{
+		agentRepo:       agentRepo,
+		mikrotikRepo:    mikrotikRepo,
+		paymentUsecase:  paymentUsecase,
+		passwordService: passwordService,
+		jwtService:      jwtService,
+	}
+}
+
+// Create registers a new agent with an empty balance
+func (uc *agentUsecase) Create(ctx context.Context, req model.CreateAgentRequest) (*entity.Agent, error) {
+	exists, err := uc.agentRepo.ExistsByUsernameOrPhone(ctx, req.Username, req.Phone, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check agent: %w", err)
+	}
+	if exists {
+		return nil, utils.ErrAgentAlreadyExists
+	}
+
+	hash, err := uc.passwordService.Hash(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	agent := &entity.Agent{
+		Username:       req.Username,
+		Name:           req.Name,
+		Phone:          req.Phone,
+		PasswordHash:   hash,
+		Status:         entity.AgentStatusActive,
+		CommissionRate: defaultCommissionRate,
+	}
+	if req.Email != "" {
+		agent.Email = &req.Email
+	}
+	if req.Address != "" {
+		agent.Address = &req.Address
+	}
+	if req.CommissionRate != nil {
+		agent.CommissionRate = *req.CommissionRate
+	}
+
+	if err := uc.agentRepo.Create(ctx, agent); err != nil {
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+
+	pkg_logger.Info("Agent created",
+		zap.String("agent_id", agent.ID),
+		zap.String("username", agent.Username),
+		zap.Float64("commission_rate", agent.CommissionRate),
+	)
+
+	return agent, nil
+}
+
+// GetByID retrieves an agent with its balance
+func (uc *agentUsecase) GetByID(ctx context.Context, id string) (*entity.Agent, error) {
+	agent, err := uc.agentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrAgentNotFound
+		}
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+	return agent, nil
+}
+
+// List retrieves paginated agents matching the request filters
+func (uc *agentUsecase) List(ctx context.Context, req model.AgentListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	agents, total, err := uc.agentRepo.List(ctx, repository.AgentFilter{
+		Status:   req.Status,
+		Search:   req.Search,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, agents), nil
+}
+
+// Update changes an agent's profile, status, commission rate or password
+func (uc *agentUsecase) Update(ctx context.Context, id string, req model.UpdateAgentRequest) (*entity.Agent, error) {
+	agent, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Phone != nil && *req.Phone != agent.Phone {
+		exists, err := uc.agentRepo.ExistsByUsernameOrPhone(ctx, agent.Username, *req.Phone, agent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check agent: %w", err)
+		}
+		if exists {
+			return nil, utils.ErrAgentAlreadyExists
+		}
+		agent.Phone = *req.Phone
+	}
+	if req.Name != nil {
+		agent.Name = *req.Name
+	}
+	if req.Email != nil {
+		agent.Email = req.Email
+	}
+	if req.Address != nil {
+		agent.Address = req.Address
+	}
+	if req.Status != nil {
+		agent.Status = entity.AgentStatus(*req.Status)
+	}
+	if req.CommissionRate != nil {
+		agent.CommissionRate = *req.CommissionRate
+	}
+	if req.Password != nil {
+		hash, err := uc.passwordService.Hash(*req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		agent.PasswordHash = hash
+	}
+
+	if err := uc.agentRepo.Update(ctx, agent); err != nil {
+		return nil, fmt.Errorf("failed to update agent: %w", err)
+	}
+
+	return agent, nil
+}
+
+// Login authenticates an agent and issues a token with the agent role
+func (uc *agentUsecase) Login(ctx context.Context, req model.AgentLoginRequest) (*model.AgentLoginResponse, error) {
+	agent, err := uc.agentRepo.GetByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := uc.passwordService.Verify(agent.PasswordHash, req.Password); err != nil {
+		pkg_logger.Warn("Failed agent login attempt", zap.String("username", req.Username))
+		return nil, utils.ErrInvalidCredentials
+	}
+	if agent.Status != entity.AgentStatusActive {
+		return nil, utils.ErrAgentInactive
+	}
+
+	token, expiresAt, err := uc.jwtService.GenerateAgentToken(agent.ID, agent.Username, entity.AgentRole)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := uc.agentRepo.UpdateLastLogin(ctx, agent.ID, now); err != nil {
+		pkg_logger.Warn("Failed to update agent last login", zap.Error(err))
+	}
+	agent.LastLogin = &now
+
+	pkg_logger.Info("Agent logged in",
+		zap.String("agent_id", agent.ID),
+		zap.String("username", agent.Username),
+	)
+
+	return &model.AgentLoginResponse{
+		Token:     token,
+		ExpiresAt: time.Unix(expiresAt, 0),
+		Agent:     agent,
+	}, nil
+}
+
+// ListTransactions retrieves an agent's ledger, newest first
+func (uc *agentUsecase) ListTransactions(ctx context.Context, agentID string, req model.AgentTransactionListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	filter := repository.AgentTransactionFilter{
+		AgentID:  agentID,
+		Type:     req.Type,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	if req.From != "" {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from", utils.ErrInvalidDate)
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := parseDate(req.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to", utils.ErrInvalidDate)
+		}
+		// Inclusive end date
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	txns, total, err := uc.agentRepo.ListTransactions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent transactions: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, txns), nil
+}
+
+// RequestTopUp files a pending top-up request
+func (uc *agentUsecase) RequestTopUp(ctx context.Context, agentID string, req model.CreateBalanceRequestRequest) (*entity.AgentBalanceRequest, error) {
+	if _, err := uc.activeAgent(ctx, agentID); err != nil {
+		return nil, err
+	}
+
+	request := &entity.AgentBalanceRequest{
+		AgentID: agentID,
+		Amount:  req.Amount,
+		Status:  entity.AgentRequestPending,
+	}
+	if req.Notes != "" {
+		request.Notes = &req.Notes
+	}
+
+	if err := uc.agentRepo.CreateBalanceRequest(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create balance request: %w", err)
+	}
+
+	pkg_logger.Info("Agent top-up requested",
+		zap.String("agent_id", agentID),
+		zap.String("request_id", request.ID),
+		zap.Float64("amount", request.Amount),
+	)
+
+	return request, nil
+}
+
+// ListBalanceRequests retrieves paginated top-up requests
+func (uc *agentUsecase) ListBalanceRequests(ctx context.Context, req model.BalanceRequestListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	reqs, total, err := uc.agentRepo.ListBalanceRequests(ctx, repository.AgentBalanceRequestFilter{
+		AgentID:  req.AgentID,
+		Status:   req.Status,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance requests: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, reqs), nil
+}
+
+// ProcessTopUp approves (crediting the balance) or rejects a pending top-up request
+func (uc *agentUsecase) ProcessTopUp(ctx context.Context, id string, approve bool, req model.ProcessBalanceRequestRequest, processedBy *int64) (*entity.AgentBalanceRequest, error) {
+	var adminNotes *string
+	if req.AdminNotes != "" {
+		adminNotes = &req.AdminNotes
+	}
+
+	request, err := uc.agentRepo.ProcessBalanceRequest(ctx, id, approve, adminNotes, processedBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrBalanceRequestNotFound
+		}
+		return nil, err
+	}
+
+	pkg_logger.Info("Agent top-up processed",
+		zap.String("request_id", request.ID),
+		zap.String("agent_id", request.AgentID),
+		zap.String("status", string(request.Status)),
+		zap.Float64("amount", request.Amount),
+	)
+
+	return request, nil
+}
+
+// CollectPayment records a field payment and settles it against the agent's balance
+func (uc *agentUsecase) CollectPayment(ctx context.Context, agentID, invoiceID string, req model.AgentCollectPaymentRequest) (*entity.Payment, *entity.Invoice, error) {
+	agent, err := uc.activeAgent(ctx, agentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notes := "Collected by agent " + agent.Username
+	if req.Notes != "" {
+		notes += ": " + req.Notes
+	}
+	payment := &entity.Payment{
+		InvoiceID:     invoiceID,
+		Amount:        req.Amount,
+		PaymentMethod: entity.PaymentMethodCash,
+		PaymentDate:   time.Now(),
+		Notes:         &notes,
+	}
+	if req.ReferenceNumber != "" {
+		payment.ReferenceNumber = &req.ReferenceNumber
+	}
+
+	inv, err := uc.agentRepo.CollectPayment(ctx, agent.ID, payment,
+		func(inv *entity.Invoice) error {
+			if inv.Status == entity.InvoiceStatusPaid || inv.Status == entity.InvoiceStatusCancelled {
+				return utils.ErrInvoiceNotPayable
+			}
+			if req.Amount > inv.Outstanding() {
+				return fmt.Errorf("%w: outstanding %.2f", utils.ErrPaymentExceedsBalance, inv.Outstanding())
+			}
+			return nil
+		},
+		func(p *entity.Payment) []*entity.AgentTransaction {
+			return agentEntries(agent, entity.AgentTransactionMonthlyPayment, p.Amount, "payment", p.ID,
+				"Customer payment "+p.ReceiptNumber)
+		},
+	)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, utils.ErrInvoiceNotFound
+		}
+		return nil, nil, err
+	}
+
+	uc.paymentUsecase.OnPaymentRecorded(ctx, payment, inv)
+
+	return payment, inv, nil
+}
+
+// RecordVoucherSale records a voucher the agent sold and settles it against the balance
+func (uc *agentUsecase) RecordVoucherSale(ctx context.Context, agentID string, req model.AgentVoucherSaleRequest) (*entity.AgentVoucherSale, error) {
+	agent, err := uc.activeAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.mikrotikRepo.GetByID(ctx, req.MikrotikID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMikrotikNotFound
+		}
+		return nil, fmt.Errorf("failed to get mikrotik: %w", err)
+	}
+
+	sale := &entity.AgentVoucherSale{
+		AgentID:          agent.ID,
+		MikrotikID:       req.MikrotikID,
+		VoucherCode:      req.VoucherCode,
+		ProfileName:      req.ProfileName,
+		Price:            req.Price,
+		CommissionAmount: agent.Commission(req.Price),
+		SoldAt:           time.Now(),
+	}
+	if req.CustomerName != "" {
+		sale.CustomerName = &req.CustomerName
+	}
+	if req.CustomerPhone != "" {
+		sale.CustomerPhone = &req.CustomerPhone
+	}
+
+	err = uc.agentRepo.RecordVoucherSale(ctx, sale, func(s *entity.AgentVoucherSale) []*entity.AgentTransaction {
+		return agentEntries(agent, entity.AgentTransactionVoucherSale, s.Price, "voucher_sale", s.ID,
+			"Voucher "+s.VoucherCode+" ("+s.ProfileName+")")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Agent voucher sale recorded",
+		zap.String("agent_id", agent.ID),
+		zap.String("voucher_code", sale.VoucherCode),
+		zap.Float64("price", sale.Price),
+		zap.Float64("commission", sale.CommissionAmount),
+	)
+
+	return sale, nil
+}
+
+// ListVoucherSales retrieves an agent's voucher sales, newest first
+func (uc *agentUsecase) ListVoucherSales(ctx context.Context, agentID string, page, pageSize int) (*model.PaginationResponse, error) {
+	page, pageSize = normalizePage(page, pageSize)
+
+	sales, total, err := uc.agentRepo.ListVoucherSales(ctx, agentID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voucher sales: %w", err)
+	}
+
+	return paginationResponse(page, pageSize, total, sales), nil
+}
+
+// activeAgent loads an agent that is allowed to transact
+func (uc *agentUsecase) activeAgent(ctx context.Context, id string) (*entity.Agent, error) {
+	agent, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if agent.Status != entity.AgentStatusActive {
+		return nil, utils.ErrAgentInactive
+	}
+	return agent, nil
+}
+
+// agentEntries builds the pair of ledger entries for a sale or collection: the amount owed
+// to the company is debited and the agent's commission on it credited back.
+func agentEntries(agent *entity.Agent, txType entity.AgentTransactionType, amount float64, refType, refID, description string) []*entity.AgentTransaction {
+	entries := []*entity.AgentTransaction{{
+		TransactionType: txType,
+		Amount:          -amount,
+		Description:     &description,
+		ReferenceType:   &refType,
+		ReferenceID:     &refID,
+	}}
+
+	if commission := agent.Commission(amount); commission > 0 {
+		commissionDesc := fmt.Sprintf("Commission %.2f%% on %s", agent.CommissionRate, description)
+		entries = append(entries, &entity.AgentTransaction{
+			TransactionType: entity.AgentTransactionCommission,
+			Amount:          commission,
+			Description:     &commissionDesc,
+			ReferenceType:   &refType,
+			ReferenceID:     &refID,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,1026 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/hotspot"
+	"mikrobill/internal/infrastructure/mikrotik/ppp"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"mikrobill/pkg/utils"
+	"mikrobill/pkg/xlsx"
+	"net"
+	"net/mail"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CustomerImportUsecase brings existing subscribers into the database. A router
+// import keeps the router's secrets and users as they are and only writes customer
+// rows; a spreadsheet import can provision the router as well. Large spreadsheets
+// are queued and processed by the background workers.
+type CustomerImportUsecase interface {
+	ImportFromRouter(ctx context.Context, mikrotikID string, req model.RouterImportRequest) (*model.ImportResult, error)
+
+	ImportFile(ctx context.Context, req model.FileImportRequest, fileName string, data []byte) (*model.FileImportResponse, error)
+	GetImportJob(ctx context.Context, id string) (*model.ImportJobResponse, error)
+	// RunImportJob processes a queued import; it is called by the worker
+	RunImportJob(ctx context.Context, id string) error
+
+	// Export writes the filtered customers to w as CSV or XLSX
+	Export(ctx context.Context, req model.CustomerExportRequest, w io.Writer) error
+}
+
+// TaskImportCustomers processes a queued spreadsheet import
+const TaskImportCustomers = "customers:import"
+
+// ImportCustomersPayload is the payload of TaskImportCustomers
+type ImportCustomersPayload struct {
+	JobID string `json:"job_id"`
+}
+
+// TaskQueue hands tasks to the background workers; *queue.Client implements it
+type TaskQueue interface {
+	EnqueueContext(ctx context.Context, taskType string, payload interface{}, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+type customerImportUsecase struct {
+	customerRepo    entity.CustomerRepository
+	profileRepo     entity.ProfileRepository
+	mikrotikRepo    repository.MikrotikRepository
+	voucherRepo     repository.VoucherRepository
+	importRepo      repository.CustomerImportRepository
+	mikrotikUseCase MikrotikUseCase
+	profileService  *ProfileService
+	customerService *CustomerService
+	jobs            TaskQueue
+	cipher          *utils.Cipher
+}
+
+func NewCustomerImportUsecase(
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	voucherRepo repository.VoucherRepository,
+	importRepo repository.CustomerImportRepository,
+	mikrotikUseCase MikrotikUseCase,
+	profileService *ProfileService,
+	customerService *CustomerService,
+	jobs TaskQueue,
+	cipher *utils.Cipher,
+) CustomerImportUsecase {
+	return &customerImportUsecase{
+		customerRepo:    customerRepo,
+		profileRepo:     profileRepo,
+		mikrotikRepo:    mikrotikRepo,
+		voucherRepo:     voucherRepo,
+		importRepo:      importRepo,
+		mikrotikUseCase: mikrotikUseCase,
+		profileService:  profileService,
+		customerService: customerService,
+		jobs:            jobs,
+		cipher:          cipher,
+	}
+}
+
+// routerLogin is a PPP secret or hotspot user mapped to the customer it becomes
+type routerLogin struct {
+	serviceType string
+	username    string
+	password    string
+	profile     string
+	comment     string
+	disabled    bool
+}
+
+func (uc *customerImportUsecase) ImportFromRouter(ctx context.Context, mikrotikID string, req model.RouterImportRequest) (*model.ImportResult, error) {
+	mk, err := uc.getMikrotik(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+	services := req.Services
+	if len(services) == 0 {
+		services = []string{"pppoe", "hotspot"}
+	}
+
+	var secrets, users []map[string]string
+	err = uc.withClient(ctx, mikrotikID, func(client *mikrotik.Client) error {
+		if slices.Contains(services, "pppoe") {
+			resp, err := ppp.NewService(client).GetAllSecrets()
+			if err != nil {
+				return routerError("list ppp secrets", err)
+			}
+			secrets, _ = resp.Data.([]map[string]string)
+		}
+		if slices.Contains(services, "hotspot") {
+			resp, err := hotspot.NewService(client).GetAllUsers()
+			if err != nil {
+				return routerError("list hotspot users", err)
+			}
+			users, _ = resp.Data.([]map[string]string)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{DryRun: req.DryRun, Rows: make([]model.ImportRow, 0, len(secrets)+len(users))}
+	profiles := make(map[string]*string)
+	for _, s := range secrets {
+		login := routerLogin{
+			serviceType: "pppoe",
+			username:    s["name"],
+			password:    s["password"],
+			profile:     s["profile"],
+			comment:     s["comment"],
+			disabled:    routerBool(s["disabled"]),
+		}
+		if service := s["service"]; service != "" && service != "any" && service != "pppoe" {
+			addImportRow(result, login, "", model.ImportSkipped, "service "+service+" is not pppoe")
+			continue
+		}
+		uc.importLogin(mk, login, req, profiles, result)
+	}
+
+	vouchers := voucherUsers(ctx, uc.voucherRepo, mikrotikID, users)
+	for _, u := range users {
+		login := routerLogin{
+			serviceType: "hotspot",
+			username:    u["name"],
+			password:    u["password"],
+			profile:     u["profile"],
+			comment:     u["comment"],
+			disabled:    routerBool(u["disabled"]),
+		}
+		if vouchers[login.username] || login.username == "default-trial" || strings.HasPrefix(login.comment, "vc-") {
+			addImportRow(result, login, "", model.ImportSkipped, "voucher user")
+			continue
+		}
+		uc.importLogin(mk, login, req, profiles, result)
+	}
+
+	pkg_logger.Info("Customers imported from router",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("total", result.Total),
+		zap.Int("created", result.Created),
+		zap.Int("merged", result.Merged),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+	return result, nil
+}
+
+// importLogin creates the customer of one router login, or merges it into the
+// customer already using the login
+func (uc *customerImportUsecase) importLogin(mk *entity.Mikrotik, login routerLogin, req model.RouterImportRequest, profiles map[string]*string, result *model.ImportResult) {
+	if login.username == "" {
+		addImportRow(result, login, "", model.ImportSkipped, "no username")
+		return
+	}
+
+	existing, err := uc.customerRepo.GetCustomerBySession(mk.ID, login.serviceType, login.username)
+	if err != nil {
+		addImportRow(result, login, "", model.ImportFailed, err.Error())
+		return
+	}
+	if existing != nil && req.OnDuplicate != "merge" {
+		addImportRow(result, login, existing.ID, model.ImportSkipped, "customer already exists")
+		return
+	}
+
+	profileID, note, err := uc.resolveProfile(mk, login, req, profiles)
+	if err != nil {
+		addImportRow(result, login, "", model.ImportFailed, err.Error())
+		return
+	}
+
+	if existing != nil {
+		if !req.DryRun {
+			if err := uc.merge(existing, login, profileID); err != nil {
+				addImportRow(result, login, existing.ID, model.ImportFailed, err.Error())
+				return
+			}
+		}
+		addImportRow(result, login, existing.ID, model.ImportMerged, note)
+		return
+	}
+
+	customer := importedCustomer(mk.ID, login, profileID)
+	if !req.DryRun {
+		if err := sealCustomerSecrets(uc.cipher, customer); err != nil {
+			addImportRow(result, login, "", model.ImportFailed, err.Error())
+			return
+		}
+		if err := uc.customerRepo.CreateCustomer(customer); err != nil {
+			addImportRow(result, login, "", model.ImportFailed, err.Error())
+			return
+		}
+	}
+	customerID := customer.ID
+	if req.DryRun {
+		customerID = ""
+	}
+	addImportRow(result, login, customerID, model.ImportCreated, note)
+}
+
+// resolveProfile finds the database profile of the login's router profile. The
+// router's default, isolation and fair usage profiles are not plans: the first two
+// leave the customer without one, a fair usage profile maps to its plan.
+func (uc *customerImportUsecase) resolveProfile(mk *entity.Mikrotik, login routerLogin, req model.RouterImportRequest, profiles map[string]*string) (*string, string, error) {
+	name := login.profile
+	switch name {
+	case "", "default", "default-encryption":
+		return nil, "", nil
+	case mk.IsolationProfile:
+		return nil, "isolation profile, plan not set", nil
+	}
+
+	key := login.serviceType + "/" + name
+	if id, ok := profiles[key]; ok {
+		if id == nil {
+			return nil, "profile " + name + " is not in the database", nil
+		}
+		return id, "", nil
+	}
+
+	profile, err := uc.profileRepo.GetProfileByName(mk.ID, name)
+	if plan, ok := entity.PlanNameFromFUP(name); err != nil && login.serviceType == "pppoe" && ok {
+		profile, err = uc.profileRepo.GetProfileByName(mk.ID, plan)
+	}
+	if err != nil && login.serviceType == "pppoe" && req.ImportProfiles {
+		if req.DryRun {
+			return nil, "profile " + name + " would be imported", nil
+		}
+		if err := uc.profileService.SyncProfileFromMikrotik(mk.ID, name); err != nil {
+			return nil, "", fmt.Errorf("failed to import profile %s: %w", name, err)
+		}
+		profile, err = uc.profileRepo.GetProfileByName(mk.ID, name)
+	}
+	if err != nil {
+		profiles[key] = nil
+		return nil, "profile " + name + " is not in the database", nil
+	}
+
+	profiles[key] = &profile.ID
+	return &profile.ID, "", nil
+}
+
+// merge takes the router's password and profile into an existing customer
+func (uc *customerImportUsecase) merge(existing *entity.Customer, login routerLogin, profileID *string) error {
+	changes := &entity.Customer{ID: existing.ID, Name: existing.Name}
+	var password *string
+	if login.password != "" {
+		password = &login.password
+	}
+	if login.serviceType == "hotspot" {
+		changes.HotspotPassword, changes.HotspotProfileID = password, profileID
+	} else {
+		changes.PPPoEPassword, changes.PPPoEProfileID = password, profileID
+	}
+
+	if err := sealCustomerSecrets(uc.cipher, changes); err != nil {
+		return err
+	}
+	return uc.customerRepo.UpdateCustomer(changes)
+}
+
+// importedCustomer maps a router login to a new customer. A disabled login is
+// imported suspended, so reactivating the customer enables it again.
+func importedCustomer(mikrotikID string, login routerLogin, profileID *string) *entity.Customer {
+	username, password := login.username, login.password
+	name := login.comment
+	if login.serviceType == "hotspot" {
+		// Hotspot users created by mikrobill carry an up- prefix on the comment
+		name = strings.TrimPrefix(name, "up-")
+	}
+	if name == "" {
+		name = username
+	}
+
+	customer := &entity.Customer{
+		ID:          uuid.New().String(),
+		MikrotikID:  mikrotikID,
+		Username:    username,
+		Name:        name,
+		ServiceType: login.serviceType,
+		Status:      "active",
+	}
+	if login.serviceType == "hotspot" {
+		customer.HotspotUsername, customer.HotspotPassword, customer.HotspotProfileID = &username, &password, profileID
+	} else {
+		customer.PPPoEUsername, customer.PPPoEPassword, customer.PPPoEProfileID = &username, &password, profileID
+	}
+
+	if login.disabled {
+		now := time.Now()
+		reason := entity.SuspensionReasonManual
+		customer.Status, customer.SuspendedAt, customer.SuspensionReason = "suspended", &now, &reason
+	}
+	return customer
+}
+
+// addImportRow records the outcome of one login
+func addImportRow(result *model.ImportResult, login routerLogin, customerID, outcome, message string) {
+	recordImportRow(result, model.ImportRow{
+		ServiceType: login.serviceType,
+		Username:    login.username,
+		Profile:     login.profile,
+		Result:      outcome,
+		CustomerID:  customerID,
+		Message:     message,
+	})
+}
+
+// recordImportRow adds a row to the result and counts its outcome
+func recordImportRow(result *model.ImportResult, row model.ImportRow) {
+	result.Total++
+	switch row.Result {
+	case model.ImportCreated:
+		result.Created++
+	case model.ImportMerged:
+		result.Merged++
+	case model.ImportSkipped:
+		result.Skipped++
+	case model.ImportFailed:
+		result.Failed++
+	}
+	result.Rows = append(result.Rows, row)
+}
+
+// withClient connects to the router for the length of fn
+func (uc *customerImportUsecase) withClient(ctx context.Context, mikrotikID string, fn func(client *mikrotik.Client) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(client)
+}
+
+// importFields are the customer fields a spreadsheet column can be mapped to
+var importFields = []string{
+	"username", "name", "service_type", "status",
+	"phone", "email", "address",
+	"pppoe_username", "pppoe_password",
+	"hotspot_username", "hotspot_password",
+	"profile", "static_ip", "mac_address",
+	"billing_day", "auto_suspension",
+}
+
+// importQueueThreshold is the number of rows above which an import is queued
+// instead of run in the request
+const importQueueThreshold = 200
+
+// fileRow is a spreadsheet row keyed by customer field. Rows of queued imports are
+// stored in the job with their passwords sealed.
+type fileRow struct {
+	Line   int               `json:"line"`
+	Fields map[string]string `json:"fields"`
+}
+
+func (uc *customerImportUsecase) ImportFile(ctx context.Context, req model.FileImportRequest, fileName string, data []byte) (*model.FileImportResponse, error) {
+	mk, err := uc.getMikrotik(ctx, req.MikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[string]string)
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, fmt.Errorf("%w: mapping must be a JSON object of field to column", utils.ErrInvalidImportFile)
+		}
+	}
+	rows, err := readImportFile(fileName, data, mapping)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for _, field := range []string{"pppoe_password", "hotspot_password"} {
+			if secret := row.Fields[field]; secret != "" {
+				if row.Fields[field], err = uc.cipher.Encrypt(secret); err != nil {
+					return nil, fmt.Errorf("failed to encrypt customer password: %w", err)
+				}
+			}
+		}
+	}
+
+	if !req.DryRun && (req.Async || len(rows) > importQueueThreshold) {
+		job, err := uc.queueImport(ctx, mk.ID, fileName, rows, req.Provision)
+		if err != nil {
+			return nil, err
+		}
+		return &model.FileImportResponse{Queued: true, Job: job}, nil
+	}
+
+	result, err := uc.importRows(ctx, mk, rows, req.DryRun, req.Provision)
+	if err != nil {
+		return nil, err
+	}
+	pkg_logger.Info("Customers imported from file",
+		zap.String("mikrotik_id", mk.ID),
+		zap.String("file", fileName),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("total", result.Total),
+		zap.Int("created", result.Created),
+		zap.Int("failed", result.Failed),
+	)
+	return &model.FileImportResponse{Result: result}, nil
+}
+
+// queueImport stores the rows as a job and hands it to the workers
+func (uc *customerImportUsecase) queueImport(ctx context.Context, mikrotikID, fileName string, rows []fileRow, provision bool) (*model.ImportJobResponse, error) {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode import rows: %w", err)
+	}
+	job := &entity.CustomerImportJob{
+		ID:         uuid.New().String(),
+		MikrotikID: mikrotikID,
+		FileName:   fileName,
+		Status:     entity.ImportJobPending,
+		Provision:  provision,
+		Rows:       data,
+		TotalRows:  len(rows),
+	}
+	if err := uc.importRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	opts := queue.LowPriorityTask
+	opts.Timeout = 30 * time.Minute
+	if _, err := uc.jobs.EnqueueContext(ctx, TaskImportCustomers, ImportCustomersPayload{JobID: job.ID}, opts.ToAsynqOptions()...); err != nil {
+		msg := err.Error()
+		if finishErr := uc.importRepo.Finish(ctx, job.ID, entity.ImportJobFailed, nil, &msg); finishErr != nil {
+			pkg_logger.Error("Failed to mark import job failed", zap.String("job_id", job.ID), zap.Error(finishErr))
+		}
+		return nil, fmt.Errorf("failed to queue import: %w", err)
+	}
+
+	pkg_logger.Info("Customer import queued",
+		zap.String("job_id", job.ID),
+		zap.String("mikrotik_id", mikrotikID),
+		zap.Int("rows", len(rows)),
+	)
+	return importJobResponse(job), nil
+}
+
+func (uc *customerImportUsecase) RunImportJob(ctx context.Context, id string) error {
+	job, err := uc.importRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrImportJobNotFound
+		}
+		return fmt.Errorf("failed to get import job: %w", err)
+	}
+	started, err := uc.importRepo.Start(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to start import job: %w", err)
+	}
+	if !started {
+		pkg_logger.Info("Import job already taken, skipping", zap.String("job_id", id), zap.String("status", job.Status))
+		return nil
+	}
+
+	// The job is running from here on: a failure finishes it rather than being
+	// retried, since a retry would find it no longer pending
+	var rows []fileRow
+	result, err := func() (*model.ImportResult, error) {
+		if err := json.Unmarshal(job.Rows, &rows); err != nil {
+			return nil, fmt.Errorf("failed to decode import rows: %w", err)
+		}
+		mk, err := uc.getMikrotik(ctx, job.MikrotikID)
+		if err != nil {
+			return nil, err
+		}
+		return uc.importRows(ctx, mk, rows, false, job.Provision)
+	}()
+	if err != nil {
+		msg := err.Error()
+		pkg_logger.Error("Import job failed", zap.String("job_id", id), zap.Error(err))
+		return uc.importRepo.Finish(ctx, id, entity.ImportJobFailed, nil, &msg)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode import result: %w", err)
+	}
+	pkg_logger.Info("Import job completed",
+		zap.String("job_id", id),
+		zap.Int("total", result.Total),
+		zap.Int("created", result.Created),
+		zap.Int("failed", result.Failed),
+	)
+	return uc.importRepo.Finish(ctx, id, entity.ImportJobCompleted, data, nil)
+}
+
+func (uc *customerImportUsecase) GetImportJob(ctx context.Context, id string) (*model.ImportJobResponse, error) {
+	job, err := uc.importRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return importJobResponse(job), nil
+}
+
+// importRows validates the rows and creates the customers of the valid ones. A
+// row is rejected when it repeats a value that is unique per router, either of an
+// earlier row or of an existing customer.
+func (uc *customerImportUsecase) importRows(ctx context.Context, mk *entity.Mikrotik, rows []fileRow, dryRun, provision bool) (*model.ImportResult, error) {
+	customers := make([]*entity.Customer, len(rows))
+	problems := make([]string, len(rows))
+	profiles := make(map[string]*entity.ProfileWithPPPoE)
+	seen := make(map[string]map[string]int)
+	for i, row := range rows {
+		customer, err := uc.rowCustomer(mk, row, profiles)
+		if err != nil {
+			problems[i] = err.Error()
+			continue
+		}
+		for column, value := range uniqueValues(customer) {
+			if seen[column] == nil {
+				seen[column] = make(map[string]int)
+			}
+			if line, ok := seen[column][value]; ok {
+				problems[i] = fmt.Sprintf("%s %s repeats line %d", column, value, line)
+				break
+			}
+			seen[column][value] = row.Line
+		}
+		if problems[i] == "" {
+			customers[i] = customer
+		}
+	}
+
+	taken := make(map[string]map[string]bool, len(seen))
+	for column, values := range seen {
+		list := make([]string, 0, len(values))
+		for value := range values {
+			list = append(list, value)
+		}
+		found, err := uc.customerRepo.GetTakenValues(mk.ID, column, list)
+		if err != nil {
+			return nil, err
+		}
+		taken[column] = found
+	}
+
+	result := &model.ImportResult{DryRun: dryRun, Rows: make([]model.ImportRow, 0, len(rows))}
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		username := row.Fields["username"]
+		if username == "" {
+			username = row.Fields["pppoe_username"] + row.Fields["hotspot_username"]
+		}
+		entry := model.ImportRow{
+			Line:        row.Line,
+			ServiceType: row.Fields["service_type"],
+			Username:    username,
+			Profile:     row.Fields["profile"],
+			Result:      model.ImportFailed,
+			Message:     problems[i],
+		}
+		customer := customers[i]
+		if customer == nil {
+			recordImportRow(result, entry)
+			continue
+		}
+		entry.ServiceType, entry.Username = customer.ServiceType, customer.Username
+		for column, value := range uniqueValues(customer) {
+			if taken[column][value] {
+				entry.Message = fmt.Sprintf("%s %s is already used by another customer", column, value)
+				break
+			}
+		}
+		if entry.Message != "" {
+			recordImportRow(result, entry)
+			continue
+		}
+
+		if !dryRun {
+			var err error
+			if provision {
+				err = uc.customerService.CreateCustomer(customer)
+			} else if err = sealCustomerSecrets(uc.cipher, customer); err == nil {
+				err = uc.customerRepo.CreateCustomer(customer)
+			}
+			if err != nil {
+				entry.Message = err.Error()
+				recordImportRow(result, entry)
+				continue
+			}
+			entry.CustomerID = customer.ID
+		}
+		entry.Result = model.ImportCreated
+		recordImportRow(result, entry)
+	}
+	return result, nil
+}
+
+// rowCustomer validates a row and maps it to a new customer. The login username
+// and the customer's username default to each other, the name to the username.
+func (uc *customerImportUsecase) rowCustomer(mk *entity.Mikrotik, row fileRow, profiles map[string]*entity.ProfileWithPPPoE) (*entity.Customer, error) {
+	f := row.Fields
+	serviceType := f["service_type"]
+	if serviceType == "" {
+		switch {
+		case f["static_ip"] != "":
+			serviceType = "static_ip"
+		case f["hotspot_username"] != "" || f["hotspot_password"] != "":
+			serviceType = "hotspot"
+		default:
+			serviceType = "pppoe"
+		}
+	}
+
+	username := f["username"]
+	login := f[serviceType+"_username"]
+	if username == "" {
+		username = login
+	}
+	if login == "" {
+		login = username
+	}
+	if username == "" {
+		return nil, errors.New("username is required")
+	}
+	name := f["name"]
+	if name == "" {
+		name = username
+	}
+
+	status := f["status"]
+	switch status {
+	case "":
+		status = "inactive"
+	case "active", "inactive", "pending":
+	default:
+		return nil, fmt.Errorf("status %s is not one of active, inactive, pending", status)
+	}
+
+	customer := &entity.Customer{
+		ID:          uuid.New().String(),
+		MikrotikID:  mk.ID,
+		Username:    username,
+		Name:        name,
+		ServiceType: serviceType,
+		Status:      status,
+		Phone:       optionalField(f, "phone"),
+		Email:       optionalField(f, "email"),
+		Address:     optionalField(f, "address"),
+	}
+	if customer.Email != nil {
+		if _, err := mail.ParseAddress(*customer.Email); err != nil {
+			return nil, fmt.Errorf("email %s is not valid", *customer.Email)
+		}
+	}
+	if mac := f["mac_address"]; mac != "" {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return nil, fmt.Errorf("mac_address %s is not valid", mac)
+		}
+		customer.MacAddress = &mac
+	}
+	if day := f["billing_day"]; day != "" {
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 1 || n > 31 {
+			return nil, fmt.Errorf("billing_day %s is not a day of the month", day)
+		}
+		customer.BillingDay = n
+	}
+	if value := f["auto_suspension"]; value != "" {
+		enabled, err := parseImportBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("auto_suspension %s is not yes or no", value)
+		}
+		customer.AutoSuspension = &enabled
+	}
+
+	var profileID *string
+	if name := f["profile"]; name != "" {
+		profile, ok := profiles[name]
+		if !ok {
+			profile, _ = uc.profileRepo.GetProfileByName(mk.ID, name)
+			profiles[name] = profile
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("profile %s is not in the database", name)
+		}
+		if profile.ProfileType != serviceType {
+			return nil, fmt.Errorf("profile %s is a %s profile", name, profile.ProfileType)
+		}
+		profileID = &profile.ID
+	}
+
+	switch serviceType {
+	case "pppoe":
+		password, err := uc.rowPassword(f, "pppoe_password")
+		if err != nil {
+			return nil, err
+		}
+		customer.PPPoEUsername, customer.PPPoEPassword, customer.PPPoEProfileID = &login, &password, profileID
+	case "hotspot":
+		password, err := uc.rowPassword(f, "hotspot_password")
+		if err != nil {
+			return nil, err
+		}
+		customer.HotspotUsername, customer.HotspotPassword, customer.HotspotProfileID = &login, &password, profileID
+	case "static_ip":
+		ip := f["static_ip"]
+		if parsed := net.ParseIP(ip); parsed == nil || parsed.To4() == nil {
+			return nil, fmt.Errorf("static_ip %q is not an IPv4 address", ip)
+		}
+		if customer.MacAddress == nil {
+			return nil, errors.New("mac_address is required for static_ip")
+		}
+		if profileID == nil {
+			return nil, errors.New("profile is required for static_ip")
+		}
+		customer.StaticIP, customer.StaticIPProfileID = &ip, profileID
+	default:
+		return nil, fmt.Errorf("service_type %s is not one of pppoe, hotspot, static_ip", serviceType)
+	}
+	return customer, nil
+}
+
+// rowPassword opens a password the row holds sealed, so it is sealed again like
+// any password a client sends
+func (uc *customerImportUsecase) rowPassword(f map[string]string, field string) (string, error) {
+	if f[field] == "" {
+		return "", fmt.Errorf("%s is required", field)
+	}
+	password, err := uc.cipher.Decrypt(f[field])
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
+	}
+	return password, nil
+}
+
+// uniqueValues returns the customer's values of the columns unique per router
+func uniqueValues(c *entity.Customer) map[string]string {
+	values := map[string]string{"username": c.Username}
+	for column, value := range map[string]*string{
+		"phone":            c.Phone,
+		"pppoe_username":   c.PPPoEUsername,
+		"hotspot_username": c.HotspotUsername,
+		"static_ip":        c.StaticIP,
+	} {
+		if value != nil && *value != "" {
+			values[column] = *value
+		}
+	}
+	return values
+}
+
+func optionalField(fields map[string]string, field string) *string {
+	if value := fields[field]; value != "" {
+		return &value
+	}
+	return nil
+}
+
+func parseImportBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y", "ya":
+		return true, nil
+	case "no", "n", "tidak":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// readImportFile reads a CSV or XLSX file into rows keyed by customer field. The
+// first row is the header; mapping names the column of a field whose column is not
+// named like the field.
+func readImportFile(fileName string, data []byte, mapping map[string]string) ([]fileRow, error) {
+	var records [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		records, err = readCSV(data)
+	case ".xlsx":
+		records, err = xlsx.ReadRows(data)
+	default:
+		return nil, utils.ErrUnsupportedFileFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidImportFile, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: the file has no rows below the header", utils.ErrInvalidImportFile)
+	}
+
+	for field := range mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("%w: mapping names unknown field %s", utils.ErrInvalidImportFile, field)
+		}
+	}
+	headers := make(map[string]int, len(records[0]))
+	for i, header := range records[0] {
+		headers[normalizeHeader(header)] = i
+	}
+	columns := make(map[string]int)
+	for _, field := range importFields {
+		header, mapped := mapping[field]
+		if !mapped {
+			header = field
+		}
+		if i, ok := headers[normalizeHeader(header)]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("%w: column %s mapped to %s is not in the file", utils.ErrInvalidImportFile, header, field)
+		}
+	}
+	_, hasUsername := columns["username"]
+	_, hasPPPoE := columns["pppoe_username"]
+	_, hasHotspot := columns["hotspot_username"]
+	if !hasUsername && !hasPPPoE && !hasHotspot {
+		return nil, fmt.Errorf("%w: no username, pppoe_username or hotspot_username column", utils.ErrInvalidImportFile)
+	}
+
+	rows := make([]fileRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		fields := make(map[string]string, len(columns))
+		for field, col := range columns {
+			if col < len(record) {
+				if value := strings.TrimSpace(record[col]); value != "" {
+					fields[field] = value
+				}
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		rows = append(rows, fileRow{Line: i + 2, Fields: fields})
+	}
+	return rows, nil
+}
+
+// readCSV reads comma or semicolon separated values; spreadsheets set to a locale
+// with a decimal comma save the latter
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+// normalizeHeader makes "PPPoE Username" and "pppoe-username" match pppoe_username
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+// exportColumns are the columns of an export; they are named like the import
+// fields, so an exported file can be imported into another router
+var exportColumns = []string{
+	"id", "username", "name", "service_type", "status",
+	"phone", "email", "address",
+	"pppoe_username", "hotspot_username",
+	"profile", "static_ip", "mac_address",
+	"billing_day", "auto_suspension",
+}
+
+func (uc *customerImportUsecase) Export(ctx context.Context, req model.CustomerExportRequest, w io.Writer) error {
+	var writeRow func([]string) error
+	var finish func() error
+	switch req.Format {
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		writeRow = cw.Write
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case "xlsx":
+		xw, err := xlsx.NewWriter(w, "Customers")
+		if err != nil {
+			return err
+		}
+		writeRow, finish = xw.WriteRow, xw.Close
+	default:
+		return utils.ErrUnsupportedFileFormat
+	}
+
+	if err := writeRow(exportColumns); err != nil {
+		return err
+	}
+	filter := customerFilter(req.CustomerFilterParams)
+	profiles := make(map[string]string)
+	err := uc.customerRepo.ExportCustomers(filter, 500, func(batch []*entity.Customer) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, c := range batch {
+			if err := writeRow(uc.exportRecord(c, profiles)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return finish()
+}
+
+// exportRecord maps a customer to an export row; passwords are left out
+func (uc *customerImportUsecase) exportRecord(c *entity.Customer, profiles map[string]string) []string {
+	profileID := c.PPPoEProfileID
+	switch c.ServiceType {
+	case "hotspot":
+		profileID = c.HotspotProfileID
+	case "static_ip":
+		profileID = c.StaticIPProfileID
+	}
+	profile := ""
+	if profileID != nil {
+		name, ok := profiles[*profileID]
+		if !ok {
+			if p, err := uc.profileRepo.GetProfileByID(*profileID); err == nil {
+				name = p.Name
+			}
+			profiles[*profileID] = name
+		}
+		profile = name
+	}
+
+	billingDay := ""
+	if c.BillingDay > 0 {
+		billingDay = strconv.Itoa(c.BillingDay)
+	}
+	return []string{
+		c.ID, c.Username, c.Name, c.ServiceType, c.Status,
+		stringValue(c.Phone), stringValue(c.Email), stringValue(c.Address),
+		stringValue(c.PPPoEUsername), stringValue(c.HotspotUsername),
+		profile, stringValue(c.StaticIP), stringValue(c.MacAddress),
+		billingDay, strconv.FormatBool(c.AutoSuspensionEnabled()),
+	}
+}
+
+func (uc *customerImportUsecase) getMikrotik(ctx context.Context, id string) (*entity.Mikrotik, error) {
+	mk, err := uc.mikrotikRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMikrotikNotFound
+		}
+		return nil, fmt.Errorf("failed to get mikrotik: %w", err)
+	}
+	return mk, nil
+}
+
+func importJobResponse(job *entity.CustomerImportJob) *model.ImportJobResponse {
+	resp := &model.ImportJobResponse{
+		ID:         job.ID,
+		MikrotikID: job.MikrotikID,
+		FileName:   job.FileName,
+		Status:     job.Status,
+		Provision:  job.Provision,
+		TotalRows:  job.TotalRows,
+		Error:      stringValue(job.Error),
+		CreatedAt:  job.CreatedAt.Format(time.RFC3339),
+	}
+	if len(job.Result) > 0 {
+		var result model.ImportResult
+		if err := json.Unmarshal(job.Result, &result); err == nil {
+			resp.Result = &result
+		}
+	}
+	if job.StartedAt != nil {
+		resp.StartedAt = job.StartedAt.Format(time.RFC3339)
+	}
+	if job.FinishedAt != nil {
+		resp.FinishedAt = job.FinishedAt.Format(time.RFC3339)
+	}
+	return resp
+}
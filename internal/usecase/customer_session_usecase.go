@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	"mikrobill/pkg/utils"
+	"time"
+)
+
+const (
+	defaultSessionStatsDays = 30
+	maxSessionStatsDays     = 365
+)
+
+// CustomerSessionUsecase reads the session history recorded by the session tracker
+// and the pppoe callbacks
+type CustomerSessionUsecase interface {
+	List(ctx context.Context, customerID string, page, pageSize int) (*model.PaginationResponse, error)
+	Stats(ctx context.Context, customerID string, days int) (*model.CustomerSessionStats, error)
+}
+
+type customerSessionUsecase struct {
+	sessionRepo  repository.CustomerSessionRepository
+	customerRepo entity.CustomerRepository
+}
+
+func NewCustomerSessionUsecase(sessionRepo repository.CustomerSessionRepository, customerRepo entity.CustomerRepository) CustomerSessionUsecase {
+	return &customerSessionUsecase{
+		sessionRepo:  sessionRepo,
+		customerRepo: customerRepo,
+	}
+}
+
+func (uc *customerSessionUsecase) List(ctx context.Context, customerID string, page, pageSize int) (*model.PaginationResponse, error) {
+	if _, err := uc.customerRepo.GetCustomerByID(customerID); err != nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+
+	page, pageSize = normalizePage(page, pageSize)
+	sessions, total, err := uc.sessionRepo.List(ctx, customerID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	now := time.Now()
+	data := make([]model.CustomerSessionResponse, len(sessions))
+	for i, s := range sessions {
+		end := now
+		endedAt := ""
+		if s.EndedAt != nil {
+			end = *s.EndedAt
+			endedAt = s.EndedAt.Format("2006-01-02 15:04:05")
+		}
+		data[i] = model.CustomerSessionResponse{
+			ID:               s.ID,
+			MikrotikID:       s.MikrotikID,
+			ServiceType:      s.ServiceType,
+			Username:         s.Username,
+			IPAddress:        stringValue(s.IPAddress),
+			CallerID:         stringValue(s.CallerID),
+			Interface:        stringValue(s.Interface),
+			StartedAt:        s.StartedAt.Format("2006-01-02 15:04:05"),
+			EndedAt:          endedAt,
+			DurationSeconds:  int64(end.Sub(s.StartedAt).Seconds()),
+			DisconnectReason: stringValue(s.DisconnectReason),
+			BytesIn:          s.BytesIn,
+			BytesOut:         s.BytesOut,
+		}
+	}
+	return paginationResponse(page, pageSize, total, data), nil
+}
+
+// Stats aggregates the sessions of the last days days. Uptime counts overlapping
+// sessions, such as two logins of one PPP secret, once each.
+func (uc *customerSessionUsecase) Stats(ctx context.Context, customerID string, days int) (*model.CustomerSessionStats, error) {
+	if _, err := uc.customerRepo.GetCustomerByID(customerID); err != nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+
+	if days < 1 {
+		days = defaultSessionStatsDays
+	}
+	if days > maxSessionStatsDays {
+		days = maxSessionStatsDays
+	}
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	stats, err := uc.sessionRepo.Stats(ctx, customerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session stats: %w", err)
+	}
+
+	result := &model.CustomerSessionStats{
+		CustomerID:            customerID,
+		Days:                  days,
+		Online:                stats.Online,
+		Sessions:              stats.Sessions,
+		UptimeSeconds:         int64(stats.UptimeSeconds),
+		UptimePercent:         math.Min(math.Round(stats.UptimeSeconds/to.Sub(from).Seconds()*10000)/100, 100),
+		LongestSessionSeconds: int64(stats.LongestSeconds),
+		BytesIn:               stats.BytesIn,
+		BytesOut:              stats.BytesOut,
+	}
+	if stats.Sessions > 0 {
+		result.AverageSessionSeconds = int64(stats.UptimeSeconds) / stats.Sessions
+	}
+	if stats.LastStartedAt != nil {
+		result.LastStartedAt = stats.LastStartedAt.Format("2006-01-02 15:04:05")
+	}
+	return result, nil
+}
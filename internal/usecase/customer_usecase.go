@@ -1,184 +1,471 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mikrobill/internal/entity"
 	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/queue"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-// CustomerService handles business logic for customers
+// TaskSyncCustomer carries a customer sync job to the router
+const TaskSyncCustomer = "customers:sync"
+
+// SyncCustomerPayload is the payload of TaskSyncCustomer
+type SyncCustomerPayload struct {
+	JobID string `json:"job_id"`
+}
+
+// syncJobLease is how long a running sync job may go untouched before it is taken
+// as abandoned by a worker that died
+const syncJobLease = 15 * time.Minute
+
+// CustomerService handles business logic for customers. Router changes go through
+// an outbox: each customer change records a sync job in its own transaction, and
+// the customer sync worker carries the job to the router with retries.
 type CustomerService struct {
 	repo        entity.CustomerRepository
 	profileRepo entity.ProfileRepository
-	mtClient    *mikrotik.Client
+	syncRepo    repository.CustomerSyncRepository
+	routers     RouterManager
+	staticIP    StaticIPUsecase
+	jobs        TaskQueue
+	cipher      *utils.Cipher
 }
 
 // NewCustomerService creates a new customer service
-func NewCustomerService(repo entity.CustomerRepository, profileRepo entity.ProfileRepository, mtClient *mikrotik.Client) *CustomerService {
+func NewCustomerService(repo entity.CustomerRepository, profileRepo entity.ProfileRepository, syncRepo repository.CustomerSyncRepository,
+	routers RouterManager, staticIP StaticIPUsecase, jobs TaskQueue, cipher *utils.Cipher) *CustomerService {
 	return &CustomerService{
 		repo:        repo,
 		profileRepo: profileRepo,
-		mtClient:    mtClient,
+		syncRepo:    syncRepo,
+		routers:     routers,
+		staticIP:    staticIP,
+		jobs:        jobs,
+		cipher:      cipher,
 	}
 }
 
-// CreateCustomer creates a customer in DB and MikroTik (if PPPoE)
+// CreateCustomer creates a customer in DB; its PPP secret or static IP entries
+// follow through a sync job
 func (s *CustomerService) CreateCustomer(c *entity.Customer) error {
-	// 1. Create in Database first (Source of Truth)
-	if err := s.repo.CreateCustomer(c); err != nil {
+	if c.ServiceType == "pppoe" && stringValue(c.PPPoEUsername) == "" {
+		return fmt.Errorf("pppoe username is required")
+	}
+	if err := sealCustomerSecrets(s.cipher, c); err != nil {
+		return err
+	}
+
+	var job *entity.CustomerSyncJob
+	if hasRouterEntries(c.ServiceType) {
+		var err error
+		if job, err = newSyncJob(c.ID, c.MikrotikID, entity.SyncOperationUpsert, nil); err != nil {
+			return err
+		}
+		c.SyncStatus = entity.SyncStatusPending
+	}
+
+	if err := s.syncRepo.CreateCustomer(context.Background(), c, job); err != nil {
 		return fmt.Errorf("failed to create customer in db: %w", err)
 	}
+	s.dispatchSync(context.Background(), job)
+	return nil
+}
 
-	// 2. Sync to MikroTik if PPPoE
-	if c.ServiceType == "pppoe" && s.mtClient != nil {
-		username := ""
-		password := ""
-		profile := "default"
-
-		if c.PPPoEUsername != nil {
-			username = *c.PPPoEUsername
-		}
-		if c.PPPoEPassword != nil {
-			password = *c.PPPoEPassword
-		}
-		if c.PPPoEProfileID != nil && *c.PPPoEProfileID != "" {
-			// Resolve Profile ID to Name
-			prof, err := s.profileRepo.GetProfileByID(*c.PPPoEProfileID)
-			if err != nil {
-				log.Printf("Warning: Profile ID %s not found in DB: %v", *c.PPPoEProfileID, err)
-				// Fallback to sending ID? No, that fails. Fallback to default?
-				// Better to fail or default.
-				profile = "default"
-			} else {
-				profile = prof.Name
-			}
+// UpdateCustomer updates customer in DB; the router follows through a sync job
+func (s *CustomerService) UpdateCustomer(c *entity.Customer) error {
+	oldC, err := s.repo.GetCustomerByID(c.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := sealCustomerSecrets(s.cipher, c); err != nil {
+		return err
+	}
+
+	// The old state goes with the job: the secret is found by the old username and
+	// entries left behind by a changed address or service type are removed
+	var job *entity.CustomerSyncJob
+	if hasRouterEntries(oldC.ServiceType) || hasRouterEntries(c.ServiceType) {
+		mikrotikID := oldC.MikrotikID
+		if c.MikrotikID != "" {
+			mikrotikID = c.MikrotikID
 		}
+		if job, err = newSyncJob(c.ID, mikrotikID, entity.SyncOperationUpsert, oldC); err != nil {
+			return err
+		}
+		c.SyncStatus = entity.SyncStatusPending
+	}
 
-		if username == "" {
-			// Require username for PPPoE
-			// Rollback DB?
-			s.repo.DeleteCustomer(c.ID)
-			return fmt.Errorf("pppoe username is required")
+	if err := s.syncRepo.UpdateCustomer(context.Background(), c, job); err != nil {
+		return fmt.Errorf("failed to update customer in db: %w", err)
+	}
+	s.dispatchSync(context.Background(), job)
+	return nil
+}
+
+// DeleteCustomer deletes customer from DB; its router entries are removed by a
+// sync job holding the deleted row
+func (s *CustomerService) DeleteCustomer(id string) error {
+	c, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		return err
+	}
+
+	var job *entity.CustomerSyncJob
+	if hasRouterEntries(c.ServiceType) {
+		if job, err = newSyncJob(c.ID, c.MikrotikID, entity.SyncOperationDelete, c); err != nil {
+			return err
 		}
+	}
 
-		// Create Secret
-		mtID, err := s.mtClient.CreatePPPoESecret(
-			username,
-			password,
-			profile,
-			"", // local address
-			"", // remote address - usually assigned by profile/pool, but can be set if static
-		)
+	if err := s.syncRepo.DeleteCustomer(context.Background(), id, job); err != nil {
+		return err
+	}
+	s.dispatchSync(context.Background(), job)
+	return nil
+}
 
-		if err != nil {
-			// Rollback DB
-			log.Printf("Failed to create MikroTik secret for %s: %v. Rolling back DB.", username, err)
-			s.repo.DeleteCustomer(c.ID)
-			return fmt.Errorf("failed to create mikrotik secret: %w", err)
+// ResyncCustomer queues a sync of the customer's current state, such as after a
+// sync that failed for good
+func (s *CustomerService) ResyncCustomer(ctx context.Context, id string) (*entity.CustomerSyncJob, error) {
+	c, err := s.syncRepo.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	if c == nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+	if !hasRouterEntries(c.ServiceType) {
+		return nil, utils.ErrNothingToSync
+	}
+
+	job, err := newSyncJob(c.ID, c.MikrotikID, entity.SyncOperationUpsert, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncRepo.QueueSync(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to queue customer sync: %w", err)
+	}
+	s.dispatchSync(ctx, job)
+	return job, nil
+}
+
+// ListSyncJobs returns the customer's latest sync jobs
+func (s *CustomerService) ListSyncJobs(ctx context.Context, id string) ([]*entity.CustomerSyncJob, error) {
+	return s.syncRepo.ListByCustomer(ctx, id, 20)
+}
+
+// RunSyncJob carries a sync job to the router. final tells that the task has no
+// retries left: a failure then marks the job and the customer failed.
+func (s *CustomerService) RunSyncJob(ctx context.Context, id string, final bool) error {
+	job, err := s.syncRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrSyncJobNotFound
 		}
+		return fmt.Errorf("failed to get sync job: %w", err)
+	}
+	claimed, err := s.syncRepo.Claim(ctx, id, time.Now().Add(-syncJobLease))
+	if err != nil {
+		return fmt.Errorf("failed to claim sync job: %w", err)
+	}
+	if !claimed {
+		// Done already, failed for good or running elsewhere: a duplicate delivery.
+		// Or waiting behind an older job of the customer, which queues it once done.
+		return nil
+	}
 
-		// Update DB with MikroTik ID
-		c.MikrotikID = mtID
-		s.repo.UpdateCustomer(c)
+	if err := s.applySyncJob(ctx, job); err != nil {
+		if failErr := s.syncRepo.Fail(ctx, job, err.Error(), final); failErr != nil {
+			pkg_logger.Error("Failed to record customer sync failure", zap.String("job_id", id), zap.Error(failErr))
+		} else if final {
+			s.dispatchNextSync(ctx, job.CustomerID)
+		}
+		return err
 	}
 
+	pkg_logger.Info("Customer synced to router",
+		zap.String("job_id", id),
+		zap.String("customer_id", job.CustomerID),
+		zap.String("operation", job.Operation),
+	)
+	if err := s.syncRepo.Complete(ctx, job); err != nil {
+		return err
+	}
+	s.dispatchNextSync(ctx, job.CustomerID)
 	return nil
 }
 
-// UpdateCustomer updates customer in DB and MikroTik
-func (s *CustomerService) UpdateCustomer(c *entity.Customer) error {
-	// Get existing to compare?
-	oldC, err := s.repo.GetCustomerByID(c.ID)
+// dispatchNextSync queues the customer's next job once the one before it is
+// settled; a job that cannot be queued here is left for the relay
+func (s *CustomerService) dispatchNextSync(ctx context.Context, customerID string) {
+	next, err := s.syncRepo.NextPending(ctx, customerID)
 	if err != nil {
-		return err
+		pkg_logger.Warn("Failed to get next customer sync job, left for the relay",
+			zap.String("customer_id", customerID), zap.Error(err))
+		return
 	}
+	s.dispatchSync(ctx, next)
+}
 
-	// 1. Update Database
-	if err := s.repo.UpdateCustomer(c); err != nil {
-		return fmt.Errorf("failed to update customer in db: %w", err)
+// RelaySyncJobs queues the jobs whose task was lost between the commit and the
+// queue, or whose worker died; the idempotency key keeps jobs still queued from
+// being queued twice
+func (s *CustomerService) RelaySyncJobs(ctx context.Context) (int, error) {
+	now := time.Now()
+	jobs, err := s.syncRepo.ListStale(ctx, now.Add(-time.Minute), now.Add(-syncJobLease), 500)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stale sync jobs: %w", err)
 	}
 
-	// 2. Sync to MikroTik
-	if c.ServiceType == "pppoe" && s.mtClient != nil {
-		// Needs MikroTik ID. If missing, try to find by OLD username
-		mtID := c.MikrotikID
-		if mtID == "" {
-			usernameToFind := ""
-			if oldC.PPPoEUsername != nil {
-				usernameToFind = *oldC.PPPoEUsername
-			}
-			if usernameToFind != "" {
-				foundID, err := s.mtClient.FindPPPoESecretID(usernameToFind)
-				if err == nil && foundID != "" {
-					mtID = foundID
-				}
-			}
+	queued := 0
+	for _, job := range jobs {
+		if s.dispatchSync(ctx, job) {
+			queued++
 		}
+	}
+	return queued, nil
+}
 
-		if mtID != "" {
-			username := ""
-			password := ""
-			profile := ""
+// applySyncJob makes the router match the job: the customer's current row for an
+// upsert, the removal of the snapshot's entries for a delete
+func (s *CustomerService) applySyncJob(ctx context.Context, job *entity.CustomerSyncJob) error {
+	var previous *entity.Customer
+	if len(job.Snapshot) > 0 {
+		if err := json.Unmarshal(job.Snapshot, &previous); err != nil {
+			return fmt.Errorf("failed to decode sync job snapshot: %w", err)
+		}
+	}
 
-			if c.PPPoEUsername != nil {
-				username = *c.PPPoEUsername
-			}
-			if c.PPPoEPassword != nil {
-				password = *c.PPPoEPassword
-			}
-			if c.PPPoEProfileID != nil {
-				profile = *c.PPPoEProfileID
-			}
+	if job.Operation == entity.SyncOperationDelete {
+		if previous == nil {
+			return nil
+		}
+		return s.removeRouterEntries(ctx, previous)
+	}
 
-			err := s.mtClient.UpdatePPPoESecret(
-				mtID,
-				username,
-				password,
-				profile,
-				"", "",
-			)
-			if err != nil {
-				return fmt.Errorf("failed to update mikrotik secret: %w", err)
+	c, err := s.syncRepo.GetCustomer(ctx, job.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+	if c == nil {
+		// Deleted since; its delete job removes the entries
+		return nil
+	}
+
+	// Entries of the old state the new one does not take over
+	if previous != nil {
+		moved := previous.MikrotikID != c.MikrotikID
+		if previous.ServiceType == "pppoe" && (moved || c.ServiceType != "pppoe") {
+			if err := s.removeRouterEntries(ctx, previous); err != nil {
+				return err
+			}
+			previous = nil
+		}
+		if previous != nil && previous.ServiceType == "static_ip" && (moved || c.ServiceType != "static_ip" ||
+			stringValue(previous.StaticIP) != stringValue(c.StaticIP) || previous.Username != c.Username) {
+			if err := s.removeRouterEntries(ctx, previous); err != nil {
+				return err
 			}
-		} else {
-			// Not found in MikroTik? Maybe active but no secret?
-			// Or maybe we should create it?
-			// For Safe Update, let's just log warning.
-			log.Printf("Warning: MikroTik Secret ID not found for customer %s. Skipping MikroTik update.", c.Name)
 		}
 	}
 
+	switch c.ServiceType {
+	case "pppoe":
+		return s.upsertSecret(ctx, previous, c)
+	case "static_ip":
+		if err := s.staticIP.Provision(ctx, c); err != nil {
+			return fmt.Errorf("failed to provision static ip: %w", err)
+		}
+	}
 	return nil
 }
 
-// DeleteCustomer deletes customer from DB and MikroTik
-func (s *CustomerService) DeleteCustomer(id string) error {
-	c, err := s.repo.GetCustomerByID(id)
+// upsertSecret updates the customer's PPP secret, found by its old or current
+// username, or creates it when the router has neither
+func (s *CustomerService) upsertSecret(ctx context.Context, previous, c *entity.Customer) error {
+	client, err := s.routerClient(ctx, c)
 	if err != nil {
 		return err
 	}
+	username := stringValue(c.PPPoEUsername)
+	password, err := s.cipher.Decrypt(stringValue(c.PPPoEPassword))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt pppoe password: %w", err)
+	}
+	profile := s.profileName(c.PPPoEProfileID)
 
-	// 1. Delete from MikroTik first? Or DB first?
-	// If we delete from DB first, we lose the ID needed for MikroTik.
+	mtID := ""
+	if previous != nil && stringValue(previous.PPPoEUsername) != "" {
+		if mtID, err = client.FindPPPoESecretID(*previous.PPPoEUsername); err != nil {
+			return routerError("find ppp secret", err)
+		}
+	}
+	if mtID == "" {
+		if mtID, err = client.FindPPPoESecretID(username); err != nil {
+			return routerError("find ppp secret", err)
+		}
+	}
 
-	if c.ServiceType == "pppoe" && s.mtClient != nil {
-		mtID := c.MikrotikID
-		if mtID == "" && c.PPPoEUsername != nil {
-			mtID, _ = s.mtClient.FindPPPoESecretID(*c.PPPoEUsername)
+	if mtID != "" {
+		if c.Isolated {
+			// The secret stays on the isolation profile until reactivation
+			profile = ""
 		}
+		if err := client.UpdatePPPoESecret(mtID, username, password, profile, "", ""); err != nil {
+			return fmt.Errorf("failed to update mikrotik secret: %w", err)
+		}
+		return nil
+	}
+
+	if profile == "" {
+		profile = "default"
+	}
+	// Local and remote addresses come from the profile's pool
+	if _, err := client.CreatePPPoESecret(username, password, profile, "", ""); err != nil {
+		return fmt.Errorf("failed to create mikrotik secret: %w", err)
+	}
+	return nil
+}
 
+// removeRouterEntries removes the PPP secret or static IP entries of the customer
+// as it is in c; entries already gone are not an error
+func (s *CustomerService) removeRouterEntries(ctx context.Context, c *entity.Customer) error {
+	switch c.ServiceType {
+	case "pppoe":
+		username := stringValue(c.PPPoEUsername)
+		if username == "" {
+			return nil
+		}
+		client, err := s.routerClient(ctx, c)
+		if err != nil {
+			return err
+		}
+		mtID, err := client.FindPPPoESecretID(username)
+		if err != nil {
+			return routerError("find ppp secret", err)
+		}
 		if mtID != "" {
-			if err := s.mtClient.DeletePPPoESecret(mtID); err != nil {
-				log.Printf("Warning: Failed to delete MikroTik secret: %v", err)
-				// Proceed to delete from DB anyway?
-				// Yes, because we want to remove from our system.
+			if err := client.DeletePPPoESecret(mtID); err != nil {
+				return fmt.Errorf("failed to delete mikrotik secret: %w", err)
 			}
 		}
+	case "static_ip":
+		if err := s.staticIP.Deprovision(ctx, c); err != nil {
+			return fmt.Errorf("failed to remove static ip entries: %w", err)
+		}
 	}
+	return nil
+}
 
-	// 2. Delete from Database
-	return s.repo.DeleteCustomer(id)
+// dispatchSync queues the job's task after its transaction committed. A job that
+// cannot be queued now stays pending and is picked up by RelaySyncJobs; false
+// when the job was not queued by this call.
+func (s *CustomerService) dispatchSync(ctx context.Context, job *entity.CustomerSyncJob) bool {
+	if job == nil {
+		return false
+	}
+	opts := queue.QueueOptions{
+		Queue:    queue.QueueDefault,
+		MaxRetry: 8,
+		Timeout:  2 * time.Minute,
+		TaskID:   job.IdempotencyKey,
+	}
+	_, err := s.jobs.EnqueueContext(ctx, TaskSyncCustomer, SyncCustomerPayload{JobID: job.ID}, opts.ToAsynqOptions()...)
+	if err != nil {
+		if !errors.Is(err, asynq.ErrTaskIDConflict) {
+			pkg_logger.Warn("Failed to queue customer sync, left for the relay",
+				zap.String("job_id", job.ID), zap.Error(err))
+		}
+		return false
+	}
+	return true
+}
+
+// newSyncJob builds the sync job of a change to a customer; snapshot is the
+// customer before the change
+func newSyncJob(customerID, mikrotikID, operation string, snapshot *entity.Customer) (*entity.CustomerSyncJob, error) {
+	job := &entity.CustomerSyncJob{
+		ID:         uuid.New().String(),
+		CustomerID: customerID,
+		MikrotikID: mikrotikID,
+		Operation:  operation,
+		Status:     entity.SyncJobPending,
+	}
+	// The queue task ID: the first dispatch and a relay of the same job are queued once
+	job.IdempotencyKey = fmt.Sprintf("customer-sync:%s:%s:%s", customerID, operation, job.ID)
+	if snapshot != nil {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode customer snapshot: %w", err)
+		}
+		job.Snapshot = data
+	}
+	return job, nil
+}
+
+// hasRouterEntries reports whether customers of the service type have entries the
+// customer service keeps on the router; hotspot users are managed by the hotspot
+// endpoints
+func hasRouterEntries(serviceType string) bool {
+	return serviceType == "pppoe" || serviceType == "static_ip"
+}
+
+// routerClient returns the connection to the router the customer is on
+func (s *CustomerService) routerClient(ctx context.Context, c *entity.Customer) (*mikrotik.Client, error) {
+	client, err := s.routers.Client(ctx, c.MikrotikID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to customer router: %w", err)
+	}
+	return client, nil
+}
+
+// profileName resolves a profile ID to the profile name used on the router
+func (s *CustomerService) profileName(profileID *string) string {
+	if profileID == nil || *profileID == "" {
+		return ""
+	}
+	prof, err := s.profileRepo.GetProfileByID(*profileID)
+	if err != nil {
+		log.Printf("Warning: Profile ID %s not found in DB: %v", *profileID, err)
+		return ""
+	}
+	return prof.Name
+}
+
+// sealCustomerSecrets encrypts the PPPoE and hotspot passwords before they are stored.
+// The passwords are plain text: one that looks like ciphertext is refused, as it
+// would be stored unsealed and then fail to decrypt.
+func sealCustomerSecrets(cipher *utils.Cipher, c *entity.Customer) error {
+	for _, secret := range []*string{c.PPPoEPassword, c.HotspotPassword} {
+		if secret == nil || *secret == "" {
+			continue
+		}
+		if utils.IsEncrypted(*secret) {
+			return utils.ErrSealedPassword
+		}
+		sealed, err := cipher.Encrypt(*secret)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt customer password: %w", err)
+		}
+		*secret = sealed
+	}
+	return nil
 }
 
 // GetCustomer returns a customer
@@ -186,7 +473,32 @@ func (s *CustomerService) GetCustomer(id string) (*entity.Customer, error) {
 	return s.repo.GetCustomerByID(id)
 }
 
-// ListCustomers returns list of customers
-func (s *CustomerService) ListCustomers(page, limit int) ([]*entity.Customer, int, error) {
-	return s.repo.ListCustomers(page, limit)
+// ListCustomers returns a page of the customer list
+func (s *CustomerService) ListCustomers(req model.CustomerListRequest) (*entity.CustomerPage, error) {
+	desc := req.Order == "desc"
+	if req.Sort == "" {
+		// Newest first by default
+		desc = req.Order != "asc"
+	}
+	return s.repo.ListCustomers(entity.CustomerListQuery{
+		Filter: customerFilter(req.CustomerFilterParams),
+		Sort:   req.Sort,
+		Desc:   desc,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+		Page:   req.Page,
+	})
+}
+
+func customerFilter(params model.CustomerFilterParams) entity.CustomerFilter {
+	return entity.CustomerFilter{
+		MikrotikID:  params.MikrotikID,
+		ServiceType: params.ServiceType,
+		Status:      params.Status,
+		ProfileID:   params.ProfileID,
+		SyncStatus:  params.SyncStatus,
+		Online:      params.Online,
+		Overdue:     params.Overdue,
+		Search:      params.Search,
+	}
 }
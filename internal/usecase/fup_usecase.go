@@ -0,0 +1,349 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/ppp"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FUPUsecase enforces the fair usage quotas of PPPoE profiles. A customer past the
+// quota is moved to a copy of their profile limited to the FUP rate, and moved back
+// when the next billing cycle starts.
+type FUPUsecase interface {
+	Enforce(ctx context.Context, now time.Time) (*model.FUPResult, error)
+	GetStatus(ctx context.Context, customerID string) (*model.FUPStatus, error)
+}
+
+type fupUsecase struct {
+	fupRepo         repository.FUPRepository
+	usageRepo       repository.UsageRepository
+	customerRepo    entity.CustomerRepository
+	profileRepo     entity.ProfileRepository
+	mikrotikUseCase MikrotikUseCase
+	publisher       entity.RedisPublisher
+}
+
+func NewFUPUsecase(
+	fupRepo repository.FUPRepository,
+	usageRepo repository.UsageRepository,
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+	mikrotikUseCase MikrotikUseCase,
+	publisher entity.RedisPublisher,
+) FUPUsecase {
+	return &fupUsecase{
+		fupRepo:         fupRepo,
+		usageRepo:       usageRepo,
+		customerRepo:    customerRepo,
+		profileRepo:     profileRepo,
+		mikrotikUseCase: mikrotikUseCase,
+		publisher:       publisher,
+	}
+}
+
+func (uc *fupUsecase) Enforce(ctx context.Context, now time.Time) (*model.FUPResult, error) {
+	candidates, err := uc.fupRepo.ListCandidates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fup customers: %w", err)
+	}
+
+	result := &model.FUPResult{}
+	profiles := make(map[string]*entity.ProfileWithPPPoE)
+	for _, cand := range candidates {
+		profile, ok := profiles[cand.ProfileID]
+		if !ok {
+			if profile, err = uc.profileRepo.GetProfileByID(cand.ProfileID); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("profile %s: %v", cand.ProfileID, err))
+				continue
+			}
+			profiles[cand.ProfileID] = profile
+		}
+
+		customer, err := uc.customerRepo.GetCustomerByID(cand.CustomerID)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", cand.CustomerID, err))
+			continue
+		}
+		// Suspension owns the secret's profile until the customer is reactivated
+		if customer.Status == "suspended" {
+			result.Skipped++
+			continue
+		}
+
+		result.Checked++
+		if err := uc.enforce(ctx, customer, profile, now, result); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", customer.Username, err))
+			pkg_logger.Warn("Failed to enforce fair usage",
+				zap.String("customer_id", customer.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if result.Throttled > 0 || result.Restored > 0 || result.Failed > 0 {
+		pkg_logger.Info("Fair usage enforced",
+			zap.Int("checked", result.Checked),
+			zap.Int("throttled", result.Throttled),
+			zap.Int("restored", result.Restored),
+			zap.Int("notified", result.Notified),
+			zap.Int("failed", result.Failed),
+		)
+	}
+	return result, nil
+}
+
+// enforce brings one customer's router profile and notifications in line with
+// their usage in the current billing cycle. The state is saved only after the
+// router accepted the change, so a failure is retried on the next run.
+func (uc *fupUsecase) enforce(ctx context.Context, customer *entity.Customer, profile *entity.ProfileWithPPPoE, now time.Time, result *model.FUPResult) error {
+	cycleStart := billingCycleStart(truncateDate(now), customer.BillingDay)
+
+	state, dirty, err := uc.state(ctx, customer.ID, cycleStart)
+	if err != nil {
+		return err
+	}
+
+	if state.Throttled && (state.CycleStart.Format(dateLayout) != cycleStart.Format(dateLayout) || !profile.FUPEnabled()) {
+		if err := uc.setSecretProfile(ctx, customer, profile.Name, nil); err != nil {
+			return fmt.Errorf("failed to restore profile: %w", err)
+		}
+		state.Throttled = false
+		state.ThrottledAt = nil
+		dirty = true
+		result.Restored++
+
+		pkg_logger.Info("Customer restored from fair usage rate",
+			zap.String("customer_id", customer.ID),
+			zap.String("profile", profile.Name),
+		)
+		uc.publishEvent(customer, "fup_restored", 0, 0, 0, false)
+	}
+	if state.CycleStart.Format(dateLayout) != cycleStart.Format(dateLayout) {
+		state.CycleStart = cycleStart
+		state.NotifiedPercent = 0
+		dirty = true
+	}
+
+	if profile.FUPEnabled() {
+		quota := *profile.FUPQuotaBytes
+		used, err := uc.usageRepo.Total(ctx, customer.ID, cycleStart, truncateDate(now).AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("failed to read usage: %w", err)
+		}
+
+		level := 0
+		switch {
+		case used >= quota:
+			level = entity.FUPExceededPercent
+		case used*100 >= quota*entity.FUPWarningPercent:
+			level = entity.FUPWarningPercent
+		}
+
+		if level == entity.FUPExceededPercent && !state.Throttled {
+			err := uc.setSecretProfile(ctx, customer, profile.FUPProfileName(), func(client *mikrotik.Client) error {
+				return ensureFUPProfile(client, profile)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply fup profile: %w", err)
+			}
+			state.Throttled = true
+			state.ThrottledAt = &now
+			dirty = true
+			result.Throttled++
+
+			pkg_logger.Info("Customer throttled by fair usage policy",
+				zap.String("customer_id", customer.ID),
+				zap.Int64("used_bytes", used),
+				zap.Int64("quota_bytes", quota),
+			)
+		}
+
+		if level > state.NotifiedPercent {
+			eventType := "fup_warning"
+			if level == entity.FUPExceededPercent {
+				eventType = "fup_exceeded"
+			}
+			uc.publishEvent(customer, eventType, int(used*100/quota), used, quota, state.Throttled)
+			state.NotifiedPercent = level
+			dirty = true
+			result.Notified++
+		}
+	}
+
+	if !dirty {
+		return nil
+	}
+	return uc.fupRepo.SaveState(ctx, state)
+}
+
+// state loads the customer's FUP state, starting a fresh one in the given cycle
+// for customers seen for the first time
+func (uc *fupUsecase) state(ctx context.Context, customerID string, cycleStart time.Time) (*entity.CustomerFUPState, bool, error) {
+	state, err := uc.fupRepo.GetState(ctx, customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.CustomerFUPState{CustomerID: customerID, CycleStart: cycleStart}, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get fup state: %w", err)
+	}
+	return state, false, nil
+}
+
+func (uc *fupUsecase) GetStatus(ctx context.Context, customerID string) (*model.FUPStatus, error) {
+	customer, err := uc.customerRepo.GetCustomerByID(customerID)
+	if err != nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+
+	today := truncateDate(time.Now())
+	cycleStart := billingCycleStart(today, customer.BillingDay)
+	status := &model.FUPStatus{
+		CustomerID: customer.ID,
+		CycleStart: cycleStart.Format(dateLayout),
+		CycleEnd:   nextBillingDate(cycleStart, customer.BillingDay).AddDate(0, 0, -1).Format(dateLayout),
+	}
+
+	used, err := uc.usageRepo.Total(ctx, customer.ID, cycleStart, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	status.UsedBytes = used
+
+	if customer.ServiceType == "pppoe" && customer.PPPoEProfileID != nil {
+		if profile, err := uc.profileRepo.GetProfileByID(*customer.PPPoEProfileID); err == nil && profile.FUPEnabled() {
+			status.Enabled = true
+			status.QuotaBytes = *profile.FUPQuotaBytes
+			status.Percent = math.Round(float64(used)/float64(status.QuotaBytes)*10000) / 100
+		}
+	}
+
+	state, err := uc.fupRepo.GetState(ctx, customer.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get fup state: %w", err)
+	}
+	if state != nil && state.Throttled {
+		status.Throttled = true
+		if state.ThrottledAt != nil {
+			status.ThrottledAt = state.ThrottledAt.Format("2006-01-02 15:04:05")
+		}
+	}
+	return status, nil
+}
+
+// setSecretProfile moves the customer's PPP secret to profileName and kicks the live
+// session, since profile changes only apply to new sessions. prepare, when set, runs
+// on the same connection before the secret is changed.
+func (uc *fupUsecase) setSecretProfile(ctx context.Context, customer *entity.Customer, profileName string, prepare func(*mikrotik.Client) error) error {
+	username := stringValue(customer.PPPoEUsername)
+	if username == "" {
+		return fmt.Errorf("customer %s has no pppoe username", customer.ID)
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, customer.MikrotikID)
+	if err != nil {
+		return err
+	}
+
+	secretID, err := client.FindPPPoESecretID(username)
+	if err != nil {
+		return fmt.Errorf("failed to find ppp secret: %w", err)
+	}
+	if secretID == "" {
+		return fmt.Errorf("%w: %s", utils.ErrSecretNotFound, username)
+	}
+
+	if prepare != nil {
+		if err := prepare(client); err != nil {
+			return err
+		}
+	}
+
+	if err := client.UpdatePPPoESecret(secretID, "", "", profileName, "", ""); err != nil {
+		return err
+	}
+
+	if _, err := ppp.NewService(client).DisconnectByUsername(username); err != nil {
+		pkg_logger.Warn("Failed to disconnect active session",
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// ensureFUPProfile creates or updates the throttled copy of profile on the router, so
+// a changed FUP rate reaches customers throttled from then on
+func ensureFUPProfile(client *mikrotik.Client, profile *entity.ProfileWithPPPoE) error {
+	params := mikrotik.PPPoEProfileParams{
+		Name:             profile.FUPProfileName(),
+		RateLimitUp:      stringValue(profile.FUPRateLimitUp),
+		RateLimitDown:    stringValue(profile.FUPRateLimitDown),
+		IdleTimeout:      stringValue(profile.IdleTimeout),
+		SessionTimeout:   stringValue(profile.SessionTimeout),
+		KeepaliveTimeout: stringValue(profile.KeepaliveTimeout),
+		OnlyOne:          profile.OnlyOne,
+		DNSServer:        stringValue(profile.DNSServer),
+	}
+	if profile.PPPoEDetails != nil {
+		params.LocalAddress = profile.PPPoEDetails.LocalAddress
+		params.RemoteAddress = stringValue(profile.PPPoEDetails.RemoteAddress)
+	}
+
+	id, err := client.FindPPPoEProfileID(params.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find fup profile: %w", err)
+	}
+	if id == "" {
+		return client.CreatePPPoEProfile(params)
+	}
+	return client.UpdatePPPoEProfile(params)
+}
+
+// billingCycleStart returns the latest billing date on or before today
+func billingCycleStart(today time.Time, billingDay int) time.Time {
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	start := nextBillingDate(firstOfMonth.AddDate(0, -1, 0), billingDay)
+	if start.After(today) {
+		start = nextBillingDate(firstOfMonth.AddDate(0, -2, 0), billingDay)
+	}
+	return start
+}
+
+func (uc *fupUsecase) publishEvent(customer *entity.Customer, eventType string, percent int, used, quota int64, throttled bool) {
+	if uc.publisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(model.FUPEvent{
+		Type:       eventType,
+		CustomerID: customer.ID,
+		Name:       customer.Name,
+		Percent:    percent,
+		UsedBytes:  used,
+		QuotaBytes: quota,
+		Throttled:  throttled,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return
+	}
+	if err := uc.publisher.Publish(eventsChannel, string(data)); err != nil {
+		pkg_logger.Warn("Failed to publish fup event", zap.Error(err))
+	}
+}
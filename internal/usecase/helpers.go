@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"mikrobill/internal/model"
+	"time"
+)
+
+// Helpers shared by the usecases: request dates and list pagination
+
+const dateLayout = "2006-01-02"
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func parseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, s, time.Local)
+}
+
+// normalizePage defaults a list request to the first page of 20
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+func paginationResponse(page, pageSize int, total int64, data interface{}) *model.PaginationResponse {
+	return &model.PaginationResponse{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: int(total),
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Data:       data,
+	}
+}
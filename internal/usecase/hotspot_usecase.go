@@ -0,0 +1,534 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/hotspot"
+	mkmodel "mikrobill/internal/infrastructure/mikrotik/model"
+	"mikrobill/internal/model"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HotspotUsecase operates a router's hotspot: users, user profiles, active sessions,
+// hosts, vouchers and the sales reports written by profile on-login scripts.
+type HotspotUsecase interface {
+	ListUsers(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error)
+	// GetUser, UpdateUser and DeleteUser take a router id (*1A) or a user name
+	GetUser(ctx context.Context, mikrotikID, user string) (map[string]string, error)
+	CreateUser(ctx context.Context, mikrotikID string, req model.HotspotUserRequest) (map[string]string, error)
+	UpdateUser(ctx context.Context, mikrotikID, user string, req model.UpdateHotspotUserRequest) (map[string]string, error)
+	DeleteUser(ctx context.Context, mikrotikID, user string) error
+
+	ListProfiles(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error)
+	GetProfile(ctx context.Context, mikrotikID, profile string) (map[string]string, error)
+	CreateProfile(ctx context.Context, mikrotikID string, req model.HotspotProfileRequest) (map[string]string, error)
+	UpdateProfile(ctx context.Context, mikrotikID, profile string, req model.UpdateHotspotProfileRequest) (map[string]string, error)
+	DeleteProfile(ctx context.Context, mikrotikID, profile string) error
+
+	ListActive(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error)
+	Disconnect(ctx context.Context, mikrotikID, activeID string) error
+	ListHosts(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error)
+	RemoveHost(ctx context.Context, mikrotikID, hostID string) error
+
+	// GenerateVouchers and ListVouchers go through the stored voucher batches
+	GenerateVouchers(ctx context.Context, mikrotikID string, req model.GenerateVoucherBatchRequest, createdBy *int64) (*model.GenerateVoucherBatchResult, error)
+	ListVouchers(ctx context.Context, mikrotikID string, req model.VoucherListRequest) (*model.PaginationResponse, error)
+
+	Report(ctx context.Context, mikrotikID string, req model.HotspotReportRequest) (*model.HotspotReport, error)
+}
+
+type hotspotUsecase struct {
+	mikrotikUseCase MikrotikUseCase
+	voucherUsecase  VoucherUsecase
+}
+
+func NewHotspotUsecase(mikrotikUseCase MikrotikUseCase, voucherUsecase VoucherUsecase) HotspotUsecase {
+	return &hotspotUsecase{
+		mikrotikUseCase: mikrotikUseCase,
+		voucherUsecase:  voucherUsecase,
+	}
+}
+
+func (uc *hotspotUsecase) ListUsers(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error) {
+	var users []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		resp, err := svc.GetAllUsers()
+		if err != nil {
+			return routerError("list hotspot users", err)
+		}
+		users, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterRows(users, req), req.Page, req.PageSize), nil
+}
+
+func (uc *hotspotUsecase) GetUser(ctx context.Context, mikrotikID, user string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) (err error) {
+		found, err = findUser(svc, user)
+		return err
+	})
+	return found, err
+}
+
+func (uc *hotspotUsecase) CreateUser(ctx context.Context, mikrotikID string, req model.HotspotUserRequest) (map[string]string, error) {
+	var created map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		_, err := svc.AddUser(mkmodel.UserRequest{
+			Server:     req.Server,
+			Name:       req.Name,
+			Password:   req.Password,
+			Profile:    req.Profile,
+			MacAddress: req.MacAddress,
+			TimeLimit:  req.TimeLimit,
+			DataLimit:  req.DataLimit,
+			Comment:    req.Comment,
+			Disabled:   req.Disabled,
+		})
+		if err != nil {
+			return routerError("add hotspot user", err)
+		}
+		created, err = findUser(svc, req.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Hotspot user created",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("name", req.Name),
+		zap.String("profile", req.Profile),
+	)
+	return created, nil
+}
+
+func (uc *hotspotUsecase) UpdateUser(ctx context.Context, mikrotikID, user string, req model.UpdateHotspotUserRequest) (map[string]string, error) {
+	var updated map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		current, err := findUser(svc, user)
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.UpdateUser(current[".id"], mkmodel.UserUpdateRequest{
+			Name:       req.Name,
+			Password:   req.Password,
+			Profile:    req.Profile,
+			MacAddress: req.MacAddress,
+			TimeLimit:  req.TimeLimit,
+			DataLimit:  req.DataLimit,
+			Comment:    req.Comment,
+			Disabled:   req.Disabled,
+		})
+		if err != nil {
+			return routerError("update hotspot user", err)
+		}
+		updated, err = findUser(svc, current[".id"])
+		return err
+	})
+	return updated, err
+}
+
+func (uc *hotspotUsecase) DeleteUser(ctx context.Context, mikrotikID, user string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		current, err := findUser(svc, user)
+		if err != nil {
+			return err
+		}
+		if _, err := svc.DeleteUser(current[".id"]); err != nil {
+			return routerError("remove hotspot user", err)
+		}
+		return nil
+	})
+}
+
+func (uc *hotspotUsecase) ListProfiles(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error) {
+	var profiles []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		resp, err := svc.ListProfiles()
+		if err != nil {
+			return routerError("list hotspot user profiles", err)
+		}
+		profiles, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A profile has no profile or server of its own to filter on
+	req.Profile, req.Server = "", ""
+	return paginateRows(filterRows(profiles, req), req.Page, req.PageSize), nil
+}
+
+func (uc *hotspotUsecase) GetProfile(ctx context.Context, mikrotikID, profile string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) (err error) {
+		found, err = findProfile(svc, profile)
+		return err
+	})
+	return found, err
+}
+
+func (uc *hotspotUsecase) CreateProfile(ctx context.Context, mikrotikID string, req model.HotspotProfileRequest) (map[string]string, error) {
+	var created map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		config := mkmodel.ProfileRequest{
+			Name:         req.Name,
+			SharedUsers:  req.SharedUsers,
+			RateLimit:    req.RateLimit,
+			ExpMode:      mkmodel.ExpireMode(req.ExpireMode),
+			Validity:     req.Validity,
+			Price:        req.Price,
+			SellingPrice: req.SellingPrice,
+			AddressPool:  req.AddressPool,
+			LockUser:     mkmodel.LockStatus(req.LockUser),
+			LockServer:   mkmodel.LockStatus(req.LockServer),
+			ParentQueue:  req.ParentQueue,
+		}
+		resp, err := svc.CreateProfile(config)
+		if err != nil {
+			return routerError("add hotspot user profile", err)
+		}
+		// The service sanitizes the name, so prefer the profile it read back
+		if data, ok := resp.Data.(map[string]string); ok {
+			created = data
+			return nil
+		}
+		created, err = findProfile(svc, req.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Hotspot user profile created",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("name", created["name"]),
+	)
+	return created, nil
+}
+
+func (uc *hotspotUsecase) UpdateProfile(ctx context.Context, mikrotikID, profile string, req model.UpdateHotspotProfileRequest) (map[string]string, error) {
+	var updated map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		current, err := findProfile(svc, profile)
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.UpdateProfile(current[".id"], mkmodel.ProfileUpdateRequest{
+			Name:        current["name"], // recorded by the regenerated on-login script
+			RateLimit:   req.RateLimit,
+			SharedUsers: req.SharedUsers,
+			AddressPool: req.AddressPool,
+			ParentQueue: req.ParentQueue,
+			ExpMode:     mkmodel.ExpireMode(req.ExpireMode),
+			Price:       req.Price,
+			LockUser:    mkmodel.LockStatus(req.LockUser),
+			LockServer:  mkmodel.LockStatus(req.LockServer),
+			Validity:    req.Validity,
+		})
+		if err != nil {
+			return routerError("update hotspot user profile", err)
+		}
+		updated, err = findProfile(svc, current[".id"])
+		return err
+	})
+	return updated, err
+}
+
+func (uc *hotspotUsecase) DeleteProfile(ctx context.Context, mikrotikID, profile string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		current, err := findProfile(svc, profile)
+		if err != nil {
+			return err
+		}
+		if _, err := svc.DeleteProfile(current[".id"]); err != nil {
+			return routerError("remove hotspot user profile", err)
+		}
+		return nil
+	})
+}
+
+func (uc *hotspotUsecase) ListActive(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error) {
+	var active []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		resp, err := svc.GetActiveUsers()
+		if err != nil {
+			return routerError("list active hotspot sessions", err)
+		}
+		active, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterRows(active, req), req.Page, req.PageSize), nil
+}
+
+func (uc *hotspotUsecase) Disconnect(ctx context.Context, mikrotikID, activeID string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		if _, err := svc.RemoveActiveUser(activeID); err != nil {
+			return routerError("disconnect hotspot session", err)
+		}
+		return nil
+	})
+}
+
+func (uc *hotspotUsecase) ListHosts(ctx context.Context, mikrotikID string, req model.HotspotListRequest) (*model.PaginationResponse, error) {
+	var hosts []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		resp, err := svc.GetHosts()
+		if err != nil {
+			return routerError("list hotspot hosts", err)
+		}
+		hosts, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterRows(hosts, req), req.Page, req.PageSize), nil
+}
+
+func (uc *hotspotUsecase) RemoveHost(ctx context.Context, mikrotikID, hostID string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		if _, err := svc.RemoveHost(hostID); err != nil {
+			return routerError("remove hotspot host", err)
+		}
+		return nil
+	})
+}
+
+func (uc *hotspotUsecase) GenerateVouchers(ctx context.Context, mikrotikID string, req model.GenerateVoucherBatchRequest, createdBy *int64) (*model.GenerateVoucherBatchResult, error) {
+	return uc.voucherUsecase.GenerateBatch(ctx, mikrotikID, req, createdBy)
+}
+
+func (uc *hotspotUsecase) ListVouchers(ctx context.Context, mikrotikID string, req model.VoucherListRequest) (*model.PaginationResponse, error) {
+	req.MikrotikID = mikrotikID
+	return uc.voucherUsecase.List(ctx, req)
+}
+
+func (uc *hotspotUsecase) Report(ctx context.Context, mikrotikID string, req model.HotspotReportRequest) (*model.HotspotReport, error) {
+	var (
+		day, month time.Time
+		err        error
+	)
+	report := &model.HotspotReport{}
+	switch {
+	case req.Date != "":
+		if day, err = time.Parse("2006-01-02", req.Date); err != nil {
+			return nil, utils.ErrInvalidDate
+		}
+		report.Period = req.Date
+	case req.Month != "":
+		if month, err = time.Parse("2006-01", req.Month); err != nil {
+			return nil, fmt.Errorf("%w: month must be YYYY-MM", utils.ErrInvalidDate)
+		}
+		report.Period = req.Month
+	default:
+		day = time.Now()
+		report.Period = day.Format("2006-01-02")
+	}
+
+	var records []map[string]string
+	err = uc.withService(ctx, mikrotikID, func(svc *hotspot.Service) error {
+		if !day.IsZero() {
+			// RouterOS before 7.10 writes dates as jan/02/2006, later versions as 2006-01-02
+			for _, source := range []string{strings.ToLower(day.Format("Jan/02/2006")), day.Format("2006-01-02")} {
+				records, err = svc.GetReportByDate(source, false)
+				if err != nil || len(records) > 0 {
+					break
+				}
+			}
+		} else {
+			records, err = svc.GetReports()
+		}
+		if err != nil {
+			return routerError("read hotspot sales records", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byProfile := map[string]*model.HotspotProfileSales{}
+	report.Sales = []model.HotspotSale{}
+	for _, r := range records {
+		sale, at, ok := parseSaleRecord(r["name"])
+		if !ok {
+			continue
+		}
+		if !month.IsZero() && (at.Year() != month.Year() || at.Month() != month.Month()) {
+			continue
+		}
+
+		report.Sales = append(report.Sales, sale)
+		report.Count++
+		report.Total += sale.Price
+
+		p, ok := byProfile[sale.Profile]
+		if !ok {
+			p = &model.HotspotProfileSales{Profile: sale.Profile}
+			byProfile[sale.Profile] = p
+		}
+		p.Count++
+		p.Total += sale.Price
+	}
+
+	report.ByProfile = make([]model.HotspotProfileSales, 0, len(byProfile))
+	for _, p := range byProfile {
+		report.ByProfile = append(report.ByProfile, *p)
+	}
+	sort.Slice(report.ByProfile, func(i, j int) bool { return report.ByProfile[i].Profile < report.ByProfile[j].Profile })
+	sort.SliceStable(report.Sales, func(i, j int) bool {
+		return report.Sales[i].Date+" "+report.Sales[i].Time < report.Sales[j].Date+" "+report.Sales[j].Time
+	})
+
+	return report, nil
+}
+
+// withService connects to the router for the length of fn
+func (uc *hotspotUsecase) withService(ctx context.Context, mikrotikID string, fn func(svc *hotspot.Service) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(hotspot.NewService(client))
+}
+
+// findUser looks a hotspot user up by router id or name
+func findUser(svc *hotspot.Service, user string) (map[string]string, error) {
+	resp, err := svc.GetAllUsers()
+	if err != nil {
+		return nil, routerError("list hotspot users", err)
+	}
+	users, _ := resp.Data.([]map[string]string)
+	if u := findRow(users, user); u != nil {
+		return u, nil
+	}
+	return nil, utils.ErrHotspotUserNotFound
+}
+
+// findProfile looks a hotspot user profile up by router id or name
+func findProfile(svc *hotspot.Service, profile string) (map[string]string, error) {
+	resp, err := svc.ListProfiles()
+	if err != nil {
+		return nil, routerError("list hotspot user profiles", err)
+	}
+	profiles, _ := resp.Data.([]map[string]string)
+	if p := findRow(profiles, profile); p != nil {
+		return p, nil
+	}
+	return nil, utils.ErrHotspotProfileNotFound
+}
+
+func findRow(rows []map[string]string, key string) map[string]string {
+	field := "name"
+	if strings.HasPrefix(key, "*") {
+		field = ".id"
+	}
+	for _, row := range rows {
+		if row[field] == key {
+			return row
+		}
+	}
+	return nil
+}
+
+// routerError tells what the router refused apart from failing to reach it
+func routerError(action string, err error) error {
+	if msg, ok := mikrotik.DeviceMessage(err); ok {
+		return fmt.Errorf("%w: %s", utils.ErrRouterRejected, msg)
+	}
+	return fmt.Errorf("%w: failed to %s: %v", utils.ErrConnectionFailed, action, err)
+}
+
+// filterRows applies the list filters to router rows
+func filterRows(rows []map[string]string, req model.HotspotListRequest) []map[string]string {
+	search := strings.ToLower(req.Search)
+	filtered := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		if req.Profile != "" && row["profile"] != req.Profile {
+			continue
+		}
+		if req.Server != "" && row["server"] != req.Server {
+			continue
+		}
+		if req.Disabled != nil && (row["disabled"] == "true") != *req.Disabled {
+			continue
+		}
+		if search != "" && !rowContains(row, search) {
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered
+}
+
+func rowContains(row map[string]string, search string) bool {
+	for _, field := range []string{"name", "user", "address", "mac-address", "comment"} {
+		if strings.Contains(strings.ToLower(row[field]), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func paginateRows(rows []map[string]string, page, pageSize int) *model.PaginationResponse {
+	page, pageSize = normalizePage(page, pageSize)
+	start := min((page-1)*pageSize, len(rows))
+	end := min(start+pageSize, len(rows))
+	return paginationResponse(page, pageSize, int64(len(rows)), rows[start:end])
+}
+
+// parseSaleRecord parses the name of a sales record script,
+// date-|-time-|-user-|-price-|-address-|-mac-|-validity-|-profile-|-comment
+func parseSaleRecord(name string) (model.HotspotSale, time.Time, bool) {
+	parts := strings.Split(name, "-|-")
+	if len(parts) < 9 || parts[0] == "" {
+		return model.HotspotSale{}, time.Time{}, false
+	}
+
+	at, err := time.Parse("Jan/02/2006", strings.ToUpper(parts[0][:1])+parts[0][1:])
+	if err != nil {
+		if at, err = time.Parse("2006-01-02", parts[0]); err != nil {
+			return model.HotspotSale{}, time.Time{}, false
+		}
+	}
+	price, _ := strconv.ParseFloat(parts[3], 64)
+
+	return model.HotspotSale{
+		Date:       at.Format("2006-01-02"),
+		Time:       parts[1],
+		Username:   parts[2],
+		Price:      price,
+		Address:    parts[4],
+		MacAddress: parts[5],
+		Validity:   parts[6],
+		Profile:    parts[7],
+		Comment:    strings.Join(parts[8:], "-|-"),
+	}, at, true
+}
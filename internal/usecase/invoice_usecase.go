@@ -0,0 +1,433 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type InvoiceUsecase interface {
+	// CRUD Operations
+	Create(ctx context.Context, req model.CreateInvoiceRequest) (*entity.Invoice, error)
+	GetByID(ctx context.Context, id string) (*entity.Invoice, error)
+	List(ctx context.Context, req model.InvoiceListRequest) (*model.PaginationResponse, error)
+	Update(ctx context.Context, id string, req model.UpdateInvoiceRequest) (*entity.Invoice, error)
+	Delete(ctx context.Context, id string) error
+
+	// Billing
+	GenerateMonthlyInvoices(ctx context.Context, date time.Time) (*model.InvoiceGenerationResult, error)
+	AutoGenerateEnabled(ctx context.Context) bool
+}
+
+type invoiceUsecase struct {
+	invoiceRepo  repository.InvoiceRepository
+	settingRepo  repository.SettingRepository
+	customerRepo entity.CustomerRepository
+	profileRepo  entity.ProfileRepository
+}
+
+func NewInvoiceUsecase(
+	invoiceRepo repository.InvoiceRepository,
+	settingRepo repository.SettingRepository,
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+) InvoiceUsecase {
+	return &invoiceUsecase{
+		invoiceRepo:  invoiceRepo,
+		settingRepo:  settingRepo,
+		customerRepo: customerRepo,
+		profileRepo:  profileRepo,
+	}
+}
+
+// Create issues a manual invoice for a customer. Amount defaults to the customer's profile price.
+func (uc *invoiceUsecase) Create(ctx context.Context, req model.CreateInvoiceRequest) (*entity.Invoice, error) {
+	customer, err := uc.customerRepo.GetCustomerByID(req.CustomerID)
+	if err != nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+
+	issueDate := truncateDate(time.Now())
+
+	inv := &entity.Invoice{
+		MikrotikID:  customer.MikrotikID,
+		CustomerID:  customer.ID,
+		InvoiceType: entity.InvoiceTypeMonthly,
+		IssueDate:   issueDate,
+		Status:      entity.InvoiceStatusUnpaid,
+	}
+	if req.InvoiceType != "" {
+		inv.InvoiceType = entity.InvoiceType(req.InvoiceType)
+	}
+	if req.Description != "" {
+		inv.Description = &req.Description
+	}
+	if req.Notes != "" {
+		inv.Notes = &req.Notes
+	}
+
+	if profile := uc.customerProfile(customer); profile != nil {
+		inv.ProfileID = &profile.ID
+		inv.ProfileName = &profile.Name
+		if profile.Price != nil {
+			inv.Amount = *profile.Price
+		}
+	}
+	if req.Amount != nil {
+		inv.Amount = *req.Amount
+	} else if inv.ProfileID == nil || inv.Amount == 0 {
+		return nil, utils.ErrInvoicePriceNotSet
+	}
+
+	if req.PeriodStart != "" {
+		start, err := parseDate(req.PeriodStart)
+		if err != nil {
+			return nil, fmt.Errorf("%w: period_start", utils.ErrInvalidDate)
+		}
+		end := nextBillingDate(start, customer.BillingDay).AddDate(0, 0, -1)
+		inv.PeriodStart = &start
+		inv.PeriodEnd = &end
+
+		if inv.InvoiceType == entity.InvoiceTypeMonthly {
+			exists, err := uc.invoiceRepo.ExistsForPeriod(ctx, customer.ID, start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check existing invoice: %w", err)
+			}
+			if exists {
+				return nil, utils.ErrInvoiceAlreadyExists
+			}
+		}
+	}
+
+	if req.DueDate != "" {
+		due, err := parseDate(req.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: due_date", utils.ErrInvalidDate)
+		}
+		inv.DueDate = due
+	} else {
+		dueDays := getIntSetting(ctx, uc.settingRepo, entity.SettingCategoryBilling, entity.SettingInvoiceDueDays, 7)
+		inv.DueDate = issueDate.AddDate(0, 0, dueDays)
+	}
+
+	if err := uc.invoiceRepo.Create(ctx, inv); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrCompanyProfileMissing
+		}
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	pkg_logger.Info("Invoice created",
+		zap.String("id", inv.ID),
+		zap.String("invoice_number", inv.InvoiceNumber),
+		zap.String("customer_id", inv.CustomerID),
+		zap.Float64("amount", inv.Amount),
+	)
+
+	return inv, nil
+}
+
+// GetByID retrieves an invoice by ID
+func (uc *invoiceUsecase) GetByID(ctx context.Context, id string) (*entity.Invoice, error) {
+	inv, err := uc.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return inv, nil
+}
+
+// List retrieves paginated invoices matching the request filters
+func (uc *invoiceUsecase) List(ctx context.Context, req model.InvoiceListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	filter := repository.InvoiceFilter{
+		MikrotikID: req.MikrotikID,
+		CustomerID: req.CustomerID,
+		Status:     req.Status,
+		Type:       req.Type,
+		Search:     req.Search,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if req.DueFrom != "" {
+		from, err := parseDate(req.DueFrom)
+		if err != nil {
+			return nil, fmt.Errorf("%w: due_from", utils.ErrInvalidDate)
+		}
+		filter.DueFrom = &from
+	}
+	if req.DueTo != "" {
+		to, err := parseDate(req.DueTo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: due_to", utils.ErrInvalidDate)
+		}
+		filter.DueTo = &to
+	}
+
+	invoices, total, err := uc.invoiceRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, invoices), nil
+}
+
+// Update updates editable invoice fields
+func (uc *invoiceUsecase) Update(ctx context.Context, id string, req model.UpdateInvoiceRequest) (*entity.Invoice, error) {
+	inv, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount != nil {
+		inv.Amount = *req.Amount
+		if inv.PaidAmount > 0 {
+			// Re-derive paid/partially_paid against the new amount
+			inv.ApplyPaidAmount(inv.PaidAmount, time.Now())
+		}
+	}
+	if req.Description != nil {
+		inv.Description = req.Description
+	}
+	if req.Notes != nil {
+		inv.Notes = req.Notes
+	}
+	if req.DueDate != "" {
+		due, err := parseDate(req.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: due_date", utils.ErrInvalidDate)
+		}
+		inv.DueDate = due
+	}
+	if req.Status != "" && entity.InvoiceStatus(req.Status) != inv.Status {
+		// Only an invoice still owing money can fall overdue, and a cancelled
+		// one stays cancelled
+		if inv.Status == entity.InvoiceStatusCancelled ||
+			(req.Status == string(entity.InvoiceStatusOverdue) && inv.Status == entity.InvoiceStatusPaid) {
+			return nil, utils.ErrInvoiceStatusChange
+		}
+		inv.Status = entity.InvoiceStatus(req.Status)
+	}
+
+	if err := uc.invoiceRepo.Update(ctx, inv); err != nil {
+		return nil, fmt.Errorf("failed to update invoice: %w", err)
+	}
+
+	pkg_logger.Info("Invoice updated",
+		zap.String("id", inv.ID),
+		zap.String("status", string(inv.Status)),
+	)
+
+	return inv, nil
+}
+
+// Delete removes an invoice that has no payments recorded
+func (uc *invoiceUsecase) Delete(ctx context.Context, id string) error {
+	inv, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inv.Status == entity.InvoiceStatusPaid || inv.PaidAmount > 0 {
+		return utils.ErrInvoiceNotDeletable
+	}
+
+	if err := uc.invoiceRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete invoice: %w", err)
+	}
+
+	pkg_logger.Info("Invoice deleted", zap.String("id", id))
+	return nil
+}
+
+// GenerateMonthlyInvoices issues one invoice per billable customer whose billing day falls on date.
+// Billing days past the end of a short month are billed on its last day. Running it twice for
+// the same date is safe: customers already invoiced for the period are skipped.
+func (uc *invoiceUsecase) GenerateMonthlyInvoices(ctx context.Context, date time.Time) (*model.InvoiceGenerationResult, error) {
+	date = truncateDate(date)
+	result := &model.InvoiceGenerationResult{Date: date.Format(dateLayout)}
+
+	customers, err := uc.customerRepo.GetBillableCustomers(billingDaysFor(date))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billable customers: %w", err)
+	}
+
+	dueDays := getIntSetting(ctx, uc.settingRepo, entity.SettingCategoryBilling, entity.SettingInvoiceDueDays, 7)
+
+	for _, customer := range customers {
+		result.Processed++
+
+		created, err := uc.generateForCustomer(ctx, customer, date, dueDays)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", customer.Username, err))
+			pkg_logger.Warn("Failed to generate invoice",
+				zap.String("customer_id", customer.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	pkg_logger.Info("Monthly invoice generation finished",
+		zap.String("date", result.Date),
+		zap.Int("processed", result.Processed),
+		zap.Int("created", result.Created),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// AutoGenerateEnabled reports the billing.auto_generate_invoice setting
+func (uc *invoiceUsecase) AutoGenerateEnabled(ctx context.Context) bool {
+	return getBoolSetting(ctx, uc.settingRepo, entity.SettingCategoryBilling, entity.SettingAutoGenerateInvoice, true)
+}
+
+func (uc *invoiceUsecase) generateForCustomer(ctx context.Context, customer *entity.Customer, date time.Time, dueDays int) (bool, error) {
+	exists, err := uc.invoiceRepo.ExistsForPeriod(ctx, customer.ID, date)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing invoice: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	profile := uc.customerProfile(customer)
+	if profile == nil || profile.Price == nil || *profile.Price <= 0 {
+		return false, utils.ErrInvoicePriceNotSet
+	}
+
+	periodEnd := nextBillingDate(date, customer.BillingDay).AddDate(0, 0, -1)
+	description := fmt.Sprintf("%s %s - %s", profile.Name, date.Format("02 Jan 2006"), periodEnd.Format("02 Jan 2006"))
+
+	inv := &entity.Invoice{
+		MikrotikID:  customer.MikrotikID,
+		CustomerID:  customer.ID,
+		ProfileID:   &profile.ID,
+		ProfileName: &profile.Name,
+		InvoiceType: entity.InvoiceTypeMonthly,
+		Description: &description,
+		Amount:      *profile.Price,
+		PeriodStart: &date,
+		PeriodEnd:   &periodEnd,
+		IssueDate:   date,
+		DueDate:     date.AddDate(0, 0, dueDays),
+		Status:      entity.InvoiceStatusUnpaid,
+	}
+
+	if err := uc.invoiceRepo.Create(ctx, inv); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, utils.ErrCompanyProfileMissing
+		}
+		return false, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	return true, nil
+}
+
+// customerProfile resolves the profile that prices the customer's service
+func (uc *invoiceUsecase) customerProfile(customer *entity.Customer) *entity.MikrotikProfile {
+	profileID := customer.PPPoEProfileID
+	if customer.ServiceType == "hotspot" || profileID == nil {
+		profileID = customer.HotspotProfileID
+	}
+	if profileID == nil || *profileID == "" {
+		return nil
+	}
+
+	profile, err := uc.profileRepo.GetProfileByID(*profileID)
+	if err != nil {
+		return nil
+	}
+	return &profile.MikrotikProfile
+}
+
+// billingDaysFor returns the billing days that are due on date. On the last day of
+// a month this also includes the days that do not exist in that month (e.g. 29-31 in February).
+func billingDaysFor(date time.Time) []int {
+	day := date.Day()
+	if day != daysInMonth(date) {
+		return []int{day}
+	}
+
+	days := make([]int, 0, 32-day)
+	for d := day; d <= 31; d++ {
+		days = append(days, d)
+	}
+	return days
+}
+
+// nextBillingDate returns the billing date one month after from, clamped to month length
+func nextBillingDate(from time.Time, billingDay int) time.Time {
+	if billingDay < 1 {
+		billingDay = from.Day()
+	}
+	firstOfNext := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, from.Location())
+	day := billingDay
+	if last := daysInMonth(firstOfNext); day > last {
+		day = last
+	}
+	return time.Date(firstOfNext.Year(), firstOfNext.Month(), day, 0, 0, 0, 0, from.Location())
+}
+
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// getIntSetting reads a numeric app setting, falling back to def when missing or invalid
+func getIntSetting(ctx context.Context, repo repository.SettingRepository, category, key string, def int) int {
+	s, err := repo.GetSetting(ctx, category, key)
+	if err != nil || s.SettingValue == nil {
+		return def
+	}
+	v, err := strconv.Atoi(*s.SettingValue)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getFloatSetting reads a decimal app setting, falling back to def when missing or invalid
+func getFloatSetting(ctx context.Context, repo repository.SettingRepository, category, key string, def float64) float64 {
+	s, err := repo.GetSetting(ctx, category, key)
+	if err != nil || s.SettingValue == nil {
+		return def
+	}
+	v, err := strconv.ParseFloat(*s.SettingValue, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getBoolSetting reads a boolean app setting, falling back to def when missing or invalid
+func getBoolSetting(ctx context.Context, repo repository.SettingRepository, category, key string, def bool) bool {
+	s, err := repo.GetSetting(ctx, category, key)
+	if err != nil || s.SettingValue == nil {
+		return def
+	}
+	v, err := strconv.ParseBool(*s.SettingValue)
+	if err != nil {
+		return def
+	}
+	return v
+}
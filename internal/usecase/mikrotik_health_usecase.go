@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/model"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// healthPollTimeout bounds each read, as the router's own timeout is often minutes
+	healthPollTimeout = 10 * time.Second
+	// healthPollWorkers is how many routers are polled at once
+	healthPollWorkers = 5
+)
+
+// PollHealth reads resources from every active router and stores the readings.
+// Routers are read over the router manager's connections, and the manager records
+// their online and offline transitions.
+func (s *mikrotikUseCase) PollHealth(ctx context.Context) (*model.MikrotikHealthPollResult, error) {
+	all, err := s.mikrotikRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mikrotiks: %w", err)
+	}
+	mikrotiks := all[:0]
+	for _, mk := range all {
+		if mk.IsActive {
+			mikrotiks = append(mikrotiks, mk)
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = &model.MikrotikHealthPollResult{Checked: len(mikrotiks)}
+		slots  = make(chan struct{}, healthPollWorkers)
+	)
+	for i := range mikrotiks {
+		mk := &mikrotiks[i]
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := s.pollRouter(ctx, mk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Offline++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", mk.Name, err))
+				return
+			}
+			result.Online++
+		}()
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// pollRouter stores one router's reading
+func (s *mikrotikUseCase) pollRouter(ctx context.Context, mk *entity.Mikrotik) error {
+	client, err := s.routers.Client(ctx, mk.ID)
+	if err != nil {
+		return err
+	}
+
+	health, err := readHealth(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mikrotikRepo.SaveHealth(ctx, mk.ID, health); err != nil {
+		return fmt.Errorf("failed to save health: %w", err)
+	}
+	return nil
+}
+
+func readHealth(ctx context.Context, client *mikrotik.Client) (*entity.MikrotikHealth, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthPollTimeout)
+	defer cancel()
+
+	reply, err := client.RunContext(ctx, "/system/resource/print")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system resource: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return nil, fmt.Errorf("router returned no system resource")
+	}
+	resource := reply.Re[0].Map
+
+	health := &entity.MikrotikHealth{
+		Uptime:    resource["uptime"],
+		Version:   resource["version"],
+		BoardName: resource["board-name"],
+	}
+	health.CPULoad, _ = strconv.Atoi(resource["cpu-load"])
+	health.FreeMemory, _ = strconv.ParseInt(resource["free-memory"], 10, 64)
+	health.TotalMemory, _ = strconv.ParseInt(resource["total-memory"], 10, 64)
+
+	// Virtual routers (CHR, x86) have no routerboard to describe
+	if reply, err := client.RunContext(ctx, "/system/routerboard/print"); err == nil && len(reply.Re) > 0 {
+		board := reply.Re[0].Map
+		if board["routerboard"] == "true" {
+			if board["model"] != "" {
+				health.BoardName = board["model"]
+			}
+			health.SerialNumber = board["serial-number"]
+		}
+	}
+
+	return health, nil
+}
+
+// ListStatusHistory lists a router's status transitions, newest first
+func (s *mikrotikUseCase) ListStatusHistory(ctx context.Context, id string, page, pageSize int) (*model.PaginationResponse, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	page, pageSize = normalizePage(page, pageSize)
+	changes, total, err := s.mikrotikRepo.ListStatusHistory(ctx, id, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+
+	data := make([]model.MikrotikStatusChangeResponse, len(changes))
+	for i, c := range changes {
+		from := ""
+		if c.FromStatus != nil {
+			from = string(*c.FromStatus)
+		}
+		data[i] = model.MikrotikStatusChangeResponse{
+			ID:         c.ID,
+			FromStatus: from,
+			ToStatus:   string(c.ToStatus),
+			Reason:     c.Reason,
+			CreatedAt:  c.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	}
+	return paginationResponse(page, pageSize, total, data), nil
+}
@@ -25,11 +25,11 @@ func NewProfileService(repo entity.ProfileRepository, mikrotikUseCase MikrotikUs
 }
 
 // CreateProfileWithSync creates a profile in database and syncs to MikroTik
-func (s *ProfileService) CreateProfileWithSync(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE) error {
+func (s *ProfileService) CreateProfileWithSync(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE, staticIPDetails *entity.MikrotikProfileStaticIP, queueSettings *entity.MikrotikQueueSettings) error {
 	log.Printf("[ProfileService] CreateProfileWithSync - Creating profile: %s", profile.Name)
 
 	// Validate profile
-	if err := s.validateProfile(profile, pppoeDetails); err != nil {
+	if err := s.validateProfile(profile, pppoeDetails, staticIPDetails); err != nil {
 		log.Printf("[ProfileService] CreateProfileWithSync - Validation failed: %v", err)
 		return fmt.Errorf("validation failed: %w", err)
 	}
@@ -39,10 +39,18 @@ func (s *ProfileService) CreateProfileWithSync(profile *entity.MikrotikProfile,
 		log.Printf("[ProfileService] CreateProfileWithSync - DB creation failed: %v", err)
 		return err
 	}
+	if err := s.repo.SaveStaticIPDetails(profile.ID, staticIPDetails); err != nil {
+		log.Printf("[ProfileService] CreateProfileWithSync - Saving static IP details failed: %v", err)
+		return err
+	}
+	if err := s.repo.SaveQueueSettings(profile.ID, queueSettings); err != nil {
+		log.Printf("[ProfileService] CreateProfileWithSync - Saving queue settings failed: %v", err)
+		return err
+	}
 
 	// Sync to MikroTik if enabled
 	if profile.SyncWithMikrotik {
-		if err := s.syncProfileToMikrotik(profile, pppoeDetails); err != nil {
+		if err := s.syncProfileToMikrotik(profile, pppoeDetails, queueSettings); err != nil {
 			log.Printf("[ProfileService] CreateProfileWithSync - WARNING: Sync failed: %v", err)
 			// Don't fail the entire operation if sync fails
 			// Just log the error and continue
@@ -58,11 +66,11 @@ func (s *ProfileService) CreateProfileWithSync(profile *entity.MikrotikProfile,
 }
 
 // UpdateProfileWithSync updates a profile in database and syncs to MikroTik
-func (s *ProfileService) UpdateProfileWithSync(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE) error {
+func (s *ProfileService) UpdateProfileWithSync(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE, staticIPDetails *entity.MikrotikProfileStaticIP, queueSettings *entity.MikrotikQueueSettings) error {
 	log.Printf("[ProfileService] UpdateProfileWithSync - Updating profile: %s", profile.ID)
 
 	// Validate profile
-	if err := s.validateProfile(profile, pppoeDetails); err != nil {
+	if err := s.validateProfile(profile, pppoeDetails, staticIPDetails); err != nil {
 		log.Printf("[ProfileService] UpdateProfileWithSync - Validation failed: %v", err)
 		return fmt.Errorf("validation failed: %w", err)
 	}
@@ -72,10 +80,18 @@ func (s *ProfileService) UpdateProfileWithSync(profile *entity.MikrotikProfile,
 		log.Printf("[ProfileService] UpdateProfileWithSync - DB update failed: %v", err)
 		return err
 	}
+	if err := s.repo.SaveStaticIPDetails(profile.ID, staticIPDetails); err != nil {
+		log.Printf("[ProfileService] UpdateProfileWithSync - Saving static IP details failed: %v", err)
+		return err
+	}
+	if err := s.repo.SaveQueueSettings(profile.ID, queueSettings); err != nil {
+		log.Printf("[ProfileService] UpdateProfileWithSync - Saving queue settings failed: %v", err)
+		return err
+	}
 
 	// Sync to MikroTik if enabled
 	if profile.SyncWithMikrotik {
-		if err := s.syncProfileToMikrotik(profile, pppoeDetails); err != nil {
+		if err := s.syncProfileToMikrotik(profile, pppoeDetails, queueSettings); err != nil {
 			log.Printf("[ProfileService] UpdateProfileWithSync - WARNING: Sync failed: %v", err)
 		} else {
 			now := time.Now()
@@ -104,7 +120,6 @@ func (s *ProfileService) DeleteProfileWithSync(id string) error {
 		if err != nil {
 			log.Printf("[ProfileService] DeleteProfileWithSync - WARNING: Failed to get MikroTik client: %v", err)
 		} else {
-			defer client.Close()
 			if err := client.DeletePPPoEProfile(profile.Name); err != nil {
 				log.Printf("[ProfileService] DeleteProfileWithSync - WARNING: Failed to delete from MikroTik: %v", err)
 				// Continue with DB deletion even if MikroTik deletion fails
@@ -141,7 +156,7 @@ func (s *ProfileService) SyncProfileToMikrotik(id string) error {
 		return err
 	}
 
-	if err := s.syncProfileToMikrotik(&profile.MikrotikProfile, profile.PPPoEDetails); err != nil {
+	if err := s.syncProfileToMikrotik(&profile.MikrotikProfile, profile.PPPoEDetails, profile.QueueSettings); err != nil {
 		return err
 	}
 
@@ -159,7 +174,6 @@ func (s *ProfileService) SyncProfileFromMikrotik(mikrotikID string, profileName
 	if err != nil {
 		return fmt.Errorf("failed to get mikrotik client: %w", err)
 	}
-	defer client.Close()
 
 	mtProfile, err := client.GetPPPoEProfile(profileName)
 	if err != nil {
@@ -198,7 +212,6 @@ func (s *ProfileService) SyncAllProfiles(mikrotikID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get mikrotik client: %w", err)
 	}
-	defer client.Close()
 
 	mtProfiles, err := client.GetPPPoEProfiles()
 	if err != nil {
@@ -227,8 +240,9 @@ func (s *ProfileService) SyncAllProfiles(mikrotikID string) error {
 	return nil
 }
 
-// syncProfileToMikrotik is a helper to sync a profile to MikroTik
-func (s *ProfileService) syncProfileToMikrotik(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE) error {
+// syncProfileToMikrotik is a helper to sync a profile to MikroTik; queue settings
+// extend the rate-limit and set the parent queue and queue type
+func (s *ProfileService) syncProfileToMikrotik(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE, queueSettings *entity.MikrotikQueueSettings) error {
 	if profile.ProfileType != "pppoe" {
 		return fmt.Errorf("only pppoe profiles are supported for sync")
 	}
@@ -250,6 +264,13 @@ func (s *ProfileService) syncProfileToMikrotik(profile *entity.MikrotikProfile,
 		params.RateLimitUp = *profile.RateLimitUp
 		params.RateLimitDown = *profile.RateLimitDown
 	}
+	if queueSettings != nil {
+		params.RateLimit = profileRateLimit(profile, queueSettings)
+		params.ParentQueue = stringValue(queueSettings.ParentQueue)
+		if queueSettings.QueueType != "" {
+			params.QueueType = queueSettings.QueueType + "/" + queueSettings.QueueType
+		}
+	}
 	if profile.IdleTimeout != nil {
 		params.IdleTimeout = *profile.IdleTimeout
 	}
@@ -269,7 +290,6 @@ func (s *ProfileService) syncProfileToMikrotik(profile *entity.MikrotikProfile,
 	if err != nil {
 		return fmt.Errorf("failed to get mikrotik client: %w", err)
 	}
-	defer client.Close()
 
 	profileID, err := client.FindPPPoEProfileID(profile.Name)
 	if err != nil {
@@ -295,9 +315,9 @@ func (s *ProfileService) convertMikrotikProfileToDB(mikrotikID string, mtProfile
 		IsActive:         true,
 	}
 
-	// Parse rate-limit
+	// Parse rate-limit; only its first field, the rate, maps to the profile
 	if rateLimit, ok := mtProfile["rate-limit"]; ok && rateLimit != "" {
-		parts := strings.Split(rateLimit, "/")
+		parts := strings.Split(strings.Fields(rateLimit)[0], "/")
 		if len(parts) == 2 {
 			profile.RateLimitUp = &parts[0]
 			profile.RateLimitDown = &parts[1]
@@ -338,7 +358,7 @@ func (s *ProfileService) convertMikrotikProfileToDB(mikrotikID string, mtProfile
 }
 
 // validateProfile validates profile data
-func (s *ProfileService) validateProfile(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE) error {
+func (s *ProfileService) validateProfile(profile *entity.MikrotikProfile, pppoeDetails *entity.MikrotikProfilePPPoE, staticIPDetails *entity.MikrotikProfileStaticIP) error {
 	if profile.Name == "" {
 		return fmt.Errorf("profile name is required")
 	}
@@ -356,5 +376,26 @@ func (s *ProfileService) validateProfile(profile *entity.MikrotikProfile, pppoeD
 		}
 	}
 
+	if profile.ProfileType == "static_ip" {
+		if staticIPDetails == nil {
+			return fmt.Errorf("static ip details required for static_ip profile")
+		}
+		if staticIPDetails.Gateway == "" || stringValue(staticIPDetails.Interface) == "" {
+			return fmt.Errorf("gateway and interface are required for static_ip profile")
+		}
+		if _, err := gatewayAddress(staticIPDetails); err != nil {
+			return err
+		}
+	}
+
+	if profile.FUPEnabled() {
+		if profile.ProfileType != "pppoe" {
+			return fmt.Errorf("fair usage quota is only supported for pppoe profiles")
+		}
+		if stringValue(profile.FUPRateLimitUp) == "" || stringValue(profile.FUPRateLimitDown) == "" {
+			return fmt.Errorf("fup rate limits are required with a fair usage quota")
+		}
+	}
+
 	return nil
 }
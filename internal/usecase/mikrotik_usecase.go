@@ -35,26 +35,35 @@ type MikrotikUseCase interface {
 
 	// Client Management
 	GetMikrotikClient() (*mikrotik.Client, error)
+	// GetClientByID returns the shared connection to an active router; callers must not close it
 	GetClientByID(ctx context.Context, id string) (*mikrotik.Client, error)
 	TestMikrotikConnection(ctx context.Context) error
+
+	// Health
+	PollHealth(ctx context.Context) (*model.MikrotikHealthPollResult, error)
+	ListStatusHistory(ctx context.Context, id string, page, pageSize int) (*model.PaginationResponse, error)
 }
 
 type mikrotikUseCase struct {
-	mikrotikRepo  repository.MikrotikRepository
-	encryptionKey string
+	mikrotikRepo repository.MikrotikRepository
+	cipher       *utils.Cipher
+	routers      RouterManager
 }
 
-func NewMikrotikUseCase(mikrotikRepo repository.MikrotikRepository, encryptionKey string) MikrotikUseCase {
+func NewMikrotikUseCase(mikrotikRepo repository.MikrotikRepository, cipher *utils.Cipher, routers RouterManager) MikrotikUseCase {
 	return &mikrotikUseCase{
-		mikrotikRepo:  mikrotikRepo,
-		encryptionKey: encryptionKey,
+		mikrotikRepo: mikrotikRepo,
+		cipher:       cipher,
+		routers:      routers,
 	}
 }
 
 // Create creates new Mikrotik configuration
 func (s *mikrotikUseCase) Create(ctx context.Context, req model.CreateMikrotikRequest) (*entity.Mikrotik, error) {
-	// Standard assignment (Plain Text)
-	encryptedPassword := req.APIPassword
+	encryptedPassword, err := s.cipher.Encrypt(req.APIPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt api password: %w", err)
+	}
 
 	mikrotik := &entity.Mikrotik{
 		Name:                 req.Name,
@@ -68,12 +77,28 @@ func (s *mikrotikUseCase) Create(ctx context.Context, req model.CreateMikrotikRe
 		Description:          req.Description,
 		IsActive:             req.IsActive,
 		Status:               entity.MikrotikStatusOffline,
+		SuspensionMode:       req.SuspensionMode,
+		IsolationProfile:     req.IsolationProfile,
+		IsolationRateLimit:   req.IsolationRateLimit,
+		IsolationAddressList: req.IsolationAddressList,
 	}
 
 	// Set default timeout if not provided
 	if mikrotik.Timeout == 0 {
 		mikrotik.Timeout = 300000 // Default 5 minutes
 	}
+	if mikrotik.SuspensionMode == "" {
+		mikrotik.SuspensionMode = entity.SuspensionModeDisable
+	}
+	if mikrotik.IsolationProfile == "" {
+		mikrotik.IsolationProfile = "isolir"
+	}
+	if mikrotik.IsolationRateLimit == "" {
+		mikrotik.IsolationRateLimit = "256k/256k"
+	}
+	if mikrotik.IsolationAddressList == "" {
+		mikrotik.IsolationAddressList = "isolir"
+	}
 
 	if err := s.mikrotikRepo.Create(ctx, mikrotik); err != nil {
 		return nil, fmt.Errorf("failed to create mikrotik: %w", err)
@@ -157,8 +182,11 @@ func (s *mikrotikUseCase) Update(ctx context.Context, id string, req model.Updat
 		mk.APIUsername = req.APIUsername
 	}
 	if req.APIPassword != "" {
-		// Standard assignment (Plain Text)
-		mk.APIEncryptedPassword = req.APIPassword
+		encryptedPassword, err := s.cipher.Encrypt(req.APIPassword)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt api password: %w", err)
+		}
+		mk.APIEncryptedPassword = encryptedPassword
 	}
 	if req.Keepalive != nil {
 		mk.Keepalive = *req.Keepalive
@@ -174,11 +202,29 @@ func (s *mikrotikUseCase) Update(ctx context.Context, id string, req model.Updat
 	}
 	if req.IsActive != nil {
 		mk.IsActive = *req.IsActive
+		// A disabled router cannot serve the single-router endpoints
+		if !mk.IsActive {
+			mk.IsDefault = false
+		}
+	}
+	if req.SuspensionMode != "" {
+		mk.SuspensionMode = req.SuspensionMode
+	}
+	if req.IsolationProfile != "" {
+		mk.IsolationProfile = req.IsolationProfile
+	}
+	if req.IsolationRateLimit != "" {
+		mk.IsolationRateLimit = req.IsolationRateLimit
+	}
+	if req.IsolationAddressList != "" {
+		mk.IsolationAddressList = req.IsolationAddressList
 	}
 
 	if err := s.mikrotikRepo.Update(ctx, mk); err != nil {
 		return fmt.Errorf("failed to update mikrotik: %w", err)
 	}
+	// Open connections still use the old address, credentials or active flag
+	s.routers.Evict(mk.ID)
 
 	pkg_logger.Info("Mikrotik updated",
 		zap.String("id", mk.ID),
@@ -188,11 +234,25 @@ func (s *mikrotikUseCase) Update(ctx context.Context, id string, req model.Updat
 	return nil
 }
 
-// Delete deletes Mikrotik configuration
+// Delete deletes a Mikrotik configuration. Customers, invoices and vouchers cascade
+// with the router, so one that still has customers is refused.
 func (s *mikrotikUseCase) Delete(ctx context.Context, id string) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	customers, err := s.mikrotikRepo.CountCustomers(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to count customers: %w", err)
+	}
+	if customers > 0 {
+		return utils.ErrMikrotikInUse
+	}
+
 	if err := s.mikrotikRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete mikrotik: %w", err)
 	}
+	s.routers.Evict(id)
 
 	pkg_logger.Info("Mikrotik deleted", zap.String("id", id))
 	return nil
@@ -208,31 +268,25 @@ func (s *mikrotikUseCase) TestConnectionByID(ctx context.Context, id string) err
 		return fmt.Errorf("failed to get mikrotik: %w", err)
 	}
 
-	// Use password directly
-	password := mk.APIEncryptedPassword
-
-	pkg_logger.Debug("Decrypt TestConnectionByID", zap.String("password", password))
+	cfg, err := routerConfig(mk, s.cipher)
+	if err != nil {
+		return err
+	}
 
 	// Create client and test connection
-	client, err := mikrotik.NewClient(mikrotik.Config{
-		Host:     mk.Host,
-		Port:     mk.Port,
-		Username: mk.APIUsername,
-		Password: password,
-		Timeout:  time.Duration(mk.Timeout) * time.Millisecond,
-	})
+	client, err := mikrotik.NewClient(cfg)
 	if err != nil {
 		// Update status to offline on connection failure
-		_ = s.mikrotikRepo.UpdateStatus(ctx, id, string(entity.MikrotikStatusOffline))
-		return fmt.Errorf("failed to connect to mikrotik: %w", err)
+		_ = s.mikrotikRepo.ChangeStatus(ctx, id, entity.MikrotikStatusOffline, err.Error())
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
 	}
 	defer client.Close()
 
 	// Test connection by getting system resource
 	_, err = client.Run("/system/resource/print")
 	if err != nil {
-		_ = s.mikrotikRepo.UpdateStatus(ctx, id, string(entity.MikrotikStatusError))
-		return fmt.Errorf("failed to get system resource: %w", err)
+		_ = s.mikrotikRepo.ChangeStatus(ctx, id, entity.MikrotikStatusError, err.Error())
+		return fmt.Errorf("%w: failed to get system resource: %v", utils.ErrConnectionFailed, err)
 	}
 
 	// Update status to online on success
@@ -252,7 +306,8 @@ func (s *mikrotikUseCase) UpdateStatus(ctx context.Context, id string, status st
 	return s.mikrotikRepo.UpdateStatus(ctx, id, status)
 }
 
-// SetActiveMikrotik sets a Mikrotik as active (only one can be active at a time)
+// SetActiveMikrotik makes a Mikrotik the default one served by the single-router
+// endpoints, enabling it if it was disabled. Other routers stay enabled.
 func (s *mikrotikUseCase) SetActiveMikrotik(ctx context.Context, id string) error {
 	// Verify mikrotik exists
 	_, err := s.mikrotikRepo.GetByID(ctx, id)
@@ -263,36 +318,31 @@ func (s *mikrotikUseCase) SetActiveMikrotik(ctx context.Context, id string) erro
 		return fmt.Errorf("failed to get mikrotik: %w", err)
 	}
 
-	// Deactivate all mikrotiks first
-	if err := s.mikrotikRepo.DeactivateAll(ctx); err != nil {
-		return fmt.Errorf("failed to deactivate all mikrotiks: %w", err)
-	}
-
-	// Activate the specified mikrotik
-	if err := s.mikrotikRepo.SetActive(ctx, id, true); err != nil {
+	if err := s.mikrotikRepo.Activate(ctx, id); err != nil {
 		return fmt.Errorf("failed to activate mikrotik: %w", err)
 	}
 
-	pkg_logger.Info("Mikrotik set as active", zap.String("id", id))
+	pkg_logger.Info("Mikrotik set as default", zap.String("id", id))
 	return nil
 }
 
-// GetActiveMikrotik retrieves the currently active Mikrotik
+// GetActiveMikrotik retrieves the default Mikrotik
 func (s *mikrotikUseCase) GetActiveMikrotik(ctx context.Context) (*model.MikrotikStatusResponse, error) {
 	mk, err := s.mikrotikRepo.GetActiveMikrotik(ctx)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("no active mikrotik found")
+			return nil, fmt.Errorf("no active mikrotik found: %w", utils.ErrMikrotikNotFound)
 		}
 		return nil, fmt.Errorf("failed to get active mikrotik: %w", err)
 	}
 
 	return &model.MikrotikStatusResponse{
-		ID:       mk.ID,
-		Name:     mk.Name,
-		Host:     mk.Host,
-		IsActive: mk.IsActive,
-		Status:   string(mk.Status),
+		ID:        mk.ID,
+		Name:      mk.Name,
+		Host:      mk.Host,
+		IsActive:  mk.IsActive,
+		IsDefault: mk.IsDefault,
+		Status:    string(mk.Status),
 	}, nil
 }
 
@@ -311,24 +361,16 @@ func (s *mikrotikUseCase) GetMikrotikClient() (*mikrotik.Client, error) {
 		return nil, errors.New("mikrotik is not active")
 	}
 
-	pkg_logger.Debug("Active MK", zap.String("username", mk.APIUsername), zap.String("encrypted", mk.APIEncryptedPassword))
-
-	// Use password directly
-	password := mk.APIEncryptedPassword
-
-	pkg_logger.Debug("Decrypt GetMikrotikClient", zap.String("password", password))
+	cfg, err := routerConfig(mk, s.cipher)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create client
-	client, err := mikrotik.NewClient(mikrotik.Config{
-		Host:     mk.Host,
-		Port:     mk.Port,
-		Username: mk.APIUsername,
-		Password: password,
-		Timeout:  time.Duration(mk.Timeout) * time.Millisecond,
-	})
+	client, err := mikrotik.NewClient(cfg)
 	if err != nil {
 		// Update mikrotik status to offline
-		_ = s.mikrotikRepo.UpdateStatus(context.Background(), mk.ID, string(entity.MikrotikStatusOffline))
+		_ = s.mikrotikRepo.ChangeStatus(context.Background(), mk.ID, entity.MikrotikStatusOffline, err.Error())
 		return nil, fmt.Errorf("failed to connect to mikrotik: %w", err)
 	}
 
@@ -344,32 +386,10 @@ func (s *mikrotikUseCase) GetMikrotikClient() (*mikrotik.Client, error) {
 	return client, nil
 }
 
-// GetClientByID retrieves a Mikrotik client by ID
+// GetClientByID returns the router manager's shared connection to a router.
+// Callers must not close it.
 func (s *mikrotikUseCase) GetClientByID(ctx context.Context, id string) (*mikrotik.Client, error) {
-	mk, err := s.mikrotikRepo.GetByID(ctx, id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, utils.ErrMikrotikNotFound
-		}
-		return nil, fmt.Errorf("failed to get mikrotik: %w", err)
-	}
-
-	// Use password directly (Plain Text)
-	password := mk.APIEncryptedPassword
-
-	// Create client
-	client, err := mikrotik.NewClient(mikrotik.Config{
-		Host:     mk.Host,
-		Port:     mk.Port,
-		Username: mk.APIUsername,
-		Password: password,
-		Timeout:  time.Duration(mk.Timeout) * time.Millisecond,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to mikrotik: %w", err)
-	}
-
-	return client, nil
+	return s.routers.Client(ctx, id)
 }
 
 // TestMikrotikConnection tests connection to the active Mikrotik
@@ -396,6 +416,10 @@ func (s *mikrotikUseCase) toMikrotikResponse(mk *entity.Mikrotik) *model.Mikroti
 	if mk.LastSync != nil {
 		lastSync = mk.LastSync.Format("2006-01-02 15:04:05")
 	}
+	healthCheckedAt := ""
+	if mk.HealthCheckedAt != nil {
+		healthCheckedAt = mk.HealthCheckedAt.Format("2006-01-02 15:04:05")
+	}
 
 	return &model.MikrotikResponse{
 		ID:          mk.ID,
@@ -408,11 +432,41 @@ func (s *mikrotikUseCase) toMikrotikResponse(mk *entity.Mikrotik) *model.Mikroti
 		Location:    mk.Location,
 		Description: mk.Description,
 		IsActive:    mk.IsActive,
+		IsDefault:   mk.IsDefault,
 		Status:      string(mk.Status),
-		Version:     "", // Dynamic field, not in DB
-		Uptime:      "", // Dynamic field, not in DB
+		Version:     mk.Version,
+		Uptime:      mk.Uptime,
 		LastSync:    lastSync,
 		CreatedAt:   mk.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:   mk.UpdatedAt.Format("2006-01-02 15:04:05"),
+
+		CPULoad:         mk.CPULoad,
+		FreeMemory:      mk.FreeMemory,
+		TotalMemory:     mk.TotalMemory,
+		BoardName:       mk.BoardName,
+		SerialNumber:    mk.SerialNumber,
+		HealthCheckedAt: healthCheckedAt,
+
+		SuspensionMode:       mk.SuspensionMode,
+		IsolationProfile:     mk.IsolationProfile,
+		IsolationRateLimit:   mk.IsolationRateLimit,
+		IsolationAddressList: mk.IsolationAddressList,
+	}
+}
+
+// routerConfig builds the API connection settings for a router record; this is
+// the only place the stored API password is decrypted.
+func routerConfig(mk *entity.Mikrotik, cipher *utils.Cipher) (mikrotik.Config, error) {
+	password, err := cipher.Decrypt(mk.APIEncryptedPassword)
+	if err != nil {
+		return mikrotik.Config{}, fmt.Errorf("failed to decrypt api password of mikrotik %s: %w", mk.ID, err)
 	}
+
+	return mikrotik.Config{
+		Host:     mk.Host,
+		Port:     mk.Port,
+		Username: mk.APIUsername,
+		Password: password,
+		Timeout:  time.Duration(mk.Timeout) * time.Millisecond,
+	}, nil
 }
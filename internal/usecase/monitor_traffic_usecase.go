@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"mikrobill/internal/entity"
-	"mikrobill/internal/infrastructure/mikrotik"
 	mon "mikrobill/internal/infrastructure/mikrotik/monitor"
 	"sync"
 	"time"
@@ -14,7 +13,7 @@ import (
 
 // OnDemandTrafficService monitors traffic only for requested customers
 type OnDemandTrafficService struct {
-	client    *mikrotik.Client
+	routers   RouterManager
 	db        entity.CustomerRepository
 	publisher entity.RedisPublisher
 
@@ -39,12 +38,12 @@ type CustomerMonitor struct {
 
 // NewOnDemandTrafficService creates a new on-demand traffic service
 func NewOnDemandTrafficService(
-	client *mikrotik.Client,
+	routers RouterManager,
 	db entity.CustomerRepository,
 	publisher entity.RedisPublisher,
 ) *OnDemandTrafficService {
 	return &OnDemandTrafficService{
-		client:         client,
+		routers:        routers,
 		db:             db,
 		publisher:      publisher,
 		activeMonitors: make(map[string]*CustomerMonitor),
@@ -201,8 +200,14 @@ func (s *OnDemandTrafficService) runMonitorLoop(ctx context.Context, customer *e
 			}
 			s.mu.Unlock()
 
-			// Start monitoring stream
-			trafficChan, err := mon.MonitorTraffic(ctx, s.client, interfaceName)
+			// Start monitoring stream on its own connection to the customer's router
+			var trafficChan <-chan mon.InterfaceTraffic
+			client, err := s.routers.Stream(ctx, customer.MikrotikID)
+			if err == nil {
+				if trafficChan, err = mon.MonitorTraffic(ctx, client, interfaceName); err != nil {
+					client.Close()
+				}
+			}
 			if err != nil {
 				log.Printf("[OnDemand] Failed to start monitor for %s on %s: %v",
 					customer.Name, interfaceName, err)
@@ -221,6 +226,7 @@ func (s *OnDemandTrafficService) runMonitorLoop(ctx context.Context, customer *e
 
 			// Process traffic data
 			streamClosed := s.processTrafficStream(ctx, customer, trafficChan)
+			client.Close()
 
 			if streamClosed {
 				log.Printf("[OnDemand] Stream closed for %s, attempting restart...", customer.Name)
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/payment"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PaymentGatewayUsecase interface {
+	// CreateCharge creates (or reuses) an online payment link for the invoice's outstanding balance
+	CreateCharge(ctx context.Context, invoiceID, gateway string) (*entity.PaymentGatewayTransaction, error)
+	ListByInvoice(ctx context.Context, invoiceID string) ([]entity.PaymentGatewayTransaction, error)
+	// HandleNotification verifies and applies a gateway callback. Repeated notifications are harmless.
+	HandleNotification(ctx context.Context, gateway string, header http.Header, body []byte) error
+	// SyncStatus pulls the transaction state from the gateway, for notifications that never arrived
+	SyncStatus(ctx context.Context, id string) (*entity.PaymentGatewayTransaction, error)
+}
+
+type paymentGatewayUsecase struct {
+	gatewayRepo    repository.PaymentGatewayRepository
+	invoiceRepo    repository.InvoiceRepository
+	paymentUsecase PaymentUsecase
+	gateways       *payment.Registry
+	chargeExpiry   time.Duration
+}
+
+func NewPaymentGatewayUsecase(
+	gatewayRepo repository.PaymentGatewayRepository,
+	invoiceRepo repository.InvoiceRepository,
+	paymentUsecase PaymentUsecase,
+	gateways *payment.Registry,
+	chargeExpiry time.Duration,
+) PaymentGatewayUsecase {
+	return &paymentGatewayUsecase{
+		gatewayRepo:    gatewayRepo,
+		invoiceRepo:    invoiceRepo,
+		paymentUsecase: paymentUsecase,
+		gateways:       gateways,
+		chargeExpiry:   chargeExpiry,
+	}
+}
+
+func (uc *paymentGatewayUsecase) CreateCharge(ctx context.Context, invoiceID, gatewayName string) (*entity.PaymentGatewayTransaction, error) {
+	gateway, err := uc.gateways.Get(gatewayName)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := uc.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if inv.Status == entity.InvoiceStatusPaid || inv.Status == entity.InvoiceStatusCancelled {
+		return nil, utils.ErrInvoiceNotPayable
+	}
+
+	// Gateways charge whole rupiah
+	amount := math.Round(inv.Outstanding())
+	if amount <= 0 {
+		return nil, utils.ErrNothingToPay
+	}
+
+	now := time.Now()
+	existing, err := uc.gatewayRepo.FindReusable(ctx, inv.ID, gateway.Name(), amount, now)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up pending charges: %w", err)
+	}
+
+	req := payment.ChargeRequest{
+		OrderID:     fmt.Sprintf("%s-%d", inv.InvoiceNumber, now.Unix()),
+		Amount:      amount,
+		Description: "Invoice " + inv.InvoiceNumber,
+		Expiry:      uc.chargeExpiry,
+	}
+	if c := inv.Customer; c != nil {
+		req.CustomerName = c.Name
+		req.CustomerEmail = stringValue(c.Email)
+		req.CustomerPhone = stringValue(c.Phone)
+	}
+
+	charge, err := gateway.CreateCharge(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s charge: %w", gateway.Name(), err)
+	}
+
+	txn := &entity.PaymentGatewayTransaction{
+		InvoiceID:  inv.ID,
+		Gateway:    gateway.Name(),
+		OrderID:    req.OrderID,
+		PaymentURL: &charge.PaymentURL,
+		Token:      &charge.Token,
+		Amount:     amount,
+		Status:     entity.GatewayTransactionPending,
+		ExpiresAt:  charge.ExpiresAt,
+	}
+	if err := uc.gatewayRepo.Create(ctx, txn); err != nil {
+		return nil, fmt.Errorf("failed to save gateway transaction: %w", err)
+	}
+
+	pkg_logger.Info("Gateway charge created",
+		zap.String("gateway", txn.Gateway),
+		zap.String("order_id", txn.OrderID),
+		zap.String("invoice_id", inv.ID),
+		zap.Float64("amount", amount),
+	)
+
+	return txn, nil
+}
+
+func (uc *paymentGatewayUsecase) ListByInvoice(ctx context.Context, invoiceID string) ([]entity.PaymentGatewayTransaction, error) {
+	txns, err := uc.gatewayRepo.ListByInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gateway transactions: %w", err)
+	}
+	return txns, nil
+}
+
+func (uc *paymentGatewayUsecase) HandleNotification(ctx context.Context, gatewayName string, header http.Header, body []byte) error {
+	gateway, err := uc.gateways.Get(gatewayName)
+	if err != nil {
+		return err
+	}
+
+	n, err := gateway.ParseNotification(header, body)
+	if err != nil {
+		return err
+	}
+
+	txn, err := uc.gatewayRepo.GetByOrderID(ctx, gateway.Name(), n.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", utils.ErrGatewayTransactionNotFound, n.OrderID)
+		}
+		return fmt.Errorf("failed to get gateway transaction: %w", err)
+	}
+
+	return uc.apply(ctx, txn, n)
+}
+
+func (uc *paymentGatewayUsecase) SyncStatus(ctx context.Context, id string) (*entity.PaymentGatewayTransaction, error) {
+	txn, err := uc.gatewayRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrGatewayTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to get gateway transaction: %w", err)
+	}
+
+	gateway, err := uc.gateways.Get(txn.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := gateway.GetStatus(ctx, txn.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s status: %w", txn.Gateway, err)
+	}
+
+	if err := uc.apply(ctx, txn, n); err != nil {
+		return nil, err
+	}
+	return uc.gatewayRepo.GetByID(ctx, id)
+}
+
+// apply moves the transaction to the reported state and settles the invoice on success
+func (uc *paymentGatewayUsecase) apply(ctx context.Context, txn *entity.PaymentGatewayTransaction, n *payment.Notification) error {
+	raw := string(n.Raw)
+	txn.Status = entity.GatewayTransactionStatus(n.Status)
+	txn.LastNotification = &raw
+	if n.TransactionID != "" {
+		txn.TransactionID = &n.TransactionID
+	}
+	if n.PaymentType != "" {
+		txn.PaymentType = &n.PaymentType
+	}
+	if n.FraudStatus != "" {
+		txn.FraudStatus = &n.FraudStatus
+	}
+
+	if n.Status != payment.StatusSuccess {
+		if err := uc.gatewayRepo.UpdateStatus(ctx, txn); err != nil {
+			return fmt.Errorf("failed to update gateway transaction: %w", err)
+		}
+		return nil
+	}
+
+	// Never trust the notified amount beyond what was charged. The mismatch is kept on
+	// the transaction for finance and acknowledged, as retries would not change it.
+	if math.Abs(n.Amount-txn.Amount) >= 1 {
+		msg := fmt.Sprintf("amount mismatch: charged %.2f, notified %.2f", txn.Amount, n.Amount)
+		pkg_logger.Error("Gateway notified a different amount than was charged",
+			zap.String("gateway", txn.Gateway),
+			zap.String("order_id", txn.OrderID),
+			zap.String("invoice_id", txn.InvoiceID),
+			zap.Float64("charged", txn.Amount),
+			zap.Float64("notified", n.Amount),
+		)
+		txn.SettleError = &msg
+		return uc.gatewayRepo.UpdateStatus(ctx, txn)
+	}
+
+	paidAt := time.Now()
+	if n.PaidAt != nil {
+		paidAt = *n.PaidAt
+	}
+
+	p, _, err := uc.paymentUsecase.SettleGatewayTransaction(ctx, txn, txn.Amount, paidAt)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvoiceNotPayable) || errors.Is(err, utils.ErrPaymentExceedsBalance) {
+			// The money arrived but the invoice was settled another way in the meantime.
+			// Acknowledge it so the gateway stops retrying; finance refunds it manually.
+			pkg_logger.Warn("Gateway payment received for an invoice that cannot take it",
+				zap.String("gateway", txn.Gateway),
+				zap.String("order_id", txn.OrderID),
+				zap.String("invoice_id", txn.InvoiceID),
+				zap.Error(err),
+			)
+			msg := err.Error()
+			txn.SettleError = &msg
+			return uc.gatewayRepo.UpdateStatus(ctx, txn)
+		}
+		return err
+	}
+	if p == nil {
+		pkg_logger.Info("Duplicate gateway notification ignored",
+			zap.String("gateway", txn.Gateway),
+			zap.String("order_id", txn.OrderID),
+		)
+	}
+	return nil
+}
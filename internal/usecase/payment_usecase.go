@@ -0,0 +1,335 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// eventsChannel is the Redis channel relayed to dashboard WebSocket clients
+const eventsChannel = "mikrotik:events"
+
+type PaymentUsecase interface {
+	RecordPayment(ctx context.Context, invoiceID string, req model.CreatePaymentRequest, receivedBy *int64) (*entity.Payment, *entity.Invoice, error)
+	GetByID(ctx context.Context, id string) (*entity.Payment, error)
+	List(ctx context.Context, req model.PaymentListRequest) (*model.PaginationResponse, error)
+	GetReceipt(ctx context.Context, id string) (*model.PaymentReceipt, error)
+	VoidPayment(ctx context.Context, id string) (*entity.Invoice, error)
+	// SettleGatewayTransaction records the payment for a successful gateway transaction.
+	// It returns a nil payment when the transaction had already been settled.
+	SettleGatewayTransaction(ctx context.Context, txn *entity.PaymentGatewayTransaction, amount float64, paidAt time.Time) (*entity.Payment, *entity.Invoice, error)
+	// OnPaymentRecorded runs the follow-ups of a payment stored outside this usecase,
+	// such as one collected by an agent: event publishing and reactivation.
+	OnPaymentRecorded(ctx context.Context, payment *entity.Payment, inv *entity.Invoice)
+}
+
+type paymentUsecase struct {
+	paymentRepo       repository.PaymentRepository
+	gatewayRepo       repository.PaymentGatewayRepository
+	settingRepo       repository.SettingRepository
+	publisher         entity.RedisPublisher
+	suspensionUsecase SuspensionUsecase
+}
+
+func NewPaymentUsecase(
+	paymentRepo repository.PaymentRepository,
+	gatewayRepo repository.PaymentGatewayRepository,
+	settingRepo repository.SettingRepository,
+	publisher entity.RedisPublisher,
+	suspensionUsecase SuspensionUsecase,
+) PaymentUsecase {
+	return &paymentUsecase{
+		paymentRepo:       paymentRepo,
+		gatewayRepo:       gatewayRepo,
+		settingRepo:       settingRepo,
+		publisher:         publisher,
+		suspensionUsecase: suspensionUsecase,
+	}
+}
+
+// RecordPayment records a (possibly partial) payment against an invoice and
+// moves the invoice to partially_paid or paid accordingly.
+func (uc *paymentUsecase) RecordPayment(ctx context.Context, invoiceID string, req model.CreatePaymentRequest, receivedBy *int64) (*entity.Payment, *entity.Invoice, error) {
+	paymentDate := time.Now()
+	if req.PaymentDate != "" {
+		parsed, err := parseDateTime(req.PaymentDate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: payment_date", utils.ErrInvalidDate)
+		}
+		paymentDate = parsed
+	}
+
+	payment := &entity.Payment{
+		InvoiceID:     invoiceID,
+		Amount:        req.Amount,
+		PaymentMethod: entity.PaymentMethodCash,
+		PaymentDate:   paymentDate,
+		ReceivedBy:    receivedBy,
+	}
+	if req.PaymentMethod != "" {
+		payment.PaymentMethod = entity.PaymentMethod(req.PaymentMethod)
+	}
+	if req.ReferenceNumber != "" {
+		payment.ReferenceNumber = &req.ReferenceNumber
+	}
+	if req.Notes != "" {
+		payment.Notes = &req.Notes
+	}
+
+	inv, err := uc.paymentRepo.Record(ctx, payment, func(inv *entity.Invoice) error {
+		if inv.Status == entity.InvoiceStatusPaid || inv.Status == entity.InvoiceStatusCancelled {
+			return utils.ErrInvoiceNotPayable
+		}
+		if req.Amount > inv.Outstanding() {
+			return fmt.Errorf("%w: outstanding %.2f", utils.ErrPaymentExceedsBalance, inv.Outstanding())
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, utils.ErrInvoiceNotFound
+		}
+		return nil, nil, err
+	}
+
+	uc.OnPaymentRecorded(ctx, payment, inv)
+
+	return payment, inv, nil
+}
+
+// SettleGatewayTransaction records the gateway payment once, however many notifications arrive
+func (uc *paymentUsecase) SettleGatewayTransaction(ctx context.Context, txn *entity.PaymentGatewayTransaction, amount float64, paidAt time.Time) (*entity.Payment, *entity.Invoice, error) {
+	payment := &entity.Payment{
+		Amount:        amount,
+		PaymentMethod: entity.PaymentMethodGateway,
+		PaymentDate:   paidAt,
+	}
+	reference := txn.OrderID
+	if txn.TransactionID != nil && *txn.TransactionID != "" {
+		reference = *txn.TransactionID
+	}
+	payment.ReferenceNumber = &reference
+	notes := "Paid via " + txn.Gateway
+	if txn.PaymentType != nil && *txn.PaymentType != "" {
+		notes += " (" + *txn.PaymentType + ")"
+	}
+	payment.Notes = &notes
+
+	inv, settled, err := uc.gatewayRepo.Settle(ctx, txn, payment, func(inv *entity.Invoice) error {
+		if inv.Status == entity.InvoiceStatusPaid || inv.Status == entity.InvoiceStatusCancelled {
+			return utils.ErrInvoiceNotPayable
+		}
+		outstanding := inv.Outstanding()
+		if payment.Amount > outstanding {
+			// Charges are rounded to whole rupiah; anything beyond that is a real overpayment
+			if payment.Amount-outstanding >= 1 {
+				return fmt.Errorf("%w: outstanding %.2f", utils.ErrPaymentExceedsBalance, outstanding)
+			}
+			payment.Amount = outstanding
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, utils.ErrGatewayTransactionNotFound
+		}
+		return nil, nil, err
+	}
+	if !settled {
+		return nil, nil, nil
+	}
+
+	uc.OnPaymentRecorded(ctx, payment, inv)
+
+	return payment, inv, nil
+}
+
+// OnPaymentRecorded logs, publishes and reactivates after any recorded payment
+func (uc *paymentUsecase) OnPaymentRecorded(ctx context.Context, payment *entity.Payment, inv *entity.Invoice) {
+	pkg_logger.Info("Payment recorded",
+		zap.String("payment_id", payment.ID),
+		zap.String("receipt_number", payment.ReceiptNumber),
+		zap.String("invoice_id", inv.ID),
+		zap.Float64("amount", payment.Amount),
+		zap.String("method", string(payment.PaymentMethod)),
+		zap.String("invoice_status", string(inv.Status)),
+	)
+
+	uc.publishPaymentEvent(payment, inv)
+
+	// Lift a non-payment suspension as soon as the debt is settled. Failures are retried
+	// by the periodic overdue job, so they must not fail the payment itself.
+	if inv.Status == entity.InvoiceStatusPaid && uc.suspensionUsecase != nil {
+		if _, err := uc.suspensionUsecase.ReactivateIfSettled(ctx, inv.CustomerID); err != nil {
+			pkg_logger.Warn("Failed to reactivate customer after payment",
+				zap.String("customer_id", inv.CustomerID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// GetByID retrieves a payment by ID
+func (uc *paymentUsecase) GetByID(ctx context.Context, id string) (*entity.Payment, error) {
+	payment, err := uc.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+// List retrieves paginated payments matching the request filters
+func (uc *paymentUsecase) List(ctx context.Context, req model.PaymentListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	filter := repository.PaymentFilter{
+		InvoiceID:  req.InvoiceID,
+		CustomerID: req.CustomerID,
+		Method:     req.Method,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if req.From != "" {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from", utils.ErrInvalidDate)
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := parseDate(req.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to", utils.ErrInvalidDate)
+		}
+		// Inclusive end date
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	payments, total, err := uc.paymentRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, payments), nil
+}
+
+// GetReceipt builds the receipt for a payment, including company details from company_profile
+func (uc *paymentUsecase) GetReceipt(ctx context.Context, id string) (*model.PaymentReceipt, error) {
+	payment, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &model.PaymentReceipt{
+		ReceiptNumber:   payment.ReceiptNumber,
+		PaymentDate:     payment.PaymentDate.Format("2006-01-02 15:04:05"),
+		Amount:          payment.Amount,
+		PaymentMethod:   string(payment.PaymentMethod),
+		ReferenceNumber: stringValue(payment.ReferenceNumber),
+		Notes:           stringValue(payment.Notes),
+	}
+
+	if inv := payment.Invoice; inv != nil {
+		receipt.InvoiceNumber = inv.InvoiceNumber
+		receipt.InvoiceAmount = inv.Total()
+		receipt.PaidAmount = inv.PaidAmount
+		receipt.Outstanding = inv.Outstanding()
+		receipt.InvoiceStatus = string(inv.Status)
+		if inv.PeriodStart != nil && inv.PeriodEnd != nil {
+			receipt.Period = inv.PeriodStart.Format("02 Jan 2006") + " - " + inv.PeriodEnd.Format("02 Jan 2006")
+		}
+	}
+
+	if c := payment.Customer; c != nil {
+		receipt.CustomerName = c.Name
+		receipt.CustomerPhone = stringValue(c.Phone)
+		receipt.CustomerAddress = stringValue(c.Address)
+	}
+
+	if company, err := uc.settingRepo.GetCompanyProfile(ctx); err == nil {
+		receipt.CompanyName = company.CompanyName
+		receipt.CompanyAddress = stringValue(company.CompanyAddress)
+		receipt.CompanyPhone = stringValue(company.CompanyPhone)
+		receipt.CompanyEmail = stringValue(company.CompanyEmail)
+		receipt.LogoURL = stringValue(company.LogoURL)
+	}
+
+	return receipt, nil
+}
+
+// VoidPayment removes a payment recorded by mistake and recalculates the invoice status
+func (uc *paymentUsecase) VoidPayment(ctx context.Context, id string) (*entity.Invoice, error) {
+	inv, err := uc.paymentRepo.Void(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to void payment: %w", err)
+	}
+
+	pkg_logger.Info("Payment voided",
+		zap.String("payment_id", id),
+		zap.String("invoice_id", inv.ID),
+		zap.String("invoice_status", string(inv.Status)),
+	)
+
+	return inv, nil
+}
+
+func (uc *paymentUsecase) publishPaymentEvent(payment *entity.Payment, inv *entity.Invoice) {
+	if uc.publisher == nil {
+		return
+	}
+
+	event := model.PaymentEvent{
+		Type:          "payment_received",
+		PaymentID:     payment.ID,
+		ReceiptNumber: payment.ReceiptNumber,
+		InvoiceID:     inv.ID,
+		InvoiceNumber: inv.InvoiceNumber,
+		CustomerID:    inv.CustomerID,
+		Amount:        payment.Amount,
+		PaymentMethod: string(payment.PaymentMethod),
+		PaidAmount:    inv.PaidAmount,
+		Outstanding:   inv.Outstanding(),
+		InvoiceStatus: string(inv.Status),
+		Timestamp:     time.Now().Format(time.RFC3339),
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err := uc.publisher.Publish(eventsChannel, string(data)); err != nil {
+		pkg_logger.Warn("Failed to publish payment event", zap.Error(err))
+	}
+}
+
+// parseDateTime accepts either an RFC3339 timestamp or a plain date
+func parseDateTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return parseDate(s)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,340 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik/ppp"
+	"mikrobill/internal/model"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PPPUsecase reads a router's PPP secrets, profiles and active sessions. Secrets that
+// belong to customers are changed through the customer and suspension flows, so
+// only unlinked ones can be enabled or disabled here.
+type PPPUsecase interface {
+	ListActive(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error)
+	Disconnect(ctx context.Context, mikrotikID, activeID string) error
+	DisconnectUser(ctx context.Context, mikrotikID, username string) (*model.PPPDisconnectResult, error)
+
+	ListSecrets(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error)
+	// GetSecret, EnableSecret and DisableSecret take a router id (*1A) or a secret name
+	GetSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error)
+	EnableSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error)
+	DisableSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error)
+
+	ListProfiles(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error)
+	GetProfile(ctx context.Context, mikrotikID, profile string) (map[string]string, error)
+
+	Stats(ctx context.Context, mikrotikID string) (*model.PPPStats, error)
+}
+
+type pppUsecase struct {
+	customerRepo    entity.CustomerRepository
+	mikrotikUseCase MikrotikUseCase
+}
+
+func NewPPPUsecase(customerRepo entity.CustomerRepository, mikrotikUseCase MikrotikUseCase) PPPUsecase {
+	return &pppUsecase{
+		customerRepo:    customerRepo,
+		mikrotikUseCase: mikrotikUseCase,
+	}
+}
+
+func (uc *pppUsecase) ListActive(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error) {
+	var active []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		resp, err := svc.GetActiveConnections()
+		if err != nil {
+			return routerError("list active ppp sessions", err)
+		}
+		active, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req.Unlinked = false
+	return paginateRows(filterPPPRows(active, req, nil), req.Page, req.PageSize), nil
+}
+
+func (uc *pppUsecase) Disconnect(ctx context.Context, mikrotikID, activeID string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		if _, err := svc.DisconnectActiveConnection(activeID); err != nil {
+			return routerError("disconnect ppp session", err)
+		}
+		return nil
+	})
+}
+
+func (uc *pppUsecase) DisconnectUser(ctx context.Context, mikrotikID, username string) (*model.PPPDisconnectResult, error) {
+	result := &model.PPPDisconnectResult{Username: username}
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		resp, err := svc.DisconnectByUsername(username)
+		if err != nil {
+			return routerError("disconnect ppp sessions", err)
+		}
+		if data, ok := resp.Data.(map[string]interface{}); ok {
+			result.Disconnected, _ = data["disconnected"].(int)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("PPP sessions disconnected",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("username", username),
+		zap.Int("count", result.Disconnected),
+	)
+	return result, nil
+}
+
+func (uc *pppUsecase) ListSecrets(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error) {
+	var linked map[string]string
+	if req.Unlinked {
+		var err error
+		if linked, err = uc.customerRepo.GetPPPoEUsernames(mikrotikID); err != nil {
+			return nil, err
+		}
+	}
+
+	var secrets []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		resp, err := svc.GetAllSecrets()
+		if err != nil {
+			return routerError("list ppp secrets", err)
+		}
+		secrets, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterPPPRows(secrets, req, linked), req.Page, req.PageSize), nil
+}
+
+func (uc *pppUsecase) GetSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) (err error) {
+		found, err = findSecret(svc, secret)
+		return err
+	})
+	return found, err
+}
+
+func (uc *pppUsecase) EnableSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error) {
+	return uc.setSecretDisabled(ctx, mikrotikID, secret, false)
+}
+
+func (uc *pppUsecase) DisableSecret(ctx context.Context, mikrotikID, secret string) (map[string]string, error) {
+	return uc.setSecretDisabled(ctx, mikrotikID, secret, true)
+}
+
+func (uc *pppUsecase) setSecretDisabled(ctx context.Context, mikrotikID, secret string, disabled bool) (map[string]string, error) {
+	linked, err := uc.customerRepo.GetPPPoEUsernames(mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated map[string]string
+	err = uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		current, err := findSecret(svc, secret)
+		if err != nil {
+			return err
+		}
+		if _, ok := linked[current["name"]]; ok {
+			return utils.ErrSecretLinked
+		}
+
+		if disabled {
+			_, err = svc.DisableSecret(current[".id"])
+		} else {
+			_, err = svc.EnableSecret(current[".id"])
+		}
+		if err != nil {
+			return routerError("update ppp secret", err)
+		}
+		updated, err = findSecret(svc, current[".id"])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("PPP secret updated",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("name", updated["name"]),
+		zap.Bool("disabled", disabled),
+	)
+	return updated, nil
+}
+
+func (uc *pppUsecase) ListProfiles(ctx context.Context, mikrotikID string, req model.PPPListRequest) (*model.PaginationResponse, error) {
+	var profiles []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		resp, err := svc.ListProfiles()
+		if err != nil {
+			return routerError("list ppp profiles", err)
+		}
+		profiles, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req.Service, req.Profile, req.Disabled, req.Unlinked = "", "", nil, false
+	return paginateRows(filterPPPRows(profiles, req, nil), req.Page, req.PageSize), nil
+}
+
+func (uc *pppUsecase) GetProfile(ctx context.Context, mikrotikID, profile string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		resp, err := svc.ListProfiles()
+		if err != nil {
+			return routerError("list ppp profiles", err)
+		}
+		profiles, _ := resp.Data.([]map[string]string)
+		if found = findRow(profiles, profile); found == nil {
+			return utils.ErrPPPProfileNotFound
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (uc *pppUsecase) Stats(ctx context.Context, mikrotikID string) (*model.PPPStats, error) {
+	linked, err := uc.customerRepo.GetPPPoEUsernames(mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		activeByService map[string]int
+		secrets         []map[string]string
+	)
+	stats := &model.PPPStats{MikrotikID: mikrotikID}
+	err = uc.withService(ctx, mikrotikID, func(svc *ppp.Service) error {
+		active, err := svc.GetActiveConnectionStats()
+		if err != nil {
+			return routerError("read ppp session stats", err)
+		}
+		stats.Active, _ = active["total"].(int)
+		activeByService, _ = active["by_service"].(map[string]int)
+
+		resp, err := svc.GetAllSecrets()
+		if err != nil {
+			return routerError("list ppp secrets", err)
+		}
+		secrets, _ = resp.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byService := map[string]*model.PPPServiceStats{}
+	serviceStats := func(service string) *model.PPPServiceStats {
+		s, ok := byService[service]
+		if !ok {
+			s = &model.PPPServiceStats{Service: service}
+			byService[service] = s
+		}
+		return s
+	}
+	for service, count := range activeByService {
+		serviceStats(service).Active = count
+	}
+	for _, secret := range secrets {
+		s := serviceStats(secret["service"])
+		s.Secrets++
+		if secret["disabled"] == "true" {
+			s.DisabledSecrets++
+		}
+		if _, ok := linked[secret["name"]]; !ok {
+			stats.UnlinkedSecrets++
+		}
+	}
+	stats.Secrets = len(secrets)
+
+	stats.ByService = make([]model.PPPServiceStats, 0, len(byService))
+	for _, s := range byService {
+		stats.ByService = append(stats.ByService, *s)
+	}
+	sort.Slice(stats.ByService, func(i, j int) bool { return stats.ByService[i].Service < stats.ByService[j].Service })
+
+	return stats, nil
+}
+
+// withService connects to the router for the length of fn
+func (uc *pppUsecase) withService(ctx context.Context, mikrotikID string, fn func(svc *ppp.Service) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(ppp.NewService(client))
+}
+
+// findSecret looks a PPP secret up by router id or name
+func findSecret(svc *ppp.Service, secret string) (map[string]string, error) {
+	resp, err := svc.GetAllSecrets()
+	if err != nil {
+		return nil, routerError("list ppp secrets", err)
+	}
+	secrets, _ := resp.Data.([]map[string]string)
+	if s := findRow(secrets, secret); s != nil {
+		return s, nil
+	}
+	return nil, utils.ErrSecretNotFound
+}
+
+// filterPPPRows applies the list filters; linked, when given, holds the secret
+// names customers use and is only consulted for req.Unlinked.
+func filterPPPRows(rows []map[string]string, req model.PPPListRequest, linked map[string]string) []map[string]string {
+	search := strings.ToLower(req.Search)
+	filtered := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		if req.Service != "" && row["service"] != req.Service {
+			continue
+		}
+		if req.Profile != "" && row["profile"] != req.Profile {
+			continue
+		}
+		if req.Disabled != nil && (row["disabled"] == "true") != *req.Disabled {
+			continue
+		}
+		if req.Unlinked {
+			if _, ok := linked[row["name"]]; ok {
+				continue
+			}
+		}
+		if search != "" && !pppRowContains(row, search) {
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered
+}
+
+func pppRowContains(row map[string]string, search string) bool {
+	for _, field := range []string{"name", "address", "remote-address", "caller-id", "comment"} {
+		if strings.Contains(strings.ToLower(row[field]), search) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,451 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	mkmodel "mikrobill/internal/infrastructure/mikrotik/model"
+	"mikrobill/internal/infrastructure/mikrotik/queue"
+	"mikrobill/internal/model"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// QueueUsecase manages a router's simple queues and queue trees, and the simple
+// queues that shape a customer by their profile's rate and queue settings
+type QueueUsecase interface {
+	ListSimple(ctx context.Context, mikrotikID string, req model.QueueListRequest) (*model.PaginationResponse, error)
+	// GetSimple, UpdateSimple and DeleteSimple take a router id (*1A) or a queue name
+	GetSimple(ctx context.Context, mikrotikID, name string) (map[string]string, error)
+	CreateSimple(ctx context.Context, mikrotikID string, req model.SimpleQueueRequest) (map[string]string, error)
+	UpdateSimple(ctx context.Context, mikrotikID, name string, req model.UpdateSimpleQueueRequest) (map[string]string, error)
+	DeleteSimple(ctx context.Context, mikrotikID, name string) error
+
+	ListTree(ctx context.Context, mikrotikID string, req model.QueueListRequest) (*model.PaginationResponse, error)
+	GetTree(ctx context.Context, mikrotikID, name string) (map[string]string, error)
+	CreateTree(ctx context.Context, mikrotikID string, req model.QueueTreeRequest) (map[string]string, error)
+	UpdateTree(ctx context.Context, mikrotikID, name string, req model.UpdateQueueTreeRequest) (map[string]string, error)
+	DeleteTree(ctx context.Context, mikrotikID, name string) error
+
+	// ApplyProfileQueue creates or updates the simple queue name limiting target
+	// to the profile's rate and queue settings, on the profile's router
+	ApplyProfileQueue(ctx context.Context, profile *entity.ProfileWithPPPoE, name, target, comment string) error
+}
+
+type queueUsecase struct {
+	mikrotikUseCase MikrotikUseCase
+}
+
+func NewQueueUsecase(mikrotikUseCase MikrotikUseCase) QueueUsecase {
+	return &queueUsecase{mikrotikUseCase: mikrotikUseCase}
+}
+
+func (uc *queueUsecase) ListSimple(ctx context.Context, mikrotikID string, req model.QueueListRequest) (*model.PaginationResponse, error) {
+	var queues []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) (err error) {
+		queues, err = listSimpleQueues(svc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterQueues(queues, req), req.Page, req.PageSize), nil
+}
+
+func (uc *queueUsecase) GetSimple(ctx context.Context, mikrotikID, name string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) (err error) {
+		found, err = findSimpleQueue(svc, name)
+		return err
+	})
+	return found, err
+}
+
+func (uc *queueUsecase) CreateSimple(ctx context.Context, mikrotikID string, req model.SimpleQueueRequest) (map[string]string, error) {
+	var created map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		_, err := svc.AddSimpleQueue(mkmodel.SimpleQueueRequest{
+			Name:           req.Name,
+			Target:         req.Target,
+			Parent:         req.Parent,
+			MaxLimit:       req.MaxLimit,
+			LimitAt:        req.LimitAt,
+			BurstLimit:     req.BurstLimit,
+			BurstThreshold: req.BurstThreshold,
+			BurstTime:      req.BurstTime,
+			Priority:       req.Priority,
+			Queue:          req.Queue,
+			PacketMarks:    strings.Join(req.PacketMarks, ","),
+			Comment:        req.Comment,
+			Disabled:       req.Disabled,
+		})
+		if err != nil {
+			return routerError("add simple queue", err)
+		}
+		created, err = findSimpleQueue(svc, req.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Simple queue created",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("name", req.Name),
+		zap.String("target", req.Target),
+	)
+	return created, nil
+}
+
+func (uc *queueUsecase) UpdateSimple(ctx context.Context, mikrotikID, name string, req model.UpdateSimpleQueueRequest) (map[string]string, error) {
+	var updated map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		current, err := findSimpleQueue(svc, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.UpdateSimpleQueue(current[".id"], mkmodel.SimpleQueueUpdateRequest{
+			Name:           req.Name,
+			Target:         req.Target,
+			Parent:         req.Parent,
+			MaxLimit:       req.MaxLimit,
+			LimitAt:        req.LimitAt,
+			BurstLimit:     req.BurstLimit,
+			BurstThreshold: req.BurstThreshold,
+			BurstTime:      req.BurstTime,
+			Priority:       req.Priority,
+			Queue:          req.Queue,
+			PacketMarks:    strings.Join(req.PacketMarks, ","),
+			Comment:        req.Comment,
+			Disabled:       req.Disabled,
+		})
+		if err != nil {
+			return routerError("update simple queue", err)
+		}
+		updated, err = findSimpleQueue(svc, current[".id"])
+		return err
+	})
+	return updated, err
+}
+
+func (uc *queueUsecase) DeleteSimple(ctx context.Context, mikrotikID, name string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		current, err := findSimpleQueue(svc, name)
+		if err != nil {
+			return err
+		}
+		if _, err := svc.DeleteSimpleQueue(current[".id"]); err != nil {
+			return routerError("remove simple queue", err)
+		}
+		return nil
+	})
+}
+
+func (uc *queueUsecase) ListTree(ctx context.Context, mikrotikID string, req model.QueueListRequest) (*model.PaginationResponse, error) {
+	var queues []map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) (err error) {
+		queues, err = listQueueTrees(svc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateRows(filterQueues(queues, req), req.Page, req.PageSize), nil
+}
+
+func (uc *queueUsecase) GetTree(ctx context.Context, mikrotikID, name string) (map[string]string, error) {
+	var found map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) (err error) {
+		found, err = findQueueTree(svc, name)
+		return err
+	})
+	return found, err
+}
+
+func (uc *queueUsecase) CreateTree(ctx context.Context, mikrotikID string, req model.QueueTreeRequest) (map[string]string, error) {
+	var created map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		_, err := svc.AddQueueTree(mkmodel.QueueTreeRequest{
+			Name:           req.Name,
+			Parent:         req.Parent,
+			PacketMark:     req.PacketMark,
+			MaxLimit:       req.MaxLimit,
+			LimitAt:        req.LimitAt,
+			BurstLimit:     req.BurstLimit,
+			BurstThreshold: req.BurstThreshold,
+			BurstTime:      req.BurstTime,
+			Priority:       req.Priority,
+			Queue:          req.Queue,
+			Comment:        req.Comment,
+			Disabled:       req.Disabled,
+		})
+		if err != nil {
+			return routerError("add queue tree", err)
+		}
+		created, err = findQueueTree(svc, req.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Queue tree created",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("name", req.Name),
+		zap.String("parent", req.Parent),
+	)
+	return created, nil
+}
+
+func (uc *queueUsecase) UpdateTree(ctx context.Context, mikrotikID, name string, req model.UpdateQueueTreeRequest) (map[string]string, error) {
+	var updated map[string]string
+	err := uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		current, err := findQueueTree(svc, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.UpdateQueueTree(current[".id"], mkmodel.QueueTreeUpdateRequest{
+			Name:           req.Name,
+			Parent:         req.Parent,
+			PacketMark:     req.PacketMark,
+			MaxLimit:       req.MaxLimit,
+			LimitAt:        req.LimitAt,
+			BurstLimit:     req.BurstLimit,
+			BurstThreshold: req.BurstThreshold,
+			BurstTime:      req.BurstTime,
+			Priority:       req.Priority,
+			Queue:          req.Queue,
+			Comment:        req.Comment,
+			Disabled:       req.Disabled,
+		})
+		if err != nil {
+			return routerError("update queue tree", err)
+		}
+		updated, err = findQueueTree(svc, current[".id"])
+		return err
+	})
+	return updated, err
+}
+
+func (uc *queueUsecase) DeleteTree(ctx context.Context, mikrotikID, name string) error {
+	return uc.withService(ctx, mikrotikID, func(svc *queue.Service) error {
+		current, err := findQueueTree(svc, name)
+		if err != nil {
+			return err
+		}
+		if _, err := svc.DeleteQueueTree(current[".id"]); err != nil {
+			return routerError("remove queue tree", err)
+		}
+		return nil
+	})
+}
+
+func (uc *queueUsecase) ApplyProfileQueue(ctx context.Context, profile *entity.ProfileWithPPPoE, name, target, comment string) error {
+	config := profileSimpleQueue(&profile.MikrotikProfile, profile.QueueSettings)
+	config.Name, config.Target, config.Comment = name, target, comment
+
+	return uc.withService(ctx, profile.MikrotikID, func(svc *queue.Service) error {
+		current, err := findSimpleQueue(svc, name)
+		if errors.Is(err, utils.ErrQueueNotFound) {
+			if _, err := svc.AddSimpleQueue(config); err != nil {
+				return routerError("add simple queue", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.UpdateSimpleQueue(current[".id"], mkmodel.SimpleQueueUpdateRequest{
+			Target:         config.Target,
+			Parent:         config.Parent,
+			MaxLimit:       config.MaxLimit,
+			LimitAt:        config.LimitAt,
+			BurstLimit:     config.BurstLimit,
+			BurstThreshold: config.BurstThreshold,
+			BurstTime:      config.BurstTime,
+			Priority:       config.Priority,
+			Queue:          config.Queue,
+			PacketMarks:    config.PacketMarks,
+			Comment:        config.Comment,
+		})
+		if err != nil {
+			return routerError("update simple queue", err)
+		}
+		return nil
+	})
+}
+
+// withService connects to the router for the length of fn
+func (uc *queueUsecase) withService(ctx context.Context, mikrotikID string, fn func(svc *queue.Service) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(queue.NewService(client))
+}
+
+func listSimpleQueues(svc *queue.Service) ([]map[string]string, error) {
+	resp, err := svc.GetAllSimpleQueues()
+	if err != nil {
+		return nil, routerError("list simple queues", err)
+	}
+	queues, _ := resp.Data.([]map[string]string)
+	return queues, nil
+}
+
+func listQueueTrees(svc *queue.Service) ([]map[string]string, error) {
+	resp, err := svc.GetAllQueueTrees()
+	if err != nil {
+		return nil, routerError("list queue trees", err)
+	}
+	queues, _ := resp.Data.([]map[string]string)
+	return queues, nil
+}
+
+// findSimpleQueue looks a simple queue up by router id or name
+func findSimpleQueue(svc *queue.Service, name string) (map[string]string, error) {
+	queues, err := listSimpleQueues(svc)
+	if err != nil {
+		return nil, err
+	}
+	if q := findRow(queues, name); q != nil {
+		return q, nil
+	}
+	return nil, utils.ErrQueueNotFound
+}
+
+// findQueueTree looks a queue tree entry up by router id or name
+func findQueueTree(svc *queue.Service, name string) (map[string]string, error) {
+	queues, err := listQueueTrees(svc)
+	if err != nil {
+		return nil, err
+	}
+	if q := findRow(queues, name); q != nil {
+		return q, nil
+	}
+	return nil, utils.ErrQueueNotFound
+}
+
+// filterQueues applies the list filters to queue rows
+func filterQueues(rows []map[string]string, req model.QueueListRequest) []map[string]string {
+	search := strings.ToLower(req.Search)
+	filtered := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		if req.Parent != "" && row["parent"] != req.Parent {
+			continue
+		}
+		if req.Disabled != nil && (row["disabled"] == "true") != *req.Disabled {
+			continue
+		}
+		if search != "" && !queueContains(row, search) {
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered
+}
+
+func queueContains(row map[string]string, search string) bool {
+	for _, field := range []string{"name", "target", "packet-mark", "packet-marks", "comment"} {
+		if strings.Contains(strings.ToLower(row[field]), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// profileSimpleQueue builds the simple queue of a profile: its rate as max-limit
+// unless the queue settings set one, and the settings' bursts, guaranteed rate,
+// parent, priority and queue type
+func profileSimpleQueue(profile *entity.MikrotikProfile, settings *entity.MikrotikQueueSettings) mkmodel.SimpleQueueRequest {
+	config := mkmodel.SimpleQueueRequest{
+		MaxLimit: limitPair(profile.RateLimitUp, profile.RateLimitDown),
+	}
+	if settings == nil {
+		return config
+	}
+
+	if maxLimit := limitPair(settings.MaxLimitUp, settings.MaxLimitDown); maxLimit != "" {
+		config.MaxLimit = maxLimit
+	}
+	config.LimitAt = limitPair(settings.LimitAtUp, settings.LimitAtDown)
+	config.BurstLimit = limitPair(settings.BurstLimitUp, settings.BurstLimitDown)
+	config.BurstThreshold = limitPair(settings.BurstThresholdUp, settings.BurstThresholdDown)
+	if config.BurstLimit != "" && settings.BurstTime != "" {
+		config.BurstTime = settings.BurstTime + "/" + settings.BurstTime
+	}
+	if settings.Priority != "" {
+		config.Priority = settings.Priority + "/" + settings.Priority
+	}
+	if settings.QueueType != "" {
+		config.Queue = settings.QueueType + "/" + settings.QueueType
+	}
+	config.Parent = stringValue(settings.ParentQueue)
+	config.PacketMarks = strings.Join(settings.PacketMarks, ",")
+	return config
+}
+
+// profileRateLimit builds the rate-limit of a PPP profile from its rate and queue
+// settings. RouterOS reads the fields by position, up/down [burst] [threshold]
+// [burst-time] [priority] [limit-at], so a field before a set one gets its default.
+func profileRateLimit(profile *entity.MikrotikProfile, settings *entity.MikrotikQueueSettings) string {
+	config := profileSimpleQueue(profile, settings)
+	if config.MaxLimit == "" {
+		return ""
+	}
+
+	fields := []string{
+		config.MaxLimit,
+		config.BurstLimit,
+		config.BurstThreshold,
+		config.BurstTime,
+		"",
+		config.LimitAt,
+	}
+	// 8 is the lowest priority and the default, left out to keep the limit short
+	if settings != nil && settings.Priority != "8" {
+		fields[4] = settings.Priority
+	}
+	defaults := []string{"", "0/0", "0/0", "0s/0s", "8", ""}
+
+	last := 0
+	for i, f := range fields {
+		if f != "" {
+			last = i
+		}
+	}
+	for i := 1; i <= last; i++ {
+		if fields[i] == "" {
+			fields[i] = defaults[i]
+		}
+	}
+	return strings.Join(fields[:last+1], " ")
+}
+
+// limitPair joins an up and down limit, either of which may be unset
+func limitPair(up, down *string) string {
+	u, d := stringValue(up), stringValue(down)
+	if u == "" && d == "" {
+		return ""
+	}
+	if u == "" {
+		u = "0"
+	}
+	if d == "" {
+		d = "0"
+	}
+	return u + "/" + d
+}
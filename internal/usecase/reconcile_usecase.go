@@ -0,0 +1,650 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/hotspot"
+	mkmodel "mikrobill/internal/infrastructure/mikrotik/model"
+	"mikrobill/internal/infrastructure/mikrotik/ppp"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReconcileUsecase compares a router's PPP secrets, PPP profiles and hotspot users
+// with the customers and profiles in the database. Customer writes reach the router
+// best-effort, so the two drift apart; push writes the database state back to the
+// router, adopt takes the router state into the database.
+type ReconcileUsecase interface {
+	Reconcile(ctx context.Context, mikrotikID string, req model.ReconcileRequest) (*model.DriftReport, error)
+}
+
+type reconcileUsecase struct {
+	customerRepo    entity.CustomerRepository
+	profileRepo     entity.ProfileRepository
+	mikrotikRepo    repository.MikrotikRepository
+	fupRepo         repository.FUPRepository
+	voucherRepo     repository.VoucherRepository
+	mikrotikUseCase MikrotikUseCase
+	profileService  *ProfileService
+	cipher          *utils.Cipher
+}
+
+func NewReconcileUsecase(
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	fupRepo repository.FUPRepository,
+	voucherRepo repository.VoucherRepository,
+	mikrotikUseCase MikrotikUseCase,
+	profileService *ProfileService,
+	cipher *utils.Cipher,
+) ReconcileUsecase {
+	return &reconcileUsecase{
+		customerRepo:    customerRepo,
+		profileRepo:     profileRepo,
+		mikrotikRepo:    mikrotikRepo,
+		fupRepo:         fupRepo,
+		voucherRepo:     voucherRepo,
+		mikrotikUseCase: mikrotikUseCase,
+		profileService:  profileService,
+		cipher:          cipher,
+	}
+}
+
+// drift is a report item with the change that resolves it; apply is nil for items
+// that are only reported
+type drift struct {
+	item  model.DriftItem
+	apply func(client *mikrotik.Client) error
+}
+
+// routerState is what the router holds, read in one connection
+type routerState struct {
+	secrets  []map[string]string
+	profiles []map[string]string
+	users    []map[string]string
+}
+
+func (uc *reconcileUsecase) Reconcile(ctx context.Context, mikrotikID string, req model.ReconcileRequest) (*model.DriftReport, error) {
+	if req.Direction == "" {
+		req.Direction = model.ReconcilePush
+	}
+
+	mk, err := uc.mikrotikRepo.GetByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMikrotikNotFound
+		}
+		return nil, fmt.Errorf("failed to get mikrotik: %w", err)
+	}
+	customers, err := uc.customerRepo.GetRouterSessionCustomers(mikrotikID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+	profiles, err := uc.profileRepo.GetProfilesByMikrotikID(mikrotikID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	var state routerState
+	err = uc.withClient(ctx, mikrotikID, func(client *mikrotik.Client) error {
+		pppSvc, hsSvc := ppp.NewService(client), hotspot.NewService(client)
+		secrets, err := pppSvc.GetAllSecrets()
+		if err != nil {
+			return routerError("list ppp secrets", err)
+		}
+		state.secrets, _ = secrets.Data.([]map[string]string)
+
+		pppProfiles, err := pppSvc.ListProfiles()
+		if err != nil {
+			return routerError("list ppp profiles", err)
+		}
+		state.profiles, _ = pppProfiles.Data.([]map[string]string)
+
+		users, err := hsSvc.GetAllUsers()
+		if err != nil {
+			return routerError("list hotspot users", err)
+		}
+		state.users, _ = users.Data.([]map[string]string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Profiles go first, so secrets pushed afterwards find theirs on the router
+	drifts := uc.diffProfiles(mk, profiles, state.profiles, req)
+	drifts = append(drifts, uc.diffSecrets(ctx, mk, customers, profiles, state.secrets, req)...)
+	drifts = append(drifts, uc.diffHotspotUsers(ctx, mikrotikID, customers, profiles, state.users, req)...)
+
+	report := &model.DriftReport{
+		MikrotikID: mikrotikID,
+		CheckedAt:  time.Now().Format(time.RFC3339),
+		Direction:  req.Direction,
+		DryRun:     req.DryRun,
+		Items:      make([]model.DriftItem, 0, len(drifts)),
+	}
+	for _, d := range drifts {
+		switch d.item.Type {
+		case model.DriftMissingOnRouter:
+			report.Summary.Missing++
+		case model.DriftOrphanedOnRouter:
+			report.Summary.Orphaned++
+		case model.DriftMismatch:
+			report.Summary.Mismatched++
+		}
+	}
+
+	if !req.DryRun {
+		uc.apply(ctx, mikrotikID, drifts, &report.Summary)
+	}
+	for _, d := range drifts {
+		report.Items = append(report.Items, d.item)
+	}
+
+	pkg_logger.Info("Router reconciled",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("direction", req.Direction),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("missing", report.Summary.Missing),
+		zap.Int("orphaned", report.Summary.Orphaned),
+		zap.Int("mismatched", report.Summary.Mismatched),
+		zap.Int("applied", report.Summary.Applied),
+		zap.Int("failed", report.Summary.Failed),
+	)
+	return report, nil
+}
+
+// apply resolves the drifts with an action over one connection. A failed item is
+// recorded on the item and does not stop the others.
+func (uc *reconcileUsecase) apply(ctx context.Context, mikrotikID string, drifts []drift, summary *model.DriftSummary) {
+	err := uc.withClient(ctx, mikrotikID, func(client *mikrotik.Client) error {
+		for i := range drifts {
+			d := &drifts[i]
+			if d.apply == nil {
+				continue
+			}
+			if err := d.apply(client); err != nil {
+				d.item.Error = err.Error()
+				summary.Failed++
+				pkg_logger.Warn("Failed to reconcile drift",
+					zap.String("mikrotik_id", mikrotikID),
+					zap.String("kind", d.item.Kind),
+					zap.String("name", d.item.Name),
+					zap.Error(err),
+				)
+				continue
+			}
+			d.item.Applied = true
+			summary.Applied++
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range drifts {
+			if drifts[i].apply != nil {
+				drifts[i].item.Error = err.Error()
+				summary.Failed++
+			}
+		}
+	}
+}
+
+// diffProfiles compares the PPPoE profiles kept in sync with the router. Router
+// profiles the database does not know can be adopted; the built-in, isolation and
+// fair usage profiles are the router's own and never reported.
+func (uc *reconcileUsecase) diffProfiles(mk *entity.Mikrotik, profiles []*entity.ProfileWithPPPoE, routerProfiles []map[string]string, req model.ReconcileRequest) []drift {
+	push := req.Direction == model.ReconcilePush
+	known := map[string]bool{"default": true, "default-encryption": true, mk.IsolationProfile: true}
+
+	var drifts []drift
+	for _, p := range profiles {
+		if p.ProfileType != "pppoe" {
+			continue
+		}
+		known[p.Name], known[p.FUPProfileName()] = true, true
+		if !p.SyncWithMikrotik {
+			continue
+		}
+
+		profile := p
+		item := model.DriftItem{Kind: model.DriftKindPPPProfile, Name: p.Name, ProfileID: p.ID, Action: "none"}
+		current := findRow(routerProfiles, p.Name)
+		if current == nil {
+			item.Type = model.DriftMissingOnRouter
+			d := drift{item: item}
+			if push {
+				d.item.Action = "create"
+				d.apply = func(*mikrotik.Client) error { return uc.profileService.SyncProfileToMikrotik(profile.ID) }
+			}
+			drifts = append(drifts, d)
+			continue
+		}
+
+		item.Fields = profileDiff(p, current)
+		if len(item.Fields) == 0 {
+			continue
+		}
+		item.Type = model.DriftMismatch
+		d := drift{item: item}
+		if push {
+			d.item.Action = "update_router"
+			d.apply = func(*mikrotik.Client) error { return uc.profileService.SyncProfileToMikrotik(profile.ID) }
+		} else {
+			d.item.Action = "update_database"
+			d.apply = func(*mikrotik.Client) error {
+				return uc.profileService.SyncProfileFromMikrotik(profile.MikrotikID, profile.Name)
+			}
+		}
+		drifts = append(drifts, d)
+	}
+
+	for _, row := range routerProfiles {
+		name := row["name"]
+		if known[name] {
+			continue
+		}
+		d := drift{item: model.DriftItem{Kind: model.DriftKindPPPProfile, Type: model.DriftOrphanedOnRouter, Name: name, Action: "none"}}
+		switch {
+		case !push:
+			d.item.Action = "create_database"
+			d.apply = func(*mikrotik.Client) error { return uc.profileService.SyncProfileFromMikrotik(mk.ID, name) }
+		case req.RemoveOrphans:
+			id := row[".id"]
+			d.item.Action = "delete"
+			d.apply = func(client *mikrotik.Client) error {
+				if _, err := ppp.NewService(client).DeleteProfile(id); err != nil {
+					return routerError("remove ppp profile", err)
+				}
+				return nil
+			}
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts
+}
+
+// diffSecrets compares the PPP secrets of PPPoE customers. The expected profile
+// follows isolation and fair usage, which move a customer off their plan's profile;
+// a suspended customer who is not isolated has the secret disabled.
+func (uc *reconcileUsecase) diffSecrets(ctx context.Context, mk *entity.Mikrotik, customers []*entity.Customer, profiles []*entity.ProfileWithPPPoE, secrets []map[string]string, req model.ReconcileRequest) []drift {
+	push := req.Direction == model.ReconcilePush
+	byID := profilesByID(profiles)
+
+	var drifts []drift
+	seen := make(map[string]bool)
+	for _, c := range customers {
+		username := stringValue(c.PPPoEUsername)
+		if c.ServiceType != "pppoe" || username == "" {
+			continue
+		}
+		seen[username] = true
+		customer := c
+
+		item := model.DriftItem{Kind: model.DriftKindPPPSecret, Name: username, CustomerID: c.ID, Action: "none"}
+		password, err := uc.cipher.Decrypt(stringValue(c.PPPoEPassword))
+		if err != nil {
+			item.Type = model.DriftMismatch
+			item.Error = fmt.Sprintf("failed to decrypt pppoe password: %v", err)
+			drifts = append(drifts, drift{item: item})
+			continue
+		}
+
+		plan := byID[stringValue(c.PPPoEProfileID)]
+		moved := c.Isolated || uc.throttled(ctx, c, plan)
+		profileName := "default"
+		switch {
+		case c.Isolated:
+			profileName = mk.IsolationProfile
+		case moved:
+			profileName = plan.FUPProfileName()
+		case plan != nil:
+			profileName = plan.Name
+		}
+		disabled := c.Status == "suspended" && !c.Isolated
+
+		current := findRow(secrets, username)
+		if current == nil {
+			item.Type = model.DriftMissingOnRouter
+			d := drift{item: item}
+			if push {
+				d.item.Action = "create"
+				d.apply = func(client *mikrotik.Client) error {
+					_, err := ppp.NewService(client).AddSecret(mkmodel.PPPSecretRequest{
+						Name:     username,
+						Password: password,
+						Service:  "pppoe",
+						Profile:  profileName,
+						Disabled: disabled,
+					})
+					if err != nil {
+						return routerError("add ppp secret", err)
+					}
+					return nil
+				}
+			}
+			drifts = append(drifts, d)
+			continue
+		}
+
+		var update mkmodel.PPPSecretUpdateRequest
+		if current["password"] != "" && current["password"] != password {
+			item.Fields = append(item.Fields, model.FieldDiff{Field: "password", Database: "********", Router: "********"})
+			update.Password = password
+		}
+		if current["profile"] != profileName {
+			item.Fields = append(item.Fields, model.FieldDiff{Field: "profile", Database: profileName, Router: current["profile"]})
+			update.Profile = profileName
+		}
+		if routerBool(current["disabled"]) != disabled {
+			item.Fields = append(item.Fields, model.FieldDiff{Field: "disabled", Database: strconv.FormatBool(disabled), Router: current["disabled"]})
+			update.Disabled = &disabled
+		}
+		if len(item.Fields) == 0 {
+			continue
+		}
+		item.Type = model.DriftMismatch
+		d := drift{item: item}
+
+		if push {
+			id := current[".id"]
+			d.item.Action = "update_router"
+			d.apply = func(client *mikrotik.Client) error {
+				if _, err := ppp.NewService(client).UpdateSecret(id, update); err != nil {
+					return routerError("update ppp secret", err)
+				}
+				return nil
+			}
+		} else if adopt := uc.adoptSecret(customer, current, update, moved); adopt != nil {
+			// Suspension owns the disabled flag and the isolation and fair usage profiles
+			d.item.Action = "update_database"
+			d.apply = func(*mikrotik.Client) error { return adopt() }
+		}
+		drifts = append(drifts, d)
+	}
+
+	for _, row := range secrets {
+		if seen[row["name"]] {
+			continue
+		}
+		drifts = append(drifts, orphan(model.DriftKindPPPSecret, row, push && req.RemoveOrphans, func(client *mikrotik.Client, id string) error {
+			if _, err := ppp.NewService(client).DeleteSecret(id); err != nil {
+				return routerError("remove ppp secret", err)
+			}
+			return nil
+		}))
+	}
+	return drifts
+}
+
+// adoptSecret returns the database update taking the router's password and profile
+// into the customer, or nil when neither can be adopted
+func (uc *reconcileUsecase) adoptSecret(customer *entity.Customer, current map[string]string, update mkmodel.PPPSecretUpdateRequest, moved bool) func() error {
+	adoptPassword := update.Password != ""
+	adoptProfile := update.Profile != "" && !moved
+	if !adoptPassword && !adoptProfile {
+		return nil
+	}
+
+	return func() error {
+		changes := &entity.Customer{ID: customer.ID, Name: customer.Name}
+		if adoptPassword {
+			sealed, err := uc.cipher.Encrypt(current["password"])
+			if err != nil {
+				return fmt.Errorf("failed to encrypt pppoe password: %w", err)
+			}
+			changes.PPPoEPassword = &sealed
+		}
+		if adoptProfile {
+			profile, err := uc.profileRepo.GetProfileByName(customer.MikrotikID, current["profile"])
+			if err != nil {
+				return fmt.Errorf("profile %s is not in the database", current["profile"])
+			}
+			changes.PPPoEProfileID = &profile.ID
+		}
+		return uc.customerRepo.UpdateCustomer(changes)
+	}
+}
+
+// diffHotspotUsers compares the hotspot users of hotspot customers. Voucher users
+// are tracked by the voucher reconciliation and left out of the orphans.
+func (uc *reconcileUsecase) diffHotspotUsers(ctx context.Context, mikrotikID string, customers []*entity.Customer, profiles []*entity.ProfileWithPPPoE, users []map[string]string, req model.ReconcileRequest) []drift {
+	push := req.Direction == model.ReconcilePush
+	byID := profilesByID(profiles)
+
+	var drifts []drift
+	seen := map[string]bool{"default-trial": true}
+	for _, c := range customers {
+		username := stringValue(c.HotspotUsername)
+		if c.ServiceType != "hotspot" || username == "" {
+			continue
+		}
+		seen[username] = true
+		customer := c
+
+		item := model.DriftItem{Kind: model.DriftKindHotspotUser, Name: username, CustomerID: c.ID, Action: "none"}
+		password, err := uc.cipher.Decrypt(stringValue(c.HotspotPassword))
+		if err != nil {
+			item.Type = model.DriftMismatch
+			item.Error = fmt.Sprintf("failed to decrypt hotspot password: %v", err)
+			drifts = append(drifts, drift{item: item})
+			continue
+		}
+		profileName := "default"
+		if p := byID[stringValue(c.HotspotProfileID)]; p != nil {
+			profileName = p.Name
+		}
+
+		current := findRow(users, username)
+		if current == nil {
+			item.Type = model.DriftMissingOnRouter
+			d := drift{item: item}
+			if push {
+				d.item.Action = "create"
+				d.apply = func(client *mikrotik.Client) error {
+					_, err := hotspot.NewService(client).AddUser(mkmodel.UserRequest{
+						Name:     username,
+						Password: password,
+						Profile:  profileName,
+						Comment:  customer.Username,
+					})
+					if err != nil {
+						return routerError("add hotspot user", err)
+					}
+					return nil
+				}
+			}
+			drifts = append(drifts, d)
+			continue
+		}
+
+		var update mkmodel.UserUpdateRequest
+		if password != "" && current["password"] != "" && current["password"] != password {
+			item.Fields = append(item.Fields, model.FieldDiff{Field: "password", Database: "********", Router: "********"})
+			update.Password = password
+		}
+		if current["profile"] != profileName {
+			item.Fields = append(item.Fields, model.FieldDiff{Field: "profile", Database: profileName, Router: current["profile"]})
+			update.Profile = profileName
+		}
+		if len(item.Fields) == 0 {
+			continue
+		}
+		item.Type = model.DriftMismatch
+		d := drift{item: item}
+
+		if push {
+			id := current[".id"]
+			d.item.Action = "update_router"
+			d.apply = func(client *mikrotik.Client) error {
+				if _, err := hotspot.NewService(client).UpdateUser(id, update); err != nil {
+					return routerError("update hotspot user", err)
+				}
+				return nil
+			}
+		} else {
+			d.item.Action = "update_database"
+			d.apply = func(*mikrotik.Client) error {
+				changes := &entity.Customer{ID: customer.ID, Name: customer.Name}
+				if update.Password != "" {
+					sealed, err := uc.cipher.Encrypt(current["password"])
+					if err != nil {
+						return fmt.Errorf("failed to encrypt hotspot password: %w", err)
+					}
+					changes.HotspotPassword = &sealed
+				}
+				if update.Profile != "" {
+					profile, err := uc.profileRepo.GetProfileByName(customer.MikrotikID, current["profile"])
+					if err != nil {
+						return fmt.Errorf("profile %s is not in the database", current["profile"])
+					}
+					changes.HotspotProfileID = &profile.ID
+				}
+				return uc.customerRepo.UpdateCustomer(changes)
+			}
+		}
+		drifts = append(drifts, d)
+	}
+
+	vouchers := voucherUsers(ctx, uc.voucherRepo, mikrotikID, users)
+	for _, row := range users {
+		if seen[row["name"]] || vouchers[row["name"]] {
+			continue
+		}
+		drifts = append(drifts, orphan(model.DriftKindHotspotUser, row, push && req.RemoveOrphans, func(client *mikrotik.Client, id string) error {
+			if _, err := hotspot.NewService(client).DeleteUser(id); err != nil {
+				return routerError("remove hotspot user", err)
+			}
+			return nil
+		}))
+	}
+	return drifts
+}
+
+// voucherUsers returns the router users that are vouchers: carrying a batch comment
+// or stored as a voucher of the router
+func voucherUsers(ctx context.Context, voucherRepo repository.VoucherRepository, mikrotikID string, users []map[string]string) map[string]bool {
+	vouchers := make(map[string]bool)
+	comments, err := voucherRepo.BatchComments(ctx, mikrotikID)
+	if err != nil {
+		pkg_logger.Warn("Failed to read voucher batches", zap.String("mikrotik_id", mikrotikID), zap.Error(err))
+	}
+	batchComment := make(map[string]bool, len(comments))
+	for _, c := range comments {
+		batchComment[c] = true
+	}
+
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		if batchComment[u["comment"]] {
+			vouchers[u["name"]] = true
+			continue
+		}
+		names = append(names, u["name"])
+	}
+	if len(names) == 0 {
+		return vouchers
+	}
+
+	known, err := voucherRepo.ExistingUsernames(ctx, mikrotikID, names)
+	if err != nil {
+		pkg_logger.Warn("Failed to read voucher usernames", zap.String("mikrotik_id", mikrotikID), zap.Error(err))
+	}
+	for _, name := range known {
+		vouchers[name] = true
+	}
+	return vouchers
+}
+
+// throttled reports whether fair usage moved the customer to the plan's FUP profile
+func (uc *reconcileUsecase) throttled(ctx context.Context, customer *entity.Customer, plan *entity.ProfileWithPPPoE) bool {
+	if plan == nil {
+		return false
+	}
+	state, err := uc.fupRepo.GetState(ctx, customer.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			pkg_logger.Warn("Failed to get fup state", zap.String("customer_id", customer.ID), zap.Error(err))
+		}
+		return false
+	}
+	return state.Throttled
+}
+
+// withClient connects to the router for the length of fn
+func (uc *reconcileUsecase) withClient(ctx context.Context, mikrotikID string, fn func(client *mikrotik.Client) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(client)
+}
+
+// orphan reports a router entry without a database record; it is deleted only when
+// remove is set
+func orphan(kind string, row map[string]string, remove bool, del func(client *mikrotik.Client, id string) error) drift {
+	d := drift{item: model.DriftItem{Kind: kind, Type: model.DriftOrphanedOnRouter, Name: row["name"], Action: "none"}}
+	if remove {
+		id := row[".id"]
+		d.item.Action = "delete"
+		d.apply = func(client *mikrotik.Client) error { return del(client, id) }
+	}
+	return d
+}
+
+// profileDiff compares the profile fields mikrobill writes to the router. Only the
+// rate of the rate-limit is compared, the rest follows the queue settings.
+func profileDiff(p *entity.ProfileWithPPPoE, current map[string]string) []model.FieldDiff {
+	var diffs []model.FieldDiff
+	add := func(field, database, router string) {
+		if database != router {
+			diffs = append(diffs, model.FieldDiff{Field: field, Database: database, Router: router})
+		}
+	}
+
+	rate := ""
+	if p.RateLimitUp != nil && p.RateLimitDown != nil {
+		rate = *p.RateLimitUp + "/" + *p.RateLimitDown
+	}
+	routerRate := ""
+	if fields := strings.Fields(current["rate-limit"]); len(fields) > 0 {
+		routerRate = fields[0]
+	}
+	add("rate_limit", rate, routerRate)
+
+	if p.PPPoEDetails != nil {
+		add("local_address", p.PPPoEDetails.LocalAddress, current["local-address"])
+		add("remote_address", stringValue(p.PPPoEDetails.RemoteAddress), current["remote-address"])
+	}
+	// A profile without only-one is created with the router's default
+	add("only_one", strconv.FormatBool(p.OnlyOne), strconv.FormatBool(current["only-one"] == "yes"))
+	return diffs
+}
+
+func profilesByID(profiles []*entity.ProfileWithPPPoE) map[string]*entity.ProfileWithPPPoE {
+	byID := make(map[string]*entity.ProfileWithPPPoE, len(profiles))
+	for _, p := range profiles {
+		byID[p.ID] = p
+	}
+	return byID
+}
+
+// routerBool reads a yes/no or true/false flag from the router
+func routerBool(value string) bool {
+	return value == "true" || value == "yes"
+}
@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	routerHealthInterval = 30 * time.Second
+	routerHealthTimeout  = 10 * time.Second
+)
+
+// RouterManager keeps one API connection per active router, keyed by mikrotik id.
+// Connections are dialed on first use and shared by concurrent callers; a background
+// check drops the ones that stop answering so the next caller dials again.
+type RouterManager interface {
+	// Client returns the shared connection to a router. Callers must not close it.
+	Client(ctx context.Context, mikrotikID string) (*mikrotik.Client, error)
+	// Stream dials a dedicated connection for a listen command such as traffic or ping.
+	// Cancelling a listen interrupts every reader on its connection, so streams never
+	// use the shared one. The caller closes it; eviction closes it too.
+	Stream(ctx context.Context, mikrotikID string) (*mikrotik.Client, error)
+	// Evict closes a router's shared connection and its streams, so the next caller
+	// dials it again with the stored settings
+	Evict(mikrotikID string)
+	// EvictAll evicts every router, used on shutdown
+	EvictAll()
+
+	Start()
+	Stop()
+}
+
+type routerConn struct {
+	mu      sync.Mutex // guards client, config, dialing and gone
+	client  *mikrotik.Client
+	config  mikrotik.Config
+	dialing chan struct{} // closed when the dial in progress ends; concurrent callers wait on it
+	gone    bool          // set by Evict: the conn left the map and must not take a new client
+	evicted chan struct{} // closed by Evict to end the router's streams
+}
+
+type routerManager struct {
+	mikrotikRepo repository.MikrotikRepository
+	cipher       *utils.Cipher
+
+	mu    sync.Mutex
+	conns map[string]*routerConn
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRouterManager(mikrotikRepo repository.MikrotikRepository, cipher *utils.Cipher) RouterManager {
+	return &routerManager{
+		mikrotikRepo: mikrotikRepo,
+		cipher:       cipher,
+		conns:        make(map[string]*routerConn),
+		stop:         make(chan struct{}),
+	}
+}
+
+func (m *routerManager) Client(ctx context.Context, mikrotikID string) (*mikrotik.Client, error) {
+	for {
+		client, retry, err := m.client(ctx, mikrotikID)
+		if !retry {
+			return client, err
+		}
+	}
+}
+
+// client returns the router's shared connection, dialing it when there is none.
+// retry is true when the router was evicted meanwhile, so the caller starts over
+// with the settings stored now.
+func (m *routerManager) client(ctx context.Context, mikrotikID string) (client *mikrotik.Client, retry bool, err error) {
+	mk, err := m.activeRouter(ctx, mikrotikID)
+	if err != nil {
+		return nil, false, err
+	}
+	cfg, err := routerConfig(mk, m.cipher)
+	if err != nil {
+		return nil, false, err
+	}
+	cfg.Async = true
+
+	conn := m.conn(mikrotikID)
+	conn.mu.Lock()
+	if conn.gone {
+		conn.mu.Unlock()
+		return nil, true, nil
+	}
+	if conn.client != nil && conn.config == cfg {
+		client = conn.client
+		conn.mu.Unlock()
+		return client, false, nil
+	}
+	if wait := conn.dialing; wait != nil {
+		conn.mu.Unlock()
+		select {
+		case <-wait:
+			return nil, true, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+	if conn.client != nil {
+		// The router's address or credentials changed since it was dialed
+		conn.client.Close()
+		conn.client = nil
+	}
+	done := make(chan struct{})
+	conn.dialing = done
+	conn.mu.Unlock()
+
+	// Dialed without the lock, so callers waiting on it can give up with their context
+	client, err = mikrotik.NewClientContext(ctx, cfg)
+
+	conn.mu.Lock()
+	conn.dialing = nil
+	close(done)
+	gone := conn.gone
+	if err == nil && !gone {
+		conn.client, conn.config = client, cfg
+	}
+	conn.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the router
+			return nil, false, ctx.Err()
+		}
+		m.setStatus(mikrotikID, entity.MikrotikStatusOffline, err.Error())
+		return nil, false, fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+	if gone {
+		// Evicted while dialing, likely with the settings this dial used
+		client.Close()
+		return nil, true, nil
+	}
+
+	m.setStatus(mikrotikID, entity.MikrotikStatusOnline, "")
+	_ = m.mikrotikRepo.UpdateLastSync(context.Background(), mikrotikID)
+
+	pkg_logger.Info("Router connection opened",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("host", mk.Host),
+	)
+	return client, false, nil
+}
+
+func (m *routerManager) Stream(ctx context.Context, mikrotikID string) (*mikrotik.Client, error) {
+	mk, err := m.activeRouter(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := routerConfig(mk, m.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := mikrotik.NewClientContext(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	// Closing the connection ends the listen, which callers already handle as a
+	// dropped stream and redial, picking up the router's new settings
+	evicted := m.conn(mikrotikID).evicted
+	go func() {
+		select {
+		case <-evicted:
+			client.Close()
+		case <-ctx.Done():
+		}
+	}()
+	return client, nil
+}
+
+func (m *routerManager) Evict(mikrotikID string) {
+	m.mu.Lock()
+	conn, ok := m.conns[mikrotikID]
+	delete(m.conns, mikrotikID)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	close(conn.evicted)
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.gone = true
+	if conn.client != nil {
+		conn.client.Close()
+		conn.client = nil
+	}
+}
+
+func (m *routerManager) EvictAll() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.conns))
+	for id := range m.conns {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	for _, id := range ids {
+		m.Evict(id)
+	}
+}
+
+// Start runs the background health check until Stop
+func (m *routerManager) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(routerHealthInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.checkAll()
+			}
+		}
+	}()
+}
+
+// Stop ends the health check and closes every shared connection
+func (m *routerManager) Stop() {
+	close(m.stop)
+	m.wg.Wait()
+
+	m.EvictAll()
+}
+
+// checkAll asks every open connection for the router identity and drops the ones
+// that fail; the router client already retried once on a broken connection.
+func (m *routerManager) checkAll() {
+	m.mu.Lock()
+	conns := make(map[string]*routerConn, len(m.conns))
+	for id, conn := range m.conns {
+		conns[id] = conn
+	}
+	m.mu.Unlock()
+
+	for id, conn := range conns {
+		conn.mu.Lock()
+		client := conn.client
+		conn.mu.Unlock()
+		if client == nil {
+			continue
+		}
+
+		err := pingRouter(client)
+		if err == nil {
+			continue
+		}
+
+		conn.mu.Lock()
+		if conn.client == client {
+			client.Close()
+			conn.client = nil
+		}
+		conn.mu.Unlock()
+
+		m.setStatus(id, entity.MikrotikStatusOffline, err.Error())
+		pkg_logger.Warn("Router connection dropped after failed health check",
+			zap.String("mikrotik_id", id),
+			zap.Error(err),
+		)
+	}
+}
+
+func pingRouter(client *mikrotik.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), routerHealthTimeout)
+	defer cancel()
+
+	_, err := client.RunContext(ctx, "/system/identity/print")
+	return err
+}
+
+// activeRouter loads a router the manager may connect to
+func (m *routerManager) activeRouter(ctx context.Context, mikrotikID string) (*entity.Mikrotik, error) {
+	if mikrotikID == "" {
+		return nil, utils.ErrMikrotikNotFound
+	}
+
+	mk, err := m.mikrotikRepo.GetByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMikrotikNotFound
+		}
+		return nil, fmt.Errorf("failed to get mikrotik: %w", err)
+	}
+	if !mk.IsActive {
+		return nil, utils.ErrMikrotikInactive
+	}
+	return mk, nil
+}
+
+func (m *routerManager) conn(mikrotikID string) *routerConn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn, ok := m.conns[mikrotikID]
+	if !ok {
+		conn = &routerConn{evicted: make(chan struct{})}
+		m.conns[mikrotikID] = conn
+	}
+	return conn
+}
+
+func (m *routerManager) setStatus(mikrotikID string, status entity.MikrotikStatus, reason string) {
+	if err := m.mikrotikRepo.ChangeStatus(context.Background(), mikrotikID, status, reason); err != nil {
+		pkg_logger.Warn("Failed to update router status",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,524 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/listen"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionRescanInterval is how often the tracker looks for newly active routers
+	sessionRescanInterval = time.Minute
+	sessionRetryMin       = 5 * time.Second
+	sessionRetryMax       = time.Minute
+)
+
+// sessionSource is a RouterOS menu whose entries are customer sessions
+type sessionSource struct {
+	service string // customer service type
+	menu    string
+	userKey string // field holding the login name
+	macKey  string
+}
+
+var sessionSources = []sessionSource{
+	{service: "pppoe", menu: "/ppp/active", userKey: "name", macKey: "caller-id"},
+	{service: "hotspot", menu: "/ip/hotspot/active", userKey: "user", macKey: "mac-address"},
+}
+
+// SessionTracker follows the PPP and hotspot sessions of every active router and
+// keeps the customers' online state and session history in step, the same way the
+// pppoe-up and pppoe-down callbacks do for routers that run the scripts.
+type SessionTracker interface {
+	Start()
+	Stop()
+}
+
+type sessionTracker struct {
+	mikrotikRepo repository.MikrotikRepository
+	customerRepo entity.CustomerRepository
+	sessionRepo  repository.CustomerSessionRepository
+	routers      RouterManager
+	publisher    entity.RedisPublisher
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	watching map[string]bool // mikrotik ids with a running listener
+}
+
+func NewSessionTracker(
+	mikrotikRepo repository.MikrotikRepository,
+	customerRepo entity.CustomerRepository,
+	sessionRepo repository.CustomerSessionRepository,
+	routers RouterManager,
+	publisher entity.RedisPublisher,
+) SessionTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sessionTracker{
+		mikrotikRepo: mikrotikRepo,
+		customerRepo: customerRepo,
+		sessionRepo:  sessionRepo,
+		routers:      routers,
+		publisher:    publisher,
+		ctx:          ctx,
+		cancel:       cancel,
+		watching:     make(map[string]bool),
+	}
+}
+
+// Start watches every active router until Stop
+func (t *sessionTracker) Start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(sessionRescanInterval)
+		defer ticker.Stop()
+
+		for {
+			t.rescan()
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends every listener and waits for them
+func (t *sessionTracker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// rescan starts a listener for each active router that has none
+func (t *sessionTracker) rescan() {
+	mikrotiks, err := t.mikrotikRepo.ListAll(t.ctx)
+	if err != nil {
+		pkg_logger.Warn("Failed to list routers for session tracking", zap.Error(err))
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, mk := range mikrotiks {
+		if !mk.IsActive || t.watching[mk.ID] {
+			continue
+		}
+		t.watching[mk.ID] = true
+
+		t.wg.Add(1)
+		go t.watch(mk.ID)
+	}
+}
+
+// watch keeps a router's listeners subscribed until the router is deactivated,
+// deleted or the tracker stops
+func (t *sessionTracker) watch(mikrotikID string) {
+	defer t.wg.Done()
+	defer func() {
+		t.mu.Lock()
+		delete(t.watching, mikrotikID)
+		t.mu.Unlock()
+	}()
+
+	delay := sessionRetryMin
+	for {
+		subscribed, err := t.listen(mikrotikID)
+		if t.ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, utils.ErrMikrotikNotFound) || errors.Is(err, utils.ErrMikrotikInactive) {
+			pkg_logger.Info("Session tracking stopped", zap.String("mikrotik_id", mikrotikID), zap.Error(err))
+			return
+		}
+
+		if subscribed {
+			delay = sessionRetryMin
+		}
+		pkg_logger.Warn("Session listener stopped, resubscribing",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > sessionRetryMax {
+			delay = sessionRetryMax
+		}
+	}
+}
+
+// listen resyncs the router's sessions, then applies listen events until one of
+// the listeners ends. Both share a dedicated connection, so the first to end
+// takes the other down and the caller subscribes them again together.
+func (t *sessionTracker) listen(mikrotikID string) (subscribed bool, err error) {
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	client, err := t.routers.Stream(ctx, mikrotikID)
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	// Sessions that changed while no listener ran are only visible in a full read
+	state := make(map[string]map[string]map[string]string, len(sessionSources))
+	known := make(map[string]map[string]map[string]string, len(sessionSources))
+	for _, src := range sessionSources {
+		rows, err := readSessions(client, src)
+		if err != nil {
+			return false, err
+		}
+		known[src.menu] = rows
+
+		// The listener keeps its own copies to tell new sessions from updates
+		seeded := make(map[string]map[string]string, len(rows))
+		for id, row := range rows {
+			copied := make(map[string]string, len(row))
+			for k, v := range row {
+				copied[k] = v
+			}
+			seeded[id] = copied
+		}
+		state[src.menu+"/listen"] = seeded
+	}
+	t.resync(mikrotikID, known)
+
+	client.AsyncContext(ctx)
+
+	var mu sync.Mutex
+	events := make(chan listen.ListenEvent, 64)
+	done := make(chan error, len(sessionSources))
+	for _, src := range sessionSources {
+		cfg := listen.ListenerConfig{
+			Command: src.menu + "/listen",
+			EventCh: events,
+			State:   state,
+			Mu:      &mu,
+		}
+		go func() { done <- listen.Listen(ctx, client, cfg) }()
+	}
+
+	for running := len(sessionSources); running > 0; {
+		select {
+		case ev := <-events:
+			t.apply(mikrotikID, known, ev)
+		case listenErr := <-done:
+			running--
+			if err == nil {
+				err = listenErr
+			}
+			cancel()
+		}
+	}
+	if err == nil {
+		err = errors.New("listen ended")
+	}
+	return true, err
+}
+
+// apply updates the customer behind one listen event. known holds the router's
+// sessions by menu and id, since removal events carry only the id.
+func (t *sessionTracker) apply(mikrotikID string, known map[string]map[string]map[string]string, ev listen.ListenEvent) {
+	for _, src := range sessionSources {
+		if ev.Command != src.menu+"/listen" {
+			continue
+		}
+		rows := known[src.menu]
+
+		if ev.Type == listen.EventDelete {
+			row, ok := rows[ev.ID]
+			delete(rows, ev.ID)
+			if !ok {
+				return
+			}
+			c := t.sessionCustomer(mikrotikID, src, row[src.userKey])
+			if c == nil {
+				return
+			}
+			t.recordEnd(mikrotikID, c, src, ev.ID, row)
+			if !hasSession(rows, src, row[src.userKey]) {
+				t.markDisconnected(c, src)
+			}
+			return
+		}
+
+		row, ok := rows[ev.ID]
+		if !ok {
+			row = make(map[string]string, len(ev.Data))
+			rows[ev.ID] = row
+		}
+		address := row["address"]
+		for k, v := range ev.Data {
+			row[k] = v
+		}
+		if ok && row["address"] == address {
+			return
+		}
+		if c := t.sessionCustomer(mikrotikID, src, row[src.userKey]); c != nil {
+			t.recordStart(mikrotikID, c, src, ev.ID, row)
+			t.markConnected(c, src, row)
+		}
+		return
+	}
+}
+
+// resync brings every customer on the router in line with its current sessions,
+// touching only those whose stored state is out of date, and ends the recorded
+// sessions the router no longer has
+func (t *sessionTracker) resync(mikrotikID string, known map[string]map[string]map[string]string) {
+	customers, err := t.customerRepo.GetRouterSessionCustomers(mikrotikID)
+	if err != nil {
+		pkg_logger.Warn("Failed to resync router sessions",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	byLogin := make(map[string]*entity.Customer, len(customers))
+	for _, c := range customers {
+		username := stringValue(c.PPPoEUsername)
+		if c.ServiceType == "hotspot" {
+			username = stringValue(c.HotspotUsername)
+		}
+		if username != "" {
+			byLogin[c.ServiceType+"/"+username] = c
+		}
+	}
+
+	active := make(map[string][]string, len(sessionSources))
+	online := make(map[*entity.Customer]map[string]string, len(customers))
+	for _, src := range sessionSources {
+		for id, row := range known[src.menu] {
+			active[src.service] = append(active[src.service], id)
+			c, ok := byLogin[src.service+"/"+row[src.userKey]]
+			if !ok {
+				continue
+			}
+			t.recordStart(mikrotikID, c, src, id, row)
+			online[c] = row
+		}
+	}
+
+	for _, src := range sessionSources {
+		for _, c := range customers {
+			if c.ServiceType != src.service {
+				continue
+			}
+			row, ok := online[c]
+			switch {
+			case ok && (onlineStatus(c) != c.Status || stringValue(c.AssignedIP) != row["address"]):
+				t.markConnected(c, src, row)
+			case !ok && c.Status == "active":
+				t.markDisconnected(c, src)
+			}
+		}
+	}
+
+	end := entity.CustomerSessionEnd{EndedAt: time.Now(), Reason: "not active on router at resync"}
+	if _, err := t.sessionRepo.CloseStale(context.Background(), mikrotikID, active, end); err != nil {
+		pkg_logger.Warn("Failed to close stale sessions",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (t *sessionTracker) sessionCustomer(mikrotikID string, src sessionSource, username string) *entity.Customer {
+	if username == "" {
+		return nil
+	}
+	c, err := t.customerRepo.GetCustomerBySession(mikrotikID, src.service, username)
+	if err != nil {
+		pkg_logger.Warn("Failed to look up session customer",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return c
+}
+
+func (t *sessionTracker) markConnected(c *entity.Customer, src sessionSource, row map[string]string) {
+	ip, mac, iface := sessionAddresses(src, row)
+
+	// The columns are INET and MACADDR; L2TP and PPTP report an IP as caller-id
+	if _, err := net.ParseMAC(stringValue(mac)); err != nil {
+		mac = nil
+	}
+	if err := t.customerRepo.UpdateCustomerStatus(c.ID, onlineStatus(c), ip, mac, iface); err != nil {
+		pkg_logger.Warn("Failed to mark customer online", zap.String("customer_id", c.ID), zap.Error(err))
+		return
+	}
+	c.AssignedIP = ip
+
+	t.publishSessionEvent(model.SessionEvent{
+		Status:     "connected",
+		CustomerID: c.ID,
+		Name:       c.Name,
+		IP:         stringValue(ip),
+		Interface:  stringValue(iface),
+		Service:    src.service,
+	})
+}
+
+func (t *sessionTracker) markDisconnected(c *entity.Customer, src sessionSource) {
+	// Suspension kicks the session, which must not overwrite the suspended status
+	status := "inactive"
+	if c.Status == "suspended" {
+		status = "suspended"
+	}
+
+	if err := t.customerRepo.UpdateCustomerStatus(c.ID, status, nil, nil, nil); err != nil {
+		pkg_logger.Warn("Failed to mark customer offline", zap.String("customer_id", c.ID), zap.Error(err))
+		return
+	}
+
+	t.publishSessionEvent(model.SessionEvent{
+		Status:     "disconnected",
+		CustomerID: c.ID,
+		Name:       c.Name,
+		Service:    src.service,
+	})
+}
+
+// recordStart opens the history row of a session, or refreshes the open one
+func (t *sessionTracker) recordStart(mikrotikID string, c *entity.Customer, src sessionSource, routerID string, row map[string]string) {
+	ip, callerID, iface := sessionAddresses(src, row)
+	session := &entity.CustomerSession{
+		CustomerID:      c.ID,
+		MikrotikID:      mikrotikID,
+		ServiceType:     src.service,
+		RouterSessionID: &routerID,
+		Username:        row[src.userKey],
+		IPAddress:       ip,
+		CallerID:        callerID,
+		Interface:       iface,
+		StartedAt:       time.Now().Add(-parseRouterDuration(row["uptime"])),
+	}
+	if err := t.sessionRepo.Open(context.Background(), session); err != nil {
+		pkg_logger.Warn("Failed to record session start", zap.String("customer_id", c.ID), zap.Error(err))
+	}
+}
+
+// recordEnd closes the history row of a session with the last counters the
+// router reported for it
+func (t *sessionTracker) recordEnd(mikrotikID string, c *entity.Customer, src sessionSource, routerID string, row map[string]string) {
+	end := entity.CustomerSessionEnd{EndedAt: time.Now()}
+	if v, err := strconv.ParseInt(row["bytes-in"], 10, 64); err == nil {
+		end.BytesIn = &v
+	}
+	if v, err := strconv.ParseInt(row["bytes-out"], 10, 64); err == nil {
+		end.BytesOut = &v
+	}
+	if src.service == "pppoe" {
+		end.Reason = t.pppDisconnectReason(mikrotikID, row[src.userKey])
+	}
+
+	if err := t.sessionRepo.Close(context.Background(), c.ID, routerID, end); err != nil {
+		pkg_logger.Warn("Failed to record session end", zap.String("customer_id", c.ID), zap.Error(err))
+	}
+}
+
+// pppDisconnectReason reads why the secret's last session ended (RouterOS 7). It
+// goes over the shared connection: a command on the listening one could wait
+// behind a backlog of events that only this goroutine drains.
+func (t *sessionTracker) pppDisconnectReason(mikrotikID, username string) string {
+	client, err := t.routers.Client(t.ctx, mikrotikID)
+	if err != nil {
+		return ""
+	}
+	reply, err := client.Run("/ppp/secret/print", "?name="+username, "=.proplist=last-disconnect-reason")
+	if err != nil || len(reply.Re) == 0 {
+		return ""
+	}
+	return reply.Re[0].Map["last-disconnect-reason"]
+}
+
+func (t *sessionTracker) publishSessionEvent(event model.SessionEvent) {
+	event.Type = "pppoe_event"
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err := t.publisher.Publish(eventsChannel, string(data)); err != nil {
+		pkg_logger.Warn("Failed to publish session event", zap.Error(err))
+	}
+}
+
+// readSessions reads a session menu keyed by router id
+func readSessions(client *mikrotik.Client, src sessionSource) (map[string]map[string]string, error) {
+	reply, err := client.Run(src.menu + "/print")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", src.menu, err)
+	}
+
+	rows := make(map[string]map[string]string, len(reply.Re))
+	for _, re := range reply.Re {
+		if id := re.Map[".id"]; id != "" {
+			rows[id] = re.Map
+		}
+	}
+	return rows, nil
+}
+
+// sessionAddresses picks the address, caller id and interface of a session row;
+// PPP interfaces are named <service-user> by RouterOS
+func sessionAddresses(src sessionSource, row map[string]string) (ip, callerID, iface *string) {
+	if address := row["address"]; net.ParseIP(address) != nil {
+		ip = &address
+	}
+	if caller := row[src.macKey]; caller != "" {
+		callerID = &caller
+	}
+	if src.service == "pppoe" && row["service"] != "" {
+		name := fmt.Sprintf("<%s-%s>", row["service"], row[src.userKey])
+		iface = &name
+	}
+	return ip, callerID, iface
+}
+
+// hasSession reports whether username still has another session, as when a
+// PPP secret allows more than one login
+func hasSession(rows map[string]map[string]string, src sessionSource, username string) bool {
+	for _, row := range rows {
+		if row[src.userKey] == username {
+			return true
+		}
+	}
+	return false
+}
+
+// onlineStatus is the status of a connected customer; a suspended one stays suspended
+// until billing reactivates them
+func onlineStatus(c *entity.Customer) string {
+	if c.Status == "suspended" {
+		return "suspended"
+	}
+	return "active"
+}
@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// StaticIPUsecase keeps a static-IP customer's router entries: a static ARP entry
+// binding the address to the customer's MAC, a simple queue at the profile's rate,
+// the profile's VLAN and an address-list entry telling the firewall whether the
+// customer is allowed, blocked or isolated
+type StaticIPUsecase interface {
+	// Provision creates or updates the customer's router entries and records the
+	// interface they are bound to
+	Provision(ctx context.Context, customer *entity.Customer) error
+	// Deprovision removes the customer's router entries; the VLAN stays for the
+	// profile's other customers
+	Deprovision(ctx context.Context, customer *entity.Customer) error
+	// Suspend blocks the customer. Disable mode also disables the ARP entry, isolate
+	// mode moves the address to the router's isolation list.
+	Suspend(ctx context.Context, customer *entity.Customer, mk *entity.Mikrotik, mode string) error
+	// Reactivate moves the address back to the allowed list and enables the ARP entry
+	Reactivate(ctx context.Context, customer *entity.Customer) error
+}
+
+type staticIPUsecase struct {
+	customerRepo    entity.CustomerRepository
+	profileRepo     entity.ProfileRepository
+	mikrotikUseCase MikrotikUseCase
+	queueUsecase    QueueUsecase
+}
+
+func NewStaticIPUsecase(
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+	mikrotikUseCase MikrotikUseCase,
+	queueUsecase QueueUsecase,
+) StaticIPUsecase {
+	return &staticIPUsecase{
+		customerRepo:    customerRepo,
+		profileRepo:     profileRepo,
+		mikrotikUseCase: mikrotikUseCase,
+		queueUsecase:    queueUsecase,
+	}
+}
+
+func (uc *staticIPUsecase) Provision(ctx context.Context, customer *entity.Customer) error {
+	address := stringValue(customer.StaticIP)
+	if address == "" {
+		return fmt.Errorf("static ip is required for static ip customer")
+	}
+	mac := stringValue(customer.MacAddress)
+	if mac == "" {
+		return fmt.Errorf("mac address is required for static ip customer")
+	}
+
+	profile, err := uc.staticProfile(customer)
+	if err != nil {
+		return err
+	}
+	details := profile.StaticIPDetails
+	if !macAllowed(details.AllowedMACAddresses, mac) {
+		return fmt.Errorf("mac address %s is not allowed by profile %s", mac, profile.Name)
+	}
+
+	iface := stringValue(details.Interface)
+	comment := staticComment(customer)
+	err = uc.withClient(ctx, customer.MikrotikID, func(client *mikrotik.Client) error {
+		if vlan := details.VLANInterfaceName(); vlan != "" {
+			if err := ensureVLAN(client, profile.Name, details); err != nil {
+				return err
+			}
+			iface = vlan
+		}
+
+		// A suspended customer stays cut off until reactivated
+		arpDisabled := customer.Status == "suspended" && !customer.Isolated
+		id, err := client.FindARPEntryID(address)
+		if err != nil {
+			return routerError("find arp entry", err)
+		}
+		if id == "" {
+			_, err = client.CreateARPEntry(address, mac, iface, comment, arpDisabled)
+		} else {
+			err = client.UpdateARPEntry(id, mac, iface, comment, arpDisabled)
+		}
+		if err != nil {
+			return routerError("bind arp entry", err)
+		}
+
+		entries, err := client.FindAddressListEntries(address, comment)
+		if err != nil {
+			return routerError("find address list entry", err)
+		}
+		if len(entries) == 0 {
+			list := entity.StaticIPAllowedList
+			if customer.Status == "suspended" {
+				list = entity.StaticIPBlockedList
+			}
+			if _, err := client.CreateAddressListEntry(list, address, comment); err != nil {
+				return routerError("add address list entry", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := uc.queueUsecase.ApplyProfileQueue(ctx, profile, staticQueueName(customer), address+"/32", comment); err != nil {
+		return err
+	}
+
+	// Nothing reports a static customer's sessions, so provisioned is online
+	if err := uc.customerRepo.UpdateCustomerStatus(customer.ID, onlineStatus(customer), &address, &mac, &iface); err != nil {
+		return err
+	}
+	customer.AssignedIP, customer.Interface = &address, &iface
+
+	pkg_logger.Info("Static IP customer provisioned",
+		zap.String("customer_id", customer.ID),
+		zap.String("address", address),
+		zap.String("interface", iface),
+		zap.String("profile", profile.Name),
+	)
+	return nil
+}
+
+func (uc *staticIPUsecase) Deprovision(ctx context.Context, customer *entity.Customer) error {
+	address := stringValue(customer.StaticIP)
+	if address == "" {
+		return nil
+	}
+
+	err := uc.withClient(ctx, customer.MikrotikID, func(client *mikrotik.Client) error {
+		id, err := client.FindARPEntryID(address)
+		if err != nil {
+			return routerError("find arp entry", err)
+		}
+		if id != "" {
+			if err := client.DeleteARPEntry(id); err != nil {
+				return routerError("remove arp entry", err)
+			}
+		}
+
+		entries, err := client.FindAddressListEntries(address, staticComment(customer))
+		if err != nil {
+			return routerError("find address list entry", err)
+		}
+		for _, entry := range entries {
+			if err := client.DeleteAddressListEntry(entry[".id"]); err != nil {
+				return routerError("remove address list entry", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = uc.queueUsecase.DeleteSimple(ctx, customer.MikrotikID, staticQueueName(customer))
+	if err != nil && !errors.Is(err, utils.ErrQueueNotFound) {
+		return err
+	}
+
+	pkg_logger.Info("Static IP customer deprovisioned",
+		zap.String("customer_id", customer.ID),
+		zap.String("address", address),
+	)
+	return nil
+}
+
+func (uc *staticIPUsecase) Suspend(ctx context.Context, customer *entity.Customer, mk *entity.Mikrotik, mode string) error {
+	isolate := mode == entity.SuspensionModeIsolate
+	list := entity.StaticIPBlockedList
+	if isolate {
+		list = mk.IsolationAddressList
+	}
+
+	if err := uc.setAccess(ctx, customer, list, !isolate); err != nil {
+		return err
+	}
+	if isolate {
+		// The profile does not change, so there is none to restore
+		return uc.customerRepo.UpdateIsolation(customer.ID, true, nil)
+	}
+	return nil
+}
+
+func (uc *staticIPUsecase) Reactivate(ctx context.Context, customer *entity.Customer) error {
+	if err := uc.setAccess(ctx, customer, entity.StaticIPAllowedList, false); err != nil {
+		return err
+	}
+	if customer.Isolated {
+		return uc.customerRepo.UpdateIsolation(customer.ID, false, nil)
+	}
+	return nil
+}
+
+// setAccess moves the customer's address to list and enables or disables its ARP
+// entry, recreating the address-list entry if it was removed on the router
+func (uc *staticIPUsecase) setAccess(ctx context.Context, customer *entity.Customer, list string, arpDisabled bool) error {
+	address := stringValue(customer.StaticIP)
+	if address == "" {
+		return fmt.Errorf("customer %s has no static ip", customer.ID)
+	}
+	comment := staticComment(customer)
+
+	return uc.withClient(ctx, customer.MikrotikID, func(client *mikrotik.Client) error {
+		id, err := client.FindARPEntryID(address)
+		if err != nil {
+			return routerError("find arp entry", err)
+		}
+		if id == "" {
+			return fmt.Errorf("%w: %s", utils.ErrStaticIPNotProvisioned, address)
+		}
+		if err := client.UpdateARPEntry(id, "", "", "", arpDisabled); err != nil {
+			return routerError("update arp entry", err)
+		}
+
+		entries, err := client.FindAddressListEntries(address, comment)
+		if err != nil {
+			return routerError("find address list entry", err)
+		}
+		if len(entries) == 0 {
+			if _, err := client.CreateAddressListEntry(list, address, comment); err != nil {
+				return routerError("add address list entry", err)
+			}
+			return nil
+		}
+		if err := client.UpdateAddressListEntry(entries[0][".id"], list); err != nil {
+			return routerError("update address list entry", err)
+		}
+		for _, extra := range entries[1:] {
+			if err := client.DeleteAddressListEntry(extra[".id"]); err != nil {
+				return routerError("remove address list entry", err)
+			}
+		}
+		return nil
+	})
+}
+
+// staticProfile loads the customer's static-IP profile with its settings
+func (uc *staticIPUsecase) staticProfile(customer *entity.Customer) (*entity.ProfileWithPPPoE, error) {
+	profileID := stringValue(customer.StaticIPProfileID)
+	if profileID == "" {
+		return nil, fmt.Errorf("static ip profile is required for static ip customer")
+	}
+	profile, err := uc.profileRepo.GetProfileByID(profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.ProfileType != "static_ip" || profile.StaticIPDetails == nil {
+		return nil, fmt.Errorf("profile %s is not a static ip profile", profile.Name)
+	}
+	if profile.MikrotikID != customer.MikrotikID {
+		return nil, fmt.Errorf("profile %s belongs to another router", profile.Name)
+	}
+	if stringValue(profile.StaticIPDetails.Interface) == "" {
+		return nil, fmt.Errorf("profile %s has no interface", profile.Name)
+	}
+	return profile, nil
+}
+
+// withClient connects to the router for the length of fn
+func (uc *staticIPUsecase) withClient(ctx context.Context, mikrotikID string, fn func(client *mikrotik.Client) error) error {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		if errors.Is(err, utils.ErrMikrotikNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", utils.ErrConnectionFailed, err)
+	}
+
+	return fn(client)
+}
+
+// ensureVLAN creates the profile's VLAN on its interface, with the gateway address,
+// unless the router has them already
+func ensureVLAN(client *mikrotik.Client, profileName string, details *entity.MikrotikProfileStaticIP) error {
+	name := details.VLANInterfaceName()
+	comment := "mikrobill profile " + profileName
+
+	id, err := client.FindInterfaceID(name)
+	if err != nil {
+		return routerError("find vlan interface", err)
+	}
+	if id == "" {
+		if err := client.CreateVLANInterface(name, stringValue(details.Interface), *details.VLANID, comment); err != nil {
+			return routerError("add vlan interface", err)
+		}
+	}
+
+	gateway, err := gatewayAddress(details)
+	if err != nil {
+		return err
+	}
+	id, err = client.FindIPAddressID(gateway, name)
+	if err != nil {
+		return routerError("find gateway address", err)
+	}
+	if id == "" {
+		if err := client.CreateIPAddress(gateway, name, comment); err != nil {
+			return routerError("add gateway address", err)
+		}
+	}
+	return nil
+}
+
+// gatewayAddress returns the profile's gateway as address/prefix; the netmask may
+// be dotted (255.255.255.0) or a prefix length (24 or /24)
+func gatewayAddress(details *entity.MikrotikProfileStaticIP) (string, error) {
+	netmask := strings.TrimPrefix(details.Netmask, "/")
+	prefix, err := strconv.Atoi(netmask)
+	if err != nil {
+		ip := net.ParseIP(netmask).To4()
+		if ip == nil {
+			return "", fmt.Errorf("invalid netmask %q", details.Netmask)
+		}
+		prefix, _ = net.IPMask(ip).Size()
+	}
+	if net.ParseIP(details.Gateway) == nil || prefix <= 0 || prefix > 32 {
+		return "", fmt.Errorf("invalid gateway %s/%s", details.Gateway, details.Netmask)
+	}
+	return fmt.Sprintf("%s/%d", details.Gateway, prefix), nil
+}
+
+// macAllowed reports whether mac is on the profile's allow list; an empty list allows any
+func macAllowed(allowed []string, mac string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// staticComment tags the router entries of a customer, so they survive renames
+func staticComment(customer *entity.Customer) string {
+	return "mikrobill:" + customer.ID
+}
+
+func staticQueueName(customer *entity.Customer) string {
+	return "static-" + customer.Username
+}
@@ -0,0 +1,522 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik"
+	"mikrobill/internal/infrastructure/mikrotik/ppp"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type SuspensionUsecase interface {
+	// ProcessOverdue marks overdue invoices, suspends customers past the grace period and
+	// reactivates suspended customers whose debts have been settled in the meantime
+	ProcessOverdue(ctx context.Context, now time.Time) (*model.SuspensionResult, error)
+
+	Suspend(ctx context.Context, customerID string, reason string) error
+	Reactivate(ctx context.Context, customerID string) error
+	// ReactivateIfSettled reactivates a customer suspended for non-payment once no
+	// invoice past the grace period is left open
+	ReactivateIfSettled(ctx context.Context, customerID string) (bool, error)
+}
+
+type suspensionUsecase struct {
+	customerRepo    entity.CustomerRepository
+	profileRepo     entity.ProfileRepository
+	invoiceRepo     repository.InvoiceRepository
+	settingRepo     repository.SettingRepository
+	mikrotikRepo    repository.MikrotikRepository
+	fupRepo         repository.FUPRepository
+	mikrotikUseCase MikrotikUseCase
+	staticIP        StaticIPUsecase
+	publisher       entity.RedisPublisher
+}
+
+func NewSuspensionUsecase(
+	customerRepo entity.CustomerRepository,
+	profileRepo entity.ProfileRepository,
+	invoiceRepo repository.InvoiceRepository,
+	settingRepo repository.SettingRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	fupRepo repository.FUPRepository,
+	mikrotikUseCase MikrotikUseCase,
+	staticIP StaticIPUsecase,
+	publisher entity.RedisPublisher,
+) SuspensionUsecase {
+	return &suspensionUsecase{
+		customerRepo:    customerRepo,
+		profileRepo:     profileRepo,
+		invoiceRepo:     invoiceRepo,
+		settingRepo:     settingRepo,
+		mikrotikRepo:    mikrotikRepo,
+		fupRepo:         fupRepo,
+		mikrotikUseCase: mikrotikUseCase,
+		staticIP:        staticIP,
+		publisher:       publisher,
+	}
+}
+
+func (uc *suspensionUsecase) ProcessOverdue(ctx context.Context, now time.Time) (*model.SuspensionResult, error) {
+	today := truncateDate(now)
+	result := &model.SuspensionResult{Date: today.Format(dateLayout)}
+
+	lateFee := getFloatSetting(ctx, uc.settingRepo, entity.SettingCategoryBilling, entity.SettingLateFeePercentage, 0)
+	marked, err := uc.invoiceRepo.MarkOverdue(ctx, today, lateFee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark overdue invoices: %w", err)
+	}
+	result.MarkedOverdue = marked
+
+	// 1. Suspend customers with invoices past due date + grace period
+	customerIDs, err := uc.invoiceRepo.ListOverdueCustomerIDs(ctx, uc.graceCutoff(ctx, today))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue customers: %w", err)
+	}
+
+	for _, id := range customerIDs {
+		customer, err := uc.customerRepo.GetCustomerByID(id)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		if customer.Status == "suspended" || !customer.AutoSuspensionEnabled() {
+			result.Skipped++
+			continue
+		}
+
+		err = uc.suspend(ctx, customer, entity.SuspensionReasonOverdue)
+		if errors.Is(err, utils.ErrSuspensionNotSupported) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", customer.Username, err))
+			pkg_logger.Warn("Failed to suspend customer",
+				zap.String("customer_id", customer.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		result.Suspended++
+	}
+
+	// 2. Reactivate customers that paid but were not reactivated at payment time
+	// (e.g. the router was unreachable)
+	suspended, err := uc.customerRepo.GetSuspendedCustomers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspended customers: %w", err)
+	}
+	for _, customer := range suspended {
+		ok, err := uc.reactivateIfSettled(ctx, customer, today)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", customer.Username, err))
+			continue
+		}
+		if ok {
+			result.Reactivated++
+		}
+	}
+
+	pkg_logger.Info("Overdue processing finished",
+		zap.String("date", result.Date),
+		zap.Int64("marked_overdue", result.MarkedOverdue),
+		zap.Int("suspended", result.Suspended),
+		zap.Int("reactivated", result.Reactivated),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+func (uc *suspensionUsecase) Suspend(ctx context.Context, customerID string, reason string) error {
+	customer, err := uc.customerRepo.GetCustomerByID(customerID)
+	if err != nil {
+		return utils.ErrCustomerNotFound
+	}
+	if customer.Status == "suspended" {
+		return nil
+	}
+	if reason == "" {
+		reason = entity.SuspensionReasonManual
+	}
+	return uc.suspend(ctx, customer, reason)
+}
+
+func (uc *suspensionUsecase) Reactivate(ctx context.Context, customerID string) error {
+	customer, err := uc.customerRepo.GetCustomerByID(customerID)
+	if err != nil {
+		return utils.ErrCustomerNotFound
+	}
+	if customer.Status != "suspended" {
+		return nil
+	}
+	return uc.reactivate(ctx, customer)
+}
+
+func (uc *suspensionUsecase) ReactivateIfSettled(ctx context.Context, customerID string) (bool, error) {
+	customer, err := uc.customerRepo.GetCustomerByID(customerID)
+	if err != nil {
+		return false, utils.ErrCustomerNotFound
+	}
+	return uc.reactivateIfSettled(ctx, customer, truncateDate(time.Now()))
+}
+
+func (uc *suspensionUsecase) reactivateIfSettled(ctx context.Context, customer *entity.Customer, today time.Time) (bool, error) {
+	// Manual suspensions are lifted by an admin, not by payments
+	if customer.Status != "suspended" || stringValue(customer.SuspensionReason) != entity.SuspensionReasonOverdue {
+		return false, nil
+	}
+
+	open, err := uc.invoiceRepo.CountOutstanding(ctx, customer.ID, uc.graceCutoff(ctx, today))
+	if err != nil {
+		return false, fmt.Errorf("failed to count outstanding invoices: %w", err)
+	}
+	if open > 0 {
+		return false, nil
+	}
+
+	if err := uc.reactivate(ctx, customer); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (uc *suspensionUsecase) suspend(ctx context.Context, customer *entity.Customer, reason string) error {
+	if customer.ServiceType != "pppoe" && customer.ServiceType != "static_ip" {
+		return utils.ErrSuspensionNotSupported
+	}
+
+	mk, err := uc.mikrotikRepo.GetByID(ctx, customer.MikrotikID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrMikrotikNotFound
+		}
+		return fmt.Errorf("failed to get mikrotik: %w", err)
+	}
+
+	mode := suspensionMode(customer, mk)
+	switch {
+	case customer.ServiceType == "static_ip":
+		err = uc.staticIP.Suspend(ctx, customer, mk, mode)
+	case mode == entity.SuspensionModeIsolate:
+		err = uc.isolateOnRouter(ctx, customer, mk)
+	default:
+		err = uc.disableOnRouter(ctx, customer)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := uc.customerRepo.UpdateSuspension(customer.ID, "suspended", &now, &reason); err != nil {
+		return err
+	}
+
+	pkg_logger.Info("Customer suspended",
+		zap.String("customer_id", customer.ID),
+		zap.String("name", customer.Name),
+		zap.String("reason", reason),
+		zap.String("mode", mode),
+	)
+	uc.publishStatusEvent(customer, "customer_suspended", "suspended", reason)
+	return nil
+}
+
+func (uc *suspensionUsecase) reactivate(ctx context.Context, customer *entity.Customer) error {
+	// The mode recorded at suspension time decides how to undo it, even if the
+	// router or customer setting has changed since
+	var err error
+	switch {
+	case customer.ServiceType == "static_ip":
+		err = uc.staticIP.Reactivate(ctx, customer)
+	case customer.Isolated:
+		err = uc.restoreOnRouter(ctx, customer)
+	default:
+		err = uc.enableOnRouter(ctx, customer)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Offline until the PPPoE session comes back up and the on-up callback fires;
+	// a static IP is back online as soon as the router lets it through
+	status := "inactive"
+	if customer.ServiceType == "static_ip" {
+		status = "active"
+	}
+	if err := uc.customerRepo.UpdateSuspension(customer.ID, status, nil, nil); err != nil {
+		return err
+	}
+
+	pkg_logger.Info("Customer reactivated",
+		zap.String("customer_id", customer.ID),
+		zap.String("name", customer.Name),
+	)
+	uc.publishStatusEvent(customer, "customer_reactivated", status, "")
+	return nil
+}
+
+// disableOnRouter disables the customer's PPP secret and kicks the live session
+func (uc *suspensionUsecase) disableOnRouter(ctx context.Context, customer *entity.Customer) error {
+	username := stringValue(customer.PPPoEUsername)
+	if username == "" {
+		return fmt.Errorf("customer %s has no pppoe username", customer.ID)
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, customer.MikrotikID)
+	if err != nil {
+		return err
+	}
+
+	secretID, err := client.FindPPPoESecretID(username)
+	if err != nil {
+		return fmt.Errorf("failed to find ppp secret: %w", err)
+	}
+	if secretID == "" {
+		return fmt.Errorf("%w: %s", utils.ErrSecretNotFound, username)
+	}
+
+	pppService := ppp.NewService(client)
+	if _, err := pppService.DisableSecret(secretID); err != nil {
+		return fmt.Errorf("failed to disable ppp secret: %w", err)
+	}
+
+	// A failed kick is not fatal: a disabled secret cannot re-authenticate anyway
+	if _, err := pppService.DisconnectByUsername(username); err != nil {
+		pkg_logger.Warn("Failed to disconnect active session",
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// enableOnRouter re-enables the customer's PPP secret
+func (uc *suspensionUsecase) enableOnRouter(ctx context.Context, customer *entity.Customer) error {
+	username := stringValue(customer.PPPoEUsername)
+	if username == "" {
+		return fmt.Errorf("customer %s has no pppoe username", customer.ID)
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, customer.MikrotikID)
+	if err != nil {
+		return err
+	}
+
+	secretID, err := client.FindPPPoESecretID(username)
+	if err != nil {
+		return fmt.Errorf("failed to find ppp secret: %w", err)
+	}
+	if secretID == "" {
+		return fmt.Errorf("%w: %s", utils.ErrSecretNotFound, username)
+	}
+
+	if _, err := ppp.NewService(client).EnableSecret(secretID); err != nil {
+		return fmt.Errorf("failed to enable ppp secret: %w", err)
+	}
+	return nil
+}
+
+// isolateOnRouter moves the customer's PPP secret to the router's isolation profile,
+// creating the profile first if the router does not have it yet
+func (uc *suspensionUsecase) isolateOnRouter(ctx context.Context, customer *entity.Customer, mk *entity.Mikrotik) error {
+	username := stringValue(customer.PPPoEUsername)
+	if username == "" {
+		return fmt.Errorf("customer %s has no pppoe username", customer.ID)
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, customer.MikrotikID)
+	if err != nil {
+		return err
+	}
+
+	secretID, err := client.FindPPPoESecretID(username)
+	if err != nil {
+		return fmt.Errorf("failed to find ppp secret: %w", err)
+	}
+	if secretID == "" {
+		return fmt.Errorf("%w: %s", utils.ErrSecretNotFound, username)
+	}
+
+	if err := uc.ensureIsolationProfile(client, mk, uc.profileName(customer.PPPoEProfileID)); err != nil {
+		return err
+	}
+
+	if err := client.UpdatePPPoESecret(secretID, "", "", mk.IsolationProfile, "", ""); err != nil {
+		return err
+	}
+
+	// Remember the profile to restore before the session is kicked
+	if err := uc.customerRepo.UpdateIsolation(customer.ID, true, customer.PPPoEProfileID); err != nil {
+		return err
+	}
+
+	// Profile changes only apply to new sessions
+	if _, err := ppp.NewService(client).DisconnectByUsername(username); err != nil {
+		pkg_logger.Warn("Failed to disconnect active session",
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// restoreOnRouter moves an isolated customer's PPP secret back to the profile it had before.
+// A customer throttled by fair usage gets the full-rate plan back here, so the throttle is
+// cleared and the next fair usage run applies it again if the quota is still exceeded.
+func (uc *suspensionUsecase) restoreOnRouter(ctx context.Context, customer *entity.Customer) error {
+	username := stringValue(customer.PPPoEUsername)
+	if username == "" {
+		return fmt.Errorf("customer %s has no pppoe username", customer.ID)
+	}
+
+	profileID := customer.OriginalProfileID
+	if profileID == nil {
+		// The original profile was deleted meanwhile; fall back to the current plan
+		profileID = customer.PPPoEProfileID
+	}
+	profile := uc.profileName(profileID)
+	if profile == "" {
+		profile = "default"
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, customer.MikrotikID)
+	if err != nil {
+		return err
+	}
+
+	secretID, err := client.FindPPPoESecretID(username)
+	if err != nil {
+		return fmt.Errorf("failed to find ppp secret: %w", err)
+	}
+	if secretID == "" {
+		return fmt.Errorf("%w: %s", utils.ErrSecretNotFound, username)
+	}
+
+	if err := client.UpdatePPPoESecret(secretID, "", "", profile, "", ""); err != nil {
+		return err
+	}
+
+	if err := uc.customerRepo.UpdateIsolation(customer.ID, false, nil); err != nil {
+		return err
+	}
+	if err := uc.fupRepo.ClearThrottle(ctx, customer.ID); err != nil {
+		pkg_logger.Warn("Failed to clear fair usage throttle",
+			zap.String("customer_id", customer.ID),
+			zap.Error(err),
+		)
+	}
+
+	if _, err := ppp.NewService(client).DisconnectByUsername(username); err != nil {
+		pkg_logger.Warn("Failed to disconnect isolated session",
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// ensureIsolationProfile creates the isolation profile on the router if it is missing.
+// Addressing is copied from the customer's own profile so isolated sessions still get an IP.
+func (uc *suspensionUsecase) ensureIsolationProfile(client *mikrotik.Client, mk *entity.Mikrotik, baseProfile string) error {
+	id, err := client.FindPPPoEProfileID(mk.IsolationProfile)
+	if err != nil {
+		return fmt.Errorf("failed to find isolation profile: %w", err)
+	}
+	if id != "" {
+		return nil
+	}
+
+	params := mikrotik.PPPoEProfileParams{
+		Name:        mk.IsolationProfile,
+		AddressList: mk.IsolationAddressList,
+	}
+	if up, down, ok := strings.Cut(mk.IsolationRateLimit, "/"); ok {
+		params.RateLimitUp, params.RateLimitDown = up, down
+	} else if mk.IsolationRateLimit != "" {
+		params.RateLimitUp, params.RateLimitDown = mk.IsolationRateLimit, mk.IsolationRateLimit
+	}
+
+	if baseProfile != "" {
+		if base, err := client.GetPPPoEProfile(baseProfile); err == nil {
+			params.LocalAddress = base["local-address"]
+			params.RemoteAddress = base["remote-address"]
+			params.DNSServer = base["dns-server"]
+		}
+	}
+
+	if err := client.CreatePPPoEProfile(params); err != nil {
+		return err
+	}
+
+	pkg_logger.Info("Isolation profile provisioned",
+		zap.String("mikrotik_id", mk.ID),
+		zap.String("profile", mk.IsolationProfile),
+	)
+	return nil
+}
+
+// profileName resolves a profile ID to its name on the router, or "" if unknown
+func (uc *suspensionUsecase) profileName(profileID *string) string {
+	if profileID == nil || *profileID == "" {
+		return ""
+	}
+	profile, err := uc.profileRepo.GetProfileByID(*profileID)
+	if err != nil || profile == nil {
+		return ""
+	}
+	return profile.Name
+}
+
+// suspensionMode returns the customer's suspension mode, falling back to the router's
+func suspensionMode(customer *entity.Customer, mk *entity.Mikrotik) string {
+	if mode := stringValue(customer.SuspensionMode); mode != "" {
+		return mode
+	}
+	if mk.SuspensionMode != "" {
+		return mk.SuspensionMode
+	}
+	return entity.SuspensionModeDisable
+}
+
+// graceCutoff returns the due date before which unpaid invoices lead to suspension
+func (uc *suspensionUsecase) graceCutoff(ctx context.Context, today time.Time) time.Time {
+	grace := getIntSetting(ctx, uc.settingRepo, entity.SettingCategoryBilling, entity.SettingGracePeriodDays, 3)
+	return today.AddDate(0, 0, -grace)
+}
+
+func (uc *suspensionUsecase) publishStatusEvent(customer *entity.Customer, eventType, status, reason string) {
+	if uc.publisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(model.CustomerStatusEvent{
+		Type:       eventType,
+		CustomerID: customer.ID,
+		Name:       customer.Name,
+		Status:     status,
+		Reason:     reason,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return
+	}
+	if err := uc.publisher.Publish(eventsChannel, string(data)); err != nil {
+		pkg_logger.Warn("Failed to publish customer status event", zap.Error(err))
+	}
+}
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxHourlyUsageDays caps the range of an hourly usage query
+const maxHourlyUsageDays = 31
+
+// UsageUsecase accounts the traffic of online customers. Collect samples the byte
+// counters of every active router; the difference to the previous sample is added
+// to the hourly, daily and monthly rollups.
+type UsageUsecase interface {
+	Collect(ctx context.Context) (*model.UsageCollectResult, error)
+	GetUsage(ctx context.Context, customerID string, req model.UsageRequest) (*model.UsageResponse, error)
+}
+
+type usageUsecase struct {
+	usageRepo    repository.UsageRepository
+	customerRepo entity.CustomerRepository
+	mikrotikRepo repository.MikrotikRepository
+	routers      RouterManager
+}
+
+func NewUsageUsecase(
+	usageRepo repository.UsageRepository,
+	customerRepo entity.CustomerRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	routers RouterManager,
+) UsageUsecase {
+	return &usageUsecase{
+		usageRepo:    usageRepo,
+		customerRepo: customerRepo,
+		mikrotikRepo: mikrotikRepo,
+		routers:      routers,
+	}
+}
+
+func (uc *usageUsecase) Collect(ctx context.Context) (*model.UsageCollectResult, error) {
+	mikrotiks, err := uc.mikrotikRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mikrotiks: %w", err)
+	}
+
+	result := &model.UsageCollectResult{}
+	for _, mk := range mikrotiks {
+		if !mk.IsActive {
+			continue
+		}
+		result.Routers++
+
+		if err := uc.collectRouter(ctx, mk.ID, result); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", mk.Name, err))
+		}
+	}
+
+	pkg_logger.Debug("Usage collected",
+		zap.Int("routers", result.Routers),
+		zap.Int("customers", result.Customers),
+		zap.Int64("bytes_up", result.BytesUp),
+		zap.Int64("bytes_down", result.BytesDown),
+	)
+	return result, nil
+}
+
+// collectRouter samples one router. A counter seen for the first time, or one that
+// restarted with a new session, counts from zero.
+func (uc *usageUsecase) collectRouter(ctx context.Context, mikrotikID string, result *model.UsageCollectResult) error {
+	samples, err := uc.readCounters(ctx, mikrotikID)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	previous, err := uc.usageRepo.Counters(ctx, mikrotikID)
+	if err != nil {
+		return fmt.Errorf("failed to read previous counters: %w", err)
+	}
+
+	now := time.Now()
+	counters := make([]entity.UsageCounter, 0, len(samples))
+	deltas := make([]entity.UsageDelta, 0, len(samples))
+	for _, s := range samples {
+		s.SampledAt = now
+		counters = append(counters, s)
+
+		delta := entity.UsageDelta{CustomerID: s.CustomerID, BytesUp: s.BytesUp, BytesDown: s.BytesDown}
+		if prev, ok := previous[s.CounterKey]; ok && prev.CustomerID == s.CustomerID &&
+			s.BytesUp >= prev.BytesUp && s.BytesDown >= prev.BytesDown {
+			delta.BytesUp -= prev.BytesUp
+			delta.BytesDown -= prev.BytesDown
+		}
+		deltas = append(deltas, delta)
+
+		result.BytesUp += delta.BytesUp
+		result.BytesDown += delta.BytesDown
+	}
+
+	if err := uc.usageRepo.Record(ctx, now, counters, deltas); err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	result.Customers += len(samples)
+	return nil
+}
+
+// readCounters reads the counters of the router's online customers: the dynamic
+// <pppoe-user> interface for PPPoE, the active entry for hotspot. Up is what the
+// customer sent, which the router receives.
+func (uc *usageUsecase) readCounters(ctx context.Context, mikrotikID string) ([]entity.UsageCounter, error) {
+	customers, err := uc.customerRepo.GetRouterSessionCustomers(mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+	byLogin := make(map[string]string, len(customers))
+	for _, c := range customers {
+		if c.ServiceType == "hotspot" {
+			if u := stringValue(c.HotspotUsername); u != "" {
+				byLogin["hotspot/"+u] = c.ID
+			}
+			continue
+		}
+		if u := stringValue(c.PPPoEUsername); u != "" {
+			byLogin["pppoe/"+u] = c.ID
+		}
+	}
+	if len(byLogin) == 0 {
+		return nil, nil
+	}
+
+	client, err := uc.routers.Client(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []entity.UsageCounter
+	add := func(login, key, up, down string) {
+		customerID, ok := byLogin[login]
+		if !ok {
+			return
+		}
+		sample := entity.UsageCounter{CustomerID: customerID, MikrotikID: mikrotikID, CounterKey: key}
+		sample.BytesUp, _ = strconv.ParseInt(up, 10, 64)
+		sample.BytesDown, _ = strconv.ParseInt(down, 10, 64)
+		samples = append(samples, sample)
+	}
+
+	reply, err := client.Run("/interface/print", "?type=pppoe-in", "=.proplist=.id,name,rx-byte,tx-byte")
+	if err != nil {
+		return nil, routerError("read pppoe interfaces", err)
+	}
+	for _, re := range reply.Re {
+		name := re.Map["name"]
+		if !strings.HasPrefix(name, "<pppoe-") || !strings.HasSuffix(name, ">") {
+			continue
+		}
+		user := strings.TrimSuffix(strings.TrimPrefix(name, "<pppoe-"), ">")
+		add("pppoe/"+user, re.Map[".id"]+name, re.Map["rx-byte"], re.Map["tx-byte"])
+	}
+
+	reply, err = client.Run("/ip/hotspot/active/print", "=.proplist=.id,user,bytes-in,bytes-out")
+	if err != nil {
+		return nil, routerError("read hotspot sessions", err)
+	}
+	for _, re := range reply.Re {
+		add("hotspot/"+re.Map["user"], "hotspot"+re.Map[".id"], re.Map["bytes-in"], re.Map["bytes-out"])
+	}
+
+	return samples, nil
+}
+
+func (uc *usageUsecase) GetUsage(ctx context.Context, customerID string, req model.UsageRequest) (*model.UsageResponse, error) {
+	if _, err := uc.customerRepo.GetCustomerByID(customerID); err != nil {
+		return nil, utils.ErrCustomerNotFound
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = entity.UsageGranularityDay
+	}
+
+	today := truncateDate(time.Now())
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	to := today
+	var err error
+	if req.From != "" {
+		if from, err = parseDate(req.From); err != nil {
+			return nil, fmt.Errorf("%w: from", utils.ErrInvalidDate)
+		}
+	}
+	if req.To != "" {
+		if to, err = parseDate(req.To); err != nil {
+			return nil, fmt.Errorf("%w: to", utils.ErrInvalidDate)
+		}
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to is before from", utils.ErrInvalidDate)
+	}
+	if granularity == entity.UsageGranularityHour && to.Sub(from) >= maxHourlyUsageDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: hourly usage covers at most %d days", utils.ErrInvalidDate, maxHourlyUsageDays)
+	}
+
+	// Monthly rows start on the first, so a mid-month from still includes its month
+	start := from
+	if granularity == entity.UsageGranularityMonth {
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+	}
+	periods, err := uc.usageRepo.ListUsage(ctx, customerID, granularity, start, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+
+	layout := dateLayout
+	if granularity == entity.UsageGranularityHour {
+		layout = "2006-01-02 15:04"
+	}
+	resp := &model.UsageResponse{
+		CustomerID:  customerID,
+		Granularity: granularity,
+		From:        from.Format(dateLayout),
+		To:          to.Format(dateLayout),
+		Points:      make([]model.UsagePoint, len(periods)),
+	}
+	for i, p := range periods {
+		resp.Points[i] = model.UsagePoint{
+			PeriodStart: p.PeriodStart.In(time.Local).Format(layout),
+			BytesUp:     p.BytesUp,
+			BytesDown:   p.BytesDown,
+			BytesTotal:  p.BytesUp + p.BytesDown,
+		}
+		resp.BytesUp += p.BytesUp
+		resp.BytesDown += p.BytesDown
+	}
+	resp.BytesTotal = resp.BytesUp + resp.BytesDown
+	return resp, nil
+}
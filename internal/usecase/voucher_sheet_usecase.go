@@ -0,0 +1,635 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik/hotspot"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type VoucherSheetUsecase interface {
+	// RenderSheet renders a batch as a printable HTML or PDF sheet
+	RenderSheet(ctx context.Context, batchID string, req model.VoucherSheetRequest) (*model.VoucherSheet, error)
+
+	CreateTemplate(ctx context.Context, req model.CreateVoucherTemplateRequest) (*entity.VoucherTemplate, error)
+	GetTemplate(ctx context.Context, id string) (*entity.VoucherTemplate, error)
+	ListTemplates(ctx context.Context, templateType string) ([]entity.VoucherTemplate, error)
+	UpdateTemplate(ctx context.Context, id string, req model.UpdateVoucherTemplateRequest) (*entity.VoucherTemplate, error)
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+type voucherSheetUsecase struct {
+	templateRepo    repository.VoucherTemplateRepository
+	settingRepo     repository.SettingRepository
+	voucherUsecase  VoucherUsecase
+	mikrotikUseCase MikrotikUseCase
+	httpClient      *http.Client
+}
+
+func NewVoucherSheetUsecase(
+	templateRepo repository.VoucherTemplateRepository,
+	settingRepo repository.SettingRepository,
+	voucherUsecase VoucherUsecase,
+	mikrotikUseCase MikrotikUseCase,
+) VoucherSheetUsecase {
+	return &voucherSheetUsecase{
+		templateRepo:    templateRepo,
+		settingRepo:     settingRepo,
+		voucherUsecase:  voucherUsecase,
+		mikrotikUseCase: mikrotikUseCase,
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (uc *voucherSheetUsecase) RenderSheet(ctx context.Context, batchID string, req model.VoucherSheetRequest) (*model.VoucherSheet, error) {
+	if req.Format == "" {
+		req.Format = entity.VoucherTemplateHTML
+	}
+
+	tpl, err := uc.sheetTemplate(ctx, req.TemplateID, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := uc.voucherUsecase.PrintBatch(ctx, batchID, req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	company, err := uc.sheetCompany(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The router is only asked when the caller did not say; a sheet is still
+	// printable without the DNS name, just without login URLs.
+	dnsName := req.DNSName
+	if dnsName == "" {
+		dnsName, err = uc.hotspotDNSName(ctx, batch)
+		if err != nil {
+			pkg_logger.Warn("Could not read hotspot DNS name for voucher sheet",
+				zap.String("batch_id", batch.ID),
+				zap.String("mikrotik_id", batch.MikrotikID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	data := model.VoucherSheetData{
+		Company: company,
+		Batch: model.VoucherSheetBatch{
+			ID:          batch.ID,
+			BatchName:   batch.BatchName,
+			ProfileName: batch.ProfileName,
+			Server:      stringValue(batch.Server),
+		},
+		DNSName:     dnsName,
+		Columns:     tpl.Columns,
+		RowsPerPage: tpl.RowsPerPage,
+		PrintedAt:   time.Now(),
+	}
+	if req.Columns > 0 {
+		data.Columns = req.Columns
+	}
+	if req.RowsPerPage > 0 {
+		data.RowsPerPage = req.RowsPerPage
+	}
+	showQR := tpl.ShowQR
+	if req.QR != nil {
+		showQR = *req.QR
+	}
+
+	qrCodes := make([][]byte, len(batch.Vouchers))
+	data.Vouchers = make([]model.VoucherSheetItem, len(batch.Vouchers))
+	for i, v := range batch.Vouchers {
+		item := model.VoucherSheetItem{
+			Number:    i + 1,
+			Username:  v.Username,
+			Password:  v.Password,
+			IsCode:    batch.UserType == "vc",
+			Profile:   v.ProfileName,
+			Price:     formatRupiah(v.Price),
+			Validity:  stringValue(v.Validity),
+			TimeLimit: stringValue(v.TimeLimit),
+			DataLimit: stringValue(v.DataLimit),
+		}
+		if dnsName != "" {
+			item.LoginURL = hotspotLoginURL(dnsName, v.Username, v.Password)
+		}
+		if showQR && item.LoginURL != "" {
+			png, err := qrcode.Encode(item.LoginURL, qrcode.Medium, 256)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode QR code: %w", err)
+			}
+			qrCodes[i] = png
+			item.QRCode = htmltemplate.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+		}
+		data.Vouchers[i] = item
+	}
+
+	sheet := &model.VoucherSheet{FileName: sheetFileName(batch.BatchName, req.Format)}
+	if req.Format == entity.VoucherTemplatePDF {
+		sheet.ContentType = "application/pdf"
+		sheet.Body, err = uc.renderPDF(ctx, tpl, data, qrCodes)
+	} else {
+		sheet.ContentType = "text/html; charset=utf-8"
+		sheet.Body, err = renderHTMLSheet(tpl, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkg_logger.Info("Voucher sheet rendered",
+		zap.String("batch_id", batch.ID),
+		zap.String("format", req.Format),
+		zap.String("template", tpl.TemplateName),
+		zap.Int("vouchers", len(data.Vouchers)),
+	)
+
+	return sheet, nil
+}
+
+func (uc *voucherSheetUsecase) CreateTemplate(ctx context.Context, req model.CreateVoucherTemplateRequest) (*entity.VoucherTemplate, error) {
+	exists, err := uc.templateRepo.ExistsByName(ctx, req.TemplateName, req.TemplateType, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check voucher template: %w", err)
+	}
+	if exists {
+		return nil, utils.ErrVoucherTemplateExists
+	}
+	if err := validateVoucherTemplate(req.TemplateType, req.Content); err != nil {
+		return nil, err
+	}
+
+	tpl := &entity.VoucherTemplate{
+		TemplateName: req.TemplateName,
+		TemplateType: req.TemplateType,
+		Description:  optionalString(req.Description),
+		Content:      req.Content,
+		Columns:      req.Columns,
+		RowsPerPage:  req.RowsPerPage,
+		ShowQR:       req.ShowQR,
+		IsDefault:    req.IsDefault,
+		IsActive:     true,
+	}
+	if tpl.Columns == 0 {
+		tpl.Columns = 4
+	}
+	if tpl.RowsPerPage == 0 {
+		tpl.RowsPerPage = 8
+	}
+
+	if err := uc.templateRepo.Create(ctx, tpl); err != nil {
+		return nil, fmt.Errorf("failed to create voucher template: %w", err)
+	}
+	return tpl, nil
+}
+
+func (uc *voucherSheetUsecase) GetTemplate(ctx context.Context, id string) (*entity.VoucherTemplate, error) {
+	tpl, err := uc.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrVoucherTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get voucher template: %w", err)
+	}
+	return tpl, nil
+}
+
+func (uc *voucherSheetUsecase) ListTemplates(ctx context.Context, templateType string) ([]entity.VoucherTemplate, error) {
+	templates, err := uc.templateRepo.List(ctx, templateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voucher templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (uc *voucherSheetUsecase) UpdateTemplate(ctx context.Context, id string, req model.UpdateVoucherTemplateRequest) (*entity.VoucherTemplate, error) {
+	tpl, err := uc.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TemplateName != nil && *req.TemplateName != tpl.TemplateName {
+		exists, err := uc.templateRepo.ExistsByName(ctx, *req.TemplateName, tpl.TemplateType, tpl.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check voucher template: %w", err)
+		}
+		if exists {
+			return nil, utils.ErrVoucherTemplateExists
+		}
+		tpl.TemplateName = *req.TemplateName
+	}
+	if req.Description != nil {
+		tpl.Description = optionalString(*req.Description)
+	}
+	if req.Content != nil {
+		if err := validateVoucherTemplate(tpl.TemplateType, *req.Content); err != nil {
+			return nil, err
+		}
+		tpl.Content = *req.Content
+	}
+	if req.Columns != nil {
+		tpl.Columns = *req.Columns
+	}
+	if req.RowsPerPage != nil {
+		tpl.RowsPerPage = *req.RowsPerPage
+	}
+	if req.ShowQR != nil {
+		tpl.ShowQR = *req.ShowQR
+	}
+	if req.IsDefault != nil {
+		tpl.IsDefault = *req.IsDefault
+	}
+	if req.IsActive != nil {
+		tpl.IsActive = *req.IsActive
+	}
+
+	if err := uc.templateRepo.Update(ctx, tpl); err != nil {
+		return nil, fmt.Errorf("failed to update voucher template: %w", err)
+	}
+	return tpl, nil
+}
+
+func (uc *voucherSheetUsecase) DeleteTemplate(ctx context.Context, id string) error {
+	if err := uc.templateRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrVoucherTemplateNotFound
+		}
+		return fmt.Errorf("failed to delete voucher template: %w", err)
+	}
+	return nil
+}
+
+// sheetTemplate returns the requested template, or the default one of the format
+func (uc *voucherSheetUsecase) sheetTemplate(ctx context.Context, id, format string) (*entity.VoucherTemplate, error) {
+	if id == "" {
+		tpl, err := uc.templateRepo.GetDefault(ctx, format)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: no default %s template", utils.ErrVoucherTemplateNotFound, format)
+			}
+			return nil, fmt.Errorf("failed to get default voucher template: %w", err)
+		}
+		return tpl, nil
+	}
+
+	tpl, err := uc.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.TemplateType != format {
+		return nil, fmt.Errorf("%w: template %q is for %s sheets", utils.ErrInvalidVoucherTemplate, tpl.TemplateName, tpl.TemplateType)
+	}
+	if !tpl.IsActive {
+		return nil, fmt.Errorf("%w: template %q is inactive", utils.ErrInvalidVoucherTemplate, tpl.TemplateName)
+	}
+	return tpl, nil
+}
+
+func (uc *voucherSheetUsecase) sheetCompany(ctx context.Context) (model.VoucherSheetCompany, error) {
+	company := model.VoucherSheetCompany{PrimaryColor: "#3B82F6", SecondaryColor: "#1E40AF"}
+
+	cp, err := uc.settingRepo.GetCompanyProfile(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return company, nil
+		}
+		return company, fmt.Errorf("failed to get company profile: %w", err)
+	}
+
+	company.Name = cp.CompanyName
+	company.Phone = stringValue(cp.CompanyPhone)
+	company.Website = stringValue(cp.CompanyWebsite)
+	company.LogoURL = stringValue(cp.LogoURL)
+	if cp.PrimaryColor != "" {
+		company.PrimaryColor = cp.PrimaryColor
+	}
+	if cp.SecondaryColor != "" {
+		company.SecondaryColor = cp.SecondaryColor
+	}
+	return company, nil
+}
+
+// hotspotDNSName reads the DNS name of the server profile the batch's hotspot server uses
+func (uc *voucherSheetUsecase) hotspotDNSName(ctx context.Context, batch *entity.VoucherBatch) (string, error) {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, batch.MikrotikID)
+	if err != nil {
+		return "", err
+	}
+
+	svc := hotspot.NewService(client)
+	servers, err := svc.GetHotspotServers()
+	if err != nil {
+		return "", fmt.Errorf("failed to list hotspot servers: %w", err)
+	}
+
+	// Vouchers generated for "all" servers take the first one's profile
+	profileName := ""
+	for _, s := range servers {
+		if batch.Server == nil || *batch.Server == "" || *batch.Server == "all" || s["name"] == *batch.Server {
+			profileName = s["profile"]
+			break
+		}
+	}
+	if profileName == "" {
+		return "", nil
+	}
+
+	profiles, err := svc.GetServerProfiles()
+	if err != nil {
+		return "", fmt.Errorf("failed to list hotspot server profiles: %w", err)
+	}
+	for _, p := range profiles {
+		if p["name"] == profileName {
+			return p["dns-name"], nil
+		}
+	}
+	return "", nil
+}
+
+func renderHTMLSheet(tpl *entity.VoucherTemplate, data model.VoucherSheetData) ([]byte, error) {
+	t, err := htmltemplate.New(tpl.TemplateName).Parse(tpl.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF sheet geometry, in millimetres on A4
+const (
+	sheetMargin     = 8.0
+	sheetCardGap    = 3.0
+	sheetCardHeader = 6.0
+	sheetLineHeight = 3.6
+	sheetBoldHeight = 5.0
+)
+
+// renderPDF lays the cards out in a grid, filling each card's body with the lines
+// the template renders for it; lines starting with "!" are printed in bold.
+func (uc *voucherSheetUsecase) renderPDF(ctx context.Context, tpl *entity.VoucherTemplate, data model.VoucherSheetData, qrCodes [][]byte) ([]byte, error) {
+	t, err := texttemplate.New(tpl.TemplateName).Parse(tpl.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(sheetMargin, sheetMargin, sheetMargin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle(data.Batch.BatchName, true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageW, pageH := pdf.GetPageSize()
+	cols, rows := float64(data.Columns), float64(data.RowsPerPage)
+	cardW := (pageW - 2*sheetMargin - sheetCardGap*(cols-1)) / cols
+	cardH := (pageH - 2*sheetMargin - sheetCardGap*(rows-1)) / rows
+	perPage := data.Columns * data.RowsPerPage
+
+	pr, pg, pb := hexColor(data.Company.PrimaryColor)
+	sr, sg, sb := hexColor(data.Company.SecondaryColor)
+
+	logoW := 0.0
+	if logo := uc.registerLogo(ctx, pdf, data.Company.LogoURL); logo != nil && logo.Height() > 0 {
+		logoW = (sheetCardHeader - 1) * logo.Width() / logo.Height()
+	}
+
+	var lines bytes.Buffer
+	for i, item := range data.Vouchers {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		pos := i % perPage
+		x := sheetMargin + float64(pos%data.Columns)*(cardW+sheetCardGap)
+		y := sheetMargin + float64(pos/data.Columns)*(cardH+sheetCardGap)
+
+		// Frame and header band
+		pdf.SetDrawColor(pr, pg, pb)
+		pdf.SetFillColor(pr, pg, pb)
+		pdf.SetLineWidth(0.3)
+		pdf.Rect(x, y, cardW, cardH, "D")
+		pdf.Rect(x, y, cardW, sheetCardHeader, "F")
+
+		textX := x + 1.5
+		if logoW > 0 {
+			pdf.ImageOptions("logo", x+1, y+0.5, logoW, sheetCardHeader-1, false, fpdf.ImageOptions{}, 0, "")
+			textX += logoW + 0.5
+		}
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetXY(textX, y)
+		pdf.CellFormat(x+cardW-textX-1, sheetCardHeader, tr(data.Company.Name), "", 0, "L", false, 0, "")
+		pdf.SetXY(textX, y)
+		pdf.CellFormat(x+cardW-textX-1, sheetCardHeader, fmt.Sprintf("#%d", item.Number), "", 0, "R", false, 0, "")
+
+		bodyY := y + sheetCardHeader + 1
+		bodyH := cardH - sheetCardHeader - 2
+		textW := cardW - 3
+
+		if len(qrCodes[i]) > 0 {
+			size := min(bodyH, cardW*0.4)
+			name := "qr-" + strconv.Itoa(i)
+			pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrCodes[i]))
+			pdf.ImageOptions(name, x+cardW-size-1, bodyY+(bodyH-size)/2, size, size, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+			textW -= size + 1
+		}
+
+		lines.Reset()
+		err := t.Execute(&lines, model.VoucherCardData{
+			Company: data.Company,
+			Batch:   data.Batch,
+			DNSName: data.DNSName,
+			Voucher: item,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+		}
+
+		var cardLines []string
+		height := 0.0
+		for _, line := range strings.Split(lines.String(), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			cardLines = append(cardLines, line)
+			if strings.HasPrefix(line, "!") {
+				height += sheetBoldHeight
+			} else {
+				height += sheetLineHeight
+			}
+		}
+
+		lineY := bodyY + max(0, (bodyH-height)/2)
+		for _, line := range cardLines {
+			h := sheetLineHeight
+			if text, bold := strings.CutPrefix(line, "!"); bold {
+				h = sheetBoldHeight
+				pdf.SetFont("Courier", "B", 11)
+				pdf.SetTextColor(sr, sg, sb)
+				line = text
+			} else {
+				pdf.SetFont("Helvetica", "", 7)
+				pdf.SetTextColor(60, 60, 60)
+			}
+			pdf.SetXY(x+1.5, lineY)
+			pdf.CellFormat(textW, h, tr(line), "", 0, "L", false, 0, "")
+			lineY += h
+		}
+	}
+
+	if len(data.Vouchers) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// registerLogo downloads the company logo into the PDF; sheets print without it
+// when it cannot be fetched.
+func (uc *voucherSheetUsecase) registerLogo(ctx context.Context, pdf *fpdf.Fpdf, logoURL string) *fpdf.ImageInfoType {
+	if !strings.HasPrefix(logoURL, "http://") && !strings.HasPrefix(logoURL, "https://") {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logoURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := uc.httpClient.Do(req)
+	if err != nil {
+		pkg_logger.Warn("Could not fetch company logo", zap.String("url", logoURL), zap.Error(err))
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		pkg_logger.Warn("Could not fetch company logo", zap.String("url", logoURL), zap.Int("status", resp.StatusCode))
+		return nil
+	}
+
+	imageType := ""
+	switch http.DetectContentType(body) {
+	case "image/png":
+		imageType = "PNG"
+	case "image/jpeg":
+		imageType = "JPG"
+	case "image/gif":
+		imageType = "GIF"
+	default:
+		pkg_logger.Warn("Company logo is not a PNG, JPEG or GIF image", zap.String("url", logoURL))
+		return nil
+	}
+
+	info := pdf.RegisterImageOptionsReader("logo", fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(body))
+	if !pdf.Ok() {
+		pkg_logger.Warn("Could not embed company logo", zap.String("url", logoURL), zap.Error(pdf.Error()))
+		pdf.ClearError()
+		return nil
+	}
+	return info
+}
+
+// validateVoucherTemplate parses a template and renders it with sample data, so
+// mistakes surface when it is saved rather than when a sheet is printed.
+func validateVoucherTemplate(templateType, content string) error {
+	sample := model.VoucherSheetData{
+		Company:     model.VoucherSheetCompany{Name: "Sample ISP", PrimaryColor: "#3B82F6", SecondaryColor: "#1E40AF"},
+		Batch:       model.VoucherSheetBatch{BatchName: "sample", ProfileName: "default"},
+		DNSName:     "hotspot.local",
+		Columns:     4,
+		RowsPerPage: 8,
+		PrintedAt:   time.Now(),
+		Vouchers: []model.VoucherSheetItem{
+			{Number: 1, Username: "abc123", Password: "abc123", IsCode: true, Price: formatRupiah(5000), Validity: "1d"},
+			{Number: 2, Username: "user1", Password: "pass1", Price: formatRupiah(10000), TimeLimit: "3h", DataLimit: "1G",
+				LoginURL: hotspotLoginURL("hotspot.local", "user1", "pass1"), QRCode: "data:image/png;base64,"},
+		},
+	}
+
+	if templateType == entity.VoucherTemplateHTML {
+		_, err := renderHTMLSheet(&entity.VoucherTemplate{TemplateName: "validate", Content: content}, sample)
+		return err
+	}
+
+	t, err := texttemplate.New("validate").Parse(content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+	}
+	for _, item := range sample.Vouchers {
+		card := model.VoucherCardData{Company: sample.Company, Batch: sample.Batch, DNSName: sample.DNSName, Voucher: item}
+		if err := t.Execute(io.Discard, card); err != nil {
+			return fmt.Errorf("%w: %v", utils.ErrInvalidVoucherTemplate, err)
+		}
+	}
+	return nil
+}
+
+// hotspotLoginURL builds a URL that logs the voucher in when opened behind the hotspot
+func hotspotLoginURL(dnsName, username, password string) string {
+	q := url.Values{}
+	q.Set("username", username)
+	q.Set("password", password)
+	return "http://" + dnsName + "/login?" + q.Encode()
+}
+
+// formatRupiah formats an amount as "Rp 10.000"
+func formatRupiah(amount float64) string {
+	digits := strconv.FormatInt(int64(amount+0.5), 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return "Rp " + b.String()
+}
+
+// hexColor parses "#RRGGBB", falling back to black
+func hexColor(s string) (int, int, int) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 {
+		return 0, 0, 0
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+func sheetFileName(batchName, format string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, batchName)
+	return "vouchers-" + name + "." + format
+}
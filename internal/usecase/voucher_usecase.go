@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mikrobill/internal/entity"
+	"mikrobill/internal/infrastructure/mikrotik/hotspot"
+	mkmodel "mikrobill/internal/infrastructure/mikrotik/model"
+	"mikrobill/internal/model"
+	"mikrobill/internal/port/repository"
+	pkg_logger "mikrobill/pkg/logger"
+	"mikrobill/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type VoucherUsecase interface {
+	// GenerateBatch creates vouchers on the router and stores every one that was created
+	GenerateBatch(ctx context.Context, mikrotikID string, req model.GenerateVoucherBatchRequest, createdBy *int64) (*model.GenerateVoucherBatchResult, error)
+	ListBatches(ctx context.Context, mikrotikID string, page, pageSize int) (*model.PaginationResponse, error)
+	GetBatch(ctx context.Context, id string) (*entity.VoucherBatch, error)
+	// PrintBatch returns a batch with only the vouchers in the given state (all when empty), for reprinting
+	PrintBatch(ctx context.Context, id, state string) (*entity.VoucherBatch, error)
+
+	List(ctx context.Context, req model.VoucherListRequest) (*model.PaginationResponse, error)
+	GetByID(ctx context.Context, id string) (*entity.HotspotVoucher, error)
+	// MarkSold records a voucher sold over the counter by staff
+	MarkSold(ctx context.Context, id string) (*entity.HotspotVoucher, error)
+
+	// Reconcile compares the router's stored vouchers with its hotspot users and records
+	// which have been used, have expired or were removed.
+	Reconcile(ctx context.Context, mikrotikID string) (*model.VoucherReconcileResult, error)
+	// ReconcileAll reconciles every router
+	ReconcileAll(ctx context.Context) ([]*model.VoucherReconcileResult, error)
+}
+
+type voucherUsecase struct {
+	voucherRepo     repository.VoucherRepository
+	mikrotikRepo    repository.MikrotikRepository
+	mikrotikUseCase MikrotikUseCase
+}
+
+func NewVoucherUsecase(
+	voucherRepo repository.VoucherRepository,
+	mikrotikRepo repository.MikrotikRepository,
+	mikrotikUseCase MikrotikUseCase,
+) VoucherUsecase {
+	return &voucherUsecase{
+		voucherRepo:     voucherRepo,
+		mikrotikRepo:    mikrotikRepo,
+		mikrotikUseCase: mikrotikUseCase,
+	}
+}
+
+func (uc *voucherUsecase) GenerateBatch(ctx context.Context, mikrotikID string, req model.GenerateVoucherBatchRequest, createdBy *int64) (*model.GenerateVoucherBatchResult, error) {
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, genErr := hotspot.NewService(client).GenerateVouchers(mkmodel.VoucherRequest{
+		Qty:        req.Qty,
+		Server:     req.Server,
+		UserType:   mkmodel.UserType(req.UserType),
+		UserLength: req.UserLength,
+		Prefix:     req.Prefix,
+		CharType:   mkmodel.CharType(req.CharType),
+		Profile:    req.Profile,
+		TimeLimit:  req.TimeLimit,
+		DataLimit:  req.DataLimit,
+		Comment:    req.BatchName,
+		GenCode:    fmt.Sprintf("%03d", rand.Intn(1000)),
+	})
+	if resp == nil || len(resp.Data.Vouchers) == 0 {
+		if genErr == nil {
+			genErr = errors.New("router returned no vouchers")
+		}
+		return nil, fmt.Errorf("%w: %v", utils.ErrVoucherGeneration, genErr)
+	}
+
+	batch := &entity.VoucherBatch{
+		MikrotikID:      mikrotikID,
+		BatchName:       req.BatchName,
+		ProfileName:     req.Profile,
+		Server:          optionalString(req.Server),
+		UserType:        req.UserType,
+		CharType:        req.CharType,
+		CodePrefix:      optionalString(req.Prefix),
+		CodeLength:      req.UserLength,
+		TotalVouchers:   len(resp.Data.Vouchers),
+		PricePerVoucher: req.Price,
+		Validity:        optionalString(req.Validity),
+		TimeLimit:       optionalString(req.TimeLimit),
+		DataLimit:       optionalString(req.DataLimit),
+		RouterComment:   resp.Data.Comment,
+		CreatedBy:       createdBy,
+	}
+	for _, gv := range resp.Data.Vouchers {
+		batch.Vouchers = append(batch.Vouchers, entity.HotspotVoucher{
+			MikrotikID:     mikrotikID,
+			Username:       gv.Username,
+			Password:       gv.Password,
+			ProfileName:    req.Profile,
+			Price:          req.Price,
+			Validity:       batch.Validity,
+			TimeLimit:      batch.TimeLimit,
+			DataLimit:      batch.DataLimit,
+			Status:         entity.VoucherStatusActive,
+			MikrotikUserID: optionalString(gv.ID),
+			CreatedBy:      createdBy,
+		})
+	}
+
+	// The users already exist on the router, so they must be stored even if generation stopped part way
+	if err := uc.voucherRepo.CreateBatch(ctx, batch); err != nil {
+		pkg_logger.Error("Vouchers created on router but not stored",
+			zap.String("mikrotik_id", mikrotikID),
+			zap.String("router_comment", batch.RouterComment),
+			zap.Int("count", batch.TotalVouchers),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to save voucher batch: %w", err)
+	}
+
+	result := &model.GenerateVoucherBatchResult{
+		Requested: req.Qty,
+		Created:   batch.TotalVouchers,
+		Batch:     batch,
+	}
+	if genErr != nil {
+		result.Error = genErr.Error()
+		pkg_logger.Warn("Voucher generation stopped part way",
+			zap.String("batch_id", batch.ID),
+			zap.Int("requested", req.Qty),
+			zap.Int("created", batch.TotalVouchers),
+			zap.Error(genErr),
+		)
+	}
+
+	pkg_logger.Info("Voucher batch generated",
+		zap.String("batch_id", batch.ID),
+		zap.String("mikrotik_id", mikrotikID),
+		zap.String("profile", batch.ProfileName),
+		zap.Int("count", batch.TotalVouchers),
+	)
+
+	return result, nil
+}
+
+func (uc *voucherUsecase) ListBatches(ctx context.Context, mikrotikID string, page, pageSize int) (*model.PaginationResponse, error) {
+	page, pageSize = normalizePage(page, pageSize)
+
+	batches, total, err := uc.voucherRepo.ListBatches(ctx, mikrotikID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voucher batches: %w", err)
+	}
+
+	return paginationResponse(page, pageSize, total, batches), nil
+}
+
+func (uc *voucherUsecase) GetBatch(ctx context.Context, id string) (*entity.VoucherBatch, error) {
+	batch, err := uc.voucherRepo.GetBatch(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrVoucherBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get voucher batch: %w", err)
+	}
+	return batch, nil
+}
+
+func (uc *voucherUsecase) PrintBatch(ctx context.Context, id, state string) (*entity.VoucherBatch, error) {
+	batch, err := uc.GetBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state == "" {
+		return batch, nil
+	}
+
+	vouchers := batch.Vouchers[:0]
+	for _, v := range batch.Vouchers {
+		if v.State() == state {
+			vouchers = append(vouchers, v)
+		}
+	}
+	batch.Vouchers = vouchers
+	return batch, nil
+}
+
+func (uc *voucherUsecase) List(ctx context.Context, req model.VoucherListRequest) (*model.PaginationResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	vouchers, total, err := uc.voucherRepo.List(ctx, repository.VoucherFilter{
+		MikrotikID: req.MikrotikID,
+		BatchID:    req.BatchID,
+		State:      req.State,
+		AgentID:    req.AgentID,
+		Search:     req.Search,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vouchers: %w", err)
+	}
+
+	return paginationResponse(req.Page, req.PageSize, total, vouchers), nil
+}
+
+func (uc *voucherUsecase) GetByID(ctx context.Context, id string) (*entity.HotspotVoucher, error) {
+	v, err := uc.voucherRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrVoucherNotFound
+		}
+		return nil, fmt.Errorf("failed to get voucher: %w", err)
+	}
+	return v, nil
+}
+
+func (uc *voucherUsecase) MarkSold(ctx context.Context, id string) (*entity.HotspotVoucher, error) {
+	if _, err := uc.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	ok, err := uc.voucherRepo.MarkSold(ctx, id, nil, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark voucher sold: %w", err)
+	}
+	if !ok {
+		return nil, utils.ErrVoucherNotAvailable
+	}
+
+	return uc.GetByID(ctx, id)
+}
+
+func (uc *voucherUsecase) Reconcile(ctx context.Context, mikrotikID string) (*model.VoucherReconcileResult, error) {
+	vouchers, err := uc.voucherRepo.ListOpen(ctx, mikrotikID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vouchers: %w", err)
+	}
+
+	client, err := uc.mikrotikUseCase.GetClientByID(ctx, mikrotikID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := hotspot.NewService(client).GetAllUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotspot users: %w", err)
+	}
+	users, _ := resp.Data.([]map[string]string)
+
+	byName := make(map[string]map[string]string, len(users))
+	for _, u := range users {
+		byName[u["name"]] = u
+	}
+
+	result := &model.VoucherReconcileResult{MikrotikID: mikrotikID}
+	now := time.Now()
+	open := make(map[string]bool, len(vouchers))
+
+	for i := range vouchers {
+		v := &vouchers[i]
+		open[v.Username] = true
+		result.Checked++
+
+		before := v.Status
+		applyRouterState(v, byName[v.Username], now)
+		if v.Status != before {
+			switch v.Status {
+			case entity.VoucherStatusUsed:
+				result.Used++
+			case entity.VoucherStatusExpired:
+				result.Expired++
+			case entity.VoucherStatusCancelled:
+				result.Missing++
+			}
+		}
+
+		if err := uc.voucherRepo.UpdateSync(ctx, v); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", v.Username, err))
+		}
+	}
+
+	// Router users carrying a batch comment that mikrobill does not know about
+	if comments, err := uc.voucherRepo.BatchComments(ctx, mikrotikID); err == nil && len(comments) > 0 {
+		batchComment := make(map[string]bool, len(comments))
+		for _, c := range comments {
+			batchComment[c] = true
+		}
+		var candidates []string
+		for name, u := range byName {
+			if batchComment[u["comment"]] && !open[name] {
+				candidates = append(candidates, name)
+			}
+		}
+		known, err := uc.voucherRepo.ExistingUsernames(ctx, mikrotikID, candidates)
+		if err == nil {
+			isKnown := make(map[string]bool, len(known))
+			for _, k := range known {
+				isKnown[k] = true
+			}
+			for _, name := range candidates {
+				if !isKnown[name] {
+					result.Untracked = append(result.Untracked, name)
+				}
+			}
+		}
+	}
+
+	pkg_logger.Info("Vouchers reconciled",
+		zap.String("mikrotik_id", mikrotikID),
+		zap.Int("checked", result.Checked),
+		zap.Int("used", result.Used),
+		zap.Int("expired", result.Expired),
+		zap.Int("missing", result.Missing),
+		zap.Int("untracked", len(result.Untracked)),
+	)
+
+	return result, nil
+}
+
+func (uc *voucherUsecase) ReconcileAll(ctx context.Context) ([]*model.VoucherReconcileResult, error) {
+	mikrotiks, _, err := uc.mikrotikRepo.List(ctx, 1, 1000, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mikrotiks: %w", err)
+	}
+
+	var results []*model.VoucherReconcileResult
+	for _, mk := range mikrotiks {
+		result, err := uc.Reconcile(ctx, mk.ID)
+		if err != nil {
+			results = append(results, &model.VoucherReconcileResult{
+				MikrotikID: mk.ID,
+				Failed:     1,
+				Errors:     []string{err.Error()},
+			})
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// applyRouterState moves a voucher to the state implied by its router user, or by its absence.
+// The expire monitor removes users whose validity ran out, so a used voucher that disappeared
+// has expired, while an unused one was deleted by hand.
+func applyRouterState(v *entity.HotspotVoucher, u map[string]string, now time.Time) {
+	v.LastSync = &now
+
+	if u == nil {
+		if v.Status == entity.VoucherStatusUsed {
+			v.Status = entity.VoucherStatusExpired
+		} else {
+			v.Status = entity.VoucherStatusCancelled
+		}
+		v.ExpiredAt = &now
+		return
+	}
+
+	if id := u[".id"]; id != "" {
+		v.MikrotikUserID = &id
+	}
+
+	uptime := parseRouterDuration(u["uptime"])
+	bytesIn, _ := strconv.ParseInt(u["bytes-in"], 10, 64)
+	bytesOut, _ := strconv.ParseInt(u["bytes-out"], 10, 64)
+	if uptime > 0 || bytesIn > 0 || bytesOut > 0 {
+		if v.Status == entity.VoucherStatusActive {
+			v.Status = entity.VoucherStatusUsed
+		}
+		if v.ActivatedAt == nil {
+			v.ActivatedAt = &now
+		}
+	}
+
+	limitUptime := parseRouterDuration(u["limit-uptime"])
+	limitBytes, _ := strconv.ParseInt(u["limit-bytes-total"], 10, 64)
+	if (limitUptime > 0 && uptime >= limitUptime) || (limitBytes > 0 && bytesIn+bytesOut >= limitBytes) {
+		v.Status = entity.VoucherStatusExpired
+		v.ExpiredAt = &now
+	}
+}
+
+// parseRouterDuration parses RouterOS durations such as "1w2d3h4m5s" or "2d03:04:05"
+func parseRouterDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	var total time.Duration
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		// Trailing hh:mm:ss clock part used by RouterOS v6
+		start := strings.LastIndexAny(s[:i], "wd") + 1
+		parts := strings.Split(s[start:], ":")
+		if len(parts) == 3 {
+			h, _ := strconv.Atoi(parts[0])
+			m, _ := strconv.Atoi(parts[1])
+			sec, _ := strconv.Atoi(parts[2])
+			total += time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+		}
+		s = s[:start]
+	}
+
+	units := map[byte]time.Duration{
+		'w': 7 * 24 * time.Hour,
+		'd': 24 * time.Hour,
+		'h': time.Hour,
+		'm': time.Minute,
+		's': time.Second,
+	}
+	num := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			num = num*10 + int(c-'0')
+			continue
+		}
+		if unit, ok := units[c]; ok {
+			// "ms" suffix: milliseconds are irrelevant for limits
+			if c == 'm' && i+1 < len(s) && s[i+1] == 's' {
+				i++
+			} else {
+				total += time.Duration(num) * unit
+			}
+		}
+		num = 0
+	}
+	return total
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,6 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS set_updated_at_invoices ON invoices;
+DROP TABLE IF EXISTS invoices;
+ALTER TABLE company_profile DROP COLUMN IF EXISTS invoice_next_number;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Running counter for invoice numbering, seeded from invoice_start_number
+ALTER TABLE company_profile ADD COLUMN IF NOT EXISTS invoice_next_number INTEGER;
+
+-- INVOICES TABLE
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    profile_id UUID REFERENCES mikrotik_profiles(id) ON DELETE SET NULL,
+    invoice_number VARCHAR(50) NOT NULL,
+    invoice_type invoice_type DEFAULT 'monthly',
+    profile_name VARCHAR(100),
+    description TEXT,
+    amount DECIMAL(15,2) NOT NULL,
+    period_start DATE,
+    period_end DATE,
+    issue_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    due_date DATE NOT NULL,
+    status invoice_status DEFAULT 'unpaid',
+    paid_at TIMESTAMPTZ,
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (invoice_number)
+);
+
+CREATE INDEX idx_invoices_mikrotik ON invoices(mikrotik_id);
+CREATE INDEX idx_invoices_customer ON invoices(customer_id);
+CREATE INDEX idx_invoices_status ON invoices(status);
+CREATE INDEX idx_invoices_due_date ON invoices(due_date);
+CREATE INDEX idx_invoices_profile ON invoices(profile_id);
+
+-- One monthly invoice per customer per billing period
+CREATE UNIQUE INDEX idx_invoices_customer_period ON invoices(customer_id, period_start)
+    WHERE invoice_type = 'monthly';
+
+CREATE TRIGGER set_updated_at_invoices
+    BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
@@ -0,0 +1,3 @@
+-- +goose Down
+-- Postgres cannot drop a value from an enum; move affected rows back instead.
+UPDATE invoices SET status = 'unpaid' WHERE status = 'partially_paid';
@@ -0,0 +1,4 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+-- ALTER TYPE ... ADD VALUE cannot be used in the same transaction as the new value
+ALTER TYPE invoice_status ADD VALUE IF NOT EXISTS 'partially_paid' AFTER 'unpaid';
@@ -0,0 +1,6 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payments;
+DROP SEQUENCE IF EXISTS payment_receipt_seq;
+ALTER TABLE invoices DROP COLUMN IF EXISTS paid_amount;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS paid_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- Receipt numbers: RCP-YYYYMM-000001
+CREATE SEQUENCE IF NOT EXISTS payment_receipt_seq START 1;
+
+-- PAYMENTS TABLE
+CREATE TABLE payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    receipt_number VARCHAR(50) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    payment_method payment_method NOT NULL DEFAULT 'cash',
+    payment_date TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reference_number VARCHAR(100),
+    received_by BIGINT, -- users.id of the JWT subject; no FK as the users table keys on UUID
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (receipt_number)
+);
+
+CREATE INDEX idx_payments_invoice ON payments(invoice_id);
+CREATE INDEX idx_payments_customer ON payments(customer_id);
+CREATE INDEX idx_payments_date ON payments(payment_date);
+
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_invoices_customer_status;
+ALTER TABLE invoices DROP COLUMN IF EXISTS late_fee;
+ALTER TABLE customers DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE customers DROP COLUMN IF EXISTS suspended_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Suspension bookkeeping on customers
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS suspension_reason VARCHAR(50);
+
+-- Late fee charged once when an invoice becomes overdue (billing.late_fee_percentage)
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS late_fee DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_invoices_customer_status ON invoices(customer_id, status);
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS chk_customers_suspension_mode;
+ALTER TABLE customers DROP COLUMN IF EXISTS original_profile_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS isolated;
+ALTER TABLE customers DROP COLUMN IF EXISTS suspension_mode;
+
+ALTER TABLE mikrotik DROP CONSTRAINT IF EXISTS chk_mikrotik_suspension_mode;
+ALTER TABLE mikrotik DROP COLUMN IF EXISTS isolation_address_list;
+ALTER TABLE mikrotik DROP COLUMN IF EXISTS isolation_rate_limit;
+ALTER TABLE mikrotik DROP COLUMN IF EXISTS isolation_profile;
+ALTER TABLE mikrotik DROP COLUMN IF EXISTS suspension_mode;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Per-router suspension strategy: 'disable' the PPP secret or 'isolate' it into a limited profile
+ALTER TABLE mikrotik ADD COLUMN IF NOT EXISTS suspension_mode VARCHAR(20) NOT NULL DEFAULT 'disable';
+ALTER TABLE mikrotik ADD COLUMN IF NOT EXISTS isolation_profile VARCHAR(100) NOT NULL DEFAULT 'isolir';
+ALTER TABLE mikrotik ADD COLUMN IF NOT EXISTS isolation_rate_limit VARCHAR(50) NOT NULL DEFAULT '256k/256k';
+ALTER TABLE mikrotik ADD COLUMN IF NOT EXISTS isolation_address_list VARCHAR(100) NOT NULL DEFAULT 'isolir';
+
+ALTER TABLE mikrotik ADD CONSTRAINT chk_mikrotik_suspension_mode
+    CHECK (suspension_mode IN ('disable', 'isolate'));
+
+-- Per-customer override (NULL inherits the router's mode) and the profile to restore on reactivation
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS suspension_mode VARCHAR(20);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS isolated BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS original_profile_id UUID REFERENCES mikrotik_profiles(id) ON DELETE SET NULL;
+
+ALTER TABLE customers ADD CONSTRAINT chk_customers_suspension_mode
+    CHECK (suspension_mode IS NULL OR suspension_mode IN ('disable', 'isolate'));
+
+-- +goose StatementEnd
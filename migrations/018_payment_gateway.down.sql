@@ -0,0 +1,6 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_gateway_transactions;
+UPDATE payments SET payment_method = 'other' WHERE payment_method = 'gateway';
+-- Enum values cannot be dropped; 'gateway' stays in payment_method
+-- +goose StatementEnd
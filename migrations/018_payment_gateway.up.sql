@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose NO TRANSACTION
+
+-- Payments settled by an online gateway. ALTER TYPE ... ADD VALUE stays out of a
+-- transaction, as in 014.
+ALTER TYPE payment_method ADD VALUE IF NOT EXISTS 'gateway';
+
+-- +goose StatementBegin
+
+-- PAYMENT GATEWAY TRANSACTIONS
+-- One row per charge created at a gateway. settled_at is set exactly once when the
+-- transaction is settled and is kept when its payment is voided, which makes
+-- repeated notifications harmless. settle_error holds why a successful charge
+-- could not be recorded as a payment.
+CREATE TABLE payment_gateway_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    gateway VARCHAR(50) NOT NULL,
+    order_id VARCHAR(100) NOT NULL,
+    transaction_id VARCHAR(100),
+    payment_url TEXT,
+    token VARCHAR(255),
+    amount DECIMAL(15,2) NOT NULL,
+    status payment_status NOT NULL DEFAULT 'pending',
+    payment_type VARCHAR(50),
+    fraud_status VARCHAR(50),
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    settled_at TIMESTAMPTZ,
+    settle_error TEXT,
+    last_notification TEXT,
+    expires_at TIMESTAMPTZ,
+    paid_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (gateway, order_id)
+);
+
+CREATE INDEX idx_pgt_invoice ON payment_gateway_transactions(invoice_id);
+CREATE INDEX idx_pgt_status ON payment_gateway_transactions(status);
+
+CREATE TRIGGER set_updated_at_pgt
+    BEFORE UPDATE ON payment_gateway_transactions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payments_agent;
+ALTER TABLE payments DROP COLUMN IF EXISTS agent_id;
+DROP TABLE IF EXISTS agent_voucher_sales;
+DROP TABLE IF EXISTS agent_balance_requests;
+DROP TABLE IF EXISTS agent_transactions;
+DROP TABLE IF EXISTS agent_balances;
+DROP TABLE IF EXISTS agents;
+DROP TYPE IF EXISTS request_status;
+-- +goose StatementEnd
@@ -0,0 +1,102 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE request_status AS ENUM ('pending', 'approved', 'rejected');
+
+-- AGENTS TABLE (resellers with their own login)
+CREATE TABLE agents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    username VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    email VARCHAR(255),
+    password_hash TEXT NOT NULL,
+    address TEXT,
+    status agent_status NOT NULL DEFAULT 'active',
+    commission_rate DECIMAL(5,2) NOT NULL DEFAULT 5.00 CHECK (commission_rate >= 0 AND commission_rate <= 100),
+    last_login TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (username),
+    UNIQUE (phone)
+);
+
+CREATE INDEX idx_agents_status ON agents(status);
+
+CREATE TRIGGER set_updated_at_agents
+    BEFORE UPDATE ON agents
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- AGENT BALANCES TABLE
+-- Cached running total of agent_transactions; the row is locked for every ledger posting
+CREATE TABLE agent_balances (
+    agent_id UUID PRIMARY KEY REFERENCES agents(id) ON DELETE CASCADE,
+    balance DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (balance >= 0),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- AGENT TRANSACTIONS TABLE (ledger)
+-- amount is signed: credits are positive, debits negative. Every entry keeps the balance
+-- before and after it, so the ledger can be replayed and checked against agent_balances.
+CREATE TABLE agent_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    agent_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    transaction_type agent_transaction_type NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount <> 0),
+    balance_before DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,
+    description TEXT,
+    reference_type VARCHAR(50),
+    reference_id VARCHAR(100),
+    status transaction_status NOT NULL DEFAULT 'completed',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    CHECK (balance_after = balance_before + amount)
+);
+
+CREATE INDEX idx_agent_trans_agent ON agent_transactions(agent_id, created_at);
+CREATE INDEX idx_agent_trans_type ON agent_transactions(transaction_type);
+CREATE INDEX idx_agent_trans_reference ON agent_transactions(reference_type, reference_id);
+
+-- AGENT BALANCE REQUESTS TABLE (top-ups awaiting admin approval)
+CREATE TABLE agent_balance_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    agent_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    status request_status NOT NULL DEFAULT 'pending',
+    notes TEXT,
+    admin_notes TEXT,
+    transaction_id UUID REFERENCES agent_transactions(id) ON DELETE SET NULL,
+    requested_at TIMESTAMPTZ DEFAULT now(),
+    processed_at TIMESTAMPTZ,
+    processed_by BIGINT
+);
+
+CREATE INDEX idx_abr_agent ON agent_balance_requests(agent_id);
+CREATE INDEX idx_abr_status ON agent_balance_requests(status);
+
+-- AGENT VOUCHER SALES TABLE
+CREATE TABLE agent_voucher_sales (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    agent_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    voucher_code VARCHAR(100) NOT NULL,
+    profile_name VARCHAR(100) NOT NULL,
+    price DECIMAL(15,2) NOT NULL CHECK (price > 0),
+    commission_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    customer_name VARCHAR(255),
+    customer_phone VARCHAR(20),
+    sold_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (mikrotik_id, voucher_code)
+);
+
+CREATE INDEX idx_avs_agent ON agent_voucher_sales(agent_id);
+
+-- Customer payments collected in the field by an agent
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS agent_id UUID REFERENCES agents(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_agent ON payments(agent_id);
+
+-- +goose StatementEnd
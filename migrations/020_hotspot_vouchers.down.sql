@@ -0,0 +1,5 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS hotspot_vouchers;
+DROP TABLE IF EXISTS voucher_batches;
+-- +goose StatementEnd
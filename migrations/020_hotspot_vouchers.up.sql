@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- VOUCHER BATCHES TABLE (one per bulk generation)
+CREATE TABLE voucher_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+
+    batch_name VARCHAR(100) NOT NULL,
+    profile_name VARCHAR(100) NOT NULL,
+    server VARCHAR(50),
+
+    -- Generation options, kept so a batch can be described and reprinted
+    user_type VARCHAR(2) NOT NULL CHECK (user_type IN ('up', 'vc')),
+    char_type VARCHAR(10) NOT NULL,
+    code_prefix VARCHAR(20),
+    code_length INTEGER NOT NULL,
+
+    total_vouchers INTEGER NOT NULL,
+    price_per_voucher DECIMAL(15,2) NOT NULL DEFAULT 0,
+    validity VARCHAR(20),
+    time_limit VARCHAR(20),
+    data_limit VARCHAR(20),
+
+    -- Comment written on every router user of the batch
+    router_comment VARCHAR(255) NOT NULL,
+
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_batches_mikrotik ON voucher_batches(mikrotik_id);
+
+-- HOTSPOT VOUCHERS TABLE
+CREATE TABLE hotspot_vouchers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    batch_id UUID NOT NULL REFERENCES voucher_batches(id) ON DELETE CASCADE,
+
+    username VARCHAR(100) NOT NULL,
+    password VARCHAR(100) NOT NULL,
+    profile_name VARCHAR(100) NOT NULL,
+
+    price DECIMAL(15,2) NOT NULL DEFAULT 0,
+    validity VARCHAR(20),
+    time_limit VARCHAR(20),
+    data_limit VARCHAR(20),
+
+    -- active = not used yet; sold_at tells unsold from sold
+    status voucher_status NOT NULL DEFAULT 'active',
+    sold_at TIMESTAMPTZ,
+    agent_id UUID REFERENCES agents(id) ON DELETE SET NULL,
+    activated_at TIMESTAMPTZ,
+    expired_at TIMESTAMPTZ,
+
+    -- MikroTik sync
+    mikrotik_user_id VARCHAR(100),
+    last_sync TIMESTAMPTZ,
+
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (mikrotik_id, username)
+);
+
+CREATE INDEX idx_vouchers_batch ON hotspot_vouchers(batch_id);
+CREATE INDEX idx_vouchers_status ON hotspot_vouchers(mikrotik_id, status);
+CREATE INDEX idx_vouchers_agent ON hotspot_vouchers(agent_id);
+
+CREATE TRIGGER set_updated_at_hotspot_vouchers
+    BEFORE UPDATE ON hotspot_vouchers
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
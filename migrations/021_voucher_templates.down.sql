@@ -0,0 +1,4 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS voucher_templates;
+-- +goose StatementEnd
@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- VOUCHER TEMPLATES TABLE
+-- html templates render a whole sheet with html/template.
+-- pdf templates render the text of one card with text/template; each output line is
+-- printed on its own, lines starting with "!" in bold.
+CREATE TABLE voucher_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template_name VARCHAR(100) NOT NULL,
+    template_type VARCHAR(10) NOT NULL CHECK (template_type IN ('html', 'pdf')),
+    description TEXT,
+    content TEXT NOT NULL,
+
+    -- Grid, overridable per print
+    columns INTEGER NOT NULL DEFAULT 4 CHECK (columns BETWEEN 1 AND 8),
+    rows_per_page INTEGER NOT NULL DEFAULT 8 CHECK (rows_per_page BETWEEN 1 AND 20),
+    show_qr BOOLEAN NOT NULL DEFAULT false,
+
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    UNIQUE (template_name, template_type)
+);
+
+-- At most one default per type
+CREATE UNIQUE INDEX idx_voucher_templates_default ON voucher_templates(template_type) WHERE is_default;
+
+CREATE TRIGGER set_updated_at_voucher_templates
+    BEFORE UPDATE ON voucher_templates
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+INSERT INTO voucher_templates (template_name, template_type, description, content, columns, rows_per_page, show_qr, is_default) VALUES
+('default', 'html', 'Card grid with company branding', $tpl$<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Batch.BatchName}}</title>
+<style>
+  @page { size: A4; margin: 8mm; }
+  body { font-family: Arial, Helvetica, sans-serif; margin: 0; }
+  .sheet { display: grid; grid-template-columns: repeat({{.Columns}}, 1fr); gap: 3mm; }
+  .card { border: 1px solid {{.Company.PrimaryColor}}; border-radius: 2mm; overflow: hidden; page-break-inside: avoid; font-size: 9pt; }
+  .head { background: {{.Company.PrimaryColor}}; color: #fff; padding: 1mm 2mm; display: flex; align-items: center; gap: 2mm; font-weight: bold; }
+  .head img { height: 5mm; }
+  .body { padding: 1.5mm 2mm; display: flex; justify-content: space-between; gap: 2mm; }
+  .code { font-family: "Courier New", monospace; font-size: 12pt; font-weight: bold; color: {{.Company.SecondaryColor}}; }
+  .price { font-weight: bold; }
+  .muted { color: #555; font-size: 7.5pt; }
+  .qr img { width: 18mm; height: 18mm; }
+</style>
+</head>
+<body>
+<div class="sheet">
+{{range .Vouchers}}
+  <div class="card">
+    <div class="head">{{if $.Company.LogoURL}}<img src="{{$.Company.LogoURL}}" alt="">{{end}}<span>{{$.Company.Name}}</span></div>
+    <div class="body">
+      <div>
+        {{if .IsCode}}<div class="muted">Kode Voucher</div><div class="code">{{.Username}}</div>
+        {{else}}<div class="muted">Username</div><div class="code">{{.Username}}</div>
+        <div class="muted">Password</div><div class="code">{{.Password}}</div>{{end}}
+        <div class="price">{{.Price}}</div>
+        {{if .Validity}}<div class="muted">Masa aktif {{.Validity}}</div>{{end}}
+        {{if .TimeLimit}}<div class="muted">Durasi {{.TimeLimit}}</div>{{end}}
+        {{if .DataLimit}}<div class="muted">Kuota {{.DataLimit}}</div>{{end}}
+        {{if $.DNSName}}<div class="muted">Login: http://{{$.DNSName}}</div>{{end}}
+      </div>
+      {{if .QRCode}}<div class="qr"><img src="{{.QRCode}}" alt=""></div>{{end}}
+    </div>
+  </div>
+{{end}}
+</div>
+</body>
+</html>
+$tpl$, 4, 8, false, true),
+('default', 'pdf', 'Card grid with company branding', $tpl${{if .Voucher.IsCode}}Kode Voucher
+!{{.Voucher.Username}}
+{{else}}User: {{.Voucher.Username}}
+Pass: {{.Voucher.Password}}
+{{end}}!{{.Voucher.Price}}
+{{if .Voucher.Validity}}Masa aktif {{.Voucher.Validity}}
+{{end}}{{if .Voucher.DataLimit}}Kuota {{.Voucher.DataLimit}}
+{{end}}{{if .DNSName}}Login: http://{{.DNSName}}
+{{end}}$tpl$, 4, 10, false, true);
+
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mikrotik_status_history;
+
+ALTER TABLE mikrotik
+    DROP COLUMN IF EXISTS cpu_load,
+    DROP COLUMN IF EXISTS free_memory,
+    DROP COLUMN IF EXISTS total_memory,
+    DROP COLUMN IF EXISTS uptime,
+    DROP COLUMN IF EXISTS routeros_version,
+    DROP COLUMN IF EXISTS board_name,
+    DROP COLUMN IF EXISTS serial_number,
+    DROP COLUMN IF EXISTS health_checked_at;
+-- +goose StatementEnd
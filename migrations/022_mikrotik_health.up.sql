@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Last reading of the router health poller
+ALTER TABLE mikrotik
+    ADD COLUMN cpu_load SMALLINT,
+    ADD COLUMN free_memory BIGINT,
+    ADD COLUMN total_memory BIGINT,
+    ADD COLUMN uptime VARCHAR(50),
+    ADD COLUMN routeros_version VARCHAR(50),
+    ADD COLUMN board_name VARCHAR(100),
+    ADD COLUMN serial_number VARCHAR(50),
+    ADD COLUMN health_checked_at TIMESTAMPTZ;
+
+-- MIKROTIK STATUS HISTORY TABLE (one row per status transition)
+CREATE TABLE mikrotik_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+
+    from_status mikrotik_status,
+    to_status mikrotik_status NOT NULL,
+    -- Why the router left its previous status, e.g. the connection error
+    reason TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_mikrotik_status_history_router ON mikrotik_status_history(mikrotik_id, created_at DESC);
+
+-- +goose StatementEnd
@@ -0,0 +1,6 @@
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE customers ALTER COLUMN interface TYPE VARCHAR(20) USING left(interface, 20);
+
+DROP TABLE IF EXISTS customer_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- CUSTOMER SESSIONS TABLE (one row per PPPoE or hotspot login)
+CREATE TABLE customer_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    service_type VARCHAR(20) NOT NULL CHECK (service_type IN ('pppoe', 'hotspot')),
+
+    -- The router's .id of the active entry; NULL when a callback opened the session
+    router_session_id VARCHAR(32),
+    username VARCHAR(100) NOT NULL,
+    ip_address INET,
+    -- MAC address for PPPoE and hotspot, the remote address for other PPP services
+    caller_id VARCHAR(64),
+    interface VARCHAR(64),
+
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ended_at TIMESTAMPTZ,
+    disconnect_reason TEXT,
+    bytes_in BIGINT,
+    bytes_out BIGINT,
+
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_customer_sessions_customer ON customer_sessions(customer_id, started_at DESC);
+CREATE INDEX idx_customer_sessions_open ON customer_sessions(mikrotik_id, router_session_id) WHERE ended_at IS NULL;
+
+CREATE TRIGGER set_updated_at_customer_sessions
+    BEFORE UPDATE ON customer_sessions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Dynamic interface names such as <pppoe-username> outgrow 20 characters
+ALTER TABLE customers ALTER COLUMN interface TYPE VARCHAR(64);
+
+-- +goose StatementEnd
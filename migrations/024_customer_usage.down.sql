@@ -0,0 +1,7 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS customer_usage_monthly;
+DROP TABLE IF EXISTS customer_usage_daily;
+DROP TABLE IF EXISTS customer_usage_hourly;
+DROP TABLE IF EXISTS customer_usage_counters;
+-- +goose StatementEnd
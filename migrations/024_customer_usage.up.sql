@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Last counters read for each online session. counter_key names what they were read
+-- from (the PPP interface or hotspot session); a new key means the count restarted.
+-- A customer with concurrent sessions has a row for each.
+CREATE TABLE customer_usage_counters (
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    counter_key VARCHAR(100) NOT NULL,
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    bytes_up BIGINT NOT NULL DEFAULT 0,
+    bytes_down BIGINT NOT NULL DEFAULT 0,
+    sampled_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (customer_id, counter_key)
+);
+
+CREATE INDEX idx_customer_usage_counters_mikrotik ON customer_usage_counters(mikrotik_id);
+
+-- USAGE ROLLUPS (bytes_up is sent by the customer, bytes_down received)
+CREATE TABLE customer_usage_hourly (
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    bytes_up BIGINT NOT NULL DEFAULT 0,
+    bytes_down BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (customer_id, period_start)
+);
+
+CREATE TABLE customer_usage_daily (
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    bytes_up BIGINT NOT NULL DEFAULT 0,
+    bytes_down BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (customer_id, period_start)
+);
+
+-- period_start is the first day of the calendar month
+CREATE TABLE customer_usage_monthly (
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    bytes_up BIGINT NOT NULL DEFAULT 0,
+    bytes_down BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (customer_id, period_start)
+);
+
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS customer_fup_states;
+
+ALTER TABLE mikrotik_profiles
+    DROP COLUMN IF EXISTS fup_quota_bytes,
+    DROP COLUMN IF EXISTS fup_rate_limit_up,
+    DROP COLUMN IF EXISTS fup_rate_limit_down;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Fair usage policy: past fup_quota_bytes in a billing cycle the customer is moved to
+-- a copy of the profile limited to the fup rate. NULL quota disables it.
+ALTER TABLE mikrotik_profiles
+    ADD COLUMN fup_quota_bytes BIGINT,
+    ADD COLUMN fup_rate_limit_up VARCHAR(50),
+    ADD COLUMN fup_rate_limit_down VARCHAR(50);
+
+-- CUSTOMER FUP STATE TABLE (one row per customer, reset when a billing cycle starts)
+CREATE TABLE customer_fup_states (
+    customer_id UUID PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    cycle_start DATE NOT NULL,
+    -- Highest usage threshold already notified in this cycle (0, 80 or 100)
+    notified_percent SMALLINT NOT NULL DEFAULT 0,
+    throttled BOOLEAN NOT NULL DEFAULT false,
+    throttled_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_updated_at_customer_fup_states
+    BEFORE UPDATE ON customer_fup_states
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
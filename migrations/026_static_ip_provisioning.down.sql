@@ -0,0 +1,8 @@
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS static_ip_profile_id;
+
+ALTER TABLE mikrotik_profile_static_ip
+    DROP COLUMN IF EXISTS interface;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Router interface static-IP customers are bound to, and the parent of the profile's
+-- VLAN when vlan_id is set
+ALTER TABLE mikrotik_profile_static_ip
+    ADD COLUMN interface VARCHAR(50);
+
+-- Plan of a static-IP customer; pppoe and hotspot customers keep their own columns
+ALTER TABLE customers
+    ADD COLUMN static_ip_profile_id UUID REFERENCES mikrotik_profiles(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
@@ -0,0 +1,4 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS customer_import_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- CUSTOMER IMPORT JOBS TABLE (spreadsheet imports too large to run in the request)
+CREATE TABLE customer_import_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    -- Create the customers' router entries as well as the database rows
+    provision BOOLEAN NOT NULL DEFAULT false,
+
+    -- Rows already mapped to customer fields, dropped once the job finishes
+    rows JSONB,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    result JSONB,
+    error TEXT,
+
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_import_jobs_mikrotik ON customer_import_jobs(mikrotik_id, created_at DESC);
+
+CREATE TRIGGER set_updated_at_customer_import_jobs
+    BEFORE UPDATE ON customer_import_jobs
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
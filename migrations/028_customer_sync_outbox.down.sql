@@ -0,0 +1,9 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS customer_sync_jobs;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS sync_status,
+    DROP COLUMN IF EXISTS sync_error,
+    DROP COLUMN IF EXISTS synced_at;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Router state of a customer: pending while a sync job has not reached the router,
+-- failed once its retries ran out
+ALTER TABLE customers
+    ADD COLUMN sync_status VARCHAR(20) NOT NULL DEFAULT 'synced'
+        CHECK (sync_status IN ('pending', 'synced', 'failed')),
+    ADD COLUMN sync_error TEXT,
+    ADD COLUMN synced_at TIMESTAMPTZ;
+
+-- CUSTOMER SYNC JOBS TABLE (outbox of router changes, written in the transaction of
+-- the customer change they follow)
+CREATE TABLE customer_sync_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    -- No foreign key: a delete job outlives its customer
+    customer_id UUID NOT NULL,
+    mikrotik_id UUID NOT NULL REFERENCES mikrotik(id) ON DELETE CASCADE,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('upsert', 'delete')),
+    -- Also the queue task ID, so a job is never queued twice
+    idempotency_key VARCHAR(150) NOT NULL UNIQUE,
+    -- The customer as it was before the change
+    snapshot JSONB,
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_sync_jobs_status ON customer_sync_jobs(status, created_at);
+CREATE INDEX idx_customer_sync_jobs_customer ON customer_sync_jobs(customer_id, created_at DESC);
+
+CREATE TRIGGER set_updated_at_customer_sync_jobs
+    BEFORE UPDATE ON customer_sync_jobs
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- +goose StatementEnd
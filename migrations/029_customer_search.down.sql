@@ -0,0 +1,15 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_customers_sync_status;
+DROP INDEX IF EXISTS idx_customers_static_ip_profile;
+DROP INDEX IF EXISTS idx_customers_hotspot_profile;
+DROP INDEX IF EXISTS idx_customers_pppoe_profile;
+DROP INDEX IF EXISTS idx_customers_created_id;
+DROP INDEX IF EXISTS idx_customers_name_id;
+DROP INDEX IF EXISTS idx_customers_search;
+
+ALTER TABLE customers
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN billing_day DROP NOT NULL,
+    ALTER COLUMN created_at DROP NOT NULL;
+-- +goose StatementEnd
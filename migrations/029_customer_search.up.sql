@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Full-text search over the customer list. The expression must match the one the
+-- customer repository queries with, or the index is not used.
+CREATE INDEX idx_customers_search ON customers USING GIN (
+    to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(username, '') || ' ' ||
+        coalesce(phone, '') || ' ' || coalesce(address, '') || ' ' ||
+        coalesce(pppoe_username, '') || ' ' || coalesce(hotspot_username, ''))
+);
+
+-- Keyset pagination compares (sort column, id) row values, which a NULL in the
+-- sort column would drop from the list
+UPDATE customers SET status = 'inactive' WHERE status IS NULL;
+UPDATE customers SET billing_day = 15 WHERE billing_day IS NULL;
+UPDATE customers SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE customers
+    ALTER COLUMN status SET NOT NULL,
+    ALTER COLUMN billing_day SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL;
+
+-- Keyset pagination reads the list by (sort column, id)
+CREATE INDEX idx_customers_name_id ON customers(name, id);
+CREATE INDEX idx_customers_created_id ON customers(created_at, id);
+
+-- Profile filter
+CREATE INDEX idx_customers_pppoe_profile ON customers(pppoe_profile_id);
+CREATE INDEX idx_customers_hotspot_profile ON customers(hotspot_profile_id);
+CREATE INDEX idx_customers_static_ip_profile ON customers(static_ip_profile_id);
+CREATE INDEX idx_customers_sync_status ON customers(sync_status);
+
+-- +goose StatementEnd
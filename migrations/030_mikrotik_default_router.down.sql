@@ -0,0 +1,5 @@
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_mikrotik_default;
+ALTER TABLE mikrotik DROP COLUMN IF EXISTS is_default;
+-- +goose StatementEnd
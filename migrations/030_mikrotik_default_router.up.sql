@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The default router is the one served by the single-router endpoints
+-- (/api/mikrotiks/active). is_active stays each router's own enable flag.
+ALTER TABLE mikrotik ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX idx_mikrotik_default ON mikrotik(is_default) WHERE is_default;
+
+-- The most recently activated router becomes the default
+UPDATE mikrotik SET is_default = true
+WHERE id = (
+    SELECT id FROM mikrotik
+    WHERE is_active
+    ORDER BY updated_at DESC
+    LIMIT 1
+);
+
+-- +goose StatementEnd
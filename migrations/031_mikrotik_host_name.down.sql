@@ -0,0 +1,4 @@
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE mikrotik ALTER COLUMN host TYPE INET USING host::inet;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Routers are often reached by DNS or DDNS name (e.g. *.sn.mynetname.net), which
+-- an INET column cannot hold
+ALTER TABLE mikrotik ALTER COLUMN host TYPE VARCHAR(255) USING host(host);
+
+-- +goose StatementEnd
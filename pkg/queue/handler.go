@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hibiken/asynq"
 )
@@ -17,16 +18,32 @@ type TypedHandlerFunc[T any] func(ctx context.Context, payload T) error
 
 // HandlerRegistry menyimpan semua task handlers
 type HandlerRegistry struct {
-	handlers map[string]HandlerFunc
+	handlers    map[string]HandlerFunc
+	retryDelays map[string]asynq.RetryDelayFunc
 }
 
 // NewHandlerRegistry membuat instance baru handler registry
 func NewHandlerRegistry() *HandlerRegistry {
 	return &HandlerRegistry{
-		handlers: make(map[string]HandlerFunc),
+		handlers:    make(map[string]HandlerFunc),
+		retryDelays: make(map[string]asynq.RetryDelayFunc),
 	}
 }
 
+// SetRetryDelay mengatur jeda retry untuk task type tertentu; task lain memakai
+// jeda default asynq
+func (r *HandlerRegistry) SetRetryDelay(taskType string, fn asynq.RetryDelayFunc) {
+	r.retryDelays[taskType] = fn
+}
+
+// RetryDelay adalah asynq.RetryDelayFunc yang memilih jeda sesuai task type
+func (r *HandlerRegistry) RetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if fn, ok := r.retryDelays[task.Type()]; ok {
+		return fn(n, err, task)
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
+
 // Register mendaftarkan handler untuk task type tertentu
 func (r *HandlerRegistry) Register(taskType string, handler HandlerFunc) {
 	r.handlers[taskType] = handler
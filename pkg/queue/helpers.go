@@ -2,6 +2,7 @@
 package queue
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -59,6 +60,18 @@ func (o QueueOptions) ToAsynqOptions() []asynq.Option {
 	return opts
 }
 
+// ExponentialBackoff membuat jeda retry yang berlipat dua setiap percobaan, mulai
+// dari base dan dibatasi max, dengan jitter hingga 10% agar retry tidak serentak
+func ExponentialBackoff(base, max time.Duration) asynq.RetryDelayFunc {
+	return func(n int, _ error, _ *asynq.Task) time.Duration {
+		delay := max
+		if n < 32 && base<<n > 0 && base<<n < max {
+			delay = base << n
+		}
+		return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+	}
+}
+
 // TaskBuilder adalah builder pattern untuk membuat task dengan mudah
 type TaskBuilder struct {
 	taskType string
@@ -30,6 +30,7 @@ func NewServer(cfg ServerConfig, registry *HandlerRegistry) *Server {
 			Queues:          cfg.Queues,
 			StrictPriority:  cfg.StrictPriority,
 			ShutdownTimeout: cfg.ShutdownTimeout,
+			RetryDelayFunc:  registry.RetryDelay,
 			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
 				// Extract task type dari context jika ada
 				taskType := "unknown"
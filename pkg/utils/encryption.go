@@ -6,7 +6,9 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 )
 
 // EncryptAES encrypts plaintext using AES-GCM
@@ -76,3 +78,85 @@ func normalizeKey(key []byte) []byte {
 	}
 	return key
 }
+
+// encryptedPrefix marks a stored value as ciphertext: enc:<key id>:<base64>.
+// Values without it were written before encryption and are read as plain text.
+const encryptedPrefix = "enc:"
+
+// Cipher encrypts secrets kept in the database with the current key and decrypts
+// values written under the current or any previous key, so keys can be rotated
+// without losing the rows that have not been re-encrypted yet.
+type Cipher struct {
+	keyID string
+	keys  map[string]string
+}
+
+// NewCipher creates a cipher that writes with key under keyID; previous maps the
+// ids of retired keys to the keys themselves.
+func NewCipher(keyID, key string, previous map[string]string) (*Cipher, error) {
+	if key == "" {
+		return nil, errors.New("encryption key is not configured")
+	}
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return nil, fmt.Errorf("invalid encryption key id %q", keyID)
+	}
+
+	keys := make(map[string]string, len(previous)+1)
+	for id, k := range previous {
+		keys[id] = k
+	}
+	keys[keyID] = key
+	return &Cipher{keyID: keyID, keys: keys}, nil
+}
+
+// KeyID returns the id of the key new values are encrypted with
+func (c *Cipher) KeyID() string {
+	return c.keyID
+}
+
+// Encrypt seals plaintext with the current key; an empty value stays empty
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	sealed, err := EncryptAES(plaintext, c.keys[c.keyID])
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + c.keyID + ":" + sealed, nil
+}
+
+// Decrypt opens a stored value; plain text written before encryption is returned as is
+func (c *Cipher) Decrypt(value string) (string, error) {
+	keyID, sealed, ok := splitEncrypted(value)
+	if !ok {
+		return value, nil
+	}
+	key, ok := c.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key id %q", keyID)
+	}
+	return DecryptAES(sealed, key)
+}
+
+// NeedsRotation reports whether a stored value is not yet encrypted with the current key
+func (c *Cipher) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	keyID, _, ok := splitEncrypted(value)
+	return !ok || keyID != c.keyID
+}
+
+// IsEncrypted reports whether a stored value was written by a Cipher
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func splitEncrypted(value string) (keyID, sealed string, ok bool) {
+	rest, found := strings.CutPrefix(value, encryptedPrefix)
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
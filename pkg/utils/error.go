@@ -14,5 +14,78 @@ var (
 	ErrRoleNotFound       = errors.New("role not found")
 	ErrPermissionDenied   = errors.New("permission denied")
 	ErrMikrotikNotFound   = errors.New("mikrotik not found")
+	ErrMikrotikInactive   = errors.New("mikrotik is not active")
+	ErrMikrotikInUse      = errors.New("mikrotik still has customers, move or delete them first")
 	ErrConnectionFailed   = errors.New("connection to mikrotik failed")
-)
\ No newline at end of file
+)
+var (
+	ErrCustomerNotFound      = errors.New("customer not found")
+	ErrInvoiceNotFound       = errors.New("invoice not found")
+	ErrInvoiceAlreadyExists  = errors.New("invoice already exists for this period")
+	ErrInvoicePriceNotSet    = errors.New("customer profile has no price")
+	ErrInvoiceNotDeletable   = errors.New("invoices with payments cannot be deleted")
+	ErrCompanyProfileMissing = errors.New("company profile is not configured")
+	ErrInvalidDate           = errors.New("invalid date, expected YYYY-MM-DD")
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrPaymentExceedsBalance = errors.New("payment exceeds outstanding balance")
+	ErrInvoiceNotPayable     = errors.New("invoice is already paid or cancelled")
+	ErrInvoiceStatusChange   = errors.New("invoice status cannot be changed from its current status")
+)
+
+var (
+	ErrSuspensionNotSupported = errors.New("suspension is only supported for pppoe and static ip customers")
+	ErrSecretNotFound         = errors.New("ppp secret not found on router")
+	ErrStaticIPNotProvisioned = errors.New("static ip customer is not provisioned on router")
+	ErrSecretLinked           = errors.New("ppp secret belongs to a customer, manage it through the customer")
+	ErrPPPProfileNotFound     = errors.New("ppp profile not found on router")
+)
+
+var (
+	ErrGatewayNotFound            = errors.New("payment gateway not configured")
+	ErrGatewayTransactionNotFound = errors.New("payment gateway transaction not found")
+	ErrInvalidSignature           = errors.New("invalid callback signature")
+	ErrNothingToPay               = errors.New("invoice has no outstanding balance")
+)
+
+var (
+	ErrAgentNotFound           = errors.New("agent not found")
+	ErrAgentAlreadyExists      = errors.New("agent with this username or phone already exists")
+	ErrAgentInactive           = errors.New("agent account is not active")
+	ErrInsufficientBalance     = errors.New("insufficient agent balance")
+	ErrBalanceRequestNotFound  = errors.New("balance request not found")
+	ErrBalanceRequestProcessed = errors.New("balance request has already been processed")
+)
+
+var (
+	ErrVoucherBatchNotFound = errors.New("voucher batch not found")
+	ErrVoucherNotFound      = errors.New("voucher not found")
+	ErrVoucherNotAvailable  = errors.New("voucher is already sold, used or expired")
+	ErrVoucherGeneration    = errors.New("voucher generation failed on router")
+
+	ErrVoucherTemplateNotFound = errors.New("voucher template not found")
+	ErrVoucherTemplateExists   = errors.New("voucher template with this name already exists")
+	ErrInvalidVoucherTemplate  = errors.New("invalid voucher template")
+)
+
+var (
+	ErrRouterRejected         = errors.New("router rejected the request")
+	ErrHotspotUserNotFound    = errors.New("hotspot user not found on router")
+	ErrHotspotProfileNotFound = errors.New("hotspot user profile not found on router")
+	ErrQueueNotFound          = errors.New("queue not found on router")
+)
+
+var (
+	ErrUnsupportedFileFormat = errors.New("unsupported file format, expected csv or xlsx")
+	ErrInvalidImportFile     = errors.New("invalid import file")
+	ErrImportJobNotFound     = errors.New("import job not found")
+)
+
+var (
+	ErrSyncJobNotFound = errors.New("customer sync job not found")
+	ErrNothingToSync   = errors.New("customer has no router entries to sync")
+	ErrSealedPassword  = errors.New(`customer password must not start with "enc:"`)
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid page cursor, request the first page again")
+)
@@ -0,0 +1,374 @@
+// Package xlsx reads and writes the first worksheet of an Office Open XML workbook
+// as rows of strings. It covers what spreadsheet imports and exports need: cell
+// values, not formatting, formulas or multiple sheets.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoWorksheet is returned for a workbook without a worksheet
+	ErrNoWorksheet = errors.New("xlsx: workbook has no worksheet")
+	// ErrTooLarge is returned for a workbook beyond the reader's limits
+	ErrTooLarge = errors.New("xlsx: workbook is too large")
+)
+
+const (
+	// MaxColumns is the number of columns a worksheet can have, A to XFD
+	MaxColumns = 16384
+	// MaxRows caps the rows ReadRows returns
+	MaxRows = 100000
+
+	// maxPartSize caps the decompressed size of a part, so a small archive cannot
+	// expand into gigabytes
+	maxPartSize = 64 << 20
+	// maxCells caps the cells of all rows together, counting the padding before
+	// sparse cells
+	maxCells = 4 << 20
+)
+
+// ReadRows returns the cells of the workbook's first worksheet. Rows are padded to
+// the position of their last cell, so a column keeps its index when cells before it
+// are empty. Workbooks beyond MaxRows, MaxColumns or the size limits fail with
+// ErrTooLarge.
+func ReadRows(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodePart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	if len(sheet.Rows) > MaxRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrTooLarge, MaxRows)
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	cells := 0
+	for _, r := range sheet.Rows {
+		var row []string
+		for _, c := range r.Cells {
+			// A cell without a reference follows the one before it
+			col := len(row)
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if col >= MaxColumns {
+				return nil, fmt.Errorf("%w: more than %d columns", ErrTooLarge, MaxColumns)
+			}
+			if col >= len(row) {
+				cells += col + 1 - len(row)
+				if cells > maxCells {
+					return nil, fmt.Errorf("%w: more than %d cells", ErrTooLarge, maxCells)
+				}
+				row = append(row, make([]string, col+1-len(row))...)
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, fmt.Errorf("xlsx: invalid shared string %q in %s", c.Value, c.Ref)
+				}
+				row[col] = shared[idx]
+			case "inlineStr":
+				row[col] = c.Inline.String()
+			case "b":
+				row[col] = strconv.FormatBool(c.Value == "1")
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// richText is a string item: plain text or runs of formatted text
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// firstSheetPath resolves the part of the workbook's first sheet
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrNoWorksheet
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", ErrNoWorksheet
+}
+
+// sharedStrings reads the shared string table; workbooks without one have none
+func sharedStrings(files map[string]*zip.File) ([]string, error) {
+	if _, ok := files["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodePart(files, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		shared[i] = item.String()
+	}
+	return shared, nil
+}
+
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("xlsx: missing part %s", name)
+	}
+	// The header's size is only a claim; partReader enforces it while reading
+	if f.UncompressedSize64 > maxPartSize {
+		return fmt.Errorf("%w: %s", ErrTooLarge, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(&partReader{r: rc, n: maxPartSize}).Decode(v); err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return fmt.Errorf("%w: %s", ErrTooLarge, name)
+		}
+		return fmt.Errorf("xlsx: %s: %w", name, err)
+	}
+	return nil
+}
+
+// partReader reads at most n bytes of a part and fails with ErrTooLarge when there
+// are more
+type partReader struct {
+	r io.Reader
+	n int64
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	if p.n <= 0 {
+		var probe [1]byte
+		if n, err := p.r.Read(probe[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		return 0, ErrTooLarge
+	}
+	if int64(len(b)) > p.n {
+		b = b[:p.n]
+	}
+	n, err := p.r.Read(b)
+	p.n -= int64(n)
+	return n, err
+}
+
+// columnIndex returns the zero-based column of a cell reference such as AB12
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+		if col > MaxColumns {
+			return 0, fmt.Errorf("%w: cell %s is beyond column XFD", ErrTooLarge, ref)
+		}
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("xlsx: invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// columnName returns the letters of a zero-based column
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// Writer streams rows into a single-sheet workbook. Cells are written as inline
+// strings, so numbers such as phone numbers keep their leading zeros.
+type Writer struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter starts a workbook with one sheet named sheetName
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, sheetHeader); err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row to the sheet
+func (w *Writer) WriteRow(cells []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.rows)
+	for i, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`,
+			columnName(i), w.rows, escape(cell))
+	}
+	b.WriteString(`</row>`)
+
+	_, w.err = io.WriteString(w.sheet, b.String())
+	return w.err
+}
+
+// Close finishes the sheet and the archive; it does not close the underlying writer
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := io.WriteString(w.sheet, sheetFooter); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+const sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetFooter = `</sheetData></worksheet>`
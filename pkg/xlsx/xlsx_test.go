@@ -0,0 +1,201 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSheetHeader = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+// workbook builds an archive holding the workbook parts around the given parts
+func workbook(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	all := map[string]string{
+		"xl/workbook.xml":            fmt.Sprintf(workbookXML, "Sheet1"),
+		"xl/_rels/workbook.xml.rels": workbookRels,
+	}
+	for name, body := range parts {
+		all[name] = body
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range all {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(f, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sheet(rows string) map[string]string {
+	return map[string]string{"xl/worksheets/sheet1.xml": testSheetHeader + rows + sheetFooter}
+}
+
+func TestReadRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+		want  [][]string
+	}{
+		{
+			name: "shared strings",
+			parts: map[string]string{
+				"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><r><t>Bu</t></r><r><t>di</t></r></si></sst>`,
+				"xl/worksheets/sheet1.xml": testSheetHeader +
+					`<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+					`<row r="2"><c r="A2" t="s"><v>1</v></c></row>` + sheetFooter,
+			},
+			want: [][]string{{"name"}, {"Budi"}},
+		},
+		{
+			name: "inline strings, numbers and booleans",
+			parts: sheet(`<row r="1"><c r="A1" t="inlineStr"><is><t>0812</t></is></c>` +
+				`<c r="B1"><v>15</v></c><c r="C1" t="b"><v>1</v></c></row>`),
+			want: [][]string{{"0812", "15", "true"}},
+		},
+		{
+			name:  "sparse references keep their column",
+			parts: sheet(`<row r="1"><c r="B1"><v>b</v></c><c r="D1"><v>d</v></c></row><row r="2"/>`),
+			want:  [][]string{{"", "b", "", "d"}, nil},
+		},
+		{
+			name:  "cells without references follow the previous cell",
+			parts: sheet(`<row><c r="C1"><v>c</v></c><c><v>d</v></c></row>`),
+			want:  [][]string{{"", "", "c", "d"}},
+		},
+		{
+			name:  "last column",
+			parts: sheet(`<row r="1"><c r="XFD1"><v>x</v></c></row>`),
+			want:  [][]string{append(make([]string, MaxColumns-1), "x")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadRows(workbook(t, tt.parts))
+			if err != nil {
+				t.Fatalf("ReadRows: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRowsMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{"not a zip archive", func(*testing.T) []byte { return []byte("name,phone\n") }},
+		{"no workbook", func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			zw.Create("hello.txt")
+			zw.Close()
+			return buf.Bytes()
+		}},
+		{"missing sheet part", func(t *testing.T) []byte { return workbook(t, nil) }},
+		{"broken sheet xml", func(t *testing.T) []byte { return workbook(t, sheet(`<row><c`)) }},
+		{"shared string out of range", func(t *testing.T) []byte {
+			return workbook(t, sheet(`<row><c r="A1" t="s"><v>3</v></c></row>`))
+		}},
+		{"invalid cell reference", func(t *testing.T) []byte {
+			return workbook(t, sheet(`<row><c r="12"><v>x</v></c></row>`))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRows(tt.data(t)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadRowsTooLarge(t *testing.T) {
+	manyRows := strings.Repeat("<row/>", MaxRows+1)
+
+	// Every row padded to the last column exceeds the cell budget long before the
+	// part size limit
+	var wide strings.Builder
+	for i := 1; i <= maxCells/MaxColumns+1; i++ {
+		fmt.Fprintf(&wide, `<row><c r="XFD%d"><v>x</v></c></row>`, i)
+	}
+
+	tests := []struct {
+		name  string
+		parts map[string]string
+	}{
+		{"column beyond XFD", sheet(`<row><c r="XFE1"><v>x</v></c></row>`)},
+		{"reference overflowing int", sheet(`<row><c r="ZZZZZZZZZZZZZZZZZZZZ1"><v>x</v></c></row>`)},
+		{"too many rows", sheet(manyRows)},
+		{"too many cells", sheet(wide.String())},
+		{"part beyond the size limit", sheet(strings.Repeat(" ", maxPartSize+1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRows(workbook(t, tt.parts)); !errors.Is(err, ErrTooLarge) {
+				t.Errorf("got %v, want ErrTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestPartReader(t *testing.T) {
+	data, err := io.ReadAll(&partReader{r: strings.NewReader("12345"), n: 5})
+	if err != nil || string(data) != "12345" {
+		t.Errorf("part at the limit: got %q, %v", data, err)
+	}
+
+	// A part whose header understates its size is cut off while decompressing
+	if _, err := io.ReadAll(&partReader{r: strings.NewReader("123456"), n: 5}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("part beyond the limit: got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"name", "phone", "address"},
+		{"Budi <Santoso>", "081234", ""},
+		{"", "", "Jl. Merdeka & Co"},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Customers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadRows(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadRows: %v", err)
+	}
+	// Trailing empty cells are not written
+	want := [][]string{rows[0], {"Budi <Santoso>", "081234"}, rows[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}